@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// A cell of a frame written as terminal output
+type ansiCell struct {
+	ch     rune
+	fg, bg Attribute
+}
+
+// ansiFrame is a screen's worth of cells, written out as the escape
+// sequences that draw the ones changed since the last frame written. The
+// recorder and the raw renderer share it.
+type ansiFrame struct {
+	cols, rows int
+	cells      []ansiCell // Frame being drawn
+	shown      []ansiCell // Last frame written
+}
+
+// Size the frame, forgetting what was shown so the next one is written in
+// full
+func (f *ansiFrame) resize(cols, rows int) {
+	f.cols, f.rows = cols, rows
+	f.cells = make([]ansiCell, cols*rows)
+	f.shown = make([]ansiCell, cols*rows)
+	for i := range f.shown {
+		f.shown[i].ch = -1
+	}
+}
+
+// Blank the frame being drawn
+func (f *ansiFrame) clear() {
+	clear(f.cells)
+}
+
+// Set a cell of the frame being drawn. Cells off the screen are dropped.
+func (f *ansiFrame) set(x, y int, ch rune, fg, bg Attribute) {
+	if x < 0 || y < 0 || x >= f.cols || y >= f.rows {
+		return
+	}
+	f.cells[y*f.cols+x] = ansiCell{ch, fg, bg}
+}
+
+// Write the cells that changed since the last frame written
+func (f *ansiFrame) write(b *strings.Builder) {
+	var style string
+	for y := 0; y < f.rows; y++ {
+		at := -1             // Column the cursor is at, if known
+		leftChanged := false // A wide rune covering or uncovering a cell redraws it too
+		for x := 0; x < f.cols; x++ {
+			i := y*f.cols + x
+			c := f.cells[i]
+			changed := c != f.shown[i] || leftChanged
+			leftChanged = c != f.shown[i]
+			f.shown[i] = c
+			if !changed {
+				continue
+			}
+			if c.ch == 0 {
+				c.ch = ' '
+			}
+			if at != x {
+				fmt.Fprintf(b, "\x1b[%d;%dH", y+1, x+1)
+			}
+			if s := ansiStyle(c.fg, c.bg); s != style {
+				b.WriteString(s)
+				style = s
+			}
+			b.WriteRune(c.ch)
+			at = x + 1
+			if runewidth.RuneWidth(c.ch) == 2 && x+1 < f.cols {
+				x++ // The next cell is hidden under this one
+				leftChanged = f.cells[i+1] != f.shown[i+1]
+				f.shown[i+1] = f.cells[i+1]
+				at++
+			}
+		}
+	}
+}
+
+// Style flags and the codes that set them, in order
+var ansiStyles = []struct {
+	attr Attribute
+	code string
+}{
+	{AttrBold, "1"}, {AttrDim, "2"}, {AttrCursive, "3"}, {AttrUnderline, "4"},
+	{AttrBlink, "5"}, {AttrReverse, "7"}, {AttrHidden, "8"},
+}
+
+// The escape sequence that sets a cell's colors and styles. Colors are the
+// eight standard ones, then their bright variants, like the tcell
+// renderer's.
+func ansiStyle(fg, bg Attribute) string {
+	codes := []string{"0"}
+	for _, s := range ansiStyles {
+		if fg&s.attr != 0 {
+			codes = append(codes, s.code)
+		}
+	}
+	if c := ansiColor(fg, 30); c != "" {
+		codes = append(codes, c)
+	}
+	if c := ansiColor(bg, 40); c != "" {
+		codes = append(codes, c)
+	}
+	return "\x1b[" + strings.Join(codes, ";") + "m"
+}
+
+// The code for a color, from base for the standard colors: 30 for the
+// foreground, 40 for the background
+func ansiColor(attr Attribute, base int) string {
+	c := int(attr.Color())
+	switch {
+	case c == int(ColorDefault):
+		return ""
+	case c <= 8:
+		return strconv.Itoa(base + c - 1)
+	case c <= 16:
+		return strconv.Itoa(base + 60 + c - 9)
+	}
+	return strconv.Itoa(base+8) + ";5;" + strconv.Itoa(c-1)
+}
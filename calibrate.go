@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// Calibration constants
+const (
+	calibrateTick      = 10 * time.Millisecond // How often the calibration screen redraws
+	renderSamples      = 30                    // Frames drawn to time the terminal
+	reactionTrials     = 5                     // Reaction times averaged
+	minReactionWait    = time.Second           // Shortest wait before the prompt turns green
+	maxReactionWait    = 3 * time.Second       // Longest wait before the prompt turns green
+	reactionShare      = 0.4                   // Part of a reaction a tick at level 1 should take
+	minCalibratedSpeed = 50                    // Fastest speed calibration recommends
+	maxCalibratedSpeed = 200                   // Slowest speed calibration recommends
+	aspectBoxHeight    = 5                     // Rows in the box sized to look square
+)
+
+// Calibration is what the calibration screen measured, and the speed and
+// aspect ratio it recommends
+type Calibration struct {
+	Speed    int           `json:"speed"`    // Milliseconds per tick at level 1 on normal
+	Aspect   float64       `json:"aspect"`   // Vertical slowdown for tall terminal cells
+	Render   time.Duration `json:"render"`   // Average time to draw a frame
+	Reaction time.Duration `json:"reaction"` // Average reaction time
+	Date     time.Time     `json:"date"`
+}
+
+// Scale a difficulty preset to the calibrated speed. Presets are tuned
+// around normal's start speed, so the others keep their pace relative to it.
+func (c *Calibration) adjust(d Difficulty) Difficulty {
+	if c == nil || c.Speed <= 0 {
+		return d
+	}
+	scale := float64(c.Speed) / baseSpeed
+	d.StartSpeed = int(float64(d.StartSpeed) * scale)
+	d.MinSpeed = int(float64(d.MinSpeed) * scale)
+	return d
+}
+
+// Calibration steps, in order
+const (
+	calibrateReaction = iota
+	calibrateAspect
+	calibrateDone
+)
+
+// Calibrator runs the calibration screen. It times how long the terminal
+// takes to draw a frame, then the player's reaction to a prompt, then has
+// them size a box until it looks square to find the cell aspect ratio.
+type Calibrator struct {
+	step      int
+	render    time.Duration   // Average time to draw a frame
+	reactions []time.Duration // Reaction times so far
+	goAt      time.Time       // When the prompt turns green
+	early     bool            // Was the last press too early?
+	boxWidth  int             // Width of the box being sized, in cells
+	rng       *rand.Rand
+	Result    Calibration // Filled in once done
+}
+
+// Start calibrating, timing the terminal straight away
+func NewCalibrator() *Calibrator {
+	c := &Calibrator{
+		boxWidth: int(aspectRatio*aspectBoxHeight + 0.5),
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+	c.render = measureRender()
+	c.wait(time.Now())
+	return c
+}
+
+// Average time to draw a full screen of cells. Alternate frames differ in
+// every cell so none of the drawing is skipped.
+func measureRender() time.Duration {
+	w, h := screen.Size()
+	start := time.Now()
+	for i := 0; i < renderSamples; i++ {
+		ch := '░'
+		if i%2 == 1 {
+			ch = '▒'
+		}
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				screen.SetCell(x, y, ch, colorEmpty, ColorDefault)
+			}
+		}
+		screen.Flush()
+	}
+	return time.Since(start) / renderSamples
+}
+
+// Pick a random time for the prompt to turn green
+func (c *Calibrator) wait(now time.Time) {
+	c.goAt = now.Add(minReactionWait + time.Duration(c.rng.Int63n(int64(maxReactionWait-minReactionWait))))
+}
+
+// Green reports whether the reaction prompt has turned green
+func (c *Calibrator) Green(now time.Time) bool {
+	return c.step == calibrateReaction && !now.Before(c.goAt)
+}
+
+// Press answers the reaction prompt. Pressing before it turns green starts
+// the wait again.
+func (c *Calibrator) Press(now time.Time) {
+	if c.step != calibrateReaction {
+		return
+	}
+	c.early = !c.Green(now)
+	if !c.early {
+		// The prompt only showed once its frame was drawn
+		reaction := now.Sub(c.goAt) - c.render
+		if reaction < 0 {
+			reaction = 0
+		}
+		c.reactions = append(c.reactions, reaction)
+		if len(c.reactions) == reactionTrials {
+			c.step = calibrateAspect
+			return
+		}
+	}
+	c.wait(now)
+}
+
+// Resize widens or narrows the box while sizing it
+func (c *Calibrator) Resize(delta int) {
+	if c.step == calibrateAspect {
+		c.boxWidth = min(max(c.boxWidth+delta, 1), viewWidth)
+	}
+}
+
+// Confirm accepts the box size and works out the recommendation
+func (c *Calibrator) Confirm() {
+	if c.step != calibrateAspect {
+		return
+	}
+	c.step = calibrateDone
+
+	var total time.Duration
+	for _, r := range c.reactions {
+		total += r
+	}
+	reaction := total / time.Duration(len(c.reactions))
+	speed := reactionShare*float64(reaction.Milliseconds()) + float64(c.render.Milliseconds())
+	c.Result = Calibration{
+		Speed:    min(max(int(speed/5+0.5)*5, minCalibratedSpeed), maxCalibratedSpeed),
+		Aspect:   float64(c.boxWidth) / aspectBoxHeight,
+		Render:   c.render,
+		Reaction: reaction,
+		Date:     time.Now(),
+	}
+}
+
+// Done reports whether there is a result to save
+func (c *Calibrator) Done() bool {
+	return c.step == calibrateDone
+}
+
+// Draw the calibration screen over the game area
+func drawCalibration(c *Calibrator) {
+	centerX := boardLeft + 1 + viewWidth/2
+	title := "CALIBRATION"
+	drawCentered(2, title, colorScore|AttrBold)
+
+	var hint string
+	switch c.step {
+	case calibrateReaction:
+		msg := fmt.Sprintf("Press space on green (%d/%d)", len(c.reactions)+1, reactionTrials)
+		drawCentered(4, msg, colorText)
+		bg := ColorRed
+		if c.Green(time.Now()) {
+			bg = ColorGreen
+		}
+		for y := 0; y < 3; y++ {
+			for x := -4; x < 4; x++ {
+				screen.SetCell(centerX+x, 6+y, ' ', colorText, bg)
+			}
+		}
+		if c.early {
+			early := "Too early! Wait for green."
+			drawCentered(10, early, colorFood|AttrBold)
+		}
+		hint = "Esc to cancel"
+	case calibrateAspect:
+		msg := "Make the box square with left/right"
+		drawCentered(4, msg, colorText)
+		for y := 0; y < aspectBoxHeight; y++ {
+			for x := 0; x < c.boxWidth; x++ {
+				screen.SetCell(centerX-c.boxWidth/2+x, 6+y, symbolWall, colorWall, ColorDefault)
+			}
+		}
+		hint = "Enter when done, Esc to cancel"
+	case calibrateDone:
+		r := c.Result
+		lines := []string{
+			fmt.Sprintf("Frame time:  %d ms", r.Render.Milliseconds()),
+			fmt.Sprintf("Reaction:    %d ms", r.Reaction.Milliseconds()),
+			"",
+			fmt.Sprintf("Speed:       %d ms per tick", r.Speed),
+			fmt.Sprintf("Aspect:      %.2f", r.Aspect),
+		}
+		for i, line := range lines {
+			drawText(centerX-14, 4+i, line, colorText)
+		}
+		hint = "Enter to save, Esc to discard"
+	}
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
@@ -0,0 +1,67 @@
+package main
+
+// The board is drawn through a view. Normally it shows the whole board, but
+// on a terminal too small for a board it's a window onto it that follows
+// player 1's head, like a camera. Screens and notices drawn over the board
+// fit the view rather than the board.
+var (
+	viewWidth, viewHeight  = width, height // Board cells on screen
+	cameraX, cameraY       int             // Board cell in the view's top left corner
+	screenCols, screenRows int             // Size of the terminal, 0 until known
+)
+
+// Camera constants
+const (
+	cameraMargin  = 6  // The camera moves on once the head gets this close to the view's edge
+	minViewWidth  = 20 // Smallest view, however small the terminal
+	minViewHeight = 10
+)
+
+// Fit the view to the room the layout leaves for the board: the terminal
+// less the sidebar, the border and, without the sidebar, the status line
+func fitView() {
+	viewWidth, viewHeight = width, height
+	if screenCols == 0 || screenRows == 0 {
+		return
+	}
+	rows := screenRows - 2
+	if compactLayout {
+		rows--
+	}
+	viewWidth = min(width, max(screenCols-boardLeft-2, minViewWidth))
+	viewHeight = min(height, max(rows, minViewHeight))
+}
+
+// Move the camera on to keep player 1's head away from the view's edges
+func (g *Game) followCamera() {
+	head := g.Player().Head()
+	cameraX = follow(cameraX, head.X, viewWidth, width)
+	cameraY = follow(cameraY, head.Y, viewHeight, height)
+}
+
+// Where a camera along one axis of a board of a size goes to keep a point
+// in a view of a size, clear of its edges where the board allows
+func follow(camera, at, view, size int) int {
+	margin := min(cameraMargin, (view-1)/2)
+	if at < camera+margin {
+		camera = at - margin
+	}
+	if at >= camera+view-margin {
+		camera = at - view + margin + 1
+	}
+	return min(max(camera, 0), size-view)
+}
+
+// Screen position of a board cell, with ok false when it's out of view
+func cellOnScreen(p Point) (x, y int, ok bool) {
+	x, y = p.X-cameraX, p.Y-cameraY
+	ok = x >= 0 && y >= 0 && x < viewWidth && y < viewHeight
+	return x + boardLeft + 1, y + 1, ok
+}
+
+// Draw a symbol in a board cell, if it's in view
+func setBoardCell(p Point, ch rune, fg Attribute) {
+	if x, y, ok := cellOnScreen(p); ok {
+		screen.SetCell(x, y, ch, fg, ColorDefault)
+	}
+}
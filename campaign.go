@@ -0,0 +1,240 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CampaignStage is one stage of the campaign: the board it's played on,
+// how fast and crowded it starts, and the score or length that clears it
+type CampaignStage struct {
+	Name      string
+	Level     string // Built-in level map, empty for an open board
+	Score     int    // Points that clear the stage, 0 when it goes by length
+	Length    int    // Snake length that clears the stage, 0 when it goes by score
+	Speed     int    // Level the speed starts at
+	Obstacles int    // Obstacles scattered over the board at the start
+	Intro     string // Shown before the stage starts
+	Outro     string // Shown once it's cleared
+}
+
+// The campaign's stages, in the order they're played
+var campaignStages = []CampaignStage{
+	{
+		Name: "HATCHLING", Score: 15, Speed: 1,
+		Intro: "The board is open and the food is close. Find your feet.",
+		Outro: "Not bad for a hatchling. The world is bigger than this.",
+	},
+	{
+		Name: "FIRST STONES", Level: "pebbles", Length: 10, Speed: 2,
+		Intro: "A few stones lie about. Grow to a length of 10 without touching them.",
+		Outro: "Stones are easy. Walls are next.",
+	},
+	{
+		Name: "PILLARS", Level: "pillars", Score: 30, Speed: 2, Obstacles: 4,
+		Intro: "Weave between the pillars, and mind the rubble.",
+		Outro: "The pillars stand. So do you.",
+	},
+	{
+		Name: "THE BOX", Level: "box", Length: 16, Speed: 3, Obstacles: 6,
+		Intro: "Two ways in, two ways out. Grow long without getting boxed in.",
+		Outro: "Out of the box, and longer for it.",
+	},
+	{
+		Name: "CROSSROADS", Level: "cross", Score: 50, Speed: 4, Obstacles: 8,
+		Intro: "Four quarters and a wall between each. Pick your turns.",
+		Outro: "Every road led somewhere after all.",
+	},
+	{
+		Name: "ROOMS", Level: "rooms", Length: 22, Speed: 5, Obstacles: 10,
+		Intro: "Doorways are narrow and you are not. Grow to 22.",
+		Outro: "Every room visited, no door slammed.",
+	},
+	{
+		Name: "SWITCHBACK", Level: "switchback", Score: 80, Speed: 6, Obstacles: 12,
+		Intro: "The path doubles back on itself. So will you.",
+		Outro: "Only one stage left.",
+	},
+	{
+		Name: "THE GAUNTLET", Level: "gauntlet", Length: 30, Speed: 7, Obstacles: 14,
+		Intro: "Everything you've learned, all at once. Grow to 30.",
+		Outro: "You ran the gauntlet. The campaign is yours.",
+	},
+}
+
+// What clears the stage, e.g. "SCORE 30" or "LENGTH 16"
+func (st *CampaignStage) Goal() string {
+	if st.Length > 0 {
+		return fmt.Sprintf("LENGTH %d", st.Length)
+	}
+	return fmt.Sprintf("SCORE %d", st.Score)
+}
+
+// How far a snake is towards clearing the stage, and what clears it
+func (st *CampaignStage) Progress(s *Snake) (value, target int) {
+	if st.Length > 0 {
+		return len(s.body), st.Length
+	}
+	return s.score, st.Score
+}
+
+// CampaignProgress is how far through the campaign the player has got,
+// kept in the profile so it carries on where it left off
+type CampaignProgress struct {
+	Stage     int  `json:"stage"`     // Next stage to play
+	Completed bool `json:"completed"` // Has the last stage ever been cleared?
+}
+
+// Campaign plays the stages in order, from the player's saved progress
+type Campaign struct {
+	profile *Profile
+	levels  []*Level // Map of each stage, nil for an open board
+}
+
+// Get the campaign ready, checking every stage's map fits the board
+func NewCampaign(profile *Profile) (*Campaign, error) {
+	c := &Campaign{profile: profile}
+	for _, st := range campaignStages {
+		var level *Level
+		if st.Level != "" {
+			var err error
+			if level, err = LoadLevel(st.Level); err != nil {
+				return nil, err
+			}
+		}
+		c.levels = append(c.levels, level)
+	}
+	return c, nil
+}
+
+// Stage returns the next stage to play
+func (c *Campaign) Stage() int {
+	if p := c.profile.Campaign; p != nil && p.Stage < len(campaignStages) {
+		return p.Stage
+	}
+	return 0
+}
+
+// Level returns the map for the next stage, nil for an open board
+func (c *Campaign) Level() *Level {
+	return c.levels[c.Stage()]
+}
+
+// CampaignRun is a stage being played
+type CampaignRun struct {
+	*Campaign
+	Stage   int
+	Cleared bool // Was the target met?
+}
+
+// The stage being played
+func (r *CampaignRun) stage() *CampaignStage {
+	return &campaignStages[r.Stage]
+}
+
+// Set a fresh game on the campaign's map up as its next stage: at the
+// stage's speed, with its obstacles scattered about
+func (g *Game) startStage(c *Campaign) {
+	g.campaign = &CampaignRun{Campaign: c, Stage: c.Stage()}
+	st := g.campaign.stage()
+	g.level = max(g.level, st.Speed)
+	for i := 0; i < st.Obstacles; i++ {
+		g.placeHazard()
+	}
+}
+
+// End a campaign stage as soon as its target is met
+func (g *Game) updateCampaign() {
+	if g.campaign == nil || g.state != StatePlaying {
+		return
+	}
+	if value, target := g.campaign.stage().Progress(g.Player()); value < target {
+		return
+	}
+	g.campaign.Cleared = true
+	g.state = StateGameOver
+	g.queueSound(SoundLevelUp, g.campaign.Stage+1)
+	g.logMove(nil, "cleared stage %d, %s", g.campaign.Stage+1, strings.ToLower(g.campaign.stage().Name))
+}
+
+// Save the campaign's progress after a stage is cleared. Stages the
+// autopilot helped with don't count.
+func (g *Game) recordCampaign() error {
+	if g.campaign == nil || !g.campaign.Cleared || g.botAssisted {
+		return nil
+	}
+	return g.campaign.profile.AdvanceCampaign(g.campaign.Stage)
+}
+
+// Draw a stage's intro over the game area, before it starts
+func drawCampaignIntro(g *Game) {
+	st := g.campaign.stage()
+	drawPanel([]Line{
+		{fmt.Sprintf("STAGE %d/%d: %s", g.campaign.Stage+1, len(campaignStages), st.Name), colorScore | AttrBold},
+		{},
+		{st.Intro, colorText},
+		{},
+		{"GOAL: " + st.Goal(), ColorCyan | AttrBold},
+		{fmt.Sprintf("Press %s to start", keysHint(ActionPause)), colorText},
+	})
+}
+
+// Draw how the stage went: its outro once cleared, or what's left to do
+func drawCampaignResult(g *Game) {
+	run := g.campaign
+	st := run.stage()
+	var lines []Line
+	switch {
+	case run.Cleared && run.Stage == len(campaignStages)-1 && !g.botAssisted:
+		lines = []Line{
+			{"CAMPAIGN COMPLETE!", colorScore | AttrBold},
+			{},
+			{st.Outro, colorText},
+			{},
+			{"Enter to play it again from the start", colorText},
+		}
+	case run.Cleared && g.botAssisted:
+		lines = []Line{
+			{fmt.Sprintf("STAGE %d CLEARED", run.Stage+1), colorScore | AttrBold},
+			{"The autopilot helped, so it doesn't count", colorText},
+			{},
+			{"Enter to play it again", colorText},
+		}
+	case run.Cleared:
+		lines = []Line{
+			{fmt.Sprintf("STAGE %d CLEARED!", run.Stage+1), colorScore | AttrBold},
+			{},
+			{st.Outro, colorText},
+			{},
+			{fmt.Sprintf("Enter for stage %d: %s", run.Stage+2, campaignStages[run.Stage+1].Name), colorText},
+		}
+	default:
+		value, target := st.Progress(g.Player())
+		lines = []Line{
+			{tr("game_over", keyHint(ActionQuit), keyHint(ActionRestart)), ColorRed},
+			{fmt.Sprintf("%d of %d to clear %s", value, target, st.Name), colorScore | AttrBold},
+			{},
+			{fmt.Sprintf("Enter or %s to try again", keyHint(ActionRestart)), colorText},
+		}
+		if len(g.history) > 0 {
+			lines = append(lines, Line{fmt.Sprintf("Press %s to rewind the crash", keyHint(ActionRewind)), colorText})
+		}
+	}
+	drawPanel(lines)
+}
+
+// Draw the stage's goal in the sidebar
+func drawCampaignGoal(sb *Sidebar, g *Game) {
+	st := g.campaign.stage()
+	sb.Textf(colorText, "STAGE %d/%d", g.campaign.Stage+1, len(campaignStages))
+	value, target := st.Progress(g.Player())
+	fg := colorText
+	if value >= target {
+		fg = ColorGreen | AttrBold
+	}
+	label := "SCORE"
+	if st.Length > 0 {
+		label = "LEN"
+	}
+	sb.Bar(label, value, target, fg)
+}
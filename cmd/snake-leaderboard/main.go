@@ -0,0 +1,217 @@
+// Command snake-leaderboard is a small reference server for go-snake's
+// online leaderboard. It keeps the top scores for each mode and board size,
+// and each weekly or daily challenge, in memory, saving them to a JSON file if one is given.
+//
+//	snake-leaderboard -addr :8090 -data scores.json
+//	go-snake -leaderboard http://localhost:8090
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server constants
+const (
+	keptScores   = 100     // Scores kept for each board
+	topScores    = 10      // Scores returned by a query
+	maxNameLen   = 20      // Longest player name accepted
+	maxChallenge = 16      // Longest challenge ID accepted, e.g. 2026-W42 or 2026-10-17
+	maxBoardSide = 1000    // Largest board width or height accepted
+	maxBodyBytes = 1 << 12 // Largest submission accepted
+)
+
+// Score is one submitted score, as go-snake sends it
+type Score struct {
+	Name      string        `json:"name"`
+	Score     int           `json:"score"`
+	Seed      int64         `json:"seed"`
+	Rules     int           `json:"rules"` // Version of go-snake's rules the game was played under
+	Mode      string        `json:"mode"`
+	Challenge string        `json:"challenge,omitempty"` // Week or day of a challenge
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Time      time.Duration `json:"time,omitempty"` // Active play time, 0 if not sent
+	Date      time.Time     `json:"date"`
+}
+
+// Scores are ranked separately for each mode and board size, and each
+// challenge
+type boardKey struct {
+	Mode          string
+	Challenge     string
+	Width, Height int
+}
+
+// Board a score is ranked on
+func (sc Score) board() boardKey {
+	return boardKey{sc.Mode, sc.Challenge, sc.Width, sc.Height}
+}
+
+// Server holds the leaderboards
+type Server struct {
+	mu     sync.Mutex
+	scores []Score // Every kept score, best first
+	path   string  // File the scores are saved to, empty to keep them in memory
+}
+
+// Load the server's scores from path. A missing file is an empty board.
+func NewServer(path string) (*Server, error) {
+	s := &Server{path: path}
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &s.scores); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	s.sort()
+	return s, nil
+}
+
+// Highest score first. Ties go to the quicker score in active play time,
+// with scores sent without one last, then to the older score.
+func (s *Server) sort() {
+	slices.SortStableFunc(s.scores, func(a, b Score) int {
+		return cmp.Or(b.Score-a.Score, cmp.Compare(a.playTime(), b.playTime()), a.Date.Compare(b.Date))
+	})
+}
+
+// Active play time to break ties by, unknown times losing them
+func (sc Score) playTime() time.Duration {
+	if sc.Time <= 0 {
+		return math.MaxInt64
+	}
+	return sc.Time
+}
+
+// Best scores for a board
+func (s *Server) top(key boardKey, n int) []Score {
+	top := []Score{}
+	for _, sc := range s.scores {
+		if sc.board() == key && len(top) < n {
+			top = append(top, sc)
+		}
+	}
+	return top
+}
+
+// Add a score, dropping whatever falls off its board, and save
+func (s *Server) add(sc Score) error {
+	s.scores = append(s.scores, sc)
+	s.sort()
+	counts := make(map[boardKey]int)
+	kept := s.scores[:0]
+	for _, e := range s.scores {
+		key := e.board()
+		if counts[key] < keptScores {
+			kept = append(kept, e)
+		}
+		counts[key]++
+	}
+	s.scores = kept
+
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(s.scores, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, data, 0o644)
+}
+
+// Check a submission is something go-snake could have sent
+func validate(sc Score) error {
+	switch {
+	case sc.Name == "" || len([]rune(sc.Name)) > maxNameLen:
+		return fmt.Errorf("name must be 1 to %d characters", maxNameLen)
+	case sc.Score <= 0:
+		return errors.New("score must be positive")
+	case sc.Mode == "" || strings.ContainsFunc(sc.Mode, func(r rune) bool { return r < 'a' || r > 'z' }):
+		return errors.New("mode must be a lowercase word")
+	case len(sc.Challenge) > maxChallenge || strings.ContainsFunc(sc.Challenge, func(r rune) bool { return !strings.ContainsRune("0123456789-W", r) }):
+		return errors.New("challenge must be a week like 2026-W42 or a day like 2026-10-17")
+	case sc.Width <= 0 || sc.Height <= 0 || sc.Width > maxBoardSide || sc.Height > maxBoardSide:
+		return errors.New("board size is out of range")
+	case sc.Time < 0:
+		return errors.New("time must not be negative")
+	}
+	return nil
+}
+
+// POST /scores adds a score
+func (s *Server) submit(w http.ResponseWriter, r *http.Request) {
+	var sc Score
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes)).Decode(&sc); err != nil {
+		http.Error(w, "bad score: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validate(sc); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// The server's clock dates scores, so they can't be backdated to win ties
+	sc.Date = time.Now().UTC()
+
+	s.mu.Lock()
+	err := s.add(sc)
+	s.mu.Unlock()
+	if err != nil {
+		log.Println("save scores:", err)
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+// GET /scores?mode=&width=&height= lists the best scores for a board, and
+// &challenge= for a challenge's
+func (s *Server) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	width, errW := strconv.Atoi(q.Get("width"))
+	height, errH := strconv.Atoi(q.Get("height"))
+	if q.Get("mode") == "" || errW != nil || errH != nil {
+		http.Error(w, "mode, width and height are required", http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	top := s.top(boardKey{q.Get("mode"), q.Get("challenge"), width, height}, topScores)
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(top)
+}
+
+func main() {
+	addr := flag.String("addr", ":8090", "address to listen on")
+	path := flag.String("data", "", "file to keep scores in between runs (default in memory only)")
+	flag.Parse()
+
+	s, err := NewServer(*path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "snake-leaderboard:", err)
+		os.Exit(2)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /scores", s.submit)
+	mux.HandleFunc("GET /scores", s.list)
+	log.Printf("Serving the leaderboard on %s", *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
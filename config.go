@@ -0,0 +1,663 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config limits
+const (
+	defaultTheme   = "classic"
+	asciiTheme     = "ascii" // Fallback for terminals without UTF-8
+	defaultFoodSet = "classic"
+
+	minBoardWidth  = 10
+	maxBoardWidth  = 200
+	minBoardHeight = 5
+	maxBoardHeight = 100
+)
+
+// Config is the user's config file. Anything left out keeps its default.
+type Config struct {
+	Version int                 `toml:"version"`     // Format version, written by go-snake migrate
+	Theme   string              `toml:"theme"`       // Named set of symbols and colors
+	Palette string              `toml:"palette"`     // Named set of colors over the theme's, empty for none
+	Lang    string              `toml:"lang"`        // Message language, empty to follow $LANG
+	Smooth  bool                `toml:"smooth"`      // Draw the snakes moving between cells
+	Blank   bool                `toml:"blank_cells"` // Leave empty cells blank, whatever the theme
+	Sidebar string              `toml:"sidebar"`     // auto, show or hide; empty for the layout key's last choice
+	Clock   string              `toml:"clock"`       // play, wall, ticks or off: the clock the sidebar shows
+	Zone    string              `toml:"timezone"`    // Zone the daily rollover is shown in, like Europe/Berlin; empty for local time
+	Mouse   string              `toml:"mouse"`       // menus, steer or off
+	Gamepad string              `toml:"gamepad"`     // Joystick device, auto for the first one, or empty for none
+	Layout  string              `toml:"key_layout"`  // Named key bindings the [keys] section is laid over
+	Board   BoardConfig         `toml:"board"`
+	Speed   SpeedConfig         `toml:"speed"`
+	Food    FoodConfig          `toml:"food"`
+	Symbols SymbolConfig        `toml:"symbols"`
+	Colors  ColorConfig         `toml:"colors"`
+	Keys    map[string][]string `toml:"keys"`    // Action name -> key names
+	Plugins []string            `toml:"plugins"` // Plugins to load, by name
+
+	Leaderboard LeaderboardConfig `toml:"leaderboard"`
+	Sound       SoundConfig       `toml:"sound"`
+	Speedrun    SpeedrunConfig    `toml:"speedrun"`
+	Start       StartConfig       `toml:"start"`
+	Telemetry   TelemetryConfig   `toml:"telemetry"`
+}
+
+// SpeedrunConfig sets up the speedrun timer
+type SpeedrunConfig struct {
+	Timer      bool `toml:"timer"`       // Show the timer and splits
+	SplitEvery int  `toml:"split_every"` // Points between splits outside the campaign
+}
+
+// SoundConfig picks how the game sounds
+type SoundConfig struct {
+	Backend string   `toml:"backend"` // bell, tones (oto builds only) or off
+	Bell    []string `toml:"bell"`    // Sounds the bell backend rings for
+}
+
+// LeaderboardConfig points at an online leaderboard
+type LeaderboardConfig struct {
+	URL string `toml:"url"` // Server to send scores to, empty to keep them offline
+}
+
+// TelemetryConfig opts in to sending anonymous stats about each game
+type TelemetryConfig struct {
+	Enabled bool   `toml:"enabled"` // Off unless the player turns it on
+	URL     string `toml:"url"`     // Where reports are POSTed
+}
+
+// BoardConfig sets the size of the playfield in cells
+type BoardConfig struct {
+	Preset string `toml:"preset"` // Named size and pace, over the width and height
+	Width  int    `toml:"width"`
+	Height int    `toml:"height"`
+}
+
+// Take the size of the board preset, if one is picked
+func (b *BoardConfig) usePreset() error {
+	if b.Preset == "" {
+		return nil
+	}
+	p, err := boardPresetByName(b.Preset)
+	if err != nil {
+		return err
+	}
+	b.Width, b.Height = p.Width, p.Height
+	return nil
+}
+
+// SpeedConfig tunes the tick rate. Zero values keep the difficulty preset.
+type SpeedConfig struct {
+	Start          int     `toml:"start"`            // Milliseconds per tick at level 1
+	Min            int     `toml:"min"`              // Fastest tick in milliseconds
+	Acceleration   float64 `toml:"acceleration"`     // Interval multiplier per level
+	PointsPerLevel int     `toml:"points_per_level"` // Points needed per level
+	AspectRatio    float64 `toml:"aspect_ratio"`     // Vertical slowdown to make up for tall cells
+}
+
+// FoodConfig defines the food types and how long they stay around, in steps
+// of a tenth of a second of game time
+type FoodConfig struct {
+	Set         string   `toml:"set"` // Named set of symbols and values
+	Symbols     []string `toml:"symbols"`
+	Values      []int    `toml:"values"`
+	MinTime     int      `toml:"min_time"`
+	MaxTime     int      `toml:"max_time"`
+	RespawnTime int      `toml:"respawn_time"`
+	Count       int      `toml:"count"`   // Foods at once, 0 to scale with the board
+	Mystery     string   `toml:"mystery"` // on, casual (not in competitive games) or off
+}
+
+// SymbolConfig sets the characters used to draw the board
+type SymbolConfig struct {
+	Head        string            `toml:"head"`
+	Body        string            `toml:"body"`
+	Empty       string            `toml:"empty"`
+	Wall        string            `toml:"wall"`
+	Horizontal  string            `toml:"horizontal"`
+	Vertical    string            `toml:"vertical"`
+	TopLeft     string            `toml:"top_left"`
+	TopRight    string            `toml:"top_right"`
+	BottomLeft  string            `toml:"bottom_left"`
+	BottomRight string            `toml:"bottom_right"`
+	Top         string            `toml:"top"`           // Horizontal run along the top edge, empty for horizontal
+	Bottom      string            `toml:"bottom"`        // Along the bottom edge
+	Left        string            `toml:"left"`          // Vertical run down the left edge, empty for vertical
+	Right       string            `toml:"right"`         // Down the right edge
+	Density     string            `toml:"empty_density"` // How many empty cells show the empty symbol
+	Separator   string            `toml:"separator"`     // Line between the sidebar and the board
+	Star        string            `toml:"star"`          // Earned puzzle star
+	StarEmpty   string            `toml:"star_empty"`    // Puzzle star still to earn
+	Check       string            `toml:"check"`         // Completed level mark
+	Crumb       string            `toml:"crumb"`         // Crumb from a special food's burst
+	Special     map[string]string `toml:"special"`       // Special food name -> symbol
+}
+
+// ColorConfig sets the colors used to draw the board, by name
+type ColorConfig struct {
+	Snake  string `toml:"snake"`
+	Snake2 string `toml:"snake2"`
+	Food   string `toml:"food"`
+	Border string `toml:"border"`
+	Wall   string `toml:"wall"`
+	Empty  string `toml:"empty"`
+	Text   string `toml:"text"`
+	Score  string `toml:"score"`
+}
+
+// Color names accepted in the config file
+var colorNames = map[string]Attribute{
+	"default":       ColorDefault,
+	"black":         ColorBlack,
+	"red":           ColorRed,
+	"green":         ColorGreen,
+	"yellow":        ColorYellow,
+	"blue":          ColorBlue,
+	"magenta":       ColorMagenta,
+	"cyan":          ColorCyan,
+	"white":         ColorWhite,
+	"dark_gray":     ColorDarkGray,
+	"light_red":     ColorLightRed,
+	"light_green":   ColorLightGreen,
+	"light_yellow":  ColorLightYellow,
+	"light_blue":    ColorLightBlue,
+	"light_magenta": ColorLightMagenta,
+	"light_cyan":    ColorLightCyan,
+	"light_gray":    ColorLightGray,
+}
+
+// Return a config holding the built-in defaults
+func defaultConfig() *Config {
+	symbols := make([]string, len(foodSymbols))
+	for i, r := range foodSymbols {
+		symbols[i] = string(r)
+	}
+
+	return &Config{
+		Theme:    defaultTheme,
+		Mouse:    mouseMenus,
+		Clock:    clockPlay,
+		Layout:   keyLayoutStandard,
+		Sound:    SoundConfig{Backend: "bell", Bell: []string{"eat", "game_over"}},
+		Speedrun: SpeedrunConfig{SplitEvery: splitEvery},
+		Board:    BoardConfig{Width: width, Height: height},
+		Speed:    SpeedConfig{AspectRatio: aspectRatio},
+		Food: FoodConfig{
+			Set:         defaultFoodSet,
+			Symbols:     symbols,
+			Values:      append([]int(nil), foodValues...),
+			MinTime:     minFoodTime,
+			MaxTime:     maxFoodTime,
+			RespawnTime: foodRespawnTime,
+			Count:       foodCount,
+			Mystery:     mysteryFoods,
+		},
+		Symbols: SymbolConfig{
+			Head:        string(symbolSnakeHead),
+			Body:        string(symbolSnakeBody),
+			Empty:       string(symbolEmptyCell),
+			Wall:        string(symbolWall),
+			Horizontal:  string(symbolBorderHorizontal),
+			Vertical:    string(symbolBorderVertical),
+			TopLeft:     string(symbolBorderTopLeft),
+			TopRight:    string(symbolBorderTopRight),
+			BottomLeft:  string(symbolBorderBottomLeft),
+			BottomRight: string(symbolBorderBottomRight),
+			Density:     densityFull,
+			Separator:   string(symbolSeparator),
+			Star:        string(symbolStar),
+			StarEmpty:   string(symbolStarEmpty),
+			Check:       string(symbolCheck),
+			Crumb:       string(symbolCrumb),
+		},
+		Colors: ColorConfig{
+			Snake:  "green",
+			Snake2: "blue",
+			Food:   "red",
+			Border: "white",
+			Wall:   "white",
+			Empty:  "dark_gray",
+			Text:   "white",
+			Score:  "yellow",
+		},
+	}
+}
+
+// Return the default config file location, ~/.config/go-snake/config.toml
+// on Linux and the platform equivalent elsewhere
+func defaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-snake", "config.toml")
+}
+
+// Load the config file on top of the defaults, with the food set, theme and
+// palette it picks in between. A missing file at the default location is
+// not an error, but a missing file the user asked for explicitly is. A theme
+// or palette given here wins over the file's. With no theme from either,
+// terminals without UTF-8 get the ascii theme.
+func LoadConfig(path string, explicit bool, theme, palette string) (*Config, error) {
+	var data []byte
+	if path != "" {
+		var err error
+		data, err = os.ReadFile(path)
+		if err != nil && (explicit || !errors.Is(err, fs.ErrNotExist)) {
+			return nil, fmt.Errorf("config %s: %w", path, err)
+		}
+	}
+
+	data, err := configFormat.upgradeTOML(data)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+
+	// Decode once to find the theme, palette and food set, then again over
+	// them
+	cfg := defaultConfig()
+	md, err := toml.Decode(string(data), cfg)
+	if err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("config %s: unknown setting %q", path, undecoded[0].String())
+	}
+	if theme == "" {
+		theme = cfg.Theme
+		if !md.IsDefined("theme") && !utf8Terminal() {
+			theme = asciiTheme
+		}
+	}
+	base := defaultConfig()
+	if err := base.loadFoodSet(cfg.Food.Set); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if err := base.loadTheme(theme); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if palette == "" {
+		palette = cfg.Palette
+	}
+	if err := base.loadPalette(palette); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	if _, err := toml.Decode(string(data), base); err != nil {
+		return nil, fmt.Errorf("config %s: %w", path, err)
+	}
+	base.Theme, base.Palette = theme, palette
+	return base, nil
+}
+
+// Take the symbols and colors from a theme asset. A theme can also draw the
+// food its own way, overriding the food set's symbols in order.
+func (c *Config) loadTheme(name string) error {
+	theme := struct {
+		Symbols SymbolConfig `toml:"symbols"`
+		Colors  ColorConfig  `toml:"colors"`
+		Food    []string     `toml:"food"`
+	}{Symbols: c.Symbols, Colors: c.Colors}
+	if err := decodeAsset("theme", "themes", name, &theme); err != nil {
+		return err
+	}
+	c.Symbols, c.Colors = theme.Symbols, theme.Colors
+	if len(theme.Food) > 0 {
+		if len(theme.Food) < len(c.Food.Symbols) {
+			return fmt.Errorf("theme %q has %d food symbols but food set %q needs %d", name, len(theme.Food), c.Food.Set, len(c.Food.Symbols))
+		}
+		c.Food.Symbols = theme.Food[:len(c.Food.Symbols)]
+	}
+	return nil
+}
+
+// Take the colors from a palette asset, if one is picked
+func (c *Config) loadPalette(name string) error {
+	if name == "" {
+		return nil
+	}
+	palette := struct {
+		Colors ColorConfig `toml:"colors"`
+	}{c.Colors}
+	if err := decodeAsset("palette", "palettes", name, &palette); err != nil {
+		return err
+	}
+	c.Colors = palette.Colors
+	return nil
+}
+
+// Report whether the terminal takes UTF-8, going by the locale. An unset
+// locale is the C locale, which doesn't; Windows consoles and browsers
+// always do.
+func utf8Terminal() bool {
+	if runtime.GOOS == "windows" || runtime.GOOS == "js" {
+		return true
+	}
+	for _, key := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if v := os.Getenv(key); v != "" {
+			v = strings.ToLower(v)
+			return strings.Contains(v, "utf-8") || strings.Contains(v, "utf8")
+		}
+	}
+	return false
+}
+
+// Take the food symbols and values from a food set asset
+func (c *Config) loadFoodSet(name string) error {
+	set := struct {
+		Symbols []string `toml:"symbols"`
+		Values  []int    `toml:"values"`
+	}{c.Food.Symbols, c.Food.Values}
+	if err := decodeAsset("food set", "foods", name, &set); err != nil {
+		return err
+	}
+	c.Food.Symbols, c.Food.Values = set.Symbols, set.Values
+	return nil
+}
+
+// Validate checks every value and reports a bad one in terms of the
+// config file
+func (c *Config) Validate() error {
+	if c.Lang != "" {
+		if _, err := loadCatalog(c.Lang); err != nil {
+			return fmt.Errorf("lang: %w", err)
+		}
+	}
+	if c.Sidebar != "" && !validSidebar(c.Sidebar) {
+		return fmt.Errorf("sidebar must be %s, %s or %s, got %q", sidebarAuto, sidebarShow, sidebarHide, c.Sidebar)
+	}
+	if !validMouse(c.Mouse) {
+		return fmt.Errorf("mouse must be %s, %s or %s, got %q", mouseMenus, mouseSteer, mouseOff, c.Mouse)
+	}
+	if !validClock(c.Clock) {
+		return fmt.Errorf("clock must be %s, %s, %s or %s, got %q", clockPlay, clockWall, clockTicks, clockOff, c.Clock)
+	}
+	if _, err := loadTimeZone(c.Zone); err != nil {
+		return fmt.Errorf("timezone must be a zone name like Europe/Berlin, got %q", c.Zone)
+	}
+	if !validMystery(c.Food.Mystery) {
+		return fmt.Errorf("food.mystery must be %s, %s or %s, got %q", mysteryOn, mysteryCasual, mysteryOff, c.Food.Mystery)
+	}
+	if c.Speedrun.SplitEvery < 1 {
+		return fmt.Errorf("speedrun.split_every must be at least 1, got %d", c.Speedrun.SplitEvery)
+	}
+	if _, err := boardPresetByName(c.Board.Preset); c.Board.Preset != "" && err != nil {
+		return fmt.Errorf("board.preset: %w", err)
+	}
+	if c.Board.Width < minBoardWidth || c.Board.Width > maxBoardWidth {
+		return fmt.Errorf("board.width must be between %d and %d, got %d", minBoardWidth, maxBoardWidth, c.Board.Width)
+	}
+	if c.Board.Height < minBoardHeight || c.Board.Height > maxBoardHeight {
+		return fmt.Errorf("board.height must be between %d and %d, got %d", minBoardHeight, maxBoardHeight, c.Board.Height)
+	}
+	if err := c.Start.validate(c.Board.Width, c.Board.Height); err != nil {
+		return err
+	}
+
+	if c.Speed.Start < 0 || c.Speed.Min < 0 || c.Speed.PointsPerLevel < 0 {
+		return errors.New("speed.start, speed.min and speed.points_per_level can't be negative")
+	}
+	if c.Speed.Start > 0 && c.Speed.Min > c.Speed.Start {
+		return fmt.Errorf("speed.min (%d) can't be slower than speed.start (%d)", c.Speed.Min, c.Speed.Start)
+	}
+	if c.Speed.Acceleration < 0 || c.Speed.Acceleration > 1 {
+		return fmt.Errorf("speed.acceleration must be between 0 and 1 (e.g. 0.9 for 10%% faster per level), got %g", c.Speed.Acceleration)
+	}
+	if c.Speed.AspectRatio <= 0 {
+		return fmt.Errorf("speed.aspect_ratio must be greater than 0, got %g", c.Speed.AspectRatio)
+	}
+
+	if len(c.Food.Symbols) == 0 {
+		return errors.New("food.symbols needs at least one symbol")
+	}
+	if len(c.Food.Symbols) != len(c.Food.Values) {
+		return fmt.Errorf("food.symbols has %d entries but food.values has %d; they must match", len(c.Food.Symbols), len(c.Food.Values))
+	}
+	for i, s := range c.Food.Symbols {
+		if utf8.RuneCountInString(s) != 1 {
+			return fmt.Errorf("food.symbols[%d] must be a single character, got %q", i, s)
+		}
+	}
+	for i, v := range c.Food.Values {
+		if v <= 0 {
+			return fmt.Errorf("food.values[%d] must be positive, got %d", i, v)
+		}
+	}
+	if c.Food.MinTime <= 0 {
+		return fmt.Errorf("food.min_time must be positive, got %d", c.Food.MinTime)
+	}
+	if c.Food.MaxTime <= c.Food.MinTime {
+		return fmt.Errorf("food.max_time (%d) must be greater than food.min_time (%d)", c.Food.MaxTime, c.Food.MinTime)
+	}
+	if c.Food.RespawnTime <= 0 {
+		return fmt.Errorf("food.respawn_time must be positive, got %d", c.Food.RespawnTime)
+	}
+	if c.Food.Count < 0 {
+		return fmt.Errorf("food.count can't be negative, got %d", c.Food.Count)
+	}
+
+	for name, s := range c.Symbols.fields() {
+		if utf8.RuneCountInString(*s) != 1 {
+			return fmt.Errorf("symbols.%s must be a single character, got %q", name, *s)
+		}
+	}
+	for name, s := range c.Symbols.edges() {
+		if *s != "" && utf8.RuneCountInString(*s) != 1 {
+			return fmt.Errorf("symbols.%s must be a single character or left out, got %q", name, *s)
+		}
+	}
+	if !slices.Contains(emptyDensities, c.Symbols.Density) {
+		return fmt.Errorf("symbols.empty_density must be full, half, sparse or blank, got %q", c.Symbols.Density)
+	}
+	for name, s := range c.Symbols.Special {
+		if specialFoodByName(name) == nil {
+			return fmt.Errorf("symbols.special: unknown special food %q", name)
+		}
+		if utf8.RuneCountInString(s) != 1 {
+			return fmt.Errorf("symbols.special.%s must be a single character, got %q", name, s)
+		}
+	}
+	for name, s := range c.Colors.fields() {
+		if _, ok := colorNames[strings.ToLower(*s)]; !ok {
+			return fmt.Errorf("colors.%s: unknown color %q (want one of %s)", name, *s, strings.Join(sortedColorNames(), ", "))
+		}
+	}
+
+	if _, ok := keyLayouts[c.Layout]; !ok {
+		return fmt.Errorf("key_layout must be %s, got %q", strings.Join(keyLayoutNames(), " or "), c.Layout)
+	}
+	if _, err := NewKeyBindings(c.Keys); err != nil {
+		return err
+	}
+	if err := checkPlugins(c.Plugins); err != nil {
+		return fmt.Errorf("plugins: %w", err)
+	}
+
+	if _, ok := audioBackends[c.Sound.Backend]; !ok {
+		return fmt.Errorf("sound.backend: unknown backend %q (want one of %s)", c.Sound.Backend, strings.Join(audioBackendNames(), ", "))
+	}
+	for _, name := range c.Sound.Bell {
+		if _, ok := soundNames[name]; !ok {
+			return fmt.Errorf("sound.bell: unknown sound %q (want one of %s)", name, strings.Join(sortedSoundNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// Apply copies the config into the game's tunables. Call Validate first.
+func (c *Config) Apply() {
+	lang := c.Lang
+	if lang == "" {
+		lang = envLang()
+	}
+	setLang(lang) // A broken catalog just leaves the messages in English
+
+	width, height = c.Board.Width, c.Board.Height
+	boardPace = 1
+	if p, err := boardPresetByName(c.Board.Preset); err == nil {
+		boardPace = p.Pace
+	}
+	smoothMotion = c.Smooth
+	blankCells = c.Blank
+	mouseMode = c.Mouse
+	clockMode = c.Clock
+	timeZone, _ = loadTimeZone(c.Zone)
+	useKeyLayout(c.Layout)
+	aspectRatio = c.Speed.AspectRatio
+
+	foodValues = append([]int(nil), c.Food.Values...)
+	minFoodTime, maxFoodTime, foodRespawnTime = c.Food.MinTime, c.Food.MaxTime, c.Food.RespawnTime
+	foodCount = c.Food.Count
+	mysteryFoods = c.Food.Mystery
+	speedrunTimer, splitEvery = c.Speedrun.Timer, c.Speedrun.SplitEvery
+	startConfig = c.Start
+	c.applyLook()
+}
+
+// Copy the config's symbols and colors into the game's, which is all a
+// change of theme touches
+func (c *Config) applyLook() {
+	foodSymbols = make([]rune, len(c.Food.Symbols))
+	for i, s := range c.Food.Symbols {
+		foodSymbols[i] = firstRune(s)
+	}
+	symbolSnakeHead = firstRune(c.Symbols.Head)
+	symbolSnakeBody = firstRune(c.Symbols.Body)
+	symbolEmptyCell = firstRune(c.Symbols.Empty)
+	symbolWall = firstRune(c.Symbols.Wall)
+	symbolBorderHorizontal = firstRune(c.Symbols.Horizontal)
+	symbolBorderVertical = firstRune(c.Symbols.Vertical)
+	symbolBorderTopLeft = firstRune(c.Symbols.TopLeft)
+	symbolBorderTopRight = firstRune(c.Symbols.TopRight)
+	symbolBorderBottomLeft = firstRune(c.Symbols.BottomLeft)
+	symbolBorderBottomRight = firstRune(c.Symbols.BottomRight)
+	symbolBorderTop = edgeRune(c.Symbols.Top, symbolBorderHorizontal)
+	symbolBorderBottom = edgeRune(c.Symbols.Bottom, symbolBorderHorizontal)
+	symbolBorderLeft = edgeRune(c.Symbols.Left, symbolBorderVertical)
+	symbolBorderRight = edgeRune(c.Symbols.Right, symbolBorderVertical)
+	emptyDensity = c.Symbols.Density
+	symbolSeparator = firstRune(c.Symbols.Separator)
+	symbolStar = firstRune(c.Symbols.Star)
+	symbolStarEmpty = firstRune(c.Symbols.StarEmpty)
+	symbolCheck = firstRune(c.Symbols.Check)
+	symbolCrumb = firstRune(c.Symbols.Crumb)
+	for i := range specialFoods {
+		if sym, ok := c.Symbols.Special[specialFoods[i].Name]; ok {
+			specialFoods[i].Symbol = firstRune(sym)
+		}
+	}
+
+	playerColors = []Attribute{color(c.Colors.Snake), color(c.Colors.Snake2)}
+	colorFood = color(c.Colors.Food)
+	colorBorder = color(c.Colors.Border)
+	colorWall = color(c.Colors.Wall)
+	colorEmpty = color(c.Colors.Empty)
+	colorText = color(c.Colors.Text)
+	colorScore = color(c.Colors.Score)
+}
+
+// Adjust a difficulty preset to the board's pace and with any speed
+// settings from the config
+func (s SpeedConfig) adjust(d Difficulty) Difficulty {
+	d = paced(d)
+	if s.Start > 0 {
+		d.StartSpeed = s.Start
+		d.MinSpeed = min(d.MinSpeed, s.Start)
+	}
+	if s.Min > 0 {
+		d.MinSpeed = s.Min
+	}
+	if s.Acceleration > 0 {
+		d.Acceleration = s.Acceleration
+	}
+	if s.PointsPerLevel > 0 {
+		d.PointsPerLevel = s.PointsPerLevel
+	}
+	return d
+}
+
+// Symbol settings by config name, for validation
+func (s *SymbolConfig) fields() map[string]*string {
+	return map[string]*string{
+		"head":         &s.Head,
+		"body":         &s.Body,
+		"empty":        &s.Empty,
+		"wall":         &s.Wall,
+		"horizontal":   &s.Horizontal,
+		"vertical":     &s.Vertical,
+		"top_left":     &s.TopLeft,
+		"top_right":    &s.TopRight,
+		"bottom_left":  &s.BottomLeft,
+		"bottom_right": &s.BottomRight,
+		"separator":    &s.Separator,
+		"star":         &s.Star,
+		"star_empty":   &s.StarEmpty,
+		"check":        &s.Check,
+		"crumb":        &s.Crumb,
+	}
+}
+
+// Border edge settings by config name, for validation. Unlike the other
+// symbols these can be left out.
+func (s *SymbolConfig) edges() map[string]*string {
+	return map[string]*string{
+		"top":    &s.Top,
+		"bottom": &s.Bottom,
+		"left":   &s.Left,
+		"right":  &s.Right,
+	}
+}
+
+// Color settings by config name, for validation
+func (c *ColorConfig) fields() map[string]*string {
+	return map[string]*string{
+		"snake":  &c.Snake,
+		"snake2": &c.Snake2,
+		"food":   &c.Food,
+		"border": &c.Border,
+		"wall":   &c.Wall,
+		"empty":  &c.Empty,
+		"text":   &c.Text,
+		"score":  &c.Score,
+	}
+}
+
+// Look up a validated color name
+func color(name string) Attribute {
+	return colorNames[strings.ToLower(name)]
+}
+
+func firstRune(s string) rune {
+	r, _ := utf8.DecodeRuneInString(s)
+	return r
+}
+
+// The symbol for one edge of the border, or the one for its whole run when
+// the edge has none of its own
+func edgeRune(s string, run rune) rune {
+	if s == "" {
+		return run
+	}
+	return firstRune(s)
+}
+
+func sortedColorNames() []string {
+	names := make([]string, 0, len(colorNames))
+	for name := range colorNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// configSetting is one setting for the settings menu to write back to the
+// config file
+type configSetting struct {
+	Table string // Section it's in, "" for the top level
+	Key   string
+	Value any // string, int or []string
+}
+
+// Name of a setting as Validate reports it, e.g. "speed.start"
+func (s configSetting) name() string {
+	if s.Table == "" {
+		return s.Key
+	}
+	return s.Table + "." + s.Key
+}
+
+// Write settings back to the config file, creating it if need be. Each is
+// put in place of the line that sets it, or added to its section, so the
+// rest of the file keeps its comments and layout.
+func saveConfigSettings(path string, changes []configSetting) error {
+	if path == "" || len(changes) == 0 {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return withFileLock(path, func() error {
+		perm := fs.FileMode(0o644)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			data = nil
+		} else if err != nil {
+			return err
+		} else if info, err := os.Stat(path); err == nil {
+			perm = info.Mode().Perm()
+		}
+
+		if data, err = configFormat.upgradeTOML(data); err != nil {
+			return err
+		}
+		data = stampTOML(data, configFormat.Version)
+		for _, s := range changes {
+			if data, err = setTOML(data, s); err != nil {
+				return fmt.Errorf("%s: %w", s.name(), err)
+			}
+		}
+		return writeFileAtomic(path, data, perm)
+	})
+}
+
+// Set a setting in a TOML file's text. A setting written some way the line
+// edit can't follow, like a dotted key, an inline table or a value over
+// several lines, has the whole file encoded again instead, losing its
+// comments.
+func setTOML(data []byte, s configSetting) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(map[string]any{s.Key: s.Value}); err != nil {
+		return nil, err
+	}
+	edited := editTOML(data, s.Table, s.Key, strings.TrimSpace(buf.String()))
+	if s.readsBack(edited) {
+		return edited, nil
+	}
+
+	doc := map[string]any{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, err
+	}
+	table := doc
+	if s.Table != "" {
+		t, ok := doc[s.Table].(map[string]any)
+		if !ok {
+			t = map[string]any{}
+			doc[s.Table] = t
+		}
+		table = t
+	}
+	table[s.Key] = s.Value
+	buf.Reset()
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Check whether a TOML file's text holds the setting's value
+func (s configSetting) readsBack(data []byte) bool {
+	doc := map[string]any{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return false
+	}
+	table := doc
+	if s.Table != "" {
+		t, ok := doc[s.Table].(map[string]any)
+		if !ok {
+			return false
+		}
+		table = t
+	}
+	v, ok := table[s.Key]
+	return ok && fmt.Sprint(v) == fmt.Sprint(s.Value)
+}
+
+// Put a line setting a key in place of the one already setting it in a
+// section of a TOML file's text, or after the last one in the section. A
+// missing section is added at the end.
+func editTOML(data []byte, table, key, line string) []byte {
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(data) == 0 {
+		lines = nil
+	}
+	section, insert := "", -1
+	if table == "" {
+		insert = 0
+	}
+	for i, l := range lines {
+		text, _, _ := strings.Cut(l, "#")
+		text = strings.TrimSpace(text)
+		if strings.HasPrefix(text, "[") {
+			section = strings.TrimSpace(strings.Trim(text, "[]"))
+			if section == table {
+				insert = i + 1
+			}
+			continue
+		}
+		if section != table || text == "" {
+			continue
+		}
+		if k, _, ok := strings.Cut(text, "="); ok && strings.TrimSpace(k) == key {
+			lines[i] = line
+			return []byte(strings.Join(lines, "\n") + "\n")
+		}
+		insert = i + 1
+	}
+
+	if insert < 0 {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "["+table+"]", line)
+	} else {
+		lines = append(lines[:insert], append([]string{line}, lines[insert:]...)...)
+	}
+	return []byte(strings.Join(lines, "\n") + "\n")
+}
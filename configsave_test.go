@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetTOML(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		setting configSetting
+		want    string // "" when only the value is checked, for a file encoded again
+	}{
+		{"new file", "", configSetting{"", "theme", "neon"},
+			"theme = \"neon\"\n"},
+		{"replaced", "# My config\ntheme = \"retro\" \nlang = \"de\"\n", configSetting{"", "theme", "neon"},
+			"# My config\ntheme = \"neon\"\nlang = \"de\"\n"},
+		{"after the last top-level key", "theme = \"retro\"\n\n[speed]\nstart = 100\n", configSetting{"", "lang", "en"},
+			"theme = \"retro\"\nlang = \"en\"\n\n[speed]\nstart = 100\n"},
+		{"in its section", "[speed]\nstart = 100 # ms\n\n[board]\nwidth = 40\n", configSetting{"speed", "min", 30},
+			"[speed]\nstart = 100 # ms\nmin = 30\n\n[board]\nwidth = 40\n"},
+		{"new section", "theme = \"retro\"\n", configSetting{"board", "width", 50},
+			"theme = \"retro\"\n\n[board]\nwidth = 50\n"},
+		{"same key in another section", "start = 1\n[speed]\nstart = 100\n", configSetting{"speed", "start", 90},
+			"start = 1\n[speed]\nstart = 90\n"},
+		{"commented out", "# theme = \"classic\"\n", configSetting{"", "theme", "neon"},
+			"theme = \"neon\"\n# theme = \"classic\"\n"},
+		{"list", "[keys]\nup = [\"k\"]\n", configSetting{"keys", "up", []string{"w", "up"}},
+			"[keys]\nup = [\"w\", \"up\"]\n"},
+		{"dotted key", "speed.start = 100\n", configSetting{"speed", "start", 90}, ""},
+	}
+	for _, tt := range tests {
+		got, err := setTOML([]byte(tt.data), tt.setting)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if !tt.setting.readsBack(got) {
+			t.Errorf("%s: %q doesn't hold %s = %v", tt.name, got, tt.setting.name(), tt.setting.Value)
+		}
+		if tt.want != "" && string(got) != tt.want {
+			t.Errorf("%s: got %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Saving settings keeps the file's version stamp and the rest of its text
+func TestSaveConfigSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.toml")
+	changes := []configSetting{{"", "theme", "neon"}, {"speed", "start", 90}}
+	if err := saveConfigSettings(path, changes); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveConfigSettings(path, []configSetting{{"", "theme", "retro"}}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []configSetting{{"", "theme", "retro"}, {"speed", "start", 90}, {"", "version", configFormat.Version}} {
+		if !s.readsBack(data) {
+			t.Errorf("%q doesn't hold %s = %v", data, s.name(), s.Value)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Built-in assets: levels, puzzles, themes, food sets and message catalogs,
+// one per file. Level and puzzle file names start with a number giving the
+// order they are listed in, easiest first, e.g. "05-box.txt" is the fifth
+// level and is called "box".
+//
+//go:embed content
+var content embed.FS
+
+// Directory of the user's own assets, laid out like the built-in ones. A
+// file here replaces the built-in file of the same name, and new files are
+// added alongside them, so the game stays a single binary but can still be
+// modded.
+var userAssetDir = defaultUserAssetDir()
+
+// Return ~/.config/go-snake/assets on Linux and the platform equivalent
+// elsewhere, next to the config file
+func defaultUserAssetDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "go-snake", "assets")
+}
+
+// First problem found with the user's assets, reported at startup
+var assetErr error
+
+// Note a bad user asset, keeping the first one
+func assetError(err error) {
+	if assetErr == nil {
+		assetErr = err
+	}
+}
+
+// Read an asset, preferring the user's copy over the built-in one
+func readAsset(dir, file string) ([]byte, error) {
+	if userAssetDir != "" {
+		data, err := os.ReadFile(filepath.Join(userAssetDir, dir, file))
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return data, err
+		}
+	}
+	return fs.ReadFile(content, path.Join("content", dir, file))
+}
+
+// File names in an asset directory, built-in and user's own, in order
+func assetFiles(dir string) []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(entries []fs.DirEntry) {
+		for _, e := range entries {
+			if !e.IsDir() && !seen[e.Name()] {
+				seen[e.Name()] = true
+				names = append(names, e.Name())
+			}
+		}
+	}
+
+	entries, err := fs.ReadDir(content, path.Join("content", dir))
+	if err != nil {
+		panic(err) // Embedded at build time, so this can't happen
+	}
+	add(entries)
+	if userAssetDir != "" {
+		entries, err := os.ReadDir(filepath.Join(userAssetDir, dir))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			assetError(err)
+		}
+		add(entries)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Names of the assets in a directory, without order numbers or extensions
+func assetNames(dir string) []string {
+	var names []string
+	for _, file := range assetFiles(dir) {
+		names = append(names, contentName(file))
+	}
+	return names
+}
+
+// An asset file's name and contents
+type contentFile struct {
+	Name string
+	Text string
+}
+
+// Read every asset in a directory, in order
+func contentFiles(dir string) []contentFile {
+	var files []contentFile
+	for _, file := range assetFiles(dir) {
+		data, err := readAsset(dir, file)
+		if err != nil {
+			assetError(err)
+			continue
+		}
+		files = append(files, contentFile{Name: contentName(file), Text: string(data)})
+	}
+	return files
+}
+
+// Name of a content file without its order number and extension
+func contentName(file string) string {
+	name := strings.TrimSuffix(file, path.Ext(file))
+	if i := strings.IndexByte(name, '-'); i > 0 && strings.Trim(name[:i], "0123456789") == "" {
+		name = name[i+1:]
+	}
+	return name
+}
+
+// Decode a named TOML asset of some kind, such as a theme, into v.
+// Settings v has no place for are an error.
+func decodeAsset(kind, dir, name string, v any) error {
+	data, err := readAsset(dir, name+".toml")
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no %s %q (have %s)", kind, name, strings.Join(assetNames(dir), ", "))
+	} else if err != nil {
+		return err
+	}
+
+	md, err := toml.Decode(string(data), v)
+	if err != nil {
+		return fmt.Errorf("%s/%s: %w", dir, name, err)
+	}
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		return fmt.Errorf("%s/%s: unknown setting %q", dir, name, undecoded[0].String())
+	}
+	return nil
+}
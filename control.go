@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// JSON-RPC error codes
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcNotAllowed     = -32000 // The game can't do that right now
+)
+
+// Names of the game's states, as reported to control clients
+var stateNames = map[State]string{
+	StateMenu:     "menu",
+	StatePlaying:  "playing",
+	StatePaused:   "paused",
+	StateGameOver: "game over",
+}
+
+// ControlServer lets other programs on the same machine watch and drive the
+// game with JSON-RPC 2.0, a request or notification per line, over a Unix
+// socket or a loopback TCP port. Clients can ask for the state, steer,
+// pause and resume, and subscribe to a "tick" notification after every
+// tick. Requests are handled by the game loop, one at a time.
+type ControlServer struct {
+	Requests  chan *ControlRequest // For the game loop to handle
+	ln        net.Listener
+	path      string // Socket file to remove on close, if any
+	watchOnly bool   // Can clients only watch, not steer or pause?
+	mu        sync.Mutex
+	subs      map[*controlClient]bool
+}
+
+// ControlRequest is a call from a client, waiting on its reply
+type ControlRequest struct {
+	Method string
+	Params json.RawMessage
+	client *controlClient
+	reply  chan rpcResponse
+}
+
+// One connected client. Messages for it queue up to be written, and a
+// client too slow to keep up misses ticks rather than holding up the game.
+type controlClient struct {
+	conn net.Conn
+	out  chan any
+}
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"` // Absent for notifications, which get no reply
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcNotification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params"`
+}
+
+// ControlState is what a client sees of the game: the board, as sent to a
+// joining player, with the tick and the game's state by name, and what
+// the sidebar shows about the game and the session
+type ControlState struct {
+	Tick    int             `json:"tick"`
+	Status  string          `json:"status"` // menu, playing, paused or game over
+	Mode    string          `json:"mode"`
+	Width   int             `json:"width"`
+	Height  int             `json:"height"`
+	Stats   GameStats       `json:"stats"`             // What player 1 has done this game
+	Session *controlSession `json:"session,omitempty"` // Games since go-snake started
+	Notices []string        `json:"notices,omitempty"` // Toasts up now, oldest first
+	*NetFrame
+}
+
+// The session as control clients see it
+type controlSession struct {
+	Games int    `json:"games"`
+	Best  int    `json:"best"`
+	Goal  string `json:"goal,omitempty"` // e.g. "beat 80 3 times", empty for none
+	Met   int    `json:"met"`            // Games that reached the goal
+}
+
+// Take the state of the game for control clients
+func newControlState(g *Game) *ControlState {
+	cs := &ControlState{
+		Tick:     g.ticks,
+		Status:   stateNames[g.state],
+		Mode:     g.mode,
+		Width:    width,
+		Height:   height,
+		Stats:    g.gameStats(),
+		NetFrame: newNetFrame(g, 0),
+	}
+	if s := g.session; s != nil {
+		cs.Session = &controlSession{Games: s.Games, Best: s.Best, Met: s.Met}
+		if s.Goal != nil {
+			cs.Session.Goal = s.Goal.String()
+		}
+	}
+	for _, t := range g.toasts {
+		cs.Notices = append(cs.Notices, t.Text)
+	}
+	return cs
+}
+
+// Listen for control clients on addr: "unix:" and a socket path, or a
+// loopback host and port. Other hosts are refused, as anyone who can
+// connect can play.
+func ListenControl(addr string) (*ControlServer, error) {
+	cs := &ControlServer{Requests: make(chan *ControlRequest), subs: make(map[*controlClient]bool)}
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if err := removeStaleSocket(path); err != nil {
+			return nil, err
+		}
+		ln, err := net.Listen("unix", path)
+		if err != nil {
+			return nil, err
+		}
+		cs.ln, cs.path = ln, path
+	} else {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+			return nil, fmt.Errorf("%s isn't a loopback address; use localhost, 127.0.0.1 or a unix: socket", host)
+		}
+		if cs.ln, err = net.Listen("tcp", addr); err != nil {
+			return nil, err
+		}
+	}
+	go cs.accept()
+	return cs, nil
+}
+
+// Remove a socket left over from before at path. Anything else there is
+// left be and reported, so a mistyped path can't delete a file.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSocket == 0 {
+		return fmt.Errorf("%s is already there and isn't a socket", path)
+	}
+	return os.Remove(path)
+}
+
+func (cs *ControlServer) accept() {
+	for {
+		conn, err := cs.ln.Accept()
+		if err != nil {
+			return
+		}
+		c := &controlClient{conn: conn, out: make(chan any, 16)}
+		go cs.read(c)
+		go c.write()
+	}
+}
+
+// Pass a client's requests on to the game loop until it disconnects
+func (cs *ControlServer) read(c *controlClient) {
+	defer func() {
+		cs.mu.Lock()
+		delete(cs.subs, c)
+		cs.mu.Unlock()
+		close(c.out)
+	}()
+	sc := bufio.NewScanner(c.conn)
+	for sc.Scan() {
+		var req rpcRequest
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			c.out <- rpcResponse{JSONRPC: "2.0", ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, err.Error()}}
+			continue
+		}
+		r := &ControlRequest{Method: req.Method, Params: req.Params, client: c, reply: make(chan rpcResponse, 1)}
+		cs.Requests <- r
+		resp := <-r.reply
+		if req.ID != nil {
+			resp.JSONRPC, resp.ID = "2.0", req.ID
+			c.out <- resp
+		}
+	}
+}
+
+// Write queued messages until the client disconnects
+func (c *controlClient) write() {
+	enc := json.NewEncoder(c.conn)
+	for m := range c.out {
+		if err := enc.Encode(m); err != nil {
+			break
+		}
+	}
+	c.conn.Close()
+	for range c.out {
+		// Drain until the reader is done
+	}
+}
+
+// Tick sends the game's state to every subscribed client
+func (cs *ControlServer) Tick(g *Game) {
+	cs.mu.Lock()
+	defer cs.mu.Unlock()
+	if len(cs.subs) == 0 {
+		return
+	}
+	n := rpcNotification{JSONRPC: "2.0", Method: "tick", Params: newControlState(g)}
+	for c := range cs.subs {
+		select {
+		case c.out <- n:
+		default:
+		}
+	}
+}
+
+// Close stops listening and removes the socket file
+func (cs *ControlServer) Close() {
+	cs.ln.Close()
+	if cs.path != "" {
+		os.Remove(cs.path)
+	}
+}
+
+// Handle a request in the game loop. Reports whether the game was paused or
+// resumed, so the loop can start or stop its ticks.
+func (cs *ControlServer) Handle(g *Game, r *ControlRequest) bool {
+	result, err := cs.call(g, r)
+	resp := rpcResponse{Result: result}
+	var rerr *rpcError
+	if errors.As(err, &rerr) {
+		resp.Error = rerr
+	} else if err != nil {
+		resp.Error = &rpcError{rpcNotAllowed, err.Error()}
+	}
+	r.reply <- resp
+	return err == nil && (r.Method == "pause" || r.Method == "resume")
+}
+
+func (e *rpcError) Error() string { return e.Message }
+
+// Carry out a request
+func (cs *ControlServer) call(g *Game, r *ControlRequest) (any, error) {
+	if cs.watchOnly && (r.Method == "steer" || r.Method == "pause" || r.Method == "resume") {
+		return nil, errors.New("this socket can only watch the game")
+	}
+	switch r.Method {
+	case "state":
+		return newControlState(g), nil
+	case "steer":
+		var p struct {
+			Dir    string `json:"dir"`
+			Player int    `json:"player"`
+		}
+		if err := json.Unmarshal(r.Params, &p); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		dir, ok := directionByName(p.Dir)
+		if !ok {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("unknown direction %q (want up, right, down or left)", p.Dir)}
+		}
+		if p.Player < 0 || p.Player >= len(g.snakes) {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("no player %d", p.Player)}
+		}
+		if g.state != StatePlaying {
+			return nil, errors.New("the game isn't being played")
+		}
+		g.press(p.Player, dir)
+		return true, nil
+	case "pause", "resume":
+		want := map[string]State{"pause": StatePaused, "resume": StatePlaying}[r.Method]
+		if g.state != StatePlaying && g.state != StatePaused {
+			return nil, errors.New("the game isn't being played")
+		}
+		// Versus games only pause when both players agree, by their keys
+		if g.pauseVote != nil {
+			return nil, errors.New("versus games pause by the players' vote")
+		}
+		g.state = want
+		g.showChallenge, g.showIntro = false, false
+		return true, nil
+	case "subscribe", "unsubscribe":
+		cs.mu.Lock()
+		if r.Method == "subscribe" {
+			cs.subs[r.client] = true
+		} else {
+			delete(cs.subs, r.client)
+		}
+		cs.mu.Unlock()
+		return true, nil
+	}
+	return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("unknown method %q (want state, steer, pause, resume, subscribe or unsubscribe)", r.Method)}
+}
+
+// Look up a direction by name
+func directionByName(name string) (Direction, bool) {
+	for dir, n := range directionNames {
+		if strings.EqualFold(n, name) {
+			return dir, true
+		}
+	}
+	return Up, false
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+// Pausing over the control socket works in single player games but leaves
+// versus games to their pause vote
+func TestControlPause(t *testing.T) {
+	tests := []struct {
+		name   string
+		vote   bool
+		method string
+		state  State
+		want   State
+		ok     bool
+	}{
+		{"pause", false, "pause", StatePlaying, StatePaused, true},
+		{"resume", false, "resume", StatePaused, StatePlaying, true},
+		{"pause when over", false, "pause", StateGameOver, StateGameOver, false},
+		{"pause versus", true, "pause", StatePlaying, StatePlaying, false},
+		{"resume versus", true, "resume", StatePaused, StatePaused, false},
+	}
+	for _, tt := range tests {
+		g := NewGame(nil, nil, maxPlayers, 1)
+		g.state = tt.state
+		if tt.vote {
+			g.pauseVote = NewPauseVote(maxPlayers)
+		}
+		_, err := (&ControlServer{}).call(g, &ControlRequest{Method: tt.method})
+		if (err == nil) != tt.ok || g.state != tt.want {
+			t.Errorf("%s: got %v, error %v; want %v", tt.name, stateNames[g.state], err, stateNames[tt.want])
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Countdown constants
+const (
+	startCountdown = 3 * time.Second // Time to get ready before the snakes move
+)
+
+// Run down the countdown before a game starts. Reports whether it is still
+// going, in which case nothing moves this tick.
+func (g *Game) updateCountdown() bool {
+	if g.countdown <= 0 {
+		return false
+	}
+	g.countdown -= g.updateInterval()
+	return true
+}
+
+// Point a snake the way its first move will go. Any direction can be queued
+// before the start, even straight back, which turns the snake around where
+// it lies.
+func (s *Snake) face(dir Direction) {
+	if dir == s.direction.Opposite() {
+		slices.Reverse(s.body)
+	}
+	s.direction = dir
+}
+
+// Draw the seconds left before the start over the game area
+func drawCountdown(g *Game) {
+	secs := int((g.countdown + time.Second - 1) / time.Second)
+	msg := fmt.Sprintf("GET READY: %d", secs)
+	hint := "Steer now to pick your first move"
+	drawCentered(viewHeight/2, msg, colorScore|AttrBold)
+	drawCentered(viewHeight/2+1, hint, colorText)
+}
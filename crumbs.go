@@ -0,0 +1,92 @@
+package main
+
+// Crumb burst constants
+const (
+	crumbValue      = 1  // Points for each crumb
+	crumbSweepBonus = 5  // Points for eating every crumb of a burst
+	crumbTicks      = 25 // Steps a burst lasts before its crumbs are gone
+	crumbRadius     = 2  // Cells from where the special food was to the ring
+	crumbRules      = 3  // First rules version with crumb bursts
+)
+
+// Crumb is one of the low-value foods a special food bursts into when it's
+// eaten. A burst's crumbs all go at once, so sweeping up the last one means
+// the snake got them all.
+type Crumb struct {
+	At    Point
+	Timer int // Steps until the burst it's from is gone
+	Burst int // Which burst it's from
+}
+
+// Scatter a ring of crumbs around where a special food was eaten. The ring
+// is every other cell crumbRadius out, leaving out cells that are taken or,
+// in walls mode, off the board.
+func (g *Game) burstCrumbs(at Point) {
+	if g.rules < crumbRules {
+		return
+	}
+	g.bursts++
+	for dy := -crumbRadius; dy <= crumbRadius; dy += crumbRadius {
+		for dx := -crumbRadius; dx <= crumbRadius; dx += crumbRadius {
+			p, ok := g.crumbCell(at.X+dx, at.Y+dy)
+			if (dx == 0 && dy == 0) || !ok {
+				continue
+			}
+			g.crumbs = append(g.crumbs, Crumb{At: p, Timer: g.mods.foodTime(crumbTicks), Burst: g.bursts})
+		}
+	}
+}
+
+// The cell a crumb lands on at x, y, wrapping round the board's edges
+// unless they're walls, and whether one can land there
+func (g *Game) crumbCell(x, y int) (Point, bool) {
+	if g.Bordered() && (x < 0 || x >= width || y < 0 || y >= height) {
+		return Point{}, false
+	}
+	p := Point{X: (x%width + width) % width, Y: (y%height + height) % height}
+	hazards, warned := g.hazardCells()
+	if g.occupied(p) || g.walls[p] || g.isPortal(p) || g.hasFood(p) || hazards[p] || warned[p] {
+		return Point{}, false
+	}
+	return p, true
+}
+
+// Count down the crumbs, dropping those whose burst is over
+func (g *Game) ageCrumbs() {
+	left := g.crumbs[:0]
+	for _, c := range g.crumbs {
+		if c.Timer -= g.steps; c.Timer > 0 {
+			left = append(left, c)
+		}
+	}
+	g.crumbs = left
+}
+
+// Eat any crumb at p, returning whether there was one. The last of a
+// burst's crumbs brings the sweep bonus.
+func (g *Game) eatCrumb(s *Snake, p Point) bool {
+	for i, c := range g.crumbs {
+		if c.At != p {
+			continue
+		}
+		g.crumbs = append(g.crumbs[:i], g.crumbs[i+1:]...)
+		points := crumbValue
+		if !g.burstLeft(c.Burst) {
+			points += crumbSweepBonus
+			g.notify("Swept clean!")
+		}
+		g.award(s, points)
+		return true
+	}
+	return false
+}
+
+// Are any of a burst's crumbs still on the board?
+func (g *Game) burstLeft(burst int) bool {
+	for _, c := range g.crumbs {
+		if c.Burst == burst {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"math/rand"
+	"slices"
+	"strings"
+	"time"
+)
+
+// The daily challenge rolls over at midnight UTC so every player shares the
+// same board no matter where they are. Only the display is local, or in the
+// zone the config names.
+
+// The zone the daily rollover is shown in
+var timeZone = time.Local
+
+// Load a zone by its IANA name, or the local zone for an empty name
+func loadTimeZone(name string) (*time.Location, error) {
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// Daily challenge constants
+const (
+	dailyBoard     = "classic" // Board preset every daily challenge is played on
+	dailyMinBars   = 2         // Fewest mirrored pairs of wall bars on a day's board
+	dailyMaxBars   = 4         // Most
+	dailyMinBar    = 3         // Shortest wall bar, in cells
+	dailyMaxBar    = 6         // Longest
+	dailyMaxWeight = 4         // Most a food type's odds can be over the least likely
+)
+
+// DailyRules is what a day's challenge is played with, rolled from its
+// seed so everyone gets the same
+type DailyRules struct {
+	Date       string
+	Seed       int64
+	Difficulty Difficulty     // Speed, from the easier presets
+	Walls      map[Point]bool // Bars of wall in mirrored pairs
+	FoodMix    []int          // Relative odds of each food type
+}
+
+// Roll the rules for a challenge day. The board is the daily board, so the
+// walls land in the same places for everyone.
+func dailyRules(date string) DailyRules {
+	seed := dailySeed(date)
+	r := rand.New(rand.NewSource(seed))
+	rules := DailyRules{
+		Date:       date,
+		Seed:       seed,
+		Difficulty: difficulties[r.Intn(3)], // Easy, normal or hard: insane isn't for everyone
+		Walls:      dailyWalls(r),
+		FoodMix:    make([]int, len(foodSymbols)),
+	}
+	for i := range rules.FoodMix {
+		rules.FoodMix[i] = 1 + r.Intn(dailyMaxWeight)
+	}
+	return rules
+}
+
+// Lay out walls for a day: straight bars, each with its mirror image across
+// the middle of the board. Bars keep a cell clear of each other, so they
+// never close off a corner, and clear of the rows either side of the snake's
+// start, which it would otherwise wrap round into straight away.
+func dailyWalls(r *rand.Rand) map[Point]bool {
+	walls := make(map[Point]bool)
+	start := height / 2
+	clear := func(p Point) bool {
+		if p.X < 0 || p.Y < 0 || p.X >= width || p.Y >= height {
+			return false
+		}
+		if abs(p.Y-start) <= 1 {
+			return false
+		}
+		for dy := -1; dy <= 1; dy++ {
+			for dx := -1; dx <= 1; dx++ {
+				if walls[Point{X: p.X + dx, Y: p.Y + dy}] {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
+	bars := dailyMinBars + r.Intn(dailyMaxBars-dailyMinBars+1)
+	for tries := 0; bars > 0 && tries < 100; tries++ {
+		length := dailyMinBar + r.Intn(dailyMaxBar-dailyMinBar+1)
+		step := Point{X: 1}
+		if r.Intn(2) == 0 {
+			step = Point{Y: 1}
+		}
+		at := Point{X: r.Intn(width), Y: r.Intn(height)}
+		var bar []Point
+		for i := 0; i < length; i++ {
+			p := Point{X: at.X + i*step.X, Y: at.Y + i*step.Y}
+			bar = append(bar, p, Point{X: width - 1 - p.X, Y: height - 1 - p.Y})
+		}
+		if !slices.ContainsFunc(bar, func(p Point) bool { return !clear(p) }) && !overlapsMirror(bar) {
+			for _, p := range bar {
+				walls[p] = true
+			}
+			bars--
+		}
+	}
+	return walls
+}
+
+// Check whether a bar and its mirror image come within a cell of each
+// other, cells alternating between the two
+func overlapsMirror(bar []Point) bool {
+	for i := 0; i < len(bar); i += 2 {
+		for j := 1; j < len(bar); j += 2 {
+			if abs(bar[i].X-bar[j].X) <= 1 && abs(bar[i].Y-bar[j].Y) <= 1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Set a game up for a day's challenge: its speed, walls and food mix, and
+// none of the player's own modifiers
+func (g *Game) setDaily(r DailyRules) {
+	g.difficulty = r.Difficulty
+	g.mods = 0
+	g.walls = maps.Clone(r.Walls)
+	g.foodMix = slices.Clone(r.FoodMix)
+	g.nextFoodType = g.rollFoodType()
+	g.resetFood()
+}
+
+// Draw the daily challenge announcement over the game area: the day's
+// rules, and whether this is the day's official attempt
+func drawDailyChallenge(g *Game) {
+	rules := dailyRules(g.challenge)
+	drawCentered(2, "DAILY CHALLENGE "+g.challenge, ColorYellow|AttrBold)
+
+	total := 0
+	for _, w := range rules.FoodMix {
+		total += w
+	}
+	var mix []string
+	for i, w := range rules.FoodMix {
+		mix = append(mix, fmt.Sprintf("%c %d%%", foodSymbols[i], w*100/total))
+	}
+	lines := []string{
+		"Speed: " + strings.ToUpper(rules.Difficulty.Name),
+		fmt.Sprintf("Walls: %d cells", len(rules.Walls)),
+		"Food: " + strings.Join(mix, " "),
+	}
+	for i, line := range lines {
+		drawCentered(4+i, line, ColorCyan)
+	}
+
+	status := "Your one official attempt today"
+	if g.dailyLog != nil {
+		if a := g.dailyLog.Official(g.challenge, activeProfile); a != nil {
+			status = fmt.Sprintf("Practice: today's official score was %d", a.Score)
+		}
+	}
+	drawCentered(8, status, colorScore)
+	drawCentered(10, fmt.Sprintf("Press %s to start", keysHint(ActionPause)), ColorWhite)
+	drawCentered(11, keyHint(ActionStats)+" for the calendar", ColorDarkGray)
+}
+
+// Line on the game over screen saying how a daily attempt counted
+func dailyResult(g *Game) string {
+	if g.daily == nil {
+		return ""
+	}
+	if !g.daily.Practice {
+		return "Daily " + g.challenge + ": official score"
+	}
+	if a := g.dailyLog.Official(g.challenge, activeProfile); a != nil {
+		return fmt.Sprintf("Practice run; today's official score is %d", a.Score)
+	}
+	return "Practice run"
+}
+
+// Return the challenge day for a moment in time
+func dailyDate(now time.Time) string {
+	return now.UTC().Format(dailyDateFormat)
+}
+
+// Derive the RNG seed for a challenge day
+func dailySeed(date string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("go-snake daily " + date))
+	return int64(h.Sum64() >> 1)
+}
+
+// Return when the next challenge starts
+func nextDailyRollover(now time.Time) time.Time {
+	y, m, d := now.UTC().Date()
+	return time.Date(y, m, d+1, 0, 0, 0, 0, time.UTC)
+}
+
+// Describe the next rollover in the player's chosen time zone, e.g.
+// "Next daily 02:00 CEST (in 5h12m)"
+func dailyRolloverText(now time.Time, loc *time.Location) string {
+	if loc == nil {
+		loc = timeZone
+	}
+	next := nextDailyRollover(now)
+	left := next.Sub(now).Truncate(time.Minute)
+	return fmt.Sprintf("Next daily %s (in %s)", next.In(loc).Format("15:04 MST"), formatHoursMinutes(left))
+}
+
+// Format a duration as 5h12m
+func formatHoursMinutes(d time.Duration) string {
+	h := int(d.Hours())
+	m := int(d.Minutes()) % 60
+	return fmt.Sprintf("%dh%02dm", h, m)
+}
+
+// Draw a month calendar of daily attempts over the game area. Days with an
+// official attempt show its score; days with only practice runs are marked.
+func drawCalendar(log *DailyLog, profile string, month time.Time, now time.Time, loc *time.Location) {
+	left := boardLeft + 3
+	top := 1
+	colWidth := 5
+
+	first := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	title := first.Format("January 2006")
+	drawCentered(top, title, ColorYellow|AttrBold)
+
+	for i, day := range []string{"Mo", "Tu", "We", "Th", "Fr", "Sa", "Su"} {
+		drawText(left+i*colWidth, top+2, day, ColorWhite|AttrBold)
+	}
+
+	today := dailyDate(now)
+	offset := (int(first.Weekday()) + 6) % 7 // Monday first
+	for d := first; d.Month() == first.Month(); d = d.AddDate(0, 0, 1) {
+		cell := offset + d.Day() - 1
+		x := left + (cell%7)*colWidth
+		y := top + 3 + (cell/7)*2
+
+		date := d.Format(dailyDateFormat)
+		fg := ColorDarkGray
+		if date == today {
+			fg = ColorWhite | AttrBold
+		}
+		drawText(x, y, fmt.Sprintf("%2d", d.Day()), fg)
+
+		if a := log.Official(date, profile); a != nil {
+			mark := fmt.Sprintf("%d", a.Score)
+			color := ColorGreen
+			if a.Abandoned {
+				mark = "x"
+				color = ColorRed
+			}
+			drawText(x, y+1, mark, color)
+		} else if log.hasPractice(date, profile) {
+			drawText(x, y+1, "p", ColorDarkGray)
+		}
+	}
+
+	drawText(left, viewHeight, dailyRolloverText(now, loc), ColorDarkGray)
+}
+
+// Check whether any practice attempt exists for a day
+func (l *DailyLog) hasPractice(date, profile string) bool {
+	for _, a := range l.Attempts {
+		if a.Date == date && a.Profile == profile && a.Practice {
+			return true
+		}
+	}
+	return false
+}
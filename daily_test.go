@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// The challenge day is the same everywhere, only the rollover text is local
+func TestDailyRollover(t *testing.T) {
+	cest := time.FixedZone("CEST", 2*60*60)
+	pdt := time.FixedZone("PDT", -7*60*60)
+	tests := []struct {
+		name string
+		now  time.Time
+		loc  *time.Location
+		date string
+		text string
+	}{
+		{"utc", time.Date(2026, 10, 17, 18, 48, 0, 0, time.UTC), time.UTC, "2026-10-17", "Next daily 00:00 UTC (in 5h12m)"},
+		{"ahead of utc", time.Date(2026, 10, 18, 1, 30, 0, 0, cest), cest, "2026-10-17", "Next daily 02:00 CEST (in 0h30m)"},
+		{"behind utc", time.Date(2026, 10, 17, 18, 0, 0, 0, pdt), pdt, "2026-10-18", "Next daily 17:00 PDT (in 23h00m)"},
+		{"year end", time.Date(2026, 12, 31, 23, 59, 30, 0, time.UTC), time.UTC, "2026-12-31", "Next daily 00:00 UTC (in 0h00m)"},
+	}
+	for _, tt := range tests {
+		if got := dailyDate(tt.now); got != tt.date {
+			t.Errorf("%s: challenge day %s, want %s", tt.name, got, tt.date)
+		}
+		if got := dailyRolloverText(tt.now, tt.loc); got != tt.text {
+			t.Errorf("%s: %q, want %q", tt.name, got, tt.text)
+		}
+	}
+}
+
+// Everyone rolls the same rules for a day, and a new day rolls new ones
+func TestDailyRules(t *testing.T) {
+	today := dailyRules("2026-10-17")
+	if again := dailyRules("2026-10-17"); !reflect.DeepEqual(today, again) {
+		t.Errorf("rolled different rules for the same day: %+v and %+v", today, again)
+	}
+	if tomorrow := dailyRules("2026-10-18"); tomorrow.Seed == today.Seed || reflect.DeepEqual(tomorrow.Walls, today.Walls) {
+		t.Errorf("rolled the same rules two days running")
+	}
+	if n := len(today.Walls); n < 2*dailyMinBars*dailyMinBar || n > 2*dailyMaxBars*dailyMaxBar {
+		t.Errorf("%d wall cells, want %d to %d", n, 2*dailyMinBars*dailyMinBar, 2*dailyMaxBars*dailyMaxBar)
+	}
+}
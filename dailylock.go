@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Daily attempt constants
+const (
+	dailyFileName   = "daily.json"
+	dailyDateFormat = "2006-01-02"
+	dailySaveTicks  = 20 // Most ticks of input an attempt goes without being saved
+)
+
+// DailyAttempt is the record of one player's run at a daily challenge
+type DailyAttempt struct {
+	Date      string      `json:"date"`    // Challenge day, dailyDateFormat
+	Profile   string      `json:"profile"` // Player the attempt belongs to
+	Started   time.Time   `json:"started"`
+	Finished  bool        `json:"finished"`
+	Score     int         `json:"score"`
+	Inputs    []Direction `json:"inputs"`              // Direction chosen on each tick so far
+	Practice  bool        `json:"practice,omitempty"`  // Re-run after the official attempt
+	Abandoned bool        `json:"abandoned,omitempty"` // Crashed or quit while in progress
+
+	// The go-snake playing it, by process ID and when that started, so
+	// another can tell one still being played from one left by a crash
+	Owner        int       `json:"owner,omitempty"`
+	OwnerStarted time.Time `json:"owner_started"`
+}
+
+// DailyLog stores daily attempts so a crash or quit mid-run can't be used to
+// get a second official try at the same day
+type DailyLog struct {
+	Attempts []DailyAttempt `json:"attempts"`
+	path     string
+	owner    int       // This go-snake's process ID
+	started  time.Time // When it started
+}
+
+// When this go-snake started, near enough, to tell it apart from a later
+// process given the same ID
+var processStarted = time.Now()
+
+var errDailyAlreadyPlayed = errors.New("daily challenge already completed today")
+
+// Load the daily attempt log from the data directory
+func LoadDailyLog() (*DailyLog, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return newDailyLog(""), err
+	}
+	log, err := readDailyLog(filepath.Join(dir, dailyFileName))
+	if err != nil {
+		return newDailyLog(""), err // Not saved, so what's there isn't lost
+	}
+	return log, nil
+}
+
+// An empty log kept at path, or only in memory for no path
+func newDailyLog(path string) *DailyLog {
+	return &DailyLog{path: path, owner: os.Getpid(), started: processStarted}
+}
+
+// Read the log at path, empty if there isn't one yet
+func readDailyLog(path string) (*DailyLog, error) {
+	log := newDailyLog(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return log, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, log); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return log, nil
+}
+
+// Save writes the log back to disk, under the same lock as the other data
+// files. Only this go-snake's own attempts are written as it has them:
+// other attempts are written as last saved, by whichever go-snake is
+// playing them, rather than overwritten with an older copy.
+func (l *DailyLog) Save() error {
+	return l.locked(func(saved []DailyAttempt) error {
+		return l.write(l.merged(saved))
+	})
+}
+
+// Run fn holding the lock on the log, with the attempts saved in it
+func (l *DailyLog) locked(fn func(saved []DailyAttempt) error) error {
+	if l.path == "" {
+		return fn(nil)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0o755); err != nil {
+		return err
+	}
+	return withFileLock(l.path, func() error {
+		saved, err := readDailyLog(l.path)
+		if err != nil {
+			return err
+		}
+		return fn(saved.Attempts)
+	})
+}
+
+// The saved attempts with this go-snake's own as it has them, followed by
+// any it has that weren't saved. The log's attempts are left as they are,
+// as the game holds on to the one it's playing.
+func (l *DailyLog) merged(saved []DailyAttempt) []DailyAttempt {
+	attempts := slices.Clone(saved)
+	for _, a := range l.Attempts {
+		if i := slices.IndexFunc(attempts, a.same); i < 0 {
+			attempts = append(attempts, a)
+		} else if l.ours(a) {
+			attempts[i] = a
+		}
+	}
+	return attempts
+}
+
+// Is the attempt this go-snake's own?
+func (l *DailyLog) ours(a DailyAttempt) bool {
+	return a.Owner == l.owner && a.OwnerStarted.Equal(l.started)
+}
+
+// Is the attempt still being played by another go-snake? Attempts saved
+// before owners were kept are taken to be over.
+func (l *DailyLog) playedElsewhere(a DailyAttempt) bool {
+	return a.Owner > 0 && !l.ours(a) && processRunning(a.Owner, a.OwnerStarted)
+}
+
+// Write the given attempts out as the log
+func (l *DailyLog) write(attempts []DailyAttempt) error {
+	if l.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(DailyLog{Attempts: attempts}, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(l.path, data, 0o644)
+}
+
+// Check whether two attempts are the same run, one perhaps further along
+func (a DailyAttempt) same(o DailyAttempt) bool {
+	return a.Date == o.Date && a.Profile == o.Profile && a.Started.Equal(o.Started)
+}
+
+// Official returns the official (non-practice) attempt for a day, if any
+func (l *DailyLog) Official(date, profile string) *DailyAttempt {
+	for i := range l.Attempts {
+		a := &l.Attempts[i]
+		if a.Date == date && a.Profile == profile && !a.Practice {
+			return a
+		}
+	}
+	return nil
+}
+
+// Begin starts an attempt for the given day. The first attempt of the day is
+// official; any later one, including after a crash, is marked as practice.
+// An official attempt left in progress by a crash, or by an earlier game in
+// this go-snake, is closed as abandoned with whatever score its inputs had
+// reached. One another running go-snake is still playing is left to it.
+//
+// The log is checked and saved under its lock, taking in attempts other
+// go-snakes have saved, so two started side by side can't both be official.
+func (l *DailyLog) Begin(date, profile string, now time.Time) (*DailyAttempt, error) {
+	var attempt *DailyAttempt
+	err := l.locked(func(saved []DailyAttempt) error {
+		l.Attempts = l.merged(saved)
+		practice := false
+		if official := l.Official(date, profile); official != nil {
+			if !official.Finished && !l.playedElsewhere(*official) {
+				official.Finished = true
+				official.Abandoned = true
+			}
+			practice = true
+		}
+
+		l.Attempts = append(l.Attempts, DailyAttempt{
+			Date:         date,
+			Profile:      profile,
+			Started:      now,
+			Practice:     practice,
+			Owner:        l.owner,
+			OwnerStarted: l.started,
+		})
+		attempt = &l.Attempts[len(l.Attempts)-1]
+		return l.write(l.Attempts)
+	})
+	return attempt, err
+}
+
+// Record appends one tick of input to the attempt in progress. It's
+// persisted whenever the score changes and every dailySaveTicks ticks
+// besides, rather than every tick, so an abandoned attempt keeps the score
+// it reached and all but its last few ticks of input.
+func (l *DailyLog) Record(a *DailyAttempt, dir Direction, score int) error {
+	a.Inputs = append(a.Inputs, dir)
+	if score == a.Score && len(a.Inputs)%dailySaveTicks != 0 {
+		return nil
+	}
+	a.Score = score
+	return l.Save()
+}
+
+// Finish closes an attempt with its final score
+func (l *DailyLog) Finish(a *DailyAttempt, score int) error {
+	a.Finished = true
+	a.Score = score
+	return l.Save()
+}
+
+// CanPlayOfficial reports whether the player still has an official attempt
+// left for the given day
+func (l *DailyLog) CanPlayOfficial(date, profile string) error {
+	if l.Official(date, profile) != nil {
+		return errDailyAlreadyPlayed
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Two go-snakes sharing a log in dir, the second as if another process
+func twoDailyLogs(t *testing.T) (mine, theirs *DailyLog) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), dailyFileName)
+	mine, err := readDailyLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs, err = readDailyLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	theirs.owner, theirs.started = 0, time.Time{}
+	return mine, theirs
+}
+
+// The ID of a process that has been and gone
+func exitedPID(t *testing.T) int {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^$")
+	if err := cmd.Run(); err != nil {
+		t.Fatal(err)
+	}
+	return cmd.Process.Pid
+}
+
+func TestDailyBegin(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		earlier   *DailyAttempt // Saved before, or nil for none
+		practice  bool
+		abandoned bool // Whether the earlier attempt ends up abandoned
+	}{
+		{"first of the day", nil, false, false},
+		{"after a finished one", &DailyAttempt{Finished: true}, true, false},
+		{"after a crash", &DailyAttempt{Owner: -3}, true, true},
+		{"saved before owners were kept", &DailyAttempt{Owner: 0}, true, true},
+		{"after this go-snake's last game", &DailyAttempt{Owner: -1}, true, true},
+		{"while another plays", &DailyAttempt{Owner: -2}, true, false},
+		{"yesterday's", &DailyAttempt{Date: "2026-10-16"}, false, false},
+	}
+	for _, tt := range tests {
+		mine, theirs := twoDailyLogs(t)
+		if tt.earlier != nil {
+			a := *tt.earlier
+			a.Profile, a.Started = "ann", now.Add(-time.Hour)
+			if a.Date == "" {
+				a.Date = "2026-10-17"
+			}
+			switch a.Owner {
+			case -1: // This go-snake
+				a.Owner, a.OwnerStarted = mine.owner, mine.started
+			case -2: // Another running one: this test, which mine is told isn't it
+				theirs.owner, theirs.started = mine.owner, mine.started
+				mine.owner = -1
+				a.Owner, a.OwnerStarted = theirs.owner, theirs.started
+			case -3: // One that's gone
+				a.Owner, a.OwnerStarted = exitedPID(t), now
+			}
+			theirs.Attempts = []DailyAttempt{a}
+			if err := theirs.Save(); err != nil {
+				t.Fatal(err)
+			}
+		}
+		a, err := mine.Begin("2026-10-17", "ann", now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Practice != tt.practice {
+			t.Errorf("%s: practice %v, want %v", tt.name, a.Practice, tt.practice)
+		}
+		if tt.earlier == nil {
+			continue
+		}
+		saved, err := readDailyLog(mine.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(saved.Attempts) != 2 {
+			t.Fatalf("%s: saved %d attempts, want 2", tt.name, len(saved.Attempts))
+		}
+		if got := saved.Attempts[0].Abandoned; got != tt.abandoned {
+			t.Errorf("%s: earlier attempt abandoned %v, want %v", tt.name, got, tt.abandoned)
+		}
+	}
+}
+
+// Each go-snake's saves keep the other's latest progress
+func TestDailySaveKeepsOthersProgress(t *testing.T) {
+	mine, theirs := twoDailyLogs(t)
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	official, err := theirs.Begin("2026-10-17", "ann", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	practice, err := mine.Begin("2026-10-17", "bob", now.Add(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := theirs.Record(official, Up, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := mine.Record(practice, Left, 10); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := readDailyLog(mine.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scores := map[string]int{}
+	for _, a := range saved.Attempts {
+		scores[a.Profile] = a.Score
+	}
+	if scores["ann"] != 40 || scores["bob"] != 10 || len(saved.Attempts) != 2 {
+		t.Fatalf("saved %+v, want ann on 40 and bob on 10", saved.Attempts)
+	}
+}
+
+// An attempt's inputs are saved as it goes, not only when it scores
+func TestDailyRecordSavesInputs(t *testing.T) {
+	mine, _ := twoDailyLogs(t)
+	a, err := mine.Begin("2026-10-17", "ann", time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		ticks int
+		score int
+		saved int // Inputs saved after those ticks
+	}{
+		{dailySaveTicks - 1, 0, 0},
+		{1, 0, dailySaveTicks},
+		{3, 5, dailySaveTicks + 1}, // Saved as it scored
+		{dailySaveTicks - 4, 5, dailySaveTicks + 1},
+		{1, 5, 2 * dailySaveTicks},
+	}
+	for i, tt := range tests {
+		for range tt.ticks {
+			if err := mine.Record(a, Right, tt.score); err != nil {
+				t.Fatal(err)
+			}
+		}
+		saved, err := readDailyLog(mine.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := len(saved.Attempts[0].Inputs); got != tt.saved {
+			t.Errorf("step %d: %d inputs saved, want %d", i+1, got, tt.saved)
+		}
+	}
+}
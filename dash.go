@@ -0,0 +1,55 @@
+package main
+
+import "time"
+
+// Dash constants
+const (
+	dashWindow   = 200 * time.Millisecond // Longest gap between the two taps of a dash
+	dashCooldown = 30                     // Steps before a snake can dash again
+)
+
+// Check whether a direction key is the second tap of a double tap. A third
+// tap starts a new pair rather than dashing again.
+func (h *Human) doubleTap(dir Direction, now time.Time) bool {
+	tapped := dir == h.lastKey && !h.lastAt.IsZero() && now.Sub(h.lastAt) <= dashWindow
+	h.lastKey, h.lastAt = dir, now
+	if tapped {
+		h.lastAt = time.Time{}
+	}
+	return tapped
+}
+
+// Make a snake dash on its next move, carrying it two cells instead of
+// one, unless it is still cooling down from the last dash
+func (g *Game) dash(snake int) {
+	s := g.snakes[snake]
+	if !s.alive || s.Has(EffectDashCooldown) {
+		return
+	}
+	s.dashing = true
+	g.logMove(s, "dashed")
+	if s.effects == nil {
+		s.effects = make(map[Effect]int)
+	}
+	s.effects[EffectDashCooldown] = dashCooldown
+	if g.replay != nil {
+		// The dash carries the snake on the next tick
+		g.replay.Inputs = append(g.replay.Inputs, ReplayInput{Tick: g.ticks + 1, Player: snake, Dash: true})
+	}
+}
+
+// Move every snake that dashed this tick a second cell straight on
+func (g *Game) moveDashers() {
+	dashing := make([]bool, len(g.snakes))
+	any := false
+	for i, s := range g.snakes {
+		if s.dashing {
+			s.dashing = false
+			dashing[i] = s.alive
+			any = any || s.alive
+		}
+	}
+	if any {
+		g.moveSnakes(dashing)
+	}
+}
@@ -0,0 +1,505 @@
+//go:build !js
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Dashboard constants
+const (
+	dashboardPoll       = 500 * time.Millisecond // How long without ticks before the dashboard asks for the state
+	dashboardScoresAge  = 5 * time.Second        // How often the high scores are read again
+	dashboardSamples    = 400                    // Scores kept for this game's graph
+	dashboardGames      = 30                     // Finished games kept for the history graph
+	dashboardMessages   = 50                     // Notices kept in the event feed
+	dashboardHighScores = 10                     // Rows in the high score table
+	dashboardMaxState   = 16 << 20               // Largest state the dashboard reads, in bytes
+)
+
+// Directory the sockets of running go-snakes are in, each named after its
+// process ID
+func dashboardDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "go-snake")
+	}
+	return filepath.Join(os.TempDir(), fmt.Sprintf("go-snake-%d", os.Getuid()))
+}
+
+// Listen for dashboards on this go-snake's own socket. They can watch the
+// game but not play it.
+func ListenDashboard() (*ControlServer, error) {
+	dir := dashboardDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	cs, err := ListenControl("unix:" + filepath.Join(dir, strconv.Itoa(os.Getpid())+".sock"))
+	if err != nil {
+		return nil, err
+	}
+	cs.watchOnly = true
+	return cs, nil
+}
+
+// Connect to a running go-snake: the one with the given process ID, or
+// else the one started last. Sockets left by go-snakes that have gone are
+// cleared away.
+func dialDashboard(pid int) (net.Conn, int, error) {
+	dir := dashboardDir()
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, 0, err
+	}
+	type socket struct {
+		pid     int
+		path    string
+		started time.Time
+	}
+	var sockets []socket
+	for _, e := range entries {
+		n, err := strconv.Atoi(strings.TrimSuffix(e.Name(), ".sock"))
+		if err != nil || !strings.HasSuffix(e.Name(), ".sock") || (pid != 0 && n != pid) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		sockets = append(sockets, socket{n, filepath.Join(dir, e.Name()), info.ModTime()})
+	}
+	sort.Slice(sockets, func(i, j int) bool { return sockets[i].started.After(sockets[j].started) })
+	for _, s := range sockets {
+		conn, err := net.Dial("unix", s.path)
+		if err == nil {
+			return conn, s.pid, nil
+		}
+		if errors.Is(err, syscall.ECONNREFUSED) {
+			removeStaleSocket(s.path)
+		}
+	}
+	if pid != 0 {
+		return nil, 0, fmt.Errorf("no go-snake with process ID %d is running", pid)
+	}
+	return nil, 0, errors.New("no go-snake is running; start one in another pane first")
+}
+
+// Dashboard keeps what it has seen of a go-snake's games
+type Dashboard struct {
+	pid        int
+	state      *ControlState
+	samples    []int    // Player 1's score at each tick seen this game
+	games      []int    // Final scores of the games seen to the end, oldest first
+	messages   []string // Notices seen, oldest first
+	notices    []string // Notices up at the last look
+	finished   bool     // Has this game's final score been kept?
+	heat       []int    // Ticks player 1's head spent on each cell this game, row by row
+	scores     *HighScores
+	scoresRead time.Time
+}
+
+// Take in the latest state
+func (d *Dashboard) update(st *ControlState) {
+	last := d.state
+	if last != nil && st.Tick == last.Tick-1 {
+		// An answer to a question asked just before a tick, overtaken by it
+		return
+	}
+	d.state = st
+	newTick := last == nil || st.Tick != last.Tick
+	if last != nil && st.Tick < last.Tick {
+		// A new game
+		d.samples, d.heat, d.finished = nil, nil, false
+	}
+	if len(d.heat) != st.Width*st.Height {
+		d.heat = make([]int, st.Width*st.Height)
+	}
+	if len(st.Snakes) > 0 && st.Status != "menu" && (newTick || len(d.samples) == 0) {
+		p := st.Snakes[0]
+		d.samples = append(d.samples, p.Score)
+		if n := len(d.samples); n > dashboardSamples {
+			d.samples = d.samples[n-dashboardSamples:]
+		}
+		if len(p.Body) > 0 && p.Alive && st.Status == "playing" {
+			head := p.Body[0]
+			if head.X >= 0 && head.X < st.Width && head.Y >= 0 && head.Y < st.Height {
+				d.heat[head.Y*st.Width+head.X]++
+			}
+		}
+	}
+	if st.Status == "game over" && !d.finished && len(st.Snakes) > 0 {
+		d.finished = true
+		d.games = append(d.games, st.Snakes[0].Score)
+		if n := len(d.games); n > dashboardGames {
+			d.games = d.games[n-dashboardGames:]
+		}
+	}
+
+	// Notices are kept from when they first show up
+	for _, text := range st.Notices {
+		if !slices.Contains(d.notices, text) {
+			d.messages = append(d.messages, time.Now().Format("15:04:05")+"  "+text)
+		}
+	}
+	d.notices = st.Notices
+	if n := len(d.messages); n > dashboardMessages {
+		d.messages = d.messages[n-dashboardMessages:]
+	}
+
+	// The game saves its high scores when one ends, so look again then
+	if d.scores == nil || (last != nil && st.Status != last.Status) || time.Since(d.scoresRead) > dashboardScoresAge {
+		if hs, err := LoadHighScores(); err == nil || d.scores == nil {
+			d.scores = hs
+		}
+		d.scoresRead = time.Now()
+	}
+}
+
+// Digits drawn five rows high, for the big numbers
+var bigDigits = [10][5]string{
+	{"###", "# #", "# #", "# #", "###"},
+	{" # ", "## ", " # ", " # ", "###"},
+	{"###", "  #", "###", "#  ", "###"},
+	{"###", "  #", "###", "  #", "###"},
+	{"# #", "# #", "###", "  #", "  #"},
+	{"###", "#  ", "###", "  #", "###"},
+	{"###", "#  ", "###", "# #", "###"},
+	{"###", "  #", "  #", "  #", "  #"},
+	{"###", "# #", "###", "# #", "###"},
+	{"###", "# #", "###", "  #", "###"},
+}
+
+// Glyphs for the big numbers, graphs and heatmap, from empty to full.
+// Block characters where the terminal has them.
+var (
+	bigDigitFill = '#'
+	graphLevels  = []rune(" .:-=+*#")
+	heatLevels   = []rune(".:+*#")
+)
+
+// Draw a number five rows high with its label over it
+func drawBigNumber(x, y int, label string, n int, fg Attribute) {
+	drawText(x, y, label, colorText)
+	for i, ch := range strconv.Itoa(max(n, 0)) {
+		glyph := bigDigits[ch-'0']
+		for row, line := range glyph {
+			for col, c := range line {
+				if c != ' ' {
+					screen.SetCell(x+i*4+col, y+1+row, bigDigitFill, fg, ColorDefault)
+				}
+			}
+		}
+	}
+}
+
+// Draw values as columns h rows high across an area w cells wide, scaled to
+// the highest. With more values than columns, only the latest are shown;
+// each value takes cols columns.
+func drawGraph(x, y, w, h int, values []int, cols int, fg Attribute) {
+	values = values[max(len(values)-w/cols, 0):]
+	top := 1
+	for _, v := range values {
+		top = max(top, v)
+	}
+	steps := len(graphLevels) - 1
+	for i, v := range values {
+		filled := v * h * steps / top // In steps of a row
+		for row := 0; row < h; row++ {
+			level := min(max(filled-row*steps, 0), steps)
+			for c := 0; c < cols; c++ {
+				screen.SetCell(x+i*cols+c, y+h-1-row, graphLevels[level], fg, ColorDefault)
+			}
+		}
+	}
+}
+
+// Draw where player 1's head has been this game within an area, shrinking
+// the board to fit. Each cell of the map adds up the board cells it covers.
+func (d *Dashboard) drawHeatmap(r Rect) {
+	bw, bh := d.state.Width, d.state.Height
+	if bw <= 0 || bh <= 0 || r.W <= 0 || r.H <= 0 {
+		return
+	}
+	scale := max(max((bw+r.W-1)/r.W, (bh+r.H-1)/r.H), 1)
+	mw, mh := (bw+scale-1)/scale, (bh+scale-1)/scale
+	cells := make([]int, mw*mh)
+	top := 0
+	for y := 0; y < bh; y++ {
+		for x := 0; x < bw; x++ {
+			i := y/scale*mw + x/scale
+			cells[i] += d.heat[y*bw+x]
+			top = max(top, cells[i])
+		}
+	}
+	for i, n := range cells {
+		x, y := r.X+i%mw, r.Y+i/mw
+		if n == 0 {
+			screen.SetCell(x, y, ' ', ColorDefault, ColorDefault)
+			continue
+		}
+		level := min((n*len(heatLevels)-1)/top, len(heatLevels)-1)
+		fg := ColorRed
+		if level < len(heatLevels)/2 {
+			fg = ColorYellow
+		}
+		screen.SetCell(x, y, heatLevels[level], fg, ColorDefault)
+	}
+}
+
+// Draw the high scores for the game's mode, best first
+func (d *Dashboard) drawScores(r Rect) {
+	var rows [][]string
+	for i, e := range d.scores.ForMode(d.state.Mode) {
+		rows = append(rows, []string{strconv.Itoa(i + 1), e.Name, strconv.Itoa(e.Score), e.Date.Format("2006-01-02")})
+	}
+	if len(rows) == 0 {
+		drawTextIn(r.X, r.Y, r.W, "No scores yet", ColorDarkGray)
+		return
+	}
+	drawTable(r, []string{"#", "NAME", "SCORE", "DATE"},
+		[]Align{AlignRight, AlignLeft, AlignRight, AlignLeft}, rows, 0, -1, 1)
+}
+
+// Draw the dashboard over the whole screen: the game's numbers and graphs
+// on the left, and the heatmap, high scores and event feed on the right
+func (d *Dashboard) draw() {
+	screen.Clear()
+	w, h := screen.Size()
+	st := d.state
+	drawText(1, 0, "GO-SNAKE DASHBOARD", colorScore|AttrBold)
+	status := fmt.Sprintf("pid %d  %s", d.pid, strings.ToUpper(st.Status))
+	drawText(max(w-len(status)-1, 20), 0, status, colorText)
+	if len(st.Snakes) == 0 {
+		screen.Flush()
+		return
+	}
+	left := Rect{X: 1, Y: 2, W: max(w/2-2, 1), H: h - 2}
+	right := Rect{X: w/2 + 1, Y: 2, W: max(w-w/2-2, 1), H: h - 2}
+
+	// The numbers that matter most, big
+	p := st.Snakes[0]
+	drawBigNumber(left.X, left.Y, "SCORE", p.Score, ColorGreen|AttrBold)
+	drawBigNumber(left.X+max(len(strconv.Itoa(p.Score))*4+3, 8), left.Y, "LENGTH", p.Len, ColorCyan|AttrBold)
+
+	// Then the rest of the sidebar's, and the session's
+	y := left.Y + 7
+	stats := &st.Stats
+	lines := []string{
+		fmt.Sprintf("Mode:   %s", st.Mode),
+		fmt.Sprintf("Level:  %d", st.Level),
+		fmt.Sprintf("Time:   %s", clockText(stats.Time)),
+		fmt.Sprintf("Wall:   %s", clockText(stats.Wall)),
+		fmt.Sprintf("Ticks:  %d", stats.Ticks),
+		fmt.Sprintf("Food:   %d", stats.Food()),
+		fmt.Sprintf("Turns:  %d", stats.Turns),
+		fmt.Sprintf("Speed:  %.1f cells/s", stats.Speed()),
+	}
+	if s := st.Session; s != nil {
+		lines = append(lines, fmt.Sprintf("Games:  %d", s.Games), fmt.Sprintf("Best:   %d", s.Best))
+		if s.Goal != "" {
+			lines = append(lines, fmt.Sprintf("Goal:   %s (%d so far)", s.Goal, s.Met))
+		}
+	}
+	for i, line := range lines {
+		drawTextIn(left.X, y+i, left.W, line, colorText)
+	}
+	y += len(lines) + 1
+
+	// Graphs fill what's left of the left side
+	graphH := max(min((h-y-4)/2, 8), 2)
+	drawText(left.X, y, "SCORE THIS GAME", colorScore)
+	drawGraph(left.X, y+1, left.W, graphH, d.samples, 1, ColorGreen)
+	y += graphH + 2
+	drawText(left.X, y, "LAST GAMES", colorScore)
+	drawGraph(left.X, y+1, left.W, graphH, d.games, 2, ColorCyan)
+
+	// On the right, the heatmap takes up to a third, then the high scores,
+	// then as many events as fit
+	y = right.Y
+	mapH := max(min(st.Height, right.H/3), 1)
+	drawText(right.X, y, "HEATMAP", colorScore)
+	d.drawHeatmap(Rect{X: right.X, Y: y + 1, W: right.W, H: mapH})
+	y += mapH + 2
+	drawText(right.X, y, "HIGH SCORES", colorScore)
+	scoreRows := min(dashboardHighScores, max(right.H/4, 1))
+	d.drawScores(Rect{X: right.X, Y: y + 1, W: right.W, H: scoreRows + 1})
+	y += scoreRows + 3
+	drawText(right.X, y, "EVENTS", colorScore)
+	rows := max(h-y-1, 0)
+	shown := d.messages[max(len(d.messages)-rows, 0):]
+	for i, m := range shown {
+		drawTextIn(right.X, y+1+i, right.W, m, colorText)
+	}
+	screen.Flush()
+}
+
+// A message from the game: an answer to a request, or a tick notification
+type dashboardMessage struct {
+	Method string          `json:"method"`
+	Params *ControlState   `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *rpcError       `json:"error"`
+}
+
+// Read the game's messages, passing on the states in them, until the
+// connection fails
+func readDashboard(conn net.Conn, states chan<- *ControlState, errs chan<- error) {
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(nil, dashboardMaxState)
+	for sc.Scan() {
+		var m dashboardMessage
+		if err := json.Unmarshal(sc.Bytes(), &m); err != nil {
+			errs <- err
+			return
+		}
+		if m.Error != nil {
+			errs <- m.Error
+			return
+		}
+		st := m.Params
+		if m.Method == "" && len(m.Result) > 0 && m.Result[0] == '{' {
+			st = &ControlState{}
+			if err := json.Unmarshal(m.Result, st); err != nil {
+				errs <- err
+				return
+			}
+		}
+		if st != nil {
+			states <- st
+		}
+	}
+	err := sc.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	errs <- err
+}
+
+// Send the game a request
+func askDashboard(conn net.Conn, id int, method string) error {
+	_, err := fmt.Fprintf(conn, "{\"jsonrpc\": \"2.0\", \"id\": %d, \"method\": %q}\n", id, method)
+	return err
+}
+
+// Run "go-snake dashboard": watch a go-snake running in another pane, with
+// its numbers big, graphs of its scores, a heatmap of where it's been, the
+// high scores and the notices it shows
+func dashboardCommand(args []string) int {
+	flags := flag.NewFlagSet("dashboard", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-snake dashboard [-pid N]")
+		flags.PrintDefaults()
+	}
+	pid := flags.Int("pid", 0, "process ID of the go-snake to watch (default the one started last)")
+	rendererName := flags.String("renderer", defaultRenderer, "terminal library to draw with: "+strings.Join(rendererNames(), ", "))
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	conn, watching, err := dialDashboard(*pid)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	defer conn.Close()
+	states := make(chan *ControlState)
+	errs := make(chan error, 1)
+	go readDashboard(conn, states, errs)
+	id := 1
+	for _, method := range []string{"state", "subscribe"} {
+		if err := askDashboard(conn, id, method); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			return 1
+		}
+		id++
+	}
+	d := &Dashboard{pid: watching}
+	select {
+	case st := <-states:
+		d.update(st)
+	case err := <-errs:
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+
+	explicit := false
+	flags.Visit(func(f *flag.Flag) { explicit = explicit || f.Name == "renderer" })
+	if screen, err = openRenderer(*rendererName, explicit); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 2
+	}
+	screen = NewDirtyRenderer(screen)
+	if err := screen.Init(); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	if utf8Terminal() {
+		bigDigitFill = '█'
+		graphLevels = []rune(" ▁▂▃▄▅▆▇█")
+		heatLevels = []rune("░▒▓█")
+	}
+	events := make(chan Event)
+	go func() {
+		for {
+			events <- screen.PollEvent()
+		}
+	}()
+
+	// Ticks come by themselves while the game is played; in menus and
+	// pauses the dashboard asks now and then
+	poll := time.NewTicker(dashboardPoll)
+	defer poll.Stop()
+	heard := time.Now()
+
+	d.draw()
+	for {
+		select {
+		case ev := <-events:
+			if ev.Type == EventKey && (ev.Ch == 'q' || ev.Key == KeyEsc || ev.Key == KeyCtrlC) {
+				screen.Close()
+				return 0
+			}
+			d.draw()
+		case st := <-states:
+			heard = time.Now()
+			d.update(st)
+			d.draw()
+		case <-poll.C:
+			if time.Since(heard) < dashboardPoll {
+				continue
+			}
+			if err := askDashboard(conn, id, "state"); err != nil {
+				select {
+				case errs <- err:
+				default:
+				}
+			}
+			id++
+		case err := <-errs:
+			screen.Close()
+			if errors.Is(err, io.EOF) || errors.Is(err, syscall.EPIPE) || errors.Is(err, syscall.ECONNRESET) {
+				fmt.Printf("go-snake %d has quit.\n", watching)
+				return 0
+			}
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			return 1
+		}
+	}
+}
@@ -0,0 +1,19 @@
+//go:build js
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// The browser build has no sockets for a dashboard to watch
+func ListenDashboard() (*ControlServer, error) {
+	return nil, nil
+}
+
+// Run "go-snake dashboard", which needs a terminal and sockets
+func dashboardCommand(args []string) int {
+	fmt.Fprintln(os.Stderr, "go-snake: dashboard isn't available in the browser build")
+	return 2
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Data file locking constants
+const (
+	lockWait  = 2 * time.Second       // Longest to wait for another go-snake to finish with a file
+	lockStale = 10 * time.Second      // A lock held this long was left by a go-snake that died
+	lockPoll  = 10 * time.Millisecond // How often to try for a lock that's held
+)
+
+// Run fn holding the lock on a data file, so go-snakes running side by side
+// take turns to read it, change it and write it back. The lock is a file
+// next to it that only one of them can create. A lock left behind by a
+// go-snake that died holding it is taken over once it's stale.
+func withFileLock(path string, fn func() error) error {
+	lock := path + ".lock"
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(lock, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if err == nil {
+			f.Close()
+			break
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		if info, err := os.Stat(lock); err == nil && time.Since(info.ModTime()) > lockStale {
+			takeStaleLock(lock, info)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("%s is locked by another go-snake; remove %s if none is running", path, lock)
+		}
+		time.Sleep(lockPoll)
+	}
+	defer os.Remove(lock)
+	return fn()
+}
+
+// Clear away a stale lock. Removing it outright could remove a fresh lock
+// another go-snake took in its place since it was seen to be stale, so it's
+// first moved aside, which only one go-snake can do, and checked to still
+// be the stale one, by its modification time too, as a fresh lock can be
+// given the stale one's inode. A fresh lock moved aside by mistake is put
+// back.
+func takeStaleLock(lock string, stale fs.FileInfo) {
+	aside := fmt.Sprintf("%s.%d.stale", lock, os.Getpid())
+	if err := os.Rename(lock, aside); err != nil {
+		return // Another go-snake got to it first
+	}
+	if moved, err := os.Stat(aside); err == nil && (!os.SameFile(stale, moved) || !moved.ModTime().Equal(stale.ModTime())) {
+		os.Link(aside, lock) // Fails, leaving it be, if a lock has been taken since
+	}
+	os.Remove(aside)
+}
+
+// Write a data file in one go: to a temporary file beside it that's then
+// renamed over it, so a reader never sees it half written and a crash
+// mid-write leaves the old one whole
+func writeFileAtomic(path string, data []byte, perm fs.FileMode) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmp := f.Name()
+	_, err = f.Write(data)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Chmod(tmp, perm)
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+	}
+	return err
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// Go-snakes taking turns with a file under its lock never lose each
+// other's changes
+func TestFileLockTakesTurns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "count")
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := withFileLock(path, func() error {
+				data, _ := os.ReadFile(path)
+				n, _ := strconv.Atoi(string(data))
+				return writeFileAtomic(path, []byte(strconv.Itoa(n+1)), 0o644)
+			})
+			if err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if data, _ := os.ReadFile(path); string(data) != "20" {
+		t.Fatalf("counted to %s, want 20", data)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Fatal("the lock was left behind")
+	}
+}
+
+func TestFileLockStale(t *testing.T) {
+	tests := []struct {
+		name string
+		age  time.Duration
+		ok   bool
+	}{
+		{"left by a crash", 2 * lockStale, true},
+		{"held", 0, false},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "data")
+		lock := path + ".lock"
+		if err := os.WriteFile(lock, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		then := time.Now().Add(-tt.age)
+		if err := os.Chtimes(lock, then, then); err != nil {
+			t.Fatal(err)
+		}
+		ran := false
+		err := withFileLock(path, func() error {
+			ran = true
+			return nil
+		})
+		if ran != tt.ok || (err == nil) != tt.ok {
+			t.Errorf("%s: ran %v, error %v", tt.name, ran, err)
+		}
+	}
+}
+
+// A lock taken in place of a stale one since it was seen is left be
+func TestTakeStaleLockKeepsFreshLock(t *testing.T) {
+	lock := filepath.Join(t.TempDir(), "data.lock")
+	if err := os.WriteFile(lock, []byte("stale"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	then := time.Now().Add(-2 * lockStale)
+	if err := os.Chtimes(lock, then, then); err != nil {
+		t.Fatal(err)
+	}
+	stale, err := os.Stat(lock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Another go-snake clears the stale lock and takes its own
+	if err := os.Remove(lock); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(lock, []byte("fresh"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	takeStaleLock(lock, stale)
+	if data, err := os.ReadFile(lock); err != nil || string(data) != "fresh" {
+		t.Fatalf("lock holds %q, %v; want the fresh one", data, err)
+	}
+	if matches, _ := filepath.Glob(lock + ".*"); len(matches) > 0 {
+		t.Fatalf("left %v behind", matches)
+	}
+}
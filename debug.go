@@ -0,0 +1,171 @@
+//go:build debug
+
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+// The snakes and food just before the snakes move, to check the move against
+type moveCheck struct {
+	heads   []Point
+	lengths []int
+	alive   []bool
+	dashing []bool
+	food    map[Point]bool
+}
+
+// Note where everything is before the snakes move
+func (g *Game) beforeMove() *moveCheck {
+	c := &moveCheck{food: map[Point]bool{}}
+	for _, s := range g.snakes {
+		c.heads = append(c.heads, s.Head())
+		c.lengths = append(c.lengths, len(s.body))
+		c.alive = append(c.alive, s.alive)
+		c.dashing = append(c.dashing, s.dashing)
+	}
+	for _, f := range g.foods {
+		c.food[f.At] = true
+	}
+	for _, f := range g.frenzyFood {
+		c.food[f.At] = true
+	}
+	if g.puzzle != nil {
+		for _, p := range g.puzzle.Food {
+			c.food[p] = true
+		}
+	}
+	return c
+}
+
+// Check the rules the engine should never break, panicking with what went
+// wrong so a new mechanic that breaks one is caught on the tick it does.
+// Only built with the debug tag. Moves are checked against before, if the
+// snakes moved.
+func (g *Game) checkInvariants(before *moveCheck) {
+	if before != nil {
+		g.checkMoves(before)
+	}
+	cells := map[Point]*Snake{}
+	for _, s := range g.snakes {
+		if len(s.body) == 0 {
+			panic(fmt.Sprintf("tick %d: %s has no body", g.ticks, s.name))
+		}
+		for i, p := range s.body {
+			if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+				panic(fmt.Sprintf("tick %d: %s segment %d at (%d,%d) is off the board", g.ticks, s.name, i, p.X, p.Y))
+			}
+			if g.walls[p] {
+				panic(fmt.Sprintf("tick %d: %s segment %d at (%d,%d) is in a wall", g.ticks, s.name, i, p.X, p.Y))
+			}
+			if i > 0 && !g.linked(s.body[i-1], p) {
+				panic(fmt.Sprintf("tick %d: %s segments %d and %d at (%d,%d) and (%d,%d) aren't adjacent",
+					g.ticks, s.name, i-1, i, s.body[i-1].X, s.body[i-1].Y, p.X, p.Y))
+			}
+			cells[p] = s
+		}
+	}
+
+	// Only a shield lets a head onto a cell another segment holds. Overlaps
+	// further down a body are left from then, and clear as the tail passes.
+	// Dead snakes didn't move, so were checked when they last did.
+	for _, s := range g.snakes {
+		if !s.alive || s.Has(EffectInvincible) {
+			continue
+		}
+		head := s.Head()
+		for _, other := range g.snakes {
+			for i, p := range other.body {
+				if p == head && (other != s || i > 0) {
+					panic(fmt.Sprintf("tick %d: %s's head at (%d,%d) is on %s's segment %d, without a shield", g.ticks, s.name, head.X, head.Y, other.name, i))
+				}
+			}
+		}
+	}
+
+	// A snake that moves onto a deadly hazard dies there
+	deadly, _ := g.hazardCells()
+	for _, s := range g.snakes {
+		if head := s.Head(); s.alive && deadly[head] {
+			panic(fmt.Sprintf("tick %d: %s's head at (%d,%d) is on a hazard", g.ticks, s.name, head.X, head.Y))
+		}
+	}
+
+	// Food never sits under a snake, in a wall or on a portal
+	food := func(kind string, p Point) {
+		if s, ok := cells[p]; ok {
+			panic(fmt.Sprintf("tick %d: %s at (%d,%d) is under %s", g.ticks, kind, p.X, p.Y, s.name))
+		}
+		if g.walls[p] {
+			panic(fmt.Sprintf("tick %d: %s at (%d,%d) is in a wall", g.ticks, kind, p.X, p.Y))
+		}
+		if g.isPortal(p) {
+			panic(fmt.Sprintf("tick %d: %s at (%d,%d) is on a portal", g.ticks, kind, p.X, p.Y))
+		}
+	}
+	for _, f := range g.foods {
+		food("food", f.At)
+	}
+	for _, f := range g.frenzyFood {
+		food("frenzy food", f.At)
+	}
+	for _, c := range g.crumbs {
+		food("crumb", c.At)
+	}
+	if g.puzzle != nil {
+		for _, p := range g.puzzle.Food {
+			food("puzzle food", p)
+		}
+	}
+}
+
+// Check that every snake moved one cell, or two when dashing, unless it
+// crashed, and only changed length by eating, or in tron mode by each move
+func (g *Game) checkMoves(before *moveCheck) {
+	for i, s := range g.snakes {
+		if !before.alive[i] {
+			continue
+		}
+		steps := 1
+		if before.dashing[i] {
+			steps = 2
+		}
+		// The old head is now as many segments back as the snake moved, or
+		// cut off if it shrank that short. A snake that crashed stopped
+		// short, so may not have moved at all.
+		moved := slices.Index(s.body, before.heads[i])
+		if moved == -1 && len(s.body) <= steps {
+			moved = steps
+		}
+		if moved != steps && (s.alive || moved < 0 || moved > steps) {
+			panic(fmt.Sprintf("tick %d: %s's head went from (%d,%d) to (%d,%d), moving %d cells rather than %d",
+				g.ticks, s.name, before.heads[i].X, before.heads[i].Y, s.Head().X, s.Head().Y, moved, steps))
+		}
+		ate := false
+		for _, p := range s.body[:min(moved, len(s.body))] {
+			ate = ate || before.food[p]
+		}
+		if g.mode == modeTron {
+			if s.alive && len(s.body) != before.lengths[i]+steps {
+				panic(fmt.Sprintf("tick %d: %s's trail went from %d segments to %d moving %d cells", g.ticks, s.name, before.lengths[i], len(s.body), steps))
+			}
+		} else if !ate && len(s.body) != before.lengths[i] {
+			panic(fmt.Sprintf("tick %d: %s went from %d segments to %d without eating", g.ticks, s.name, before.lengths[i], len(s.body)))
+		}
+	}
+}
+
+// Can a snake's segment at b follow one at a? Either they're next to each
+// other, or a is where a portal came out and b is next to its other end.
+func (g *Game) linked(a, b Point) bool {
+	exit, ok := g.portalExit(a)
+	return adjacent(a, b) || (ok && adjacent(exit, b))
+}
+
+// Are two cells next to each other, allowing for the board wrapping round?
+func adjacent(a, b Point) bool {
+	dx := (a.X - b.X + width) % width
+	dy := (a.Y - b.Y + height) % height
+	return (dx == 0 && (dy == 1 || dy == height-1)) || (dy == 0 && (dx == 1 || dx == width-1))
+}
@@ -0,0 +1,42 @@
+package main
+
+import "time"
+
+// Score decay constants
+const (
+	decayPerSegment = 0.05             // Points lost per second for each body segment
+	rateWindow      = 10 * time.Second // How far back the sidebar score rate looks
+)
+
+// A snake's score at a point in game time
+type scoreSample struct {
+	at    time.Duration
+	score int
+}
+
+// Drain a snake's score for one tick under the score decay modifier. Longer
+// snakes lose points faster, so growing means having to keep eating.
+func (s *Snake) decayScore(elapsed time.Duration) {
+	s.decay += decayPerSegment * float64(len(s.body)) * elapsed.Seconds()
+	if whole := int(s.decay); whole > 0 {
+		s.decay -= float64(whole)
+		s.score = max(s.score-whole, 0)
+	}
+}
+
+// Remember a snake's score for working out its net rate
+func (s *Snake) sampleScore(now time.Duration) {
+	s.samples = append(s.samples, scoreSample{at: now, score: s.score})
+	for len(s.samples) > 1 && now-s.samples[1].at >= rateWindow {
+		s.samples = s.samples[1:]
+	}
+}
+
+// Net points per second over the last rateWindow of play
+func (s *Snake) scoreRate() float64 {
+	if len(s.samples) < 2 {
+		return 0
+	}
+	first, last := s.samples[0], s.samples[len(s.samples)-1]
+	return float64(last.score-first.score) / (last.at - first.at).Seconds()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Difficulty is a preset for the starting speed and how quickly the game
+// accelerates as the player levels up
+type Difficulty struct {
+	Name           string
+	StartSpeed     int     // Milliseconds per horizontal tick at level 1
+	MinSpeed       int     // Fastest the game is allowed to get
+	Acceleration   float64 // Tick interval multiplier applied per level
+	PointsPerLevel int     // Points needed to reach the next level
+}
+
+// Difficulty presets, from slowest to fastest
+var difficulties = []Difficulty{
+	{Name: "easy", StartSpeed: 140, MinSpeed: 70, Acceleration: 0.95, PointsPerLevel: 20},
+	{Name: "normal", StartSpeed: baseSpeed, MinSpeed: 50, Acceleration: 0.92, PointsPerLevel: 15},
+	{Name: "hard", StartSpeed: 80, MinSpeed: 40, Acceleration: 0.90, PointsPerLevel: 12},
+	{Name: "insane", StartSpeed: 60, MinSpeed: 25, Acceleration: 0.85, PointsPerLevel: 10},
+}
+
+// Look up a difficulty preset by name
+func difficultyByName(name string) (Difficulty, error) {
+	for _, d := range difficulties {
+		if strings.EqualFold(d.Name, name) {
+			return d, nil
+		}
+	}
+	return Difficulty{}, fmt.Errorf("unknown difficulty %q (want easy, normal, hard or insane)", name)
+}
+
+// Level reached with a given score, starting from 1
+func (d Difficulty) Level(score int) int {
+	return 1 + score/d.PointsPerLevel
+}
+
+// Milliseconds per horizontal tick at a given level
+func (d Difficulty) Speed(level int) int {
+	speed := float64(d.StartSpeed) * math.Pow(d.Acceleration, float64(level-1))
+	return max(int(speed), d.MinSpeed)
+}
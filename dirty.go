@@ -0,0 +1,114 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// A cell of a frame the dirty renderer keeps
+type dirtyCell struct {
+	ch     rune
+	fg, bg Attribute
+}
+
+// DirtyRenderer passes drawing on to another renderer one frame at a time,
+// and only the cells that changed since the last frame: the snake's head
+// and tail, food and the sidebar's numbers rather than the whole board. The
+// renderer underneath keeps what it was last sent, so it has less to
+// compare and the terminal less to draw, which helps slow terminals and
+// SSH. After the screen is resized every cell is sent again.
+type DirtyRenderer struct {
+	Renderer
+	cols, rows int
+	cells      []dirtyCell // Frame being drawn
+	shown      []dirtyCell // Last frame sent
+	resized    atomic.Bool // Set by PollEvent, which runs on its own goroutine
+	full       bool        // Send every cell of this frame
+}
+
+// Draw through r, sending it only what changes
+func NewDirtyRenderer(r Renderer) *DirtyRenderer {
+	return &DirtyRenderer{Renderer: r}
+}
+
+// Start the renderer, with the first frame sent in full
+func (r *DirtyRenderer) Init() error {
+	if err := r.Renderer.Init(); err != nil {
+		return err
+	}
+	r.fit()
+	r.full = true
+	return nil
+}
+
+// Pass on an event, noting a resize so the next frame is sent in full
+func (r *DirtyRenderer) PollEvent() Event {
+	ev := r.Renderer.PollEvent()
+	if ev.Type == EventResize {
+		r.resized.Store(true)
+	}
+	return ev
+}
+
+// Clear starts a frame. The renderer underneath is only cleared after a
+// resize, when it needs to take on its new size.
+func (r *DirtyRenderer) Clear() {
+	if r.resized.Swap(false) {
+		r.Renderer.Clear()
+		r.full = true
+	}
+	r.fit()
+	clear(r.cells)
+}
+
+// Size the frames to the screen, sending the next one in full if it has
+// changed
+func (r *DirtyRenderer) fit() {
+	if cols, rows := r.Renderer.Size(); cols != r.cols || rows != r.rows {
+		r.cols, r.rows = cols, rows
+		r.cells = make([]dirtyCell, cols*rows)
+		r.shown = make([]dirtyCell, cols*rows)
+		r.full = true
+	}
+}
+
+func (r *DirtyRenderer) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	if x < 0 || y < 0 || x >= r.cols || y >= r.rows {
+		return
+	}
+	r.cells[y*r.cols+x] = dirtyCell{ch, fg, bg}
+}
+
+// Send the cells that changed and show them. A frame with no changes isn't
+// shown at all.
+func (r *DirtyRenderer) Flush() {
+	sent := false
+	for y := 0; y < r.rows; y++ {
+		leftChanged := false // A wide rune covering or uncovering a cell sends it too
+		for x := 0; x < r.cols; x++ {
+			i := y*r.cols + x
+			c := r.cells[i]
+			changed := c != r.shown[i]
+			send := r.full || changed || leftChanged
+			leftChanged = changed
+			r.shown[i] = c
+			if send {
+				if c.ch == 0 {
+					c.ch = ' '
+				}
+				r.Renderer.SetCell(x, y, c.ch, c.fg, c.bg)
+				sent = true
+			}
+			if runewidth.RuneWidth(c.ch) == 2 && x+1 < r.cols {
+				x++ // The next cell is hidden under this one
+				leftChanged = r.cells[i+1] != r.shown[i+1]
+				r.shown[i+1] = r.cells[i+1]
+			}
+		}
+	}
+	if sent {
+		r.Renderer.Flush()
+	}
+	r.full = false
+}
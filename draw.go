@@ -0,0 +1,628 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Cell symbols
+var (
+	symbolBorderHorizontal  = '━'
+	symbolBorderVertical    = '┃'
+	symbolBorderTopLeft     = '┏'
+	symbolBorderTopRight    = '┓'
+	symbolBorderBottomLeft  = '┗'
+	symbolBorderBottomRight = '┛'
+	symbolBorderTop         = '━' // Horizontal runs, along the top edge
+	symbolBorderBottom      = '━' // and the bottom
+	symbolBorderLeft        = '┃' // Vertical runs, down the left edge
+	symbolBorderRight       = '┃' // and the right
+	symbolSnakeHead         = '▣'
+	symbolSnakeBody         = '◼'
+	symbolEmptyCell         = '⬚' // New symbol for empty cells in the game field
+	symbolWall              = '█' // Obstacle inside the game field
+	symbolSeparator         = '│' // Line between the sidebar and the board
+	symbolStar              = '★' // Earned puzzle star
+	symbolStarEmpty         = '☆' // Puzzle star still to earn
+	symbolCheck             = '✓' // Completed level mark
+	symbolCrumb             = '•' // Crumb from a special food's burst
+
+	emptyDensity = densityFull // How many empty cells show their symbol
+	blankCells   bool          // Leave every empty cell blank, whatever the theme
+)
+
+// Empty cell densities: how much of the empty ground shows the empty cell
+// symbol, for themes whose texture would be too busy on every cell
+const (
+	densityFull   = "full"   // Every cell
+	densityHalf   = "half"   // Every other cell, in a checkerboard
+	densitySparse = "sparse" // One cell in four, in a grid
+	densityBlank  = "blank"  // None
+)
+
+// Empty cell densities accepted in the config file
+var emptyDensities = []string{densityFull, densityHalf, densitySparse, densityBlank}
+
+// Symbol for an empty board cell: the empty cell symbol where the density
+// puts one, or a blank. The pattern is fixed to the board, so it moves with
+// the camera.
+func emptySymbol(p Point) rune {
+	density := emptyDensity
+	if blankCells {
+		density = densityBlank
+	}
+	switch {
+	case density == densityHalf && (p.X+p.Y)%2 != 0,
+		density == densitySparse && (p.X%2 != 0 || p.Y%2 != 0),
+		density == densityBlank:
+		return ' '
+	}
+	return symbolEmptyCell
+}
+
+// Food table constants
+const (
+	foodTableRows = 4               // Foods listed at once; longer lists page through
+	foodPageTime  = 3 * time.Second // Play each page of foods is shown for
+)
+
+// Colors, overridable from the config file. Snake colors are in playerColors.
+var (
+	colorFood   = ColorRed
+	colorBorder = ColorWhite
+	colorWall   = ColorWhite
+	colorEmpty  = ColorDarkGray
+	colorText   = ColorWhite
+	colorScore  = ColorYellow
+
+	colorHazard        = ColorLightRed // Cells a hazard makes deadly
+	colorHazardWarning = ColorYellow   // Cells about to be deadly
+)
+
+// Draw the game
+func (g *Game) Draw() {
+	screen.Clear()
+	shownList = nil
+
+	// Draw the sidebar with minimal info, or the status line in its place
+	if compactLayout {
+		drawStatusLine(g)
+	} else {
+		// Clear sidebar area explicitly to prevent artifacts
+		clearSidebarArea()
+		drawSidebar(g)
+	}
+
+	// Draw border with offset for sidebar
+	for i := 0; i < viewWidth+2; i++ {
+		screen.SetCell(i+boardLeft, 0, symbolBorderTop, colorBorder, ColorDefault)
+		screen.SetCell(i+boardLeft, viewHeight+1, symbolBorderBottom, colorBorder, ColorDefault)
+	}
+	for i := 0; i < viewHeight+2; i++ {
+		screen.SetCell(boardLeft, i, symbolBorderLeft, colorBorder, ColorDefault)
+		screen.SetCell(viewWidth+boardLeft+1, i, symbolBorderRight, colorBorder, ColorDefault)
+	}
+	screen.SetCell(boardLeft, 0, symbolBorderTopLeft, colorBorder, ColorDefault)
+	screen.SetCell(viewWidth+boardLeft+1, 0, symbolBorderTopRight, colorBorder, ColorDefault)
+	screen.SetCell(boardLeft, viewHeight+1, symbolBorderBottomLeft, colorBorder, ColorDefault)
+	screen.SetCell(viewWidth+boardLeft+1, viewHeight+1, symbolBorderBottomRight, colorBorder, ColorDefault)
+
+	// Settings and high score screens replace the game field
+	if g.showSettings && g.settingsMenu != nil {
+		drawSettings(&settings, g.settingsMenu)
+		screen.Flush()
+		return
+	}
+	if g.showLevels && g.levels != nil {
+		drawLevelMenu(g.levels)
+		screen.Flush()
+		return
+	}
+	if g.showPuzzles && g.puzzles != nil {
+		drawPuzzleMenu(g.puzzles)
+		screen.Flush()
+		return
+	}
+	if g.showScores && g.scores != nil {
+		drawHighScores(g.scores, g.mode, g.scoreRank, g.scoreTop)
+		screen.Flush()
+		return
+	}
+	if g.showStats && g.session != nil {
+		drawStats(g.session, g.session.Streak)
+		screen.Flush()
+		return
+	}
+	if g.showCalendar && g.dailyLog != nil {
+		now := time.Now()
+		drawCalendar(g.dailyLog, activeProfile, now, now, timeZone)
+		screen.Flush()
+		return
+	}
+	if g.showGlobal && g.global != nil {
+		drawGlobalScores(g.global, g.mode)
+		screen.Flush()
+		return
+	}
+	if g.calibrating != nil {
+		drawCalibration(g.calibrating)
+		screen.Flush()
+		return
+	}
+	if g.state == StateMenu && g.menu != nil {
+		drawTitleMenu(g.menu, &settings)
+		screen.Flush()
+		return
+	}
+
+	// Fill the part of the game field in view with empty cell symbols, in
+	// the color of the snake holding them in territory mode
+	g.followCamera()
+	head := g.Player().Head()
+	for x := 0; x < viewWidth; x++ {
+		for y := 0; y < viewHeight; y++ {
+			p := Point{X: x + cameraX, Y: y + cameraY}
+			symbol, fg := emptySymbol(p), colorEmpty
+			if o := g.owner(p); o >= 0 && o < len(g.snakes) {
+				fg = g.snakes[o].color
+			}
+			if g.mods.hidden(head, p) {
+				symbol = ' '
+			}
+			screen.SetCell(x+boardLeft+1, y+1, symbol, fg, ColorDefault)
+		}
+	}
+
+	// Draw level obstacles
+	for p := range g.walls {
+		if !g.mods.hidden(head, p) {
+			setBoardCell(p, symbolWall, colorWall)
+		}
+	}
+
+	drawPortals(g, head)
+
+	// Hazards and the ghost of the best run go under the live snakes
+	drawHazards(g, head)
+	drawArena(g, head)
+	drawGhost(g)
+
+	// Draw snakes with offset for sidebar
+	for _, s := range g.snakes {
+		// Playfield is dimmed while paused, and so are dead snakes
+		snakeColor := s.color
+		if g.state == StatePaused || !s.alive {
+			snakeColor = ColorDarkGray
+		}
+
+		for i, p := range s.body {
+			if g.mods.hidden(head, p) {
+				continue
+			}
+			symbol := symbolSnakeBody
+			if i == 0 {
+				// First segment is the head
+				symbol = symbolSnakeHead
+			}
+			setBoardCell(p, symbol, snakeColor)
+		}
+	}
+
+	// Between ticks, show the snakes moving on
+	if g.halfStepped() {
+		for i, s := range g.snakes {
+			if s.alive {
+				g.drawHalfStep(i, s.color)
+			}
+		}
+	}
+
+	// Draw food, with color indicating timer
+	for _, f := range g.foods {
+		if g.mods.hidden(head, f.At) {
+			continue
+		}
+		// Calculate color based on food timer
+		var fg Attribute = colorFood
+
+		// Change color as timer runs down
+		if f.Timer < minFoodTime/3 {
+			fg = colorFood | AttrBlink // Blinking when about to disappear
+		} else if f.Timer < minFoodTime/2 {
+			fg = colorFood | AttrBold // Bold when getting low
+		}
+		if g.state == StatePaused {
+			fg = ColorDarkGray
+		}
+
+		// The last seconds count down on the food itself, so the warning
+		// doesn't rely on color alone
+		symbol := f.Symbol()
+		if secs := g.foodSecondsLeft(&f); secs <= foodTickSeconds {
+			symbol = rune('0' + secs)
+		}
+		setBoardCell(f.At, symbol, fg)
+	}
+
+	// Draw frenzy food, blinking once it is about to go
+	for _, f := range g.frenzyFood {
+		if g.mods.hidden(head, f.At) {
+			continue
+		}
+		fg := colorFood | AttrBold
+		if f.Timer < minFoodTime/3 {
+			fg = colorFood | AttrBlink
+		}
+		if g.state == StatePaused {
+			fg = ColorDarkGray
+		}
+		setBoardCell(f.At, foodSymbols[f.Type], fg)
+	}
+
+	// Draw crumbs, blinking for the last third of their burst
+	for _, c := range g.crumbs {
+		if g.mods.hidden(head, c.At) {
+			continue
+		}
+		fg := colorScore
+		if c.Timer < crumbTicks/3 {
+			fg |= AttrBlink
+		}
+		if g.state == StatePaused {
+			fg = ColorDarkGray
+		}
+		setBoardCell(c.At, symbolCrumb, fg)
+	}
+
+	// Draw the puzzle's remaining food
+	if g.puzzle != nil {
+		for _, p := range g.puzzle.Food {
+			setBoardCell(p, foodSymbols[0], colorFood)
+		}
+	}
+
+	// Float the points just won up from where they were won
+	drawPopups(g)
+
+	// Announce a random event along the top border
+	if g.event != nil && g.banner > 0 && g.state != StateGameOver {
+		banner := " " + g.event.Name() + "! "
+		drawCentered(0, banner, colorScore|AttrBold)
+	}
+	drawPauseVote(g)
+
+	// Pause overlay (centered in game area)
+	if g.showChallenge && g.mode == modeDaily {
+		drawDailyChallenge(g)
+	} else if g.showChallenge {
+		drawChallenge(g.challenge, g.mods)
+	} else if g.showIntro && g.campaign != nil {
+		drawCampaignIntro(g)
+	} else if g.showIntro && g.hotseat != nil {
+		drawHotseatIntro(g)
+	} else if g.state == StatePaused {
+		lines := []Line{
+			{tr("paused"), colorScore | AttrBold},
+			{tr("resume", keysHint(ActionPause)), colorText},
+		}
+		if g.menu != nil {
+			lines = append(lines, Line{fmt.Sprintf("%s to restart, %s for menu", keyHint(ActionRestart), keyHint(ActionMenu)), colorText})
+		}
+		drawPanel(lines)
+	} else if g.state == StatePlaying && g.countdown > 0 {
+		drawCountdown(g)
+	}
+
+	// Versus win screen, puzzle result or game over message (centered in
+	// game area). Rewinding shows the board as it was, with nothing over it.
+	switch {
+	case g.state != StateGameOver:
+	case g.rewind > 0:
+		drawRewind(g)
+	case g.Versus():
+		drawWinner(g)
+	case g.puzzle != nil:
+		drawPuzzleResult(g)
+	case g.campaign != nil && !g.showGameStats:
+		drawCampaignResult(g)
+	case g.hotseat != nil && !g.showGameStats:
+		drawHotseatResult(g)
+	case g.showGameStats:
+		drawGameStats(g)
+	default:
+		drawGameOver(g)
+	}
+
+	// Short notices at the bottom of the sidebar
+	drawToasts(g)
+	screen.Flush()
+}
+
+// Clear the entire sidebar area to prevent artifacts
+func clearSidebarArea() {
+	for y := 0; y < viewHeight+4; y++ { // +4 to include score area below game
+		for x := 0; x < sidebarWidth; x++ {
+			screen.SetCell(x, y, ' ', ColorDefault, ColorDefault)
+		}
+	}
+}
+
+// Draw the sidebar with scores and food information. Rows are laid out
+// top to bottom; things that come and go during a game keep their row
+// blank while they're away so the rows below don't jump around.
+func drawSidebar(g *Game) {
+	// Draw vertical separator line
+	for i := 0; i < viewHeight+2; i++ {
+		screen.SetCell(sidebarWidth-1, i, symbolSeparator, colorBorder, ColorDefault)
+	}
+
+	sb := sidebar.reset()
+	if g.puzzle != nil {
+		drawPuzzleSidebar(sb, g)
+		return
+	}
+
+	// Draw the clock for timed and survival games
+	drawModeStatus(sb, g)
+
+	// Flag when the autopilot has the wheel, or else the play streak
+	if _, isBot := g.controllers[0].(Bot); isBot {
+		sb.Text("AUTOPILOT", colorFood|AttrBold)
+	} else if sound.Muted {
+		sb.Text("MUTED", colorText)
+	} else if days := g.session.streakDays(); days > 1 {
+		sb.Textf(colorText, "STREAK: %d DAYS", days)
+	} else {
+		sb.Blank()
+	}
+
+	// Draw minimal score display, side by side in versus mode
+	if g.Versus() {
+		for i, s := range g.snakes {
+			sb.Columnf(i, len(g.snakes), s.color|AttrBold, "%s: %d", s.name, s.score)
+		}
+		sb.Next()
+	} else {
+		sb.Text(tr("score", g.Player().score), colorScore|AttrBold)
+	}
+	if g.ghost != nil {
+		sb.Textf(ColorDarkGray, "GHOST: %d", g.ghost.game.Player().score)
+	}
+	if g.speedrun != nil {
+		drawSpeedrun(sb, g)
+	}
+
+	// Draw active game mode and level
+	sb.Text(tr("mode", strings.ToUpper(g.mode)), colorText)
+	sb.Text(tr("level", g.level), colorText)
+	if clock := g.clockLabel(); clock != "" {
+		sb.Text(clock, colorText)
+	}
+
+	// Draw timed effects from special foods
+	drawEffects(sb, g)
+
+	// Draw net points per second when the score is decaying
+	if g.mods.Has(ModScoreDecay) {
+		for i, s := range g.snakes {
+			rate := s.scoreRate()
+			fg := colorScore
+			if rate < 0 {
+				fg = colorFood
+			}
+			label := "RATE:"
+			if g.Versus() {
+				label = s.name
+			}
+			sb.Columnf(i, len(g.snakes), fg, "%s %+.1f/s", label, rate)
+		}
+		sb.Next()
+	}
+
+	// Draw food symbols and their values in a compact format
+	symW, valW := foodColumnWidths()
+	drawFoodTable(sb, g, symW, valW)
+
+	if g.levels != nil {
+		drawLevelGoal(sb, g)
+	} else if g.campaign != nil {
+		drawCampaignGoal(sb, g)
+	} else if g.hotseat != nil {
+		drawHotseatSidebar(sb, g)
+	} else if g.session != nil && g.session.Goal != nil && !g.Versus() {
+		drawSessionGoal(sb, g.session)
+	}
+
+	// Draw the countdown to the next food expiring, as blinking isn't
+	// reliable everywhere
+	if f := g.soonestFood(); f != nil {
+		secs := g.foodSecondsLeft(f)
+		fg := colorText
+		if secs <= foodTickSeconds {
+			fg = colorFood | AttrBold
+		}
+		sb.Text(tr("expires", secs), fg)
+	} else {
+		sb.Blank()
+	}
+
+	// Draw preview of the next food
+	next := tr("next")
+	sb.Table(0, TableColumn{Width: textWidth(next) + 1}, TableColumn{Width: symW + 1}, TableColumn{Width: 2}, TableColumn{Width: valW, Align: AlignRight}).Row(
+		TableCell{Text: next, Fg: colorText}, TableCell{Symbol: foodSymbols[g.nextFoodType], Fg: colorFood},
+		TableCell{Text: "=", Fg: colorScore}, TableCell{Number: foodValues[g.nextFoodType], Fg: colorScore})
+
+	// Draw the running random event
+	if g.event != nil {
+		sb.Textf(colorFood|AttrBold, "%s: %ds", g.event.Name(), g.eventSecondsLeft())
+	} else {
+		sb.Blank()
+	}
+
+	// Draw level map name
+	if g.levelName != "" {
+		sb.Text(tr("map", strings.ToUpper(g.levelName)), colorText)
+	}
+
+	// Draw the seed so the game can be replayed. Challenges go by their ID.
+	if g.challenge == "" {
+		sb.Text(tr("seed", g.seed), colorText)
+	}
+}
+
+// Columns the food table needs for the widest symbol and value
+func foodColumnWidths() (symW, valW int) {
+	symW, valW = 1, 1
+	for i, sym := range foodSymbols {
+		symW = max(symW, runewidth.RuneWidth(sym))
+		valW = max(valW, len(strconv.Itoa(foodValues[i])))
+	}
+	return symW, valW
+}
+
+// Draw the food symbols and their values. A list too long for the sidebar
+// shows a page at a time, turning every few seconds of play, with the page
+// number under it.
+func drawFoodTable(sb *Sidebar, g *Game, symW, valW int) {
+	foods := sb.Table(2, TableColumn{Width: symW + 1}, TableColumn{Width: 2}, TableColumn{Width: valW, Align: AlignRight})
+	rows := min(len(foodSymbols), foodTableRows)
+	pages := (len(foodSymbols) + rows - 1) / rows
+	page := int(g.clock/foodPageTime) % pages
+	for i := page * rows; i < (page+1)*rows; i++ {
+		if i >= len(foodSymbols) {
+			sb.Blank() // Keeping the rows below in place on the last page
+			continue
+		}
+		foods.Row(TableCell{Symbol: foodSymbols[i], Fg: colorFood},
+			TableCell{Text: "=", Fg: colorText}, TableCell{Number: foodValues[i], Fg: colorScore})
+	}
+	if pages > 1 {
+		sb.Textf(colorText, "  %d/%d", page+1, pages)
+	}
+}
+
+// Draw the game over message, final score and what to do next in a panel
+func drawGameOver(g *Game) {
+	var lines []Line
+	if g.levels != nil {
+		lines = append(lines, Line{levelResult(g), colorScore | AttrBold})
+	}
+	lines = append(lines,
+		Line{tr("game_over", keyHint(ActionQuit), keyHint(ActionRestart)), ColorRed},
+		Line{tr("final_score", g.Player().score), colorScore | AttrBold},
+	)
+	if frenzy := g.Player().frenzyScore; frenzy > 0 {
+		lines = append(lines, Line{fmt.Sprintf("Frenzy bonus: %d", frenzy), colorFood | AttrBold})
+	}
+	if result := dailyResult(g); result != "" {
+		lines = append(lines, Line{result, colorText})
+	}
+	lines = append(lines, Line{})
+
+	scores, global := keyHint(ActionScores), keyHint(ActionGlobal)
+	scoresMsg := fmt.Sprintf("Press %s for high scores", scores)
+	if g.scoreRank >= 0 {
+		scoresMsg = fmt.Sprintf("New high score #%d! Press %s", g.scoreRank+1, scores)
+	}
+	if g.global != nil {
+		scoresMsg = fmt.Sprintf("%s for high scores, %s for global", scores, global)
+		if g.scoreRank >= 0 {
+			scoresMsg = fmt.Sprintf("New high score #%d! %s or %s", g.scoreRank+1, scores, global)
+		}
+	}
+	lines = append(lines, Line{scoresMsg, colorText})
+
+	settings := keyHint(ActionSettings)
+	settingsMsg := fmt.Sprintf("Press %s for settings", settings)
+	if g.menu != nil {
+		settingsMsg = fmt.Sprintf("%s for settings, %s for menu", settings, keyHint(ActionMenu))
+	}
+	if g.levels != nil {
+		settingsMsg = fmt.Sprintf("%s for settings, Enter for levels", settings)
+	}
+	lines = append(lines, Line{settingsMsg, colorText})
+	if g.countsForStats() {
+		lines = append(lines, Line{fmt.Sprintf("Press %s for game stats", keyHint(ActionStats)), colorText})
+	}
+	if g.undos > 0 && len(g.history) > 0 {
+		lines = append(lines, Line{fmt.Sprintf("%s to rewind, %s to undo (%d left)", keyHint(ActionRewind), keyHint(ActionUndo), g.undos), colorText})
+	} else if len(g.history) > 0 {
+		lines = append(lines, Line{fmt.Sprintf("Press %s to rewind the crash", keyHint(ActionRewind)), colorText})
+	}
+
+	if g.challenge == "" {
+		lines = append(lines, Line{fmt.Sprintf("Seed: %d", g.seed), colorText})
+	}
+	lines = append(lines, Line{cardHint(g), colorText})
+	drawPanel(lines)
+}
+
+// Draw the versus result and final scores in a panel
+func drawWinner(g *Game) {
+	msg := "DRAW!"
+	fg := colorScore | AttrBold
+	if g.winner >= 0 {
+		winner := g.snakes[g.winner]
+		msg = winner.name + " WINS!"
+		fg = winner.color | AttrBold
+	}
+	if g.left {
+		msg = g.snakes[remoteSnake].name + " LEFT - " + msg
+	}
+	lines := []Line{{msg, fg}, {}}
+
+	var parts []string
+	for _, s := range g.snakes {
+		parts = append(parts, fmt.Sprintf("%s %d", s.name, s.score))
+	}
+	lines = append(lines, Line{strings.Join(parts, "  -  "), colorText})
+
+	var frenzy []string
+	for _, s := range g.snakes {
+		if s.frenzyScore > 0 {
+			frenzy = append(frenzy, fmt.Sprintf("%s %d", s.name, s.frenzyScore))
+		}
+	}
+	if len(frenzy) > 0 {
+		lines = append(lines, Line{"Frenzy: " + strings.Join(frenzy, "  -  "), colorFood | AttrBold})
+	}
+
+	hint := fmt.Sprintf("Press %s to play again or %s to quit", keyHint(ActionRestart), keyHint(ActionQuit))
+	if g.remote {
+		hint = fmt.Sprintf("Waiting for the host, %s to quit", keyHint(ActionQuit))
+	}
+	lines = append(lines, Line{hint, colorText})
+
+	if g.challenge == "" {
+		lines = append(lines, Line{fmt.Sprintf("Seed: %d", g.seed), colorText})
+	}
+	lines = append(lines, Line{cardHint(g), colorText})
+	drawPanel(lines)
+}
+
+// Line on the game over screen about saving a scorecard
+func cardHint(g *Game) string {
+	if g.cardSaved {
+		return "Saved! Print it: go-snake replay card"
+	}
+	return fmt.Sprintf("Press %s for a shareable scorecard", keyHint(ActionCard))
+}
+
+// Draw a scrolling list of menu lines over the game area, keeping the
+// selected line in view
+func drawMenuList(lines []string, selected int, pick func(int)) {
+	drawList(boardLeft+3, 4, viewHeight-6, lines, selected, pick)
+}
+
+// Draw a run of text starting at x, y
+func drawText(x, y int, text string, fg Attribute) {
+	i := 0
+	for _, ch := range text {
+		screen.SetCell(x+i, y, ch, fg, ColorDefault)
+		i++
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Special food constants
+const (
+	specialFoodChance = 0.15 // Chance a new food is a special one
+	speedUpFactor     = 0.6  // Tick interval multiplier while sped up
+	slowDownFactor    = 1.5  // Tick interval multiplier while slowed down
+	shrinkBy          = 2    // Segments lost to a shrink food
+	scoreMultiplier   = 2    // Points multiplier while the bonus is active
+)
+
+// Effect is what eating a special food does to a snake
+type Effect int
+
+const (
+	EffectNone         Effect = iota
+	EffectSpeedUp             // Game runs faster for a while
+	EffectSlowDown            // Game runs slower for a while
+	EffectShrink              // Lose a few tail segments
+	EffectInvincible          // Pass through snakes and the border for a while
+	EffectMultiplier          // Points are multiplied for a while
+	EffectPoison              // Lose points, or the game if there aren't enough
+	EffectDashCooldown        // Can't dash again yet; not from a food
+	EffectMystery             // One of the other effects, picked when eaten
+)
+
+// Short names for active effects in the sidebar
+var effectLabels = map[Effect]string{
+	EffectSpeedUp:      "FAST",
+	EffectSlowDown:     "SLOW",
+	EffectInvincible:   "SHIELD",
+	EffectMultiplier:   fmt.Sprintf("x%d", scoreMultiplier),
+	EffectDashCooldown: "DASH",
+}
+
+// SpecialFood is a food that does more than give points. Add a row to
+// specialFoods to add a new kind.
+type SpecialFood struct {
+	Name   string
+	Symbol rune
+	Value  int // Points gained, or lost for poison
+	Effect Effect
+	Ticks  int // How long a timed effect lasts, in steps
+	Weight int // Relative chance among special foods
+}
+
+// Special foods that can appear in place of a regular one
+var specialFoods = []SpecialFood{
+	{Name: "chili", Symbol: '🌶', Value: 2, Effect: EffectSpeedUp, Ticks: 100, Weight: 3},
+	{Name: "snail", Symbol: '🐌', Value: 2, Effect: EffectSlowDown, Ticks: 100, Weight: 3},
+	{Name: "scissors", Symbol: '✂', Value: 1, Effect: EffectShrink, Weight: 2},
+	{Name: "star", Symbol: '⭐', Value: 1, Effect: EffectInvincible, Ticks: 80, Weight: 1},
+	{Name: "gem", Symbol: '💎', Value: 1, Effect: EffectMultiplier, Ticks: 150, Weight: 2},
+	{Name: "poison", Symbol: '☠', Value: 5, Effect: EffectPoison, Weight: 2},
+	{Name: "mystery", Symbol: '?', Effect: EffectMystery, Weight: 2},
+}
+
+// Look up a special food by name, or nil if there is none
+func specialFoodByName(name string) *SpecialFood {
+	for i := range specialFoods {
+		if specialFoods[i].Name == name {
+			return &specialFoods[i]
+		}
+	}
+	return nil
+}
+
+// Maybe pick a special food instead of a regular one, weighted by kind
+func (g *Game) rollSpecialFood() *SpecialFood {
+	if g.rng.Float64() >= specialFoodChance {
+		return nil
+	}
+	total := 0
+	for _, f := range specialFoods {
+		total += g.specialWeight(&f)
+	}
+	r := g.rng.Intn(total)
+	for i := range specialFoods {
+		if r < g.specialWeight(&specialFoods[i]) {
+			return &specialFoods[i]
+		}
+		r -= g.specialWeight(&specialFoods[i])
+	}
+	return nil
+}
+
+// A special food's chance of turning up in this game, 0 if it can't
+func (g *Game) specialWeight(f *SpecialFood) int {
+	if f.Effect == EffectMystery && !g.mysteryAllowed() {
+		return 0
+	}
+	return f.Weight
+}
+
+// Give a snake a special food's points and effect
+func (g *Game) eatSpecialFood(s *Snake, f *SpecialFood) {
+	switch f.Effect {
+	case EffectMystery:
+		g.openMystery(s)
+		return
+	case EffectPoison:
+		if s.score < f.Value {
+			s.alive, s.died = false, causePoison
+		}
+		s.score = max(s.score-f.Value, 0)
+		return
+	case EffectShrink:
+		// The head has already moved on without dropping the tail
+		s.body = s.body[:max(len(s.body)-1-shrinkBy, 1)]
+	case EffectSpeedUp, EffectSlowDown:
+		// The two cancel each other out
+		delete(s.effects, EffectSpeedUp)
+		delete(s.effects, EffectSlowDown)
+	}
+	if f.Ticks > 0 {
+		if s.effects == nil {
+			s.effects = make(map[Effect]int)
+		}
+		s.effects[f.Effect] = f.Ticks
+	}
+	g.award(s, f.Value)
+}
+
+// Count down a snake's timed effects by some steps
+func (s *Snake) updateEffects(steps int) {
+	for e, left := range s.effects {
+		if left <= steps {
+			delete(s.effects, e)
+		} else {
+			s.effects[e] = left - steps
+		}
+	}
+}
+
+// Has reports whether a timed effect is active on a snake
+func (s *Snake) Has(e Effect) bool {
+	return s.effects[e] > 0
+}
+
+// Adjust the tick interval for speed effects. Snakes share one tick, so in
+// versus mode one snake's chili speeds up both.
+func (g *Game) effectSpeed(interval time.Duration) time.Duration {
+	for _, s := range g.snakes {
+		switch {
+		case !s.alive:
+		case s.Has(EffectSpeedUp):
+			return time.Duration(float64(interval) * speedUpFactor)
+		case s.Has(EffectSlowDown):
+			return time.Duration(float64(interval) * slowDownFactor)
+		}
+	}
+	return interval
+}
+
+// Describe a snake's active effects and the seconds left on each
+func (g *Game) effectsText(s *Snake) string {
+	var parts []string
+	for _, e := range []Effect{EffectSpeedUp, EffectSlowDown, EffectInvincible, EffectMultiplier, EffectDashCooldown} {
+		if !s.Has(e) {
+			continue
+		}
+		parts = append(parts, fmt.Sprintf("%s %ds", effectLabels[e], g.secondsLeft(s.effects[e])))
+	}
+	return strings.Join(parts, " ")
+}
+
+// Draw each snake's active effects in the sidebar
+func drawEffects(sb *Sidebar, g *Game) {
+	for i, s := range g.snakes {
+		fg := colorScore
+		if g.Versus() {
+			fg = s.color
+		}
+		sb.Columnf(i, len(g.snakes), fg|AttrBold, "%s", g.effectsText(s))
+	}
+	sb.Next()
+}
@@ -0,0 +1,125 @@
+package main
+
+import "time"
+
+// Event constants
+const (
+	bannerTicks = 20 // Steps an event banner stays on screen
+
+	frenzyDuration = 10 * time.Second
+	frenzyMinFood  = 6
+	frenzyMaxFood  = 8
+)
+
+// RandomEvent is something that happens to a game unprompted, for a while
+type RandomEvent interface {
+	Name() string      // Shown on the banner and in the sidebar
+	Start(g *Game) int // Set the event up, returning how many steps it lasts
+	Tick(g *Game) bool // Advance one tick, returning false to end early
+	End(g *Game)       // Clean up once the event is over
+}
+
+// A random event and its chance of starting on any tick
+type eventChance struct {
+	event  RandomEvent
+	chance float64
+}
+
+// Events that can start during play. Only one runs at a time.
+var randomEvents = []eventChance{
+	{FoodFrenzy{}, 1.0 / 1500},
+}
+
+// Roll for a new random event, or advance the running one
+func (g *Game) updateEvent() {
+	g.banner = max(g.banner-g.steps, 0)
+
+	if g.event != nil {
+		g.eventTicks -= g.steps
+		if !g.event.Tick(g) || g.eventTicks <= 0 {
+			g.event.End(g)
+			g.event = nil
+		}
+		return
+	}
+
+	for _, ec := range randomEvents {
+		if g.rng.Float64() < ec.chance {
+			g.startEvent(ec.event)
+			return
+		}
+	}
+}
+
+// Start an event and announce it
+func (g *Game) startEvent(e RandomEvent) {
+	g.event = e
+	g.eventTicks = e.Start(g)
+	g.banner = bannerTicks
+}
+
+// Seconds left in the running event, rounded up
+func (g *Game) eventSecondsLeft() int {
+	return g.secondsLeft(g.eventTicks)
+}
+
+// FrenzyFood is one of the extra foods scattered by a frenzy
+type FrenzyFood struct {
+	At    Point
+	Type  int // Index into foodSymbols
+	Timer int // Steps until it disappears
+}
+
+// FoodFrenzy scatters a handful of short-lived foods across the board at once.
+// Points from them are tallied separately for the summary.
+type FoodFrenzy struct{}
+
+func (FoodFrenzy) Name() string {
+	return "FOOD FRENZY"
+}
+
+func (FoodFrenzy) Start(g *Game) int {
+	ticks := int(frenzyDuration / g.countdownStep())
+	n := frenzyMinFood + g.rng.Intn(frenzyMaxFood-frenzyMinFood+1)
+	for i := 0; i < n; i++ {
+		p, ok := g.freeFoodCell()
+		if !ok {
+			break
+		}
+		g.frenzyFood = append(g.frenzyFood, FrenzyFood{
+			At:    p,
+			Type:  g.rollFoodType(),
+			Timer: g.mods.foodTime(ticks/4 + g.rng.Intn(ticks/4+1)), // A quarter to half the frenzy
+		})
+	}
+	return ticks
+}
+
+func (FoodFrenzy) Tick(g *Game) bool {
+	left := g.frenzyFood[:0]
+	for _, f := range g.frenzyFood {
+		if f.Timer -= g.steps; f.Timer > 0 {
+			left = append(left, f)
+		}
+	}
+	g.frenzyFood = left
+	return len(g.frenzyFood) > 0
+}
+
+func (FoodFrenzy) End(g *Game) {
+	g.frenzyFood = nil
+}
+
+// Eat any frenzy food at p, returning whether there was some
+func (g *Game) eatFrenzyFood(s *Snake, p Point) bool {
+	for i, f := range g.frenzyFood {
+		if f.At == p {
+			points := foodValues[f.Type]
+			s.frenzyScore += points
+			g.award(s, points)
+			g.frenzyFood = append(g.frenzyFood[:i], g.frenzyFood[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package main
+
+// Food constants
+const (
+	cellsPerFood = 600 // Board cells per food when the count scales with size
+)
+
+// Number of foods on the board at once, from the config file. Zero scales
+// it with the board size instead.
+var foodCount = 0
+
+// Food is one item of regular food on the board
+type Food struct {
+	At       Point
+	Type     int          // Index of the food type in foodSymbols
+	Special  *SpecialFood // Special food in place of Type, nil if none
+	Timer    int          // Countdown until the food disappears
+	lastTick int          // Second of the last expiry beep
+}
+
+// Symbol the food is drawn with
+func (f *Food) Symbol() rune {
+	if f.Special != nil {
+		return f.Special.Symbol
+	}
+	return foodSymbols[f.Type]
+}
+
+// Number of foods the board should hold
+func maxFoods() int {
+	if foodCount > 0 {
+		return foodCount
+	}
+	return max(width*height/cellsPerFood, 1)
+}
+
+// Draw a food type, each as likely as the next unless the game has a food
+// mix giving them odds
+func (g *Game) rollFoodType() int {
+	if len(g.foodMix) != len(foodSymbols) {
+		return g.rng.Intn(len(foodSymbols))
+	}
+	total := 0
+	for _, w := range g.foodMix {
+		total += w
+	}
+	n := g.rng.Intn(total)
+	for i, w := range g.foodMix {
+		if n < w {
+			return i
+		}
+		n -= w
+	}
+	return 0
+}
+
+// Clear the board of food and fill it back up
+func (g *Game) resetFood() {
+	g.foods, g.foodRespawns = g.foods[:0], g.foodRespawns[:0]
+	for i := 0; i < maxFoods(); i++ {
+		g.PlaceFood()
+	}
+}
+
+// Place a new food at a random location not occupied by a snake. On a
+// full board there's nowhere to put it, so none is placed.
+func (g *Game) PlaceFood() {
+	// Take the previewed food type and draw the one after it
+	f := Food{Type: g.nextFoodType}
+	g.nextFoodType = g.rollFoodType()
+
+	// Sometimes a special food turns up instead
+	f.Special = g.rollSpecialFood()
+
+	// Set a random timer for this food
+	f.Timer = g.mods.foodTime(g.rng.Intn(maxFoodTime-minFoodTime) + minFoodTime)
+
+	// Pick a free cell the spawn policy allows
+	p, ok := g.freeFoodCell()
+	if !ok {
+		return
+	}
+	f.At = p
+	g.rememberFood(f.At)
+	g.foods = append(g.foods, f)
+}
+
+// Count down each food's timer, removing expired food and respawning it
+// after a pause
+func (g *Game) updateFood() {
+	beep := false
+	left := g.foods[:0]
+	for _, f := range g.foods {
+		f.Timer -= g.steps
+		if f.Timer <= 0 {
+			// Food has disappeared, and a new one is due
+			g.foodRespawns = append(g.foodRespawns, foodRespawnTime)
+			continue
+		}
+		if secs := g.foodSecondsLeft(&f); g.foodTick && secs <= foodTickSeconds && secs != f.lastTick {
+			// Audible countdown for the last few seconds
+			f.lastTick = secs
+			beep = true
+		}
+		left = append(left, f)
+	}
+	g.foods = left
+	if beep {
+		g.queueSound(SoundTick, 0)
+	}
+
+	// Count down to respawning the food that went
+	due := g.foodRespawns[:0]
+	for _, left := range g.foodRespawns {
+		if left -= g.steps; left > 0 {
+			due = append(due, left)
+		} else {
+			g.PlaceFood()
+		}
+	}
+	g.foodRespawns = due
+}
+
+// Take the food at p off the board, returning it
+func (g *Game) takeFood(p Point) (Food, bool) {
+	for i, f := range g.foods {
+		if f.At == p {
+			g.foods = append(g.foods[:i], g.foods[i+1:]...)
+			return f, true
+		}
+	}
+	return Food{}, false
+}
+
+// Food that will expire first, or nil if there is none
+func (g *Game) soonestFood() *Food {
+	var soonest *Food
+	for i := range g.foods {
+		if soonest == nil || g.foods[i].Timer < soonest.Timer {
+			soonest = &g.foods[i]
+		}
+	}
+	return soonest
+}
+
+// Seconds until a food expires, rounded up
+func (g *Game) foodSecondsLeft(f *Food) int {
+	return g.secondsLeft(f.Timer)
+}
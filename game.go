@@ -0,0 +1,785 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"time"
+)
+
+// Game constants
+const (
+	initialSize  = 3
+	baseSpeed    = 100
+	sidebarWidth = 20 // Width of the sidebar
+
+	foodTickSeconds = 3 // Seconds before expiry when the audible tick starts
+
+	maxPlayers = 2 // Snakes on the board in versus mode
+)
+
+// Game tunables, overridable from the config file
+var (
+	width       = 40
+	height      = 15
+	aspectRatio = 1.8
+
+	// Food timers
+	minFoodTime     = 50  // Minimum steps food stays on screen
+	maxFoodTime     = 150 // Maximum steps food stays on screen
+	foodRespawnTime = 20  // Steps to wait before spawning new food
+)
+
+// Food types and values
+var (
+	foodSymbols = []rune{'🍆', '🍗', '🧀', '🍬'}
+	foodValues  = []int{1, 3, 5, 7}
+)
+
+// Game modes, as recorded in the high score file
+const (
+	modeWrap      = "wrap"      // Snake wraps around the board edges
+	modeWalls     = "walls"     // Touching the border kills the snake
+	modeTimed     = "timed"     // Time attack: score as much as possible before the clock runs out
+	modeSurvival  = "survival"  // Obstacles keep appearing until the board fills up
+	modeArcade    = "arcade"    // Pairs of portals open as the game goes on
+	modeWeekly    = "weekly"    // Seeded weekly challenge with modifiers
+	modeDaily     = "daily"     // Daily challenge with the day's speed, walls and food mix
+	modePuzzle    = "puzzle"    // Turn-based puzzles with fixed food and a move par
+	modeTerritory = "territory" // Snakes paint the cells they cross and score for what they hold
+	modeRoyale    = "royale"    // The arena closes in a ring at a time
+	modeTron      = "tron"      // Light cycles: tails never shrink and the last snake moving wins
+)
+
+// State is the part of its life a game is in
+type State int
+
+const (
+	StateMenu     State = iota // Title menu is open and the game hasn't started
+	StatePlaying               // Snakes are on the move
+	StatePaused                // Frozen until resumed
+	StateGameOver              // Finished, waiting for a restart
+)
+
+// Direction represents the snake's movement direction
+type Direction int
+
+const (
+	Up Direction = iota
+	Right
+	Down
+	Left
+)
+
+// Valid reports whether d is one of the four directions, for those that
+// come from outside the game
+func (d Direction) Valid() bool {
+	return d >= Up && d <= Left
+}
+
+// Opposite returns the reverse of a direction
+func (d Direction) Opposite() Direction {
+	return (d + 2) % 4
+}
+
+// TurnLeft returns the direction a quarter turn anticlockwise
+func (d Direction) TurnLeft() Direction {
+	return (d + 3) % 4
+}
+
+// TurnRight returns the direction a quarter turn clockwise
+func (d Direction) TurnRight() Direction {
+	return (d + 1) % 4
+}
+
+// Point represents a position on the grid
+type Point struct {
+	X, Y int
+}
+
+// Snake is one player's snake along with its input state
+type Snake struct {
+	body        []Point // Head first
+	direction   Direction
+	score       int
+	frenzyScore int  // Part of the score earned during food frenzies
+	dashing     bool // Does the snake dash on its next move?
+	alive       bool
+	died        string         // What killed the snake, one of the death causes
+	decay       float64        // Fractional points lost to score decay, not yet taken
+	samples     []scoreSample  // Recent scores for the net rate
+	effects     map[Effect]int // Steps left on each timed effect
+	name        string         // Shown in the sidebar and on the win screen
+	color       Attribute      // Color used to draw the snake
+}
+
+// Death causes: what a snake died of
+const (
+	causeBorder  = "border"  // Left the board where the border is deadly
+	causeWall    = "wall"    // Ran into a wall
+	causeHazard  = "hazard"  // Ran into a hazard
+	causeArena   = "arena"   // Caught outside the arena as it closed in
+	causeSelf    = "self"    // Ran into its own body
+	causeSnake   = "snake"   // Ran into another snake's body
+	causeHeadOn  = "head-on" // Met another snake head to head
+	causePoison  = "poison"  // Ate poison it didn't have the points for
+	causeForfeit = "left"    // Its player left a network game
+)
+
+// Head returns the snake's head cell
+func (s *Snake) Head() Point {
+	return s.body[0]
+}
+
+// Turn changes heading, ignoring attempts to reverse into the body
+func (s *Snake) Turn(dir Direction) {
+	if dir != s.direction.Opposite() {
+		s.direction = dir
+	}
+}
+
+// Game represents the state of the game
+type Game struct {
+	snakes        []*Snake // Player 1 first; more than one in versus mode
+	foods         []Food   // Regular food on the board
+	foodRespawns  []int    // Countdowns until expired food comes back
+	nextFoodType  int      // Index of the food type that spawns after this one
+	foodMix       []int    // Relative odds of each food type, nil for even odds
+	highScore     int
+	state         State // Menu, playing, paused or over
+	winner        int   // Index of the winning snake in versus mode, -1 for a draw
+	mode          string
+	scores        *HighScores   // Persistent leaderboard
+	scoreRank     int           // Leaderboard rank of this game, -1 if it didn't place
+	scoreTop      int           // First row shown on the high score screen
+	cardSaved     bool          // Has this game's scorecard been saved?
+	showScores    bool          // Is the high score screen open?
+	global        *GlobalScores // Online leaderboard after this game, nil if not submitted
+	showGlobal    bool          // Is the online leaderboard open?
+	showSettings  bool          // Is the settings menu open?
+	settingsMenu  *SettingsMenu // Settings menu, shown while showSettings
+	showStats     bool          // Is the stats screen open?
+	session       *Session      // Games played since go-snake started
+	menu          *TitleMenu    // Title menu, shown in StateMenu
+	calibrating   *Calibrator   // Calibration screen, nil unless open
+	mods          Modifiers     // Active rule modifiers
+	challenge     string        // Weekly challenge ID or daily challenge date, empty outside challenges
+	dailyLog      *DailyLog     // Daily challenge attempts, in daily games
+	daily         *DailyAttempt // This game's attempt at the daily challenge, nil until it starts
+	showCalendar  bool          // Is the daily challenge calendar open?
+	showChallenge bool          // Is the challenge announcement open?
+	campaign      *CampaignRun  // Campaign stage being played, nil outside the campaign
+	hotseat       *HotseatTurn  // Turn being played in a hot-seat tournament, nil otherwise
+	showIntro     bool          // Is the campaign stage's intro or the hot-seat handover open?
+	foodTick      bool          // Beep each second before food expires?
+	difficulty    Difficulty
+	level         int
+	walls         map[Point]bool  // Obstacle cells from the level map
+	portals       []Portal        // Linked cells from the level map or arcade mode
+	levelName     string          // Level map in play, empty for an open board
+	spawn         SpawnPolicy     // Where food may appear
+	recentFood    []Point         // Last few food positions
+	event         RandomEvent     // Random event in progress, nil if none
+	eventTicks    int             // Steps left in the running event
+	banner        int             // Steps left to show the event banner
+	frenzyFood    []FrenzyFood    // Extra foods from a food frenzy
+	crumbs        []Crumb         // Crumbs special foods burst into
+	bursts        int             // Crumb bursts so far, numbering each one
+	hazards       []Hazard        // Moving and changing hazards on the board
+	clock         time.Duration   // Game time played so far: active play time, stopping while paused
+	wall          time.Duration   // Wall-clock time from when play began to wallMark
+	wallMark      time.Time       // When wall was last brought up to date, zero before the first tick
+	countdown     time.Duration   // Time left before the snakes start moving
+	nextHazard    time.Duration   // Game time the next survival obstacles appear
+	nextPayout    time.Duration   // Game time territory is next paid for
+	paint         []int8          // Territory: which snake holds each cell, by 1 + index, 0 for none
+	closed        int             // Rings of the board the arena has closed in royale mode
+	startLength   int             // Segments the snakes started with
+	rng           *rand.Rand      // Source of all the game's randomness
+	source        *countingSource // Seeded source behind rng, counting draws for saves
+	seed          int64           // Seed rng started from, for replaying the game
+	rules         int             // Version of the rules the game is played under
+	ticks         int             // Updates played so far
+	steps         int             // Steps of game time timed things count down this tick
+	replay        *Replay         // Inputs recorded so far, nil when not recording
+	puzzle        *PuzzleRun      // Puzzle being played, nil outside puzzle mode
+	puzzles       *PuzzleMenu     // Puzzle select screen, nil outside puzzle mode
+	showPuzzles   bool            // Is the puzzle select screen open?
+	levels        *LevelMenu      // Level select screen, nil unless picking levels
+	showLevels    bool            // Is the level select screen open?
+	watching      bool            // Is this a recorded game being played back?
+	replayed      []Direction     // Inputs applied on the last replay step
+	playback      *ReplayPlayer   // Replay being watched, nil otherwise
+	controllers   []Player        // Who steers each snake
+	rival         bool            // Is the last snake a bot standing in for a second player?
+	botAssisted   bool            // Has the autopilot steered player 1 this game?
+	relative      bool            // Do left and right turn the snakes rather than point them?
+	remote        bool            // Is this a copy of a game hosted over the network?
+	left          bool            // Did the joining player leave before the end?
+	pauseVote     *PauseVote      // Versus players agreeing to pause; nil when either player may pause alone
+	sounds        []Sound         // Sounds from the last update, for the game loop to play
+	toasts        []Toast         // Notices at the bottom of the sidebar, oldest first
+	popups        []Popup         // Points floating up from where they were won
+	beatBest      bool            // Has the score passed the best saved one this game?
+	stats         GameStats       // What player 1 has done this game
+	showGameStats bool            // Is the finished game's stats panel open?
+	ghost         *Ghost          // Best run so far, raced alongside, nil when off
+	speedrun      *Speedrun       // Run being timed with splits, nil when off
+	plugins       []Plugin        // Told what happens in the game
+	keepHistory   bool            // Keep the last ticks to rewind through after the game?
+	history       []SavedGame     // State before each of the last ticks, oldest first
+	rewind        int             // Ticks back from the end being shown, 0 for the end
+	undos         int             // Crashes left to undo in a practice game
+	moveLog       *MoveLog        // Where to write what happens, nil if nowhere
+	motion        float64         // How far the snakes are through their next move, for smooth motion
+	scratch       spawnScratch    // Kept for placing food
+}
+
+// Initialize a new game for the given number of players, on an open board
+// when level is nil. Games started from the same seed get the same food.
+func NewGame(level *Level, spawn SpawnPolicy, players int, seed int64) *Game {
+	g := &Game{}
+	g.Reset(level, spawn, players, seed)
+	return g
+}
+
+// Reset sets the game up afresh, just as NewGame would, but reuses the
+// slices and maps the last game grew, so restarting and simulating game
+// after game allocate next to nothing. Nothing of the last game may be in
+// use elsewhere: its snakes, for one, become the new game's.
+func (g *Game) Reset(level *Level, spawn SpawnPolicy, players int, seed int64) {
+	g.resetUnder(rulesVersion, level, spawn, players, seed)
+}
+
+// Reset the game to be played under a given rules version, which the food
+// it starts with is rolled under too
+func (g *Game) resetUnder(rules int, level *Level, spawn SpawnPolicy, players int, seed int64) {
+	last := *g
+	*g = Game{
+		state:       StatePlaying,
+		mode:        modeWrap,
+		scoreRank:   -1,
+		winner:      -1,
+		difficulty:  difficulties[1],
+		level:       1,
+		spawn:       spawn,
+		seed:        seed,
+		rules:       rules,
+		nextHazard:  hazardInterval,
+		nextPayout:  territoryPayEvery,
+		startLength: initialSize,
+
+		// Emptied, keeping their room
+		snakes:       last.snakes[:0],
+		foods:        last.foods[:0],
+		foodRespawns: last.foodRespawns[:0],
+		recentFood:   last.recentFood[:0],
+		frenzyFood:   last.frenzyFood[:0],
+		crumbs:       last.crumbs[:0],
+		hazards:      last.hazards[:0],
+		portals:      last.portals[:0],
+		replayed:     last.replayed[:0],
+		sounds:       last.sounds[:0],
+		toasts:       last.toasts[:0],
+		popups:       last.popups[:0],
+		history:      last.history[:0],
+		controllers:  last.controllers[:0],
+		scratch:      last.scratch,
+	}
+
+	if last.source != nil {
+		g.source, g.rng = last.source, last.rng
+		g.rng.Seed(seed)
+	} else {
+		g.source = newCountingSource(seed)
+		g.rng = rand.New(g.source)
+	}
+
+	// Initialize snakes in the middle of the board, or at the level's spawn
+	if level != nil {
+		g.walls = last.walls // Survival mode adds to them
+		if g.walls == nil {
+			g.walls = make(map[Point]bool, len(level.Walls))
+		}
+		clear(g.walls)
+		maps.Copy(g.walls, level.Walls)
+		g.portals = append(g.portals, level.Portals...) // Arcade mode adds to them
+		g.levelName = level.Name
+	}
+	for i, start := range spawnPoints(level, players) {
+		s := &Snake{}
+		if i < len(last.snakes) {
+			s = last.snakes[i]
+		}
+		effects := s.effects
+		clear(effects)
+		*s = Snake{
+			body:      start.snakeStart(s.body, initialSize),
+			direction: start.Heading,
+			alive:     true,
+			samples:   s.samples[:0],
+			effects:   effects,
+			name:      playerNames[i],
+			color:     playerColors[i],
+		}
+		g.snakes = append(g.snakes, s)
+		g.controllers = append(g.controllers, &Human{})
+	}
+
+	// Pre-draw the first food type, then fill the board
+	g.nextFoodType = g.rollFoodType()
+	g.resetFood()
+}
+
+// Player names and colors, by snake index
+var (
+	playerNames  = []string{"P1", "P2"}
+	playerColors = []Attribute{ColorGreen, ColorBlue}
+)
+
+// Work out where each snake starts. A single snake starts in the middle of
+// an open board or at the level's spawn point. In versus mode the second
+// snake uses the level's second spawn point, or else starts at the mirror
+// image of the first, facing the other way.
+func spawnPoints(level *Level, players int) []SpawnPoint {
+	first := SpawnPoint{At: Point{X: width / 2, Y: height / 2}, Heading: Right}
+	if level != nil {
+		if len(level.Spawns) >= players {
+			return level.Spawns[:players]
+		}
+		first = level.Spawns[0]
+	} else if players > 1 {
+		first = SpawnPoint{At: Point{X: width / 4, Y: height / 2}, Heading: Right}
+	}
+
+	spawns := []SpawnPoint{first}
+	if players > 1 {
+		spawns = append(spawns, SpawnPoint{
+			At:      Point{X: width - 1 - first.At.X, Y: height - 1 - first.At.Y},
+			Heading: first.Heading.Opposite(),
+		})
+	}
+	return spawns
+}
+
+// Hand a snake over to a bot or back to the keyboard
+func (g *Game) setController(snake int, p Player) {
+	g.controllers[snake] = p
+	if _, isBot := p.(Bot); isBot && snake == 0 {
+		g.botAssisted = true
+	}
+}
+
+// Press a direction key for a snake, unless a bot is steering it
+func (g *Game) press(snake int, key Direction) {
+	h, ok := g.controllers[snake].(*Human)
+	if !ok {
+		return
+	}
+	dir, ok := g.keyHeading(snake, key)
+	if !ok {
+		return
+	}
+	if g.countdown > 0 {
+		// Queue the first move before the start
+		g.snakes[snake].face(dir)
+		return
+	}
+	// Tapping the way the snake is already heading twice dashes
+	if h.doubleTap(dir, time.Now()) && dir == g.snakes[snake].direction {
+		g.dash(snake)
+	}
+	h.Press(dir, g.snakes[snake].direction)
+}
+
+// Work out the heading a direction key asks for, allowing for mirrored
+// controls. With relative steering left and right turn the snake from its
+// current heading, up carries straight on (a move, in puzzles) and down
+// does nothing. Turns are relative to the last one queued, so two quick
+// lefts make a U-turn.
+func (g *Game) keyHeading(snake int, key Direction) (Direction, bool) {
+	key = g.mods.steer(key)
+	if !g.relative {
+		return key, true
+	}
+	heading := g.snakes[snake].direction
+	if h, ok := g.controllers[snake].(*Human); ok {
+		heading = h.heading(heading)
+	}
+	switch key {
+	case Up:
+		return heading, true
+	case Left:
+		return heading.TurnLeft(), true
+	case Right:
+		return heading.TurnRight(), true
+	}
+	return key, false
+}
+
+// Player returns the first (or only) player's snake
+func (g *Game) Player() *Snake {
+	return g.snakes[0]
+}
+
+// Versus reports whether more than one snake is playing
+func (g *Game) Versus() bool {
+	return len(g.snakes) > 1
+}
+
+// Bordered reports whether leaving the board is fatal rather than wrapping
+func (g *Game) Bordered() bool {
+	return g.mode == modeWalls || g.mode == modeTron
+}
+
+// Check whether a cell is taken by any snake
+func (g *Game) occupied(p Point) bool {
+	return g.occupant(p) != nil
+}
+
+// The snake with a body segment on a cell, or nil for none
+func (g *Game) occupant(p Point) *Snake {
+	for _, s := range g.snakes {
+		for _, b := range s.body {
+			if b == p {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// Update game state
+func (g *Game) Update() {
+	if g.state != StatePlaying || g.updateCountdown() {
+		return
+	}
+	g.remember()
+	g.tickWall()
+	g.ticks++
+	interval := g.updateInterval() // Steering or a new level changes it for the next tick, not this one
+	g.steps = g.tickSteps(interval)
+	g.sounds = g.sounds[:0]
+	g.motion = 0
+	g.agePopups()
+	if g.ticks == 1 && g.replay != nil {
+		g.replay.Heading = g.Player().direction
+	}
+
+	// Let each snake's player steer before it moves, and wear off effects
+	for i, s := range g.snakes {
+		if !s.alive {
+			continue
+		}
+		s.updateEffects(g.steps)
+		g.warnEffects(s, g.steps)
+		if dir, ok := g.controllers[i].Steer(g, i); ok {
+			g.steer(i, dir)
+		}
+	}
+
+	// Food comes and goes, and so do random events. Puzzles have fixed food
+	// and no events, and light cycles neither.
+	if g.puzzle == nil {
+		if g.mode != modeTron {
+			g.updateFood()
+			g.ageCrumbs()
+			g.updateEvent()
+		}
+		g.updateHazards()
+	}
+
+	// Every live snake moves a cell, and dashing snakes a second one
+	moving := make([]bool, len(g.snakes))
+	for i, s := range g.snakes {
+		moving[i] = s.alive
+	}
+	before := g.beforeMove()
+	g.moveSnakes(moving)
+	g.moveDashers()
+
+	// Score decay drains every live snake, and the sidebar tracks the net rate
+	elapsed := interval
+	if g.rules < stepRules {
+		elapsed = g.updateInterval() // Older rules timed the tick by the interval after its move
+	}
+	g.clock += elapsed
+	if g.mods.Has(ModScoreDecay) {
+		for _, s := range g.snakes {
+			if s.alive {
+				s.decayScore(elapsed)
+				s.sampleScore(g.clock)
+			}
+		}
+	}
+
+	g.checkGameOver()
+	g.updateMode()
+	g.updateCampaign()
+	g.updateSpeedrun()
+
+	// Every update is a move in a puzzle, and eating all the food solves it
+	if g.puzzle != nil {
+		g.puzzle.Moves++
+		if g.state != StateGameOver && len(g.puzzle.Food) == 0 {
+			g.puzzle.Solved = true
+			g.state = StateGameOver
+		}
+	}
+
+	// A ghost racing the game keeps up with it
+	if g.ghost != nil {
+		g.ghost.step()
+	}
+	g.checkInvariants(before)
+
+	for _, p := range g.plugins {
+		p.OnTick(g)
+		if g.state == StateGameOver {
+			p.OnGameOver(g)
+		}
+	}
+}
+
+// Move the given snakes one cell along their headings, killing those that
+// crash and growing those that eat
+func (g *Game) moveSnakes(moving []bool) {
+	// Calculate new head positions
+	heads := make([]Point, len(g.snakes))
+	deadly, _ := g.hazardCells()
+	dead := make([]string, len(g.snakes)) // Death cause, "" for a snake that lives
+	for i, s := range g.snakes {
+		if !moving[i] {
+			continue
+		}
+		head := s.Head()
+		var newHead Point
+
+		switch s.direction {
+		case Up:
+			newHead = Point{X: head.X, Y: head.Y - 1}
+		case Right:
+			newHead = Point{X: head.X + 1, Y: head.Y}
+		case Down:
+			newHead = Point{X: head.X, Y: head.Y + 1}
+		case Left:
+			newHead = Point{X: head.X - 1, Y: head.Y}
+		}
+
+		// In walls and tron modes leaving the board is fatal, unless shielded
+		if g.Bordered() && !s.Has(EffectInvincible) && (newHead.X < 0 || newHead.X >= width || newHead.Y < 0 || newHead.Y >= height) {
+			dead[i] = causeBorder
+			continue
+		}
+
+		// Implement wraparound for walls
+		if newHead.X < 0 {
+			newHead.X = width - 1
+		} else if newHead.X >= width {
+			newHead.X = 0
+		}
+
+		if newHead.Y < 0 {
+			newHead.Y = height - 1
+		} else if newHead.Y >= height {
+			newHead.Y = 0
+		}
+
+		// A portal carries the head to its other end, heading the same way
+		newHead = g.throughPortal(newHead)
+
+		// Check obstacle and hazard collision, and running into any snake's
+		// body (including its own). A shield lets a snake pass through bodies.
+		switch o := g.occupant(newHead); {
+		case g.walls[newHead]:
+			dead[i] = causeWall
+		case deadly[newHead] && g.mode == modeRoyale && g.outsideArena(newHead, g.closed):
+			dead[i] = causeArena
+		case deadly[newHead]:
+			dead[i] = causeHazard
+		case o == s && !s.Has(EffectInvincible):
+			dead[i] = causeSelf
+		case o != nil && !s.Has(EffectInvincible):
+			dead[i] = causeSnake
+		}
+		heads[i] = newHead
+	}
+
+	// Head-to-head: two snakes moving onto the same cell, or through each
+	// other, both die
+	for i, a := range g.snakes {
+		for j := i + 1; j < len(g.snakes); j++ {
+			b := g.snakes[j]
+			if !moving[i] || !moving[j] {
+				continue
+			}
+			if heads[i] == heads[j] || (heads[i] == b.Head() && heads[j] == a.Head()) {
+				if dead[i] == "" && !a.Has(EffectInvincible) {
+					dead[i] = causeHeadOn
+				}
+				if dead[j] == "" && !b.Has(EffectInvincible) {
+					dead[j] = causeHeadOn
+				}
+			}
+		}
+	}
+
+	for i, s := range g.snakes {
+		if !moving[i] {
+			continue
+		}
+		if dead[i] != "" {
+			s.alive, s.died = false, dead[i]
+			g.queueSound(SoundCrash, 0)
+			head := s.Head()
+			g.logMove(s, "crashed at (%d,%d), heading %s", head.X, head.Y, directionNames[s.direction])
+			continue
+		}
+
+		// Add new head to snake
+		newHead := heads[i]
+		symbol, eating := g.foodSymbolAt(newHead)
+		s.body = append([]Point{newHead}, s.body...)
+		g.paintCell(i, newHead)
+		before := s.score
+
+		// Check food collision against every food on the board
+		if f, ok := g.takeFood(newHead); ok {
+			// Award points based on food type, or apply a special food
+			if f.Special != nil {
+				g.eatSpecialFood(s, f.Special)
+				if s.alive {
+					g.burstCrumbs(newHead)
+				}
+			} else {
+				g.award(s, foodValues[f.Type])
+			}
+
+			// Place new food
+			g.PlaceFood()
+		} else if g.eatFrenzyFood(s, newHead) {
+			// Frenzy food grows the snake too, but isn't replaced
+		} else if g.puzzle != nil && g.puzzle.eat(newHead) {
+			g.award(s, 1)
+		} else if g.mode == modeTron {
+			// A light cycle's trail stays where it was laid
+		} else {
+			// Remove tail if no food was eaten. Crumbs are only points.
+			g.eatCrumb(s, newHead)
+			s.body = s.body[:len(s.body)-1]
+		}
+		g.popScore(s, newHead, s.score-before)
+		if eating {
+			g.logMove(s, "ate %c %+d, score %d", symbol, s.score-before, s.score)
+			for _, p := range g.plugins {
+				p.OnFoodEaten(g, s, symbol, s.score-before)
+			}
+		}
+
+		// Keep player 1's stats
+		if i == 0 {
+			g.stats.Moves++
+			if eating {
+				g.stats.eat(symbol)
+			}
+			g.stats.MaxLength = max(g.stats.MaxLength, len(s.body))
+		}
+	}
+}
+
+// Add points to a snake's score, levelling up and raising the high score
+func (g *Game) award(s *Snake, points int) {
+	if s.Has(EffectMultiplier) && points > 0 {
+		points *= scoreMultiplier
+	}
+	s.score += points
+	if points > 0 {
+		g.queueSound(SoundEat, points)
+	}
+
+	// Level up every few points
+	if level := g.difficulty.Level(s.score); level > g.level {
+		g.level = level
+		g.queueSound(SoundLevelUp, level)
+		g.notify(fmt.Sprintf("Level %d!", level))
+		for _, p := range g.plugins {
+			p.OnLevelUp(g, level)
+		}
+	}
+
+	// Update high score if current score is higher, saying so the first
+	// time a saved one is beaten
+	if s.score > g.highScore {
+		if g.highScore > 0 && !g.beatBest && !g.Versus() {
+			g.beatBest = true
+			g.notify("New high score!")
+		}
+		g.highScore = s.score
+	}
+}
+
+// End the game once the player dies, or in versus mode once at most one
+// snake is left. When the last snakes die together, the higher score wins.
+func (g *Game) checkGameOver() {
+	alive := 0
+	for i, s := range g.snakes {
+		if s.alive {
+			alive++
+			g.winner = i
+		}
+	}
+
+	if !g.Versus() {
+		if alive == 0 {
+			g.endGame()
+		}
+		return
+	}
+	if alive > 1 {
+		return
+	}
+
+	g.endGame()
+	if alive == 0 {
+		g.winner = -1
+		best := -1
+		for i, s := range g.snakes {
+			if s.score > best {
+				best, g.winner = s.score, i
+			} else if s.score == best {
+				g.winner = -1
+			}
+		}
+	}
+}
+
+// Finish the game, with a sound to say so
+func (g *Game) endGame() {
+	g.state = StateGameOver
+	g.queueSound(SoundGameOver, 0)
+	for _, s := range g.snakes {
+		g.logMove(s, "game over, score %d", s.score)
+	}
+}
+
+// Get the appropriate update interval based on speed and direction
+func getUpdateInterval(speed int, dir Direction) time.Duration {
+	if dir == Left || dir == Right {
+		// Horizontal movement
+		return time.Duration(speed) * time.Millisecond
+	} else {
+		// Vertical movement - adjust for aspect ratio
+		return time.Duration(float64(speed)*aspectRatio) * time.Millisecond
+	}
+}
+
+// Current tick interval for the game's level and direction. Snakes share one
+// tick in versus mode, so there it always runs at the horizontal speed
+// rather than favouring either player's heading.
+func (g *Game) updateInterval() time.Duration {
+	dir := g.Player().direction
+	if g.Versus() {
+		dir = Right
+	}
+	return g.effectSpeed(getUpdateInterval(g.difficulty.Speed(g.level), dir))
+}
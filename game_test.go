@@ -0,0 +1,77 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// A game reset from a finished one plays exactly as a new game does
+func TestResetPlaysLikeNewGame(t *testing.T) {
+	maze, err := LoadLevel(builtinLevelNames()[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		level   *Level
+		players int
+		mode    string
+	}{
+		{"wrap", nil, 1, modeWrap},
+		{"walls", nil, 1, modeWalls},
+		{"versus", nil, 2, modeWrap},
+		{"survival", nil, 1, modeSurvival},
+		{"level", maze, 1, modeWrap},
+		{"level versus", maze, 2, modeWrap},
+	}
+	for _, tt := range tests {
+		fresh := NewGame(tt.level, newSpawnPolicy(settings), tt.players, 5)
+		fresh.setMode(tt.mode)
+
+		// Play another game out on the one to reset
+		reused := NewGame(tt.level, newSpawnPolicy(settings), maxPlayers, 9)
+		reused.setMode(modeSurvival)
+		reused.startEvent(FoodFrenzy{})
+		for i := 0; i < 300 && reused.state == StatePlaying; i++ {
+			reused.press(0, Direction(i/7%4))
+			reused.Update()
+		}
+		reused.Reset(tt.level, newSpawnPolicy(settings), tt.players, 5)
+		reused.setMode(tt.mode)
+
+		for i := 0; i < 300 && fresh.state == StatePlaying; i++ {
+			for _, g := range []*Game{fresh, reused} {
+				g.press(0, Direction(i/5%4))
+				g.Update()
+			}
+			if reused.state != fresh.state || len(reused.snakes) != len(fresh.snakes) {
+				t.Fatalf("%s, tick %d: reset game is %v with %d snakes, new game %v with %d", tt.name, fresh.ticks, reused.state, len(reused.snakes), fresh.state, len(fresh.snakes))
+			}
+			for j, s := range fresh.snakes {
+				if r := reused.snakes[j]; !slices.Equal(r.body, s.body) || r.score != s.score {
+					t.Fatalf("%s, tick %d: snake %d is %v on %d after a reset, %v on %d new", tt.name, fresh.ticks, j+1, r.body, r.score, s.body, s.score)
+				}
+			}
+			if !slices.EqualFunc(reused.foods, fresh.foods, func(a, b Food) bool { return a.At == b.At && a.Type == b.Type }) {
+				t.Fatalf("%s, tick %d: food differs after a reset", tt.name, fresh.ticks)
+			}
+			if len(reused.walls) != len(fresh.walls) {
+				t.Fatalf("%s, tick %d: %d walls after a reset, %d new", tt.name, fresh.ticks, len(reused.walls), len(fresh.walls))
+			}
+		}
+	}
+}
+
+// Resetting a game allocates far less than starting a new one
+func TestResetReusesMemory(t *testing.T) {
+	g := NewGame(nil, UniformSpawn{}, maxPlayers, 1)
+	fresh := testing.AllocsPerRun(20, func() {
+		NewGame(nil, UniformSpawn{}, maxPlayers, 1)
+	})
+	reset := testing.AllocsPerRun(20, func() {
+		g.Reset(nil, UniformSpawn{}, maxPlayers, 1)
+	})
+	if reset*4 > fresh {
+		t.Fatalf("a reset made %v allocations, a new game %v", reset, fresh)
+	}
+}
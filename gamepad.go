@@ -0,0 +1,126 @@
+//go:build linux
+
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+// Gamepad constants, from linux/joystick.h
+const (
+	jsEventButton = 0x01
+	jsEventAxis   = 0x02
+	jsEventInit   = 0x80       // Set on the events that report the state on opening
+	jsGetAxes     = 0x80016a11 // JSIOCGAXES, the number of axes
+	jsEventSize   = 8
+	gamepadTilt   = 16384 // How far a stick has to be pushed to turn, of 32767
+)
+
+// Buttons as the xpad driver numbers them, which most pads follow. A
+// presses Enter, so it picks menu items.
+var gamepadButtons = []Key{KeyEnter, KeyPadB, KeyPadX, KeyPadY, KeyPadL, KeyPadR, KeyPadSelect, KeyPadStart}
+
+// Gamepad reads a game controller through Linux's joystick interface. The
+// d-pad and left stick press the arrow keys and the buttons their own keys,
+// so a pad steers and picks menu items like the keyboard and its buttons
+// can be bound to actions.
+type Gamepad struct {
+	f    *os.File
+	hat  uint8    // First of the d-pad's two axes, which come last
+	held [2]Key   // Key each of the stick's and the d-pad's axis pairs is pressing, or 0
+	axes [8]int16 // Where each of the first axes is
+}
+
+// Open a gamepad: a joystick device such as /dev/input/js0, or with "auto"
+// the first one there is. With "auto" and none plugged in, there's no
+// gamepad and no error.
+func OpenGamepad(path string) (*Gamepad, error) {
+	if path == "auto" {
+		found, _ := filepath.Glob("/dev/input/js*")
+		if len(found) == 0 {
+			return nil, nil
+		}
+		path = found[0]
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	var axes uint8
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), jsGetAxes, uintptr(unsafe.Pointer(&axes))); errno != 0 {
+		f.Close()
+		return nil, errors.New(path + " isn't a joystick device")
+	}
+	p := &Gamepad{f: f, hat: 6}
+	if axes >= 4 && axes < 8 {
+		p.hat = axes - 2
+	}
+	return p, nil
+}
+
+// Run passes the gamepad's presses to events until it's unplugged, then
+// reports why on gone
+func (p *Gamepad) Run(events chan<- Event, gone chan<- error) {
+	defer p.f.Close()
+	buf := make([]byte, jsEventSize)
+	for {
+		if _, err := p.f.Read(buf); err != nil {
+			gone <- err
+			return
+		}
+		value := int16(binary.LittleEndian.Uint16(buf[4:6]))
+		kind, number := buf[6], buf[7]
+		if kind&jsEventInit != 0 {
+			if kind&jsEventAxis != 0 && int(number) < len(p.axes) {
+				p.axes[number] = value
+			}
+			continue
+		}
+		switch {
+		case kind == jsEventButton && value == 1 && int(number) < len(gamepadButtons):
+			events <- Event{Type: EventKey, Key: gamepadButtons[number]}
+		case kind == jsEventAxis && int(number) < len(p.axes):
+			p.axes[number] = value
+			if ev, ok := p.tilt(number); ok {
+				events <- ev
+			}
+		}
+	}
+}
+
+// The arrow key a stick or the d-pad presses after one of its axes moved,
+// if it now points somewhere new. A stick held over presses once, and only
+// the axis pushed further counts.
+func (p *Gamepad) tilt(axis uint8) (Event, bool) {
+	pair, first := 0, uint8(0)
+	switch axis {
+	case 0, 1:
+	case p.hat, p.hat + 1:
+		pair, first = 1, p.hat
+	default:
+		return Event{}, false
+	}
+	x, y := int(p.axes[first]), int(p.axes[first+1])
+	var key Key
+	switch {
+	case max(abs(x), abs(y)) < gamepadTilt:
+	case abs(x) >= abs(y) && x < 0:
+		key = KeyArrowLeft
+	case abs(x) >= abs(y):
+		key = KeyArrowRight
+	case y < 0:
+		key = KeyArrowUp
+	default:
+		key = KeyArrowDown
+	}
+	if key == p.held[pair] {
+		return Event{}, false
+	}
+	p.held[pair] = key
+	return Event{Type: EventKey, Key: key}, key != 0
+}
@@ -0,0 +1,19 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// Gamepad stands in for Linux's joystick reader elsewhere
+type Gamepad struct{}
+
+// Gamepads are only read on Linux. With "auto" there's simply no gamepad.
+func OpenGamepad(path string) (*Gamepad, error) {
+	if path == "auto" {
+		return nil, nil
+	}
+	return nil, errors.New("gamepads are only supported on Linux")
+}
+
+// Run never has presses to pass on
+func (p *Gamepad) Run(events chan<- Event, gone chan<- error) {}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"slices"
+	"time"
+)
+
+// Timed things, like food, power-ups, events and crumbs, count down in steps
+// of game time rather than in ticks, so they last as long however fast the
+// snakes are moving. A tick faster than a step now and then counts down
+// nothing, and a slower one sometimes counts down two steps. Durations given
+// in ticks, in the code and the config file, are steps: ticks at the normal
+// starting speed.
+const (
+	stepTime  = baseSpeed * time.Millisecond // Game time in a step
+	stepRules = 4                            // First rules version counting down in steps
+)
+
+// Steps of game time a tick of the given interval takes: how many step
+// boundaries the game clock crosses over it. The clock moves on by the same
+// interval, so the two never drift apart. Under older rules every tick was
+// a step.
+func (g *Game) tickSteps(interval time.Duration) int {
+	if g.rules < stepRules {
+		return 1
+	}
+	end := g.clock + interval
+	return int(end/stepTime) - int(g.clock/stepTime)
+}
+
+// Game time a countdown's step lasts, for showing the seconds left on it
+func (g *Game) countdownStep() time.Duration {
+	if g.rules < stepRules {
+		return g.updateInterval()
+	}
+	return stepTime
+}
+
+// Seconds left on a countdown, rounded up
+func (g *Game) secondsLeft(steps int) int {
+	left := time.Duration(steps) * g.countdownStep()
+	return int((left + time.Second - 1) / time.Second)
+}
+
+// Games keep three clocks. The game clock is active play time: the game
+// time the ticks took, which stops while paused and is what speedruns,
+// leaderboards and the stats go by. The wall clock runs from the first
+// tick to the last, pauses and all. Then there are the ticks played.
+const (
+	clockPlay  = "play"  // Active play time
+	clockWall  = "wall"  // Wall-clock time, pauses and all
+	clockTicks = "ticks" // Ticks played
+	clockOff   = "off"   // No clock
+)
+
+// Clocks the sidebar can show, in the order the settings menu steps
+// through them
+var clockModes = []string{clockPlay, clockWall, clockTicks, clockOff}
+
+// Clock the sidebar and status line show, from the config file
+var clockMode = clockPlay
+
+// Check whether a clock mode is known
+func validClock(mode string) bool {
+	return slices.Contains(clockModes, mode)
+}
+
+// Bring the wall clock up to now. It starts on the first tick, from when
+// the tick's interval began, as the game clock counts that interval too.
+func (g *Game) tickWall() {
+	now := time.Now()
+	if g.wallMark.IsZero() {
+		g.wallMark = now.Add(-g.updateInterval())
+	}
+	g.wall += now.Sub(g.wallMark)
+	g.wallMark = now
+}
+
+// Wall-clock time since play began, still running while the game is
+// paused and stopped once it's over
+func (g *Game) wallTime() time.Duration {
+	if g.wallMark.IsZero() || (g.state != StatePlaying && g.state != StatePaused) {
+		return g.wall
+	}
+	return g.wall + time.Since(g.wallMark)
+}
+
+// The clock as the sidebar shows it, e.g. "TIME: 1:05", or "" with the
+// clock off
+func (g *Game) clockLabel() string {
+	switch clockMode {
+	case clockPlay:
+		return tr("clock_play", clockText(g.clock))
+	case clockWall:
+		return tr("clock_wall", clockText(g.wallTime()))
+	case clockTicks:
+		return tr("clock_ticks", g.ticks)
+	}
+	return ""
+}
+
+// Player 1's stats for the game so far, with its three clocks
+func (g *Game) gameStats() GameStats {
+	s := g.stats
+	s.Time, s.Wall, s.Ticks = g.clock, g.wallTime(), g.ticks
+	return s
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Ghost constants
+const (
+	ghostsFileName = "ghosts.json"
+	maxGhosts      = 100 // Most runs kept; the oldest go first
+)
+
+// GhostRun is the best run recorded for one game setup
+type GhostRun struct {
+	Score  int       `json:"score"`
+	Date   time.Time `json:"date"`
+	Replay Replay    `json:"replay"`
+}
+
+// GhostRuns stores the best run for each game setup, to race against
+type GhostRuns struct {
+	Best map[string]GhostRun `json:"best"` // By ghostKey
+	path string
+}
+
+// Key for the setup a game was started with. Two games with the same key
+// play out the same given the same inputs, so a run recorded in one can be
+// replayed alongside the other.
+func ghostKey(g *Game, s Settings) string {
+	key := fmt.Sprintf("%s %d %dx%d %s %d %t %t %+v %g", g.mode, g.seed, width, height,
+		g.levelName, s.SpawnDistance, s.SpawnSpread, s.ScoreDecay, g.difficulty, aspectRatio)
+	if s.Hazards {
+		key += " hazards"
+	}
+	if s.SpawnOpen {
+		key += " spawn-open"
+	}
+	if s.SpawnBalanced {
+		key += " spawn-balanced"
+	}
+	if start := startConfig.placement(g.mode, g.levelName); start != (StartPlacement{}) {
+		key += fmt.Sprintf(" start %+v", start)
+	}
+	return key
+}
+
+// Load the ghost runs from the data directory. The runs returned are usable,
+// if empty, even when loading fails.
+func LoadGhostRuns() (*GhostRuns, error) {
+	runs := &GhostRuns{Best: make(map[string]GhostRun)}
+	dir, err := dataDir()
+	if err != nil {
+		return runs, err
+	}
+	runs.path = filepath.Join(dir, ghostsFileName)
+
+	data, err := os.ReadFile(runs.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return runs, nil
+	} else if err != nil {
+		return runs, err
+	}
+
+	if err := json.Unmarshal(data, runs); err != nil {
+		return &GhostRuns{Best: make(map[string]GhostRun), path: runs.path},
+			fmt.Errorf("parse %s: %w", runs.path, err)
+	}
+	if runs.Best == nil {
+		runs.Best = make(map[string]GhostRun)
+	}
+	return runs, nil
+}
+
+// Keep a finished game's run if it beats the best one for its setup, and
+// save
+func (gr *GhostRuns) Record(key string, g *Game) error {
+	if g.replay == nil {
+		return nil
+	}
+	score := g.Player().score
+	if best, ok := gr.Best[key]; ok && best.Score >= score {
+		return nil
+	}
+	run := GhostRun{Score: score, Date: time.Now(), Replay: *g.replay}
+	run.Replay.Ticks = g.ticks
+	gr.Best[key] = run
+
+	// Make room by dropping the oldest runs
+	for len(gr.Best) > maxGhosts {
+		oldest := key
+		for k, r := range gr.Best {
+			if r.Date.Before(gr.Best[oldest].Date) {
+				oldest = k
+			}
+		}
+		delete(gr.Best, oldest)
+	}
+	return gr.Save()
+}
+
+// Save writes the runs back to disk
+func (gr *GhostRuns) Save() error {
+	if gr.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(gr.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(gr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(gr.path, data, 0o644)
+}
+
+// Ghost is a recorded run played back in step with a live game, on a game
+// of its own set up the same way
+type Ghost struct {
+	game   *Game
+	replay *Replay
+	next   int // Index of the next input to apply
+}
+
+// Get a ghost ready to play a run back on g, which must be fresh and set up
+// like the live game, but under the rules the run was recorded under, so
+// even its first food turns up as it did
+func newGhost(g *Game, r *Replay) (*Ghost, error) {
+	rules, err := checkRules(r.Rules)
+	if err != nil {
+		return nil, err
+	}
+	if g.rules != rules {
+		return nil, fmt.Errorf("run was recorded under rules version %d, but the game is set up under %d", rules, g.rules)
+	}
+	gh := &Ghost{game: g, replay: r}
+	g.setController(0, gh)
+	g.snakes[0].direction = r.Heading
+	return gh, nil
+}
+
+// Move the ghost on a tick, along with the live game. A ghost that has
+// crashed or run out of inputs stays where it ended.
+func (gh *Ghost) step() {
+	g := gh.game
+	if g.state == StateGameOver || g.ticks >= gh.replay.Ticks {
+		return
+	}
+	// Dashes are made between ticks, so they go in before the tick they
+	// carry the snake on
+	inputs := gh.replay.Inputs
+	for gh.next < len(inputs) && inputs[gh.next].Tick <= g.ticks {
+		gh.next++
+	}
+	for i := gh.next; i < len(inputs) && inputs[i].Tick == g.ticks+1; i++ {
+		if inputs[i].Dash {
+			g.dash(0)
+		}
+	}
+	g.Update()
+}
+
+// Steer the ghost's snake the way the recorded run did on the tick being
+// played
+func (gh *Ghost) Steer(g *Game, snake int) (Direction, bool) {
+	for i := gh.next; i < len(gh.replay.Inputs) && gh.replay.Inputs[i].Tick == g.ticks; i++ {
+		if in := gh.replay.Inputs[i]; !in.Dash {
+			return in.Dir, true
+		}
+	}
+	return 0, false
+}
+
+// Draw the ghost's snake faintly under the live one
+func drawGhost(g *Game) {
+	if g.ghost == nil {
+		return
+	}
+	head := g.Player().Head()
+	s := g.ghost.game.Player()
+	for _, p := range s.body {
+		if !g.mods.hidden(head, p) {
+			setBoardCell(p, symbolSnakeBody, g.Player().color|AttrDim)
+		}
+	}
+}
@@ -2,6 +2,24 @@ module github.com/groovy-sky/go-snake/v2
 
 go 1.22.0
 
-require github.com/nsf/termbox-go v1.1.1
+require (
+	github.com/BurntSushi/toml v1.6.0
+	github.com/creack/pty v1.1.24
+	github.com/ebitengine/oto/v3 v3.1.0
+	github.com/gdamore/tcell/v2 v2.7.4
+	github.com/gliderlabs/ssh v0.3.8
+	github.com/mattn/go-runewidth v0.0.15
+	github.com/nsf/termbox-go v1.1.1
+	golang.org/x/crypto v0.31.0
+	golang.org/x/sys v0.28.0
+	golang.org/x/term v0.27.0
+)
 
-require github.com/mattn/go-runewidth v0.0.9 // indirect
+require (
+	github.com/anmitsu/go-shlex v0.0.0-20200514113438-38f4b401e2be // indirect
+	github.com/ebitengine/purego v0.5.0 // indirect
+	github.com/gdamore/encoding v1.0.0 // indirect
+	github.com/lucasb-eyer/go-colorful v1.2.0 // indirect
+	github.com/rivo/uniseg v0.4.3 // indirect
+	golang.org/x/text v0.21.0 // indirect
+)
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Streak date format, in the player's own time zone
+const streakDateFormat = "2006-01-02"
+
+// SessionGoal is a target for one sitting, e.g. beat 80 three times
+type SessionGoal struct {
+	Score int // Score to reach
+	Times int // Games that have to reach it
+}
+
+// Parse a goal written as SCORE or SCORExTIMES, e.g. "80x3"
+func parseSessionGoal(s string) (SessionGoal, error) {
+	score, times, found := strings.Cut(strings.ToLower(s), "x")
+	goal := SessionGoal{Times: 1}
+	var err error
+	if goal.Score, err = strconv.Atoi(score); err != nil || goal.Score <= 0 {
+		return goal, fmt.Errorf("goal %q: score must be a positive number", s)
+	}
+	if found {
+		if goal.Times, err = strconv.Atoi(times); err != nil || goal.Times <= 0 {
+			return goal, fmt.Errorf("goal %q: times must be a positive number", s)
+		}
+	}
+	return goal, nil
+}
+
+func (sg SessionGoal) String() string {
+	if sg.Times == 1 {
+		return fmt.Sprintf("beat %d", sg.Score)
+	}
+	return fmt.Sprintf("beat %d %d times", sg.Score, sg.Times)
+}
+
+// Session tracks the games played since go-snake started
+type Session struct {
+	Goal  *SessionGoal // Nil when no goal was set
+	Games int          // Games finished
+	Best  int          // Best single player score
+	Met   int          // Games that reached the goal
+
+	Streak   *Streak        // The profile's play streak, nil until the first game
+	Lifetime *LifetimeStats // Stats over every game, nil if they couldn't be loaded
+}
+
+// Count a finished game. Only single player games without the autopilot
+// count towards the goal.
+func (s *Session) record(g *Game) {
+	s.Games++
+	if g.Versus() || g.botAssisted || g.puzzle != nil {
+		return
+	}
+	score := g.Player().score
+	s.Best = max(s.Best, score)
+	if s.Goal != nil && score >= s.Goal.Score {
+		s.Met++
+	}
+}
+
+// Done reports whether the session goal has been reached
+func (s *Session) Done() bool {
+	return s.Goal != nil && s.Met >= s.Goal.Times
+}
+
+// Streak counts the days in a row the player has played
+type Streak struct {
+	Last    string `json:"last"`    // Last day played, streakDateFormat
+	Current int    `json:"current"` // Days in a row up to Last
+	Best    int    `json:"best"`
+	Days    int    `json:"days"` // Days played in all
+}
+
+// Record playing on a day, returning whether the streak changed
+func (s *Streak) played(now time.Time) bool {
+	today := now.Format(streakDateFormat)
+	if s.Last == today {
+		return false
+	}
+	if s.Last == now.AddDate(0, 0, -1).Format(streakDateFormat) {
+		s.Current++
+	} else {
+		s.Current = 1
+	}
+	s.Last = today
+	s.Days++
+	s.Best = max(s.Best, s.Current)
+	return true
+}
+
+// Days in the streak as of now, 0 once a day has been missed. A streak
+// carries on through today until the player gets a game in.
+func (s *Streak) Length(now time.Time) int {
+	if s == nil {
+		return 0
+	}
+	if s.Last == now.Format(streakDateFormat) || s.Last == now.AddDate(0, 0, -1).Format(streakDateFormat) {
+		return s.Current
+	}
+	return 0
+}
+
+// Days in the play streak, for the sidebar
+func (s *Session) streakDays() int {
+	if s == nil {
+		return 0
+	}
+	return s.Streak.Length(time.Now())
+}
+
+// Record a day played in the profile's streak, saving if it changed
+func (p *Profile) Played(now time.Time) error {
+	if p.Streak == nil {
+		p.Streak = &Streak{}
+	}
+	if !p.Streak.played(now) {
+		return nil
+	}
+	return p.Save()
+}
+
+// Draw the session goal's progress in the sidebar
+func drawSessionGoal(sb *Sidebar, s *Session) {
+	if s.Done() {
+		sb.Text("GOAL MET!", ColorGreen|AttrBold)
+		return
+	}
+	sb.Textf(colorText, "GOAL %d: %d/%d", s.Goal.Score, s.Met, s.Goal.Times)
+}
+
+// Draw the stats screen over the game area
+func drawStats(s *Session, streak *Streak) {
+	left := boardLeft + 3
+	title := "STATS"
+	drawCentered(2, title, ColorYellow|AttrBold)
+
+	goal := "none (set one with -goal 80x3)"
+	if s.Goal != nil {
+		goal = fmt.Sprintf("%s: %d/%d", s.Goal, min(s.Met, s.Goal.Times), s.Goal.Times)
+	}
+	if streak == nil {
+		streak = &Streak{}
+	}
+	lines := []string{
+		fmt.Sprintf("Games this session: %d", s.Games),
+		fmt.Sprintf("Best this session:  %d", s.Best),
+		"Goal: " + goal,
+		"",
+		fmt.Sprintf("Play streak: %d days", streak.Length(time.Now())),
+		fmt.Sprintf("Best streak: %d days", streak.Best),
+		fmt.Sprintf("Days played: %d", streak.Days),
+	}
+	if ls := s.Lifetime; ls != nil && ls.Games > 0 {
+		lines = append(lines, "",
+			fmt.Sprintf("All time: %d games, %s", ls.Games, clockText(ls.Totals.Time)),
+			fmt.Sprintf("Food eaten:    %d", ls.Totals.Food()),
+			fmt.Sprintf("Longest snake: %d", ls.Totals.MaxLength),
+			"More with: go-snake stats",
+		)
+	}
+	for i, line := range lines {
+		drawText(left, 4+i, line, colorText)
+	}
+
+	hint := "Press any key to go back"
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
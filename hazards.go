@@ -0,0 +1,312 @@
+package main
+
+import (
+	"slices"
+	"strings"
+)
+
+// Hazard constants
+const (
+	hazardChance    = 1.0 / 150 // Chance of a new hazard on any tick, with the hazards modifier
+	maxHazards      = 3         // Hazards on the board at once
+	hazardWarnTicks = 15        // Ticks a new hazard shows where it will be before it is deadly
+
+	rivalLife      = 200 // Ticks a rival block wanders before it goes
+	rivalStepTicks = 2   // Ticks between a rival's moves
+	rivalTurnOdds  = 5   // A rival turns off its heading one step in this many
+
+	laserLife      = 240 // Ticks a laser turns before it goes
+	laserReach     = 4   // Cells the beam reaches either side of its pivot
+	laserTurnTicks = 20  // Ticks between turns of the beam
+	laserWarnTicks = 8   // Ticks the beam's next position shows before it turns
+
+	hotZoneLife   = 60 // Ticks a hot zone lasts, its warning included
+	hotZoneRadius = 1  // Cells the zone reaches around its middle
+)
+
+// HazardKind is how one sort of hazard behaves: where it appears, how it
+// moves and which cells it makes deadly. Everything a hazard needs to
+// remember is kept in its Hazard, so hazards are plain values that save and
+// rewind with the rest of the game.
+type HazardKind interface {
+	Name() string                     // Saved with the hazard, and shown when one appears
+	Symbol() rune                     // Drawn on the cells it makes deadly
+	Place(g *Game, h *Hazard) bool    // Set up a new hazard, false if there's no room
+	Tick(g *Game, h *Hazard)          // Advance a tick
+	Cells(g *Game, h *Hazard) []Point // Cells it makes deadly
+	Next(g *Game, h *Hazard) []Point  // Cells it is about to make deadly, to warn of
+}
+
+// Every kind of hazard, in the order they are rolled
+var hazardKinds = []HazardKind{RivalBlock{}, Laser{}, HotZone{}}
+
+// Look up a hazard kind by name
+func hazardKindByName(name string) HazardKind {
+	for _, k := range hazardKinds {
+		if k.Name() == name {
+			return k
+		}
+	}
+	return nil
+}
+
+// Hazard is one hazard on the board. For its first ticks it only shows
+// where it will be, and nothing it covers is deadly.
+type Hazard struct {
+	Kind string `json:"kind"`
+	At   Point  `json:"at"`  // Where it is, or what it turns around
+	Dir  int    `json:"dir"` // Which way it heads or points
+	Age  int    `json:"age"` // Ticks since it appeared
+	Life int    `json:"life"`
+}
+
+// Has the hazard's warning run out?
+func (h *Hazard) Armed() bool {
+	return h.Age >= hazardWarnTicks
+}
+
+// Advance every hazard a tick, dropping those whose time is up, and maybe
+// start a new one
+func (g *Game) updateHazards() {
+	for i := range g.hazards {
+		h := &g.hazards[i]
+		if h.Age++; h.Age < h.Life {
+			hazardKindByName(h.Kind).Tick(g, h)
+		}
+	}
+	g.hazards = slices.DeleteFunc(g.hazards, func(h Hazard) bool { return h.Age >= h.Life })
+
+	if g.mods.Has(ModHazards) && len(g.hazards) < maxHazards && g.rng.Float64() < hazardChance {
+		kind := hazardKinds[g.rng.Intn(len(hazardKinds))]
+		h := Hazard{Kind: kind.Name()}
+		if kind.Place(g, &h) {
+			g.hazards = append(g.hazards, h)
+			g.notify(kind.Name() + "!")
+			g.logMove(nil, "%s at (%d,%d)", strings.ToLower(kind.Name()), h.At.X, h.At.Y)
+		}
+	}
+}
+
+// The cells hazards, and in royale mode the closing arena, make deadly now,
+// and those they will soon
+func (g *Game) hazardCells() (deadly, warned map[Point]bool) {
+	deadly, warned = map[Point]bool{}, map[Point]bool{}
+	for i := range g.hazards {
+		h := &g.hazards[i]
+		kind := hazardKindByName(h.Kind)
+		if !h.Armed() {
+			for _, p := range kind.Cells(g, h) {
+				warned[p] = true
+			}
+			continue
+		}
+		for _, p := range kind.Cells(g, h) {
+			deadly[p] = true
+		}
+		for _, p := range kind.Next(g, h) {
+			warned[p] = true
+		}
+	}
+	g.arenaCells(deadly, warned)
+	return deadly, warned
+}
+
+// Check whether a hazard covers or is about to cover a cell
+func (g *Game) nearHazard(p Point) bool {
+	if len(g.hazards) == 0 && g.mode != modeRoyale {
+		return false
+	}
+	deadly, warned := g.hazardCells()
+	return deadly[p] || warned[p]
+}
+
+// A random cell for a new hazard, away from snake heads, with ok true for
+// every cell it would cover
+func (g *Game) hazardCell(ok func(Point) bool) (Point, bool) {
+	deadly, warned := g.hazardCells()
+	var free []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Point{X: x, Y: y}
+			if g.walls[p] || g.isPortal(p) || g.occupied(p) || g.hasFood(p) || g.nearHead(p) || deadly[p] || warned[p] || !ok(p) {
+				continue
+			}
+			free = append(free, p)
+		}
+	}
+	if len(free) == 0 {
+		return Point{}, false
+	}
+	return free[g.rng.Intn(len(free))], true
+}
+
+// Draw hazards under the snakes: deadly cells in red, and cells about to be
+// deadly blinking, so a player can see them coming
+func drawHazards(g *Game, head Point) {
+	draw := func(cells []Point, symbol rune, fg Attribute) {
+		if g.state == StatePaused {
+			fg = ColorDarkGray
+		}
+		for _, p := range cells {
+			if !g.mods.hidden(head, p) {
+				setBoardCell(p, symbol, fg)
+			}
+		}
+	}
+	for i := range g.hazards {
+		h := &g.hazards[i]
+		kind := hazardKindByName(h.Kind)
+		if !h.Armed() {
+			draw(kind.Cells(g, h), kind.Symbol(), colorHazardWarning|AttrBlink)
+			continue
+		}
+		draw(kind.Next(g, h), kind.Symbol(), colorHazardWarning|AttrBlink)
+		draw(kind.Cells(g, h), kind.Symbol(), colorHazard|AttrBold)
+	}
+}
+
+// RivalBlock wanders the board a cell at a time. It never moves onto a
+// snake, food or a wall, so it only catches heads running into it. The cell
+// it heads for next is shown ahead of it.
+type RivalBlock struct{}
+
+func (RivalBlock) Name() string { return "RIVAL" }
+func (RivalBlock) Symbol() rune { return '◆' }
+
+func (RivalBlock) Place(g *Game, h *Hazard) bool {
+	p, ok := g.hazardCell(func(Point) bool { return true })
+	h.At, h.Dir, h.Life = p, g.rng.Intn(4), rivalLife
+	return ok
+}
+
+// Carry on ahead, now and then turning, or turn when the way ahead is shut
+func (r RivalBlock) Tick(g *Game, h *Hazard) {
+	if !h.Armed() || h.Age%rivalStepTicks != 0 {
+		return
+	}
+	dirs := []Direction{Direction(h.Dir), Direction(h.Dir).TurnLeft(), Direction(h.Dir).TurnRight()}
+	if g.rng.Intn(rivalTurnOdds) == 0 {
+		turn := 1 + g.rng.Intn(2)
+		dirs[0], dirs[turn] = dirs[turn], dirs[0]
+	}
+	dirs = append(dirs, Direction(h.Dir).Opposite())
+	for _, dir := range dirs {
+		next, ok := g.move(h.At, dir)
+		if ok && !g.walls[next] && !g.occupied(next) && !g.hasFood(next) && !g.hazardOther(h, next) {
+			h.At, h.Dir = next, int(dir)
+			return
+		}
+	}
+}
+
+func (RivalBlock) Cells(g *Game, h *Hazard) []Point {
+	return []Point{h.At}
+}
+
+func (RivalBlock) Next(g *Game, h *Hazard) []Point {
+	if next, ok := g.move(h.At, Direction(h.Dir)); ok {
+		return []Point{next}
+	}
+	return nil
+}
+
+// Check whether a hazard other than h covers a cell
+func (g *Game) hazardOther(h *Hazard, p Point) bool {
+	for i := range g.hazards {
+		other := &g.hazards[i]
+		if other != h && slices.Contains(hazardKindByName(other.Kind).Cells(g, other), p) {
+			return true
+		}
+	}
+	return false
+}
+
+// Laser is a beam through a fixed pivot that turns an eighth of a circle
+// every few seconds. Before it turns, its next position is shown.
+type Laser struct{}
+
+// Steps along each way the beam can point, from straight up clockwise
+var laserSteps = []Point{{X: 0, Y: -1}, {X: 1, Y: -1}, {X: 1, Y: 0}, {X: 1, Y: 1}}
+
+func (Laser) Name() string { return "LASER" }
+func (Laser) Symbol() rune { return '╳' }
+
+func (Laser) Place(g *Game, h *Hazard) bool {
+	p, ok := g.hazardCell(func(Point) bool { return true })
+	h.At, h.Dir, h.Life = p, g.rng.Intn(len(laserSteps)), laserLife
+	return ok
+}
+
+func (Laser) Tick(g *Game, h *Hazard) {
+	if h.Armed() && h.Age%laserTurnTicks == 0 {
+		h.Dir = (h.Dir + 1) % len(laserSteps)
+	}
+}
+
+func (Laser) Cells(g *Game, h *Hazard) []Point {
+	return laserBeam(g, h.At, h.Dir)
+}
+
+func (Laser) Next(g *Game, h *Hazard) []Point {
+	if h.Age%laserTurnTicks < laserTurnTicks-laserWarnTicks {
+		return nil
+	}
+	return laserBeam(g, h.At, (h.Dir+1)%len(laserSteps))
+}
+
+// Cells of a beam through a pivot, cut off at the edges of the board and
+// passing behind walls and portals
+func laserBeam(g *Game, pivot Point, dir int) []Point {
+	step := laserSteps[dir]
+	beam := []Point{pivot}
+	for k := 1; k <= laserReach; k++ {
+		for _, sign := range []int{1, -1} {
+			p := Point{X: pivot.X + sign*k*step.X, Y: pivot.Y + sign*k*step.Y}
+			if p.X >= 0 && p.X < width && p.Y >= 0 && p.Y < height && !g.walls[p] && !g.isPortal(p) {
+				beam = append(beam, p)
+			}
+		}
+	}
+	return beam
+}
+
+// HotZone is a patch of ground that turns deadly for a while once its
+// warning runs out, then cools off
+type HotZone struct{}
+
+func (HotZone) Name() string { return "HOT ZONE" }
+func (HotZone) Symbol() rune { return '▒' }
+
+// The patch is placed where it covers no snake or food, so only heads
+// moving in are caught
+func (z HotZone) Place(g *Game, h *Hazard) bool {
+	p, ok := g.hazardCell(func(p Point) bool {
+		for _, c := range z.Cells(g, &Hazard{At: p}) {
+			if g.occupied(c) || g.hasFood(c) {
+				return false
+			}
+		}
+		return true
+	})
+	h.At, h.Life = p, hotZoneLife
+	return ok
+}
+
+func (HotZone) Tick(g *Game, h *Hazard) {}
+
+func (HotZone) Cells(g *Game, h *Hazard) []Point {
+	var cells []Point
+	for dy := -hotZoneRadius; dy <= hotZoneRadius; dy++ {
+		for dx := -hotZoneRadius; dx <= hotZoneRadius; dx++ {
+			p := Point{X: h.At.X + dx, Y: h.At.Y + dy}
+			if p.X >= 0 && p.X < width && p.Y >= 0 && p.Y < height && !g.walls[p] && !g.isPortal(p) {
+				cells = append(cells, p)
+			}
+		}
+	}
+	return cells
+}
+
+func (HotZone) Next(g *Game, h *Hazard) []Point {
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Hot-seat limits
+const (
+	minHotseatPlayers = 2
+	maxHotseatPlayers = 8
+)
+
+// Tournament passes one keyboard round a group of players, each playing a
+// game on the same seed in turn, and ranks them once everyone has played
+type Tournament struct {
+	Players []string
+	Seed    int64 // This round's seed, the same for every turn
+	Round   int   // Rounds played before this one
+	Scores  []int // Score of each player who has played this round, in turn
+}
+
+// Set a tournament up from a comma-separated list of names
+func NewTournament(names string, seed int64) (*Tournament, error) {
+	t := &Tournament{Seed: seed}
+	for _, name := range strings.Split(names, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, errors.New("player names can't be empty")
+		}
+		t.Players = append(t.Players, name)
+	}
+	if len(t.Players) < minHotseatPlayers || len(t.Players) > maxHotseatPlayers {
+		return nil, fmt.Errorf("between %d and %d players can take part, got %d", minHotseatPlayers, maxHotseatPlayers, len(t.Players))
+	}
+	return t, nil
+}
+
+// Done reports whether everyone has played this round
+func (t *Tournament) Done() bool {
+	return len(t.Scores) == len(t.Players)
+}
+
+// Start another round on a new seed once everyone has played this one
+func (t *Tournament) NextRound(seed int64) {
+	t.Round++
+	t.Scores, t.Seed = nil, seed
+}
+
+// A player's place in the round's ranking
+type hotseatRank struct {
+	Place int // From 1; players on the same score share a place
+	Name  string
+	Score int
+}
+
+// Ranking returns the players who have played this round, best first
+func (t *Tournament) Ranking() []hotseatRank {
+	ranks := make([]hotseatRank, len(t.Scores))
+	for i, score := range t.Scores {
+		ranks[i] = hotseatRank{Name: t.Players[i], Score: score}
+	}
+	sort.SliceStable(ranks, func(i, j int) bool { return ranks[i].Score > ranks[j].Score })
+	for i := range ranks {
+		ranks[i].Place = i + 1
+		if i > 0 && ranks[i].Score == ranks[i-1].Score {
+			ranks[i].Place = ranks[i-1].Place
+		}
+	}
+	return ranks
+}
+
+// HotseatTurn is one player's game in a tournament
+type HotseatTurn struct {
+	*Tournament
+	Player int
+}
+
+// Name of the player whose game it is
+func (h *HotseatTurn) Name() string {
+	return h.Players[h.Player]
+}
+
+// Set a fresh game up as the next player's turn, waiting for them to take
+// the keyboard
+func (g *Game) startTurn(t *Tournament) {
+	g.hotseat = &HotseatTurn{Tournament: t, Player: len(t.Scores)}
+	g.state = StatePaused
+	g.showIntro = true
+}
+
+// Keep the score of a finished turn
+func (g *Game) recordTurn() {
+	h := g.hotseat
+	if h == nil || len(h.Scores) != h.Player {
+		return
+	}
+	h.Scores = append(h.Scores, g.Player().score)
+	g.logMove(nil, "%s scored %d", h.Name(), g.Player().score)
+}
+
+// Draw the handover before a turn. The board is blanked, so nothing of the
+// last player's game shows while the keyboard changes hands.
+func drawHotseatIntro(g *Game) {
+	area := boardArea()
+	for y := area.Y; y < area.Y+area.H; y++ {
+		for x := area.X; x < area.X+area.W; x++ {
+			screen.SetCell(x, y, ' ', ColorDefault, ColorDefault)
+		}
+	}
+	h := g.hotseat
+	lines := []Line{
+		{fmt.Sprintf("PASS TO %s", strings.ToUpper(h.Name())), colorScore | AttrBold},
+		{fmt.Sprintf("Player %d of %d, round %d", h.Player+1, len(h.Players), h.Round+1), colorText},
+		{},
+	}
+	if h.Player > 0 {
+		best := h.Ranking()[0]
+		lines = append(lines, Line{fmt.Sprintf("Score to beat: %d by %s", best.Score, best.Name), ColorCyan | AttrBold})
+	}
+	lines = append(lines, Line{fmt.Sprintf("Press %s to start", keysHint(ActionPause)), colorText})
+	drawPanel(lines)
+}
+
+// Draw how a turn went, and once everyone has played the ranking
+func drawHotseatResult(g *Game) {
+	h := g.hotseat
+	lines := []Line{
+		{"GAME OVER", ColorRed},
+		{fmt.Sprintf("%s scored %d", h.Name(), g.Player().score), colorScore | AttrBold},
+		{},
+	}
+	if !h.Done() {
+		lines = append(lines, Line{fmt.Sprintf("Enter to pass to %s", h.Players[len(h.Scores)]), colorText})
+		drawPanel(lines)
+		return
+	}
+	lines = append(lines, Line{fmt.Sprintf("ROUND %d RESULTS", h.Round+1), colorScore | AttrBold})
+	for _, r := range h.Ranking() {
+		fg := colorText
+		if r.Place == 1 {
+			fg = ColorGreen | AttrBold
+		}
+		lines = append(lines, Line{fmt.Sprintf("%d. %-12s %5d", r.Place, r.Name, r.Score), fg})
+	}
+	lines = append(lines, Line{}, Line{fmt.Sprintf("Enter for another round, %s to quit", keyHint(ActionQuit)), colorText})
+	drawPanel(lines)
+}
+
+// Draw whose turn it is in the sidebar
+func drawHotseatSidebar(sb *Sidebar, g *Game) {
+	h := g.hotseat
+	sb.Textf(colorScore|AttrBold, "%s", strings.ToUpper(h.Name()))
+	sb.Textf(colorText, "TURN %d/%d", h.Player+1, len(h.Players))
+}
+
+// Summary is the round's ranking as plain text, to print once the terminal
+// is back, or empty if not everyone has played it
+func (t *Tournament) Summary() string {
+	if !t.Done() {
+		return ""
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "Round %d, seed %d:\n", t.Round+1, t.Seed)
+	for _, r := range t.Ranking() {
+		fmt.Fprintf(&b, "%2d. %-16s %d\n", r.Place, r.Name, r.Score)
+	}
+	return b.String()
+}
@@ -0,0 +1,379 @@
+package main
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Action is something a key press can ask the game to do
+type Action int
+
+const (
+	ActionNone Action = iota
+	ActionUp
+	ActionRight
+	ActionDown
+	ActionLeft
+	ActionP2Up // Player 2 movement, only used in versus mode
+	ActionP2Right
+	ActionP2Down
+	ActionP2Left
+	ActionPause
+	ActionQuit
+	ActionRestart
+	ActionScores
+	ActionSettings
+	ActionReplay
+	ActionAutopilot
+	ActionUndo
+	ActionMenu
+	ActionSave
+	ActionCard
+	ActionGlobal
+	ActionMute
+	ActionLayout
+	ActionStats
+	ActionRewind
+	ActionScreenshot
+	ActionDecline // Refuse the other player's pause request in versus
+)
+
+// Action names as used in the [keys] section of the config file
+var actionNames = map[string]Action{
+	"up":         ActionUp,
+	"right":      ActionRight,
+	"down":       ActionDown,
+	"left":       ActionLeft,
+	"p2_up":      ActionP2Up,
+	"p2_right":   ActionP2Right,
+	"p2_down":    ActionP2Down,
+	"p2_left":    ActionP2Left,
+	"pause":      ActionPause,
+	"quit":       ActionQuit,
+	"restart":    ActionRestart,
+	"scores":     ActionScores,
+	"settings":   ActionSettings,
+	"replay":     ActionReplay,
+	"autopilot":  ActionAutopilot,
+	"undo":       ActionUndo,
+	"menu":       ActionMenu,
+	"save":       ActionSave,
+	"card":       ActionCard,
+	"global":     ActionGlobal,
+	"mute":       ActionMute,
+	"layout":     ActionLayout,
+	"stats":      ActionStats,
+	"rewind":     ActionRewind,
+	"screenshot": ActionScreenshot,
+	"decline":    ActionDecline,
+}
+
+// Default bindings: arrows, WASD and vim-style hjkl all steer player 1
+var defaultKeys = map[string][]string{
+	"up":         {"up", "w", "k"},
+	"right":      {"right", "d", "l"},
+	"down":       {"down", "s", "j"},
+	"left":       {"left", "a", "h"},
+	"p2_up":      {"w"},
+	"p2_right":   {"d"},
+	"p2_down":    {"s"},
+	"p2_left":    {"a"},
+	"pause":      {"p", "space", "pad_start"},
+	"quit":       {"q", "esc"},
+	"restart":    {"r", "pad_select"},
+	"scores":     {"h"},
+	"settings":   {"s"},
+	"replay":     {"v"},
+	"autopilot":  {"b"},
+	"undo":       {"u", "backspace"},
+	"menu":       {"m"},
+	"save":       {"x"},
+	"card":       {"c"},
+	"global":     {"g"},
+	"mute":       {"m"}, // Shares m with the menu, which only opens while paused or after a game
+	"layout":     {"tab"},
+	"stats":      {"i"},
+	"rewind":     {"b"}, // Shares b with the autopilot, which only works while playing
+	"screenshot": {"f12"},
+	"decline":    {"n"},
+}
+
+// Key layouts, by name: bindings laid over the defaults, picked with
+// key_layout in the config file before its own [keys]. The numpad layout
+// steers with 8, 4, 6 and 2, pauses with 5 and restarts with 0, for one
+// handed play and the keypads of accessibility keyboards. With NumLock off
+// the keypad sends arrows, a center key and insert instead, so those are
+// bound too.
+var keyLayouts = map[string]map[string][]string{
+	keyLayoutStandard: nil,
+	"numpad": {
+		"up":      {"8", "up", "w", "k"},
+		"right":   {"6", "right", "d", "l"},
+		"down":    {"2", "down", "s", "j"},
+		"left":    {"4", "left", "a", "h"},
+		"pause":   {"5", "center", "p", "space", "pad_start"},
+		"restart": {"0", "insert", "r", "pad_select"},
+	},
+}
+
+// Key layout with the default bindings alone
+const keyLayoutStandard = "standard"
+
+// Bindings the config file's [keys] are laid over: the defaults, with the
+// key layout in use on top
+var baseKeys = defaultKeys
+
+// Lay the bindings in use over a key layout
+func useKeyLayout(name string) {
+	baseKeys = maps.Clone(defaultKeys)
+	maps.Copy(baseKeys, keyLayouts[name])
+}
+
+// Names of the key layouts, the standard one first, then sorted
+func keyLayoutNames() []string {
+	names := []string{keyLayoutStandard}
+	for name := range keyLayouts {
+		if name != keyLayoutStandard {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names[1:])
+	return names
+}
+
+// Names for keys that aren't a single printable character
+var specialKeys = map[string]Key{
+	"up":         KeyArrowUp,
+	"right":      KeyArrowRight,
+	"down":       KeyArrowDown,
+	"left":       KeyArrowLeft,
+	"space":      KeySpace,
+	"enter":      KeyEnter,
+	"esc":        KeyEsc,
+	"tab":        KeyTab,
+	"backspace":  KeyBackspace2,
+	"home":       KeyHome,
+	"end":        KeyEnd,
+	"pgup":       KeyPgup,
+	"pgdn":       KeyPgdn,
+	"insert":     KeyInsert,
+	"delete":     KeyDelete,
+	"f1":         KeyF1,
+	"f2":         KeyF2,
+	"f3":         KeyF3,
+	"f4":         KeyF4,
+	"f5":         KeyF5,
+	"f6":         KeyF6,
+	"f7":         KeyF7,
+	"f8":         KeyF8,
+	"f9":         KeyF9,
+	"f10":        KeyF10,
+	"f11":        KeyF11,
+	"f12":        KeyF12,
+	"pad_b":      KeyPadB,
+	"pad_x":      KeyPadX,
+	"pad_y":      KeyPadY,
+	"pad_l":      KeyPadL,
+	"pad_r":      KeyPadR,
+	"pad_select": KeyPadSelect,
+	"pad_start":  KeyPadStart,
+	"center":     KeyCenter,
+}
+
+// A key as the renderer reports it: either a special key or a character
+type keyPress struct {
+	key Key
+	ch  rune
+}
+
+// KeyBindings maps key presses to actions. One key may carry several
+// actions, e.g. 'w' steers player 1 alone but player 2 in versus mode.
+type KeyBindings struct {
+	keys  map[keyPress][]Action
+	names map[Action][]string // Keys bound to each action, as the config names them
+}
+
+// The player's key bindings, for naming keys in on-screen hints
+var activeKeys *KeyBindings
+
+// Build key bindings from action name -> key names, starting from the
+// defaults and key layout. Actions listed in cfg replace their keys there
+// entirely.
+func NewKeyBindings(cfg map[string][]string) (*KeyBindings, error) {
+	merged := make(map[string][]string, len(baseKeys))
+	for name, keys := range baseKeys {
+		merged[name] = keys
+	}
+	for name, keys := range cfg {
+		if _, ok := actionNames[name]; !ok {
+			return nil, fmt.Errorf("keys: unknown action %q (want one of %s)", name, strings.Join(sortedActionNames(), ", "))
+		}
+		merged[name] = keys
+	}
+
+	kb := &KeyBindings{keys: make(map[keyPress][]Action), names: make(map[Action][]string)}
+	for name, keys := range merged {
+		action := actionNames[name]
+		kb.names[action] = keys
+		for _, key := range keys {
+			kp, err := parseKey(key)
+			if err != nil {
+				return nil, fmt.Errorf("keys.%s: %w", name, err)
+			}
+			kb.keys[kp] = append(kb.keys[kp], action)
+		}
+	}
+	return kb, nil
+}
+
+// Parse a key name from the config file
+func parseKey(name string) (keyPress, error) {
+	if key, ok := specialKeys[strings.ToLower(name)]; ok {
+		return keyPress{key: key}, nil
+	}
+	if utf8.RuneCountInString(name) == 1 {
+		ch, _ := utf8.DecodeRuneInString(name)
+		return keyPress{ch: ch}, nil
+	}
+	return keyPress{}, fmt.Errorf("unknown key %q (use a single character or a name like up, space, esc, f1)", name)
+}
+
+// Move returns which snake a key event steers and where. In versus mode
+// player 2's movement keys take precedence over player 1's.
+func (kb *KeyBindings) Move(ev Event, versus bool) (player int, dir Direction, ok bool) {
+	for _, action := range kb.keys[eventKey(ev)] {
+		d, isMove := action.Direction()
+		if !isMove {
+			continue
+		}
+		if action.Player() == 1 {
+			if versus {
+				return 1, d, true
+			}
+			continue
+		}
+		player, dir, ok = 0, d, true
+	}
+	return player, dir, ok
+}
+
+// Has reports whether a key event is bound to an action
+func (kb *KeyBindings) Has(ev Event, action Action) bool {
+	if ev.Type != EventKey {
+		return false
+	}
+	for _, a := range kb.keys[eventKey(ev)] {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
+
+// Name the first key bound to an action for an on-screen hint, e.g. 'h'
+func keyHint(action Action) string {
+	return hintKeys(action, 1)
+}
+
+// Name up to two keys bound to an action for an on-screen hint, e.g.
+// 'p' or space
+func keysHint(action Action) string {
+	return hintKeys(action, 2)
+}
+
+// Name at most the given number of keys bound to an action, quoting
+// characters and leaving out the gamepad's buttons
+func hintKeys(action Action, most int) string {
+	kb := activeKeys
+	if kb == nil {
+		kb, _ = NewKeyBindings(nil)
+	}
+	var shown []string
+	for _, name := range kb.names[action] {
+		if strings.HasPrefix(name, "pad_") {
+			continue
+		}
+		if utf8.RuneCountInString(name) == 1 {
+			name = "'" + name + "'"
+		}
+		if shown = append(shown, name); len(shown) == most {
+			break
+		}
+	}
+	if len(shown) == 0 {
+		return "(unbound)"
+	}
+	return strings.Join(shown, " or ")
+}
+
+// Direction returns the heading a movement action asks for
+func (a Action) Direction() (Direction, bool) {
+	switch a {
+	case ActionUp, ActionP2Up:
+		return Up, true
+	case ActionRight, ActionP2Right:
+		return Right, true
+	case ActionDown, ActionP2Down:
+		return Down, true
+	case ActionLeft, ActionP2Left:
+		return Left, true
+	}
+	return Up, false
+}
+
+// Player returns which snake a movement action steers (0 or 1)
+func (a Action) Player() int {
+	if a >= ActionP2Up && a <= ActionP2Left {
+		return 1
+	}
+	return 0
+}
+
+// Key an event refers to
+func eventKey(ev Event) keyPress {
+	if ev.Ch != 0 {
+		return keyPress{ch: ev.Ch}
+	}
+	return keyPress{key: ev.Key}
+}
+
+// Name of the key an event is, as the config file writes it
+func keyName(ev Event) (string, bool) {
+	if ev.Ch != 0 {
+		return string(ev.Ch), true
+	}
+	for name, key := range specialKeys {
+		if key == ev.Key {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Keys bound to an action by name, from the config or else the defaults and
+// key layout
+func boundKeys(cfg map[string][]string, name string) []string {
+	if keys, ok := cfg[name]; ok {
+		return keys
+	}
+	return baseKeys[name]
+}
+
+// Action names in the order the actions are declared
+func orderedActionNames() []string {
+	names := sortedActionNames()
+	sort.SliceStable(names, func(i, j int) bool { return actionNames[names[i]] < actionNames[names[j]] })
+	return names
+}
+
+func sortedActionNames() []string {
+	names := make([]string, 0, len(actionNames))
+	for name := range actionNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Language used when neither the config file nor the environment picks one
+const defaultLang = "en"
+
+// Messages for the chosen language, and the English ones to fall back on
+var (
+	messages        map[string]string
+	englishMessages = loadFallbackCatalog()
+)
+
+// Load a message catalog from the lang assets
+func loadCatalog(lang string) (map[string]string, error) {
+	catalog := make(map[string]string)
+	if err := decodeAsset("language", "lang", lang, &catalog); err != nil {
+		return nil, err
+	}
+	return catalog, nil
+}
+
+func loadFallbackCatalog() map[string]string {
+	catalog, err := loadCatalog(defaultLang)
+	if err != nil {
+		assetError(err)
+	}
+	return catalog
+}
+
+// Language from the environment, e.g. "de" for LANG=de_DE.UTF-8, if there
+// is a catalog for it
+func envLang() string {
+	for _, v := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		lang, _, _ := strings.Cut(os.Getenv(v), "_")
+		lang, _, _ = strings.Cut(lang, ".")
+		if lang == "" {
+			continue
+		}
+		for _, name := range assetNames("lang") {
+			if name == lang {
+				return lang
+			}
+		}
+		break
+	}
+	return defaultLang
+}
+
+// Switch the game's messages to a language
+func setLang(lang string) error {
+	catalog, err := loadCatalog(lang)
+	if err != nil {
+		return err
+	}
+	messages = catalog
+	return nil
+}
+
+// Look up a message in the current language, filling in any arguments. A
+// message missing from the catalog falls back to English.
+func tr(key string, args ...any) string {
+	format, ok := messages[key]
+	if !ok {
+		format, ok = englishMessages[key]
+	}
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
@@ -0,0 +1,95 @@
+package main
+
+import "fmt"
+
+// The screen is laid out one of two ways: the sidebar beside the board, or
+// on a terminal too narrow for both, the board alone with a status line
+// under it. The layout follows the terminal's width until the layout key
+// picks one by hand.
+var (
+	compactLayout bool           // Is the sidebar folded into a status line?
+	layoutPinned  bool           // Was the layout picked by hand?
+	boardLeft     = sidebarWidth // Column of the board's left border
+)
+
+// Sidebar settings, from the config file or the layout key's last choice
+const (
+	sidebarAuto = "auto" // Follow the terminal's width
+	sidebarShow = "show"
+	sidebarHide = "hide"
+)
+
+// Check whether a sidebar setting is known
+func validSidebar(mode string) bool {
+	return mode == sidebarAuto || mode == sidebarShow || mode == sidebarHide
+}
+
+// Columns the board and sidebar need side by side
+func fullLayoutWidth() int {
+	return sidebarWidth + width + 2
+}
+
+// Pick the layout that fits a terminal of cols by rows, unless one was
+// picked by hand, and fit the view of the board to it
+func fitLayout(cols, rows int) {
+	screenCols, screenRows = cols, rows
+	if !layoutPinned {
+		setLayout(cols < fullLayoutWidth())
+	}
+	fitView()
+}
+
+// Show or hide the sidebar for good, or with auto let it follow the
+// terminal
+func setSidebar(mode string) {
+	layoutPinned = mode == sidebarShow || mode == sidebarHide
+	if layoutPinned {
+		setLayout(mode == sidebarHide)
+	}
+}
+
+// Switch layouts from the layout key. The choice sticks however the
+// terminal is resized. Returns the sidebar setting it amounts to, for
+// remembering.
+func toggleLayout() string {
+	layoutPinned = true
+	setLayout(!compactLayout)
+	if compactLayout {
+		return sidebarHide
+	}
+	return sidebarShow
+}
+
+func setLayout(compact bool) {
+	compactLayout = compact
+	boardLeft = sidebarWidth
+	if compact {
+		boardLeft = 0
+	}
+	fitView()
+}
+
+// Draw the status line that stands in for the sidebar: the score, the
+// snake's length and the clock, cut off at the board's width
+func drawStatusLine(g *Game) {
+	var line string
+	switch {
+	case g.puzzle != nil:
+		line = fmt.Sprintf("MOVES: %d  PAR: %d", g.puzzle.Moves, g.puzzle.Puzzle.Par)
+	case g.campaign != nil:
+		s, st := g.Player(), g.campaign.stage()
+		line = fmt.Sprintf("STAGE %d/%d  %s/%d", g.campaign.Stage+1, len(campaignStages), tr("score", s.score), st.Score)
+		if st.Length > 0 {
+			line = fmt.Sprintf("STAGE %d/%d  %s  LEN: %d/%d", g.campaign.Stage+1, len(campaignStages), tr("score", s.score), len(s.body), st.Length)
+		}
+	case g.Versus():
+		for _, s := range g.snakes {
+			line += fmt.Sprintf("%s: %d  ", s.name, s.score)
+		}
+		line += g.clockLabel()
+	default:
+		s := g.Player()
+		line = fmt.Sprintf("%s  LEN: %d  %s", tr("score", s.score), len(s.body), g.clockLabel())
+	}
+	drawTextIn(boardLeft+1, viewHeight+2, viewWidth, line, colorScore|AttrBold)
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Online leaderboard constants
+const (
+	leaderboardTimeout = 5 * time.Second // Longest wait for the leaderboard server
+	globalTopScores    = 10              // Entries fetched from the leaderboard
+)
+
+// GlobalScore is one score on an online leaderboard. The seed and rules
+// version let a server replay or spot-check a submission.
+type GlobalScore struct {
+	Name      string        `json:"name"`
+	Score     int           `json:"score"`
+	Seed      int64         `json:"seed"`
+	Rules     int           `json:"rules"`
+	Mode      string        `json:"mode"`
+	Challenge string        `json:"challenge,omitempty"` // Week or day of a challenge, e.g. 2026-10-17
+	Width     int           `json:"width"`
+	Height    int           `json:"height"`
+	Time      time.Duration `json:"time,omitempty"` // Active play time, breaking ties between equal scores
+	Date      time.Time     `json:"date"`
+}
+
+// Leaderboard is a backend that keeps scores from many players. Boards are
+// kept separately for each mode and board size, and each challenge.
+type Leaderboard interface {
+	Submit(ctx context.Context, score GlobalScore) error
+	Top(ctx context.Context, mode, challenge string, width, height int) ([]GlobalScore, error)
+}
+
+// httpLeaderboard talks to a server like cmd/snake-leaderboard: scores are
+// POSTed as JSON to /scores, and GET /scores?mode=&width=&height= returns
+// the best of them, best first, with &challenge= for a challenge's.
+type httpLeaderboard struct {
+	url    string
+	client *http.Client
+}
+
+// Connect to the leaderboard at a URL, or none when it is empty
+func newLeaderboard(rawURL string) (Leaderboard, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("leaderboard URL %q must be an http or https address", rawURL)
+	}
+	return &httpLeaderboard{
+		url:    strings.TrimSuffix(rawURL, "/") + "/scores",
+		client: &http.Client{Timeout: leaderboardTimeout},
+	}, nil
+}
+
+func (lb *httpLeaderboard) Submit(ctx context.Context, score GlobalScore) error {
+	body, err := json.Marshal(score)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, lb.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := lb.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("submit score: %s", resp.Status)
+	}
+	return nil
+}
+
+func (lb *httpLeaderboard) Top(ctx context.Context, mode, challenge string, width, height int) ([]GlobalScore, error) {
+	query := url.Values{
+		"mode":   {mode},
+		"width":  {strconv.Itoa(width)},
+		"height": {strconv.Itoa(height)},
+	}
+	if challenge != "" {
+		query.Set("challenge", challenge)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lb.url+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := lb.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch scores: %s", resp.Status)
+	}
+	var scores []GlobalScore
+	if err := json.NewDecoder(resp.Body).Decode(&scores); err != nil {
+		return nil, fmt.Errorf("fetch scores: %w", err)
+	}
+	return scores[:min(len(scores), globalTopScores)], nil
+}
+
+// GlobalScores is what the game knows of the online leaderboard for the
+// game just played
+type GlobalScores struct {
+	Loading bool
+	Entries []GlobalScore
+	Err     error
+	Mine    GlobalScore // The score submitted from this game, for highlighting
+}
+
+// The score to send to the online leaderboard for a finished game, if it
+// counts: like the local leaderboard, versus and autopilot games don't, and
+// nor do practice runs at the daily challenge
+func (g *Game) globalScore(name string) (GlobalScore, bool) {
+	score := GlobalScore{
+		Name:      name,
+		Score:     g.Player().score,
+		Seed:      g.seed,
+		Rules:     g.rules,
+		Mode:      g.mode,
+		Challenge: g.challenge,
+		Width:     width,
+		Height:    height,
+		Time:      g.clock,
+		Date:      time.Now().UTC(),
+	}
+	practice := g.daily != nil && g.daily.Practice
+	return score, score.Score > 0 && !g.Versus() && !g.botAssisted && g.puzzle == nil && !practice
+}
+
+// Submit a finished game's score, then fetch the leaderboard it went on.
+// Runs in the background; the result arrives on done.
+func submitGlobalScore(lb Leaderboard, score GlobalScore, done chan<- *GlobalScores) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*leaderboardTimeout)
+		defer cancel()
+		gs := &GlobalScores{Mine: score}
+		if gs.Err = lb.Submit(ctx, score); gs.Err == nil {
+			gs.Entries, gs.Err = lb.Top(ctx, score.Mode, score.Challenge, score.Width, score.Height)
+		}
+		done <- gs
+	}()
+}
+
+// Draw the online leaderboard over the game area
+func drawGlobalScores(gs *GlobalScores, mode string) {
+	left := boardLeft + 2
+	top := 2
+
+	if gs.Mine.Challenge != "" {
+		mode += " " + gs.Mine.Challenge
+	}
+	title := fmt.Sprintf("GLOBAL SCORES (%s, %dx%d)", mode, width, height)
+	drawCentered(top, title, ColorYellow|AttrBold)
+
+	switch {
+	case gs.Loading:
+		drawText(left, top+2, "Sending your score...", ColorWhite)
+	case gs.Err != nil:
+		drawText(left, top+2, "Couldn't reach the leaderboard:", colorFood)
+		msg := []rune(gs.Err.Error())
+		drawText(left, top+3, string(msg[:min(len(msg), viewWidth-2)]), ColorWhite)
+	case len(gs.Entries) == 0:
+		drawText(left, top+2, "No scores yet", ColorWhite)
+	}
+
+	for i, e := range gs.Entries {
+		fg := ColorWhite
+		if e.Name == gs.Mine.Name && e.Score == gs.Mine.Score && e.Seed == gs.Mine.Seed {
+			fg = ColorGreen | AttrBold
+		}
+		line := fmt.Sprintf("%2d. %-10.10s %5d %s", i+1, e.Name, e.Score, e.Date.Format("2006-01-02"))
+		drawText(left, top+2+i, line, fg)
+	}
+
+	hint := fmt.Sprintf("Press %s to go back", keyHint(ActionGlobal))
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
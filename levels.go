@@ -0,0 +1,212 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Level map symbols
+const (
+	levelEmpty = '.'
+	levelWall  = '#'
+	levelFood  = '*' // Fixed food, only used by puzzles
+)
+
+// Snake spawn symbols and the heading each one starts with
+var levelSpawns = map[rune]Direction{
+	'^': Up,
+	'>': Right,
+	'v': Down,
+	'<': Left,
+	'S': Right,
+}
+
+// SpawnPoint is where a snake's head starts and which way it faces
+type SpawnPoint struct {
+	At      Point
+	Heading Direction
+}
+
+// Level is a playfield layout with internal walls and spawn points. The
+// first spawn is player 1's; an optional second one is used in versus mode.
+type Level struct {
+	Name    string
+	Walls   map[Point]bool
+	Spawns  []SpawnPoint
+	Food    []Point  // Fixed food cells, for puzzles
+	Portals []Portal // Linked pairs of cells
+}
+
+// Built-in levels and the user's own by name, and their names from easiest
+// to hardest. Maps are drawn with '#' for walls, '.' for open cells and one
+// of ^ > v < S for the snake's head and starting heading. A second head
+// marks player 2's start in versus mode. A digit marks one end of a portal,
+// and the same digit its other end.
+var builtinLevels, builtinLevelOrder = loadBuiltinLevels()
+
+func loadBuiltinLevels() (map[string]string, []string) {
+	levels := make(map[string]string)
+	var order []string
+	for _, f := range contentFiles("levels") {
+		levels[f.Name] = f.Text
+		order = append(order, f.Name)
+	}
+	return levels, order
+}
+
+// Names of the built-in levels, easiest first
+func builtinLevelNames() []string {
+	return builtinLevelOrder
+}
+
+// Load a built-in level by name, or a level map from a file path
+func LoadLevel(nameOrPath string) (*Level, error) {
+	if src, ok := builtinLevels[nameOrPath]; ok {
+		return ParseLevel(nameOrPath, strings.NewReader(src))
+	}
+
+	f, err := os.Open(nameOrPath)
+	if err != nil {
+		return nil, fmt.Errorf("level %q is not built in (%s) and can't be read: %w",
+			nameOrPath, strings.Join(builtinLevelNames(), ", "), err)
+	}
+	defer f.Close()
+	return ParseLevel(nameOrPath, f)
+}
+
+// Parse an ASCII level map. Rows shorter than the board are padded with
+// open cells; maps larger than the board are rejected.
+func ParseLevel(name string, r io.Reader) (*Level, error) {
+	level := &Level{
+		Name:  name,
+		Walls: make(map[Point]bool),
+	}
+
+	portalEnds := make(map[rune][]Point)
+	scanner := bufio.NewScanner(r)
+	y := 0
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.HasPrefix(line, ";") {
+			continue // Comment
+		}
+		if y >= height {
+			if strings.TrimSpace(line) == "" {
+				continue
+			}
+			return nil, fmt.Errorf("%s: more than %d rows", name, height)
+		}
+
+		x := 0
+		for _, ch := range line {
+			if x >= width {
+				return nil, fmt.Errorf("%s:%d: row is wider than %d cells", name, y+1, width)
+			}
+			switch {
+			case ch == levelWall:
+				level.Walls[Point{X: x, Y: y}] = true
+			case ch == levelFood:
+				level.Food = append(level.Food, Point{X: x, Y: y})
+			case ch >= '0' && ch <= '9':
+				portalEnds[ch] = append(portalEnds[ch], Point{X: x, Y: y})
+			case ch == levelEmpty || ch == ' ':
+			default:
+				heading, ok := levelSpawns[ch]
+				if !ok {
+					return nil, fmt.Errorf("%s:%d:%d: unknown map symbol %q", name, y+1, x+1, ch)
+				}
+				if len(level.Spawns) == maxPlayers {
+					return nil, fmt.Errorf("%s:%d:%d: more than %d spawn points", name, y+1, x+1, maxPlayers)
+				}
+				level.Spawns = append(level.Spawns, SpawnPoint{At: Point{X: x, Y: y}, Heading: heading})
+			}
+			x++
+		}
+		y++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	// Each portal digit must mark exactly two cells
+	for ch := '0'; ch <= '9'; ch++ {
+		switch ends := portalEnds[ch]; len(ends) {
+		case 0:
+		case 2:
+			level.Portals = append(level.Portals, Portal{A: ends[0], B: ends[1]})
+		default:
+			return nil, fmt.Errorf("%s: portal %c needs exactly two cells, not %d", name, ch, len(ends))
+		}
+	}
+
+	if len(level.Spawns) == 0 {
+		return nil, fmt.Errorf("%s: no spawn point (one of ^ > v < S)", name)
+	}
+	if err := level.CheckSpawns(1); err != nil {
+		return nil, err
+	}
+	return level, nil
+}
+
+// CheckSpawns makes sure snakes placed for the given number of players
+// start neither inside a wall nor on top of each other
+func (l *Level) CheckSpawns(players int) error {
+	return l.checkSpawns(spawnPoints(l, players), initialSize)
+}
+
+// Check snakes of a size placed at the spawn points start neither inside a
+// wall nor on a portal nor on top of each other
+func (l *Level) checkSpawns(spawns []SpawnPoint, size int) error {
+	taken := make(map[Point]bool)
+	for _, sp := range spawns {
+		for _, p := range sp.snakeStart(nil, size) {
+			if l.Walls[p] {
+				return fmt.Errorf("%s: snake spawns inside a wall at %d,%d", l.Name, p.X+1, p.Y+1)
+			}
+			if l.portal(p) {
+				return fmt.Errorf("%s: snake spawns on a portal at %d,%d", l.Name, p.X+1, p.Y+1)
+			}
+			if taken[p] {
+				return fmt.Errorf("%s: snakes overlap at %d,%d", l.Name, p.X+1, p.Y+1)
+			}
+			taken[p] = true
+		}
+	}
+	return nil
+}
+
+// Check whether either end of one of the level's portals is at p
+func (l *Level) portal(p Point) bool {
+	for _, pt := range l.Portals {
+		if pt.A == p || pt.B == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Cells of a snake of the given size placed at the spawn point, head first,
+// with the body trailing behind the heading. They're laid out in body's
+// array when it has room, so a restart needn't allocate.
+func (sp SpawnPoint) snakeStart(body []Point, size int) []Point {
+	snake := slices.Grow(body[:0], size)[:size]
+	for i := range snake {
+		p := sp.At
+		switch sp.Heading {
+		case Up:
+			p.Y += i
+		case Right:
+			p.X -= i
+		case Down:
+			p.Y -= i
+		case Left:
+			p.X += i
+		}
+		snake[i] = Point{X: (p.X + width) % width, Y: (p.Y + height) % height}
+	}
+	return snake
+}
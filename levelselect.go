@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Level select constants
+const (
+	levelsFileName = "levels.json"
+	levelGoal      = 25 // Points needed on a level to complete it
+)
+
+// LevelRecord is the best result on a built-in level
+type LevelRecord struct {
+	Score     int       `json:"score"`
+	Completed bool      `json:"completed"`
+	Date      time.Time `json:"date"`
+}
+
+// LevelRecords stores the best result on each built-in level
+type LevelRecords struct {
+	Best map[string]LevelRecord `json:"best"` // By level name
+	path string
+}
+
+// Load the level records from the data directory. The records returned are
+// usable, if empty, even when loading fails.
+func LoadLevelRecords() (*LevelRecords, error) {
+	records := &LevelRecords{Best: make(map[string]LevelRecord)}
+	dir, err := dataDir()
+	if err != nil {
+		return records, err
+	}
+	records.path = filepath.Join(dir, levelsFileName)
+
+	data, err := os.ReadFile(records.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	} else if err != nil {
+		return records, err
+	}
+
+	if err := json.Unmarshal(data, records); err != nil {
+		return &LevelRecords{Best: make(map[string]LevelRecord), path: records.path},
+			fmt.Errorf("parse %s: %w", records.path, err)
+	}
+	if records.Best == nil {
+		records.Best = make(map[string]LevelRecord)
+	}
+	return records, nil
+}
+
+// Save writes the records back to disk
+func (lr *LevelRecords) Save() error {
+	if lr.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(lr.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(lr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(lr.path, data, 0o644)
+}
+
+// Record a finished game on a level picked from the level select screen
+func (g *Game) recordLevel() error {
+	if g.levels == nil || g.botAssisted {
+		return nil
+	}
+	score := g.Player().score
+	if g.Versus() {
+		for _, s := range g.snakes {
+			score = max(score, s.score)
+		}
+	}
+
+	records := g.levels.Records
+	best, ok := records.Best[g.levelName]
+	if ok && best.Score >= score {
+		return nil
+	}
+	records.Best[g.levelName] = LevelRecord{
+		Score:     score,
+		Completed: best.Completed || score >= levelGoal,
+		Date:      time.Now(),
+	}
+	return records.Save()
+}
+
+// LevelMenu is the level select screen
+type LevelMenu struct {
+	Records  *LevelRecords
+	Selected int
+	levels   []*Level // Parsed map of each built-in level
+}
+
+// Build the level select screen, checking every level fits the board
+func NewLevelMenu(records *LevelRecords) (*LevelMenu, error) {
+	m := &LevelMenu{Records: records}
+	for _, name := range builtinLevelNames() {
+		level, err := LoadLevel(name)
+		if err != nil {
+			return nil, err
+		}
+		m.levels = append(m.levels, level)
+	}
+	return m, nil
+}
+
+// Move the selection up or down
+func (m *LevelMenu) move(dir Direction) {
+	switch dir {
+	case Up:
+		m.Selected = (m.Selected + len(m.levels) - 1) % len(m.levels)
+	case Down:
+		m.Selected = (m.Selected + 1) % len(m.levels)
+	}
+}
+
+// Level returns the selected level
+func (m *LevelMenu) Level() *Level {
+	return m.levels[m.Selected]
+}
+
+// Draw the level select screen over the game area
+func drawLevelMenu(m *LevelMenu) {
+	completed := 0
+	lines := make([]string, len(m.levels))
+	for i, level := range m.levels {
+		best, mark := "  -", " "
+		if rec, ok := m.Records.Best[level.Name]; ok {
+			best = fmt.Sprintf("%3d", rec.Score)
+			if rec.Completed {
+				mark = string(symbolCheck)
+				completed++
+			}
+		}
+		lines[i] = fmt.Sprintf("%2d. %-16.16s best %s %s", i+1, level.Name, best, mark)
+	}
+
+	title := fmt.Sprintf("LEVELS  %d/%d COMPLETE", completed, len(m.levels))
+	drawCentered(2, title, colorScore|AttrBold)
+	drawMenuList(lines, m.Selected, func(i int) { m.Selected = i })
+
+	hint := fmt.Sprintf("Score %d to complete, Enter to play", levelGoal)
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
+
+// Draw the level goal in the sidebar
+func drawLevelGoal(sb *Sidebar, g *Game) {
+	score := g.Player().score
+	fg := colorText
+	if score >= levelGoal {
+		fg = ColorGreen | AttrBold
+	}
+	sb.Bar("GOAL", score, levelGoal, fg)
+}
+
+// Title for the game over screen of a level select game
+func levelResult(g *Game) string {
+	if g.Player().score >= levelGoal {
+		return strings.ToUpper(g.levelName) + " COMPLETE!"
+	}
+	return fmt.Sprintf("%d more to complete %s", levelGoal-g.Player().score, g.levelName)
+}
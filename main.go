@@ -1,424 +1,1557 @@
 package main
 
 import (
+	"bytes"
+	"errors"
+	"flag"
 	"fmt"
 	"math/rand"
+	"os"
+	"strings"
 	"time"
-
-	"github.com/nsf/termbox-go"
 )
 
-// Game constants
-const (
-	width        = 40
-	height       = 15
-	initialSize  = 3
-	aspectRatio  = 1.8
-	baseSpeed    = 100
-	sidebarWidth = 20 // Width of the sidebar
-
-	// Food timer constants
-	minFoodTime     = 50  // Minimum ticks food stays on screen
-	maxFoodTime     = 150 // Maximum ticks food stays on screen
-	foodRespawnTime = 20  // Ticks to wait before spawning new food
-)
+// Record a finished game on the leaderboard and save it
+func (g *Game) recordScore(name string) error {
+	if g.scores == nil || g.Versus() || g.botAssisted {
+		return nil
+	}
+	entry := ScoreEntry{
+		Name:       name,
+		Score:      g.Player().score,
+		Date:       time.Now(),
+		Width:      width,
+		Height:     height,
+		Mode:       g.mode,
+		Difficulty: g.difficulty.Name,
+	}
+	if g.scoreRank = g.scores.Add(entry); g.scoreRank < 0 {
+		return nil
+	}
+	err := g.scores.Save()
+	g.scoreRank = g.scores.Rank(entry) // Scores merged in from another go-snake may have moved it
+	return err
+}
 
-// Food types and values
-var (
-	foodSymbols = []rune{'🍆', '🍗', '🧀', '🍬'}
-	foodValues  = []int{1, 3, 5, 7}
-)
+// Default player name for the leaderboard
+func defaultPlayerName() string {
+	for _, key := range []string{"USER", "USERNAME"} {
+		if name := os.Getenv(key); name != "" {
+			return name
+		}
+	}
+	return "player"
+}
 
-// Cell symbols
+// Exit statuses beyond 0 for success, 1 for an error and 2 for bad usage,
+// so scripts and CI jobs can tell outcomes apart
 const (
-	symbolBorderHorizontal  = '━'
-	symbolBorderVertical    = '┃'
-	symbolBorderTopLeft     = '┏'
-	symbolBorderTopRight    = '┓'
-	symbolBorderBottomLeft  = '┗'
-	symbolBorderBottomRight = '┛'
-	symbolSnakeHead         = '▣'
-	symbolSnakeBody         = '◼'
-	symbolEmptyCell         = '⬚' // New symbol for empty cells in the game field
+	exitLoss    = 3 // simulate: the bot lost more games to its rival than it won
+	exitDraw    = 4 // simulate: the bot won as many games as its rival
+	exitInvalid = 5 // verify: a saved solution doesn't solve its puzzle
 )
 
-// Direction represents the snake's movement direction
-type Direction int
-
-const (
-	Up Direction = iota
-	Right
-	Down
-	Left
-)
+func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "replay":
+			os.Exit(replayCommand(os.Args[2:]))
+		case "serve-ssh":
+			os.Exit(serveSSHCommand(os.Args[2:]))
+		case "stats":
+			os.Exit(statsCommand(os.Args[2:]))
+		case "simulate":
+			os.Exit(simulateCommand(os.Args[2:]))
+		case "verify":
+			os.Exit(verifyCommand(os.Args[2:]))
+		case "migrate":
+			os.Exit(migrateCommand(os.Args[2:]))
+		case "dashboard":
+			os.Exit(dashboardCommand(os.Args[2:]))
+		case "profiles":
+			os.Exit(profilesCommand(os.Args[2:]))
+		}
+	}
 
-// Point represents a position on the grid
-type Point struct {
-	X, Y int
-}
+	playerName := flag.String("name", defaultPlayerName(), "player name recorded in the high score table (default the profile's name)")
+	profileName := flag.String("player", "", "play as this profile, with its own high scores, stats, settings and progress (default pick at startup)")
+	flag.StringVar(&settings.Mode, "mode", modeWrap, "game mode: wrap, walls, timed, survival, arcade, royale, tron or territory")
+	weekly := flag.Bool("weekly", false, "play this week's challenge with its modifiers")
+	daily := flag.Bool("daily", false, "play today's challenge: the same speed, walls and food for everyone, with one attempt that counts")
+	seed := flag.Int64("seed", 0, "seed for the food sequence, to replay the same game (default random)")
+	flag.BoolVar(&settings.FoodTick, "food-tick", false, "beep each second before food expires")
+	difficultyName := flag.String("difficulty", "normal", "starting speed and acceleration: easy, normal, hard or insane")
+	flag.IntVar(&settings.SpawnDistance, "spawn-distance", 0, "keep food at least this many cells from the head and out of its path (0 = off)")
+	flag.BoolVar(&settings.SpawnSpread, "spawn-spread", false, "bias food away from where it recently appeared")
+	flag.BoolVar(&settings.SpawnOpen, "spawn-open", false, "bias food towards open ground, never in a dead end or right behind the tail")
+	flag.BoolVar(&settings.SpawnBalanced, "spawn-balanced", false, "keep food about the same distance from the head however long the snake grows")
+	startLength := flag.Int("start-length", 0, "segments the snake starts with (overrides the config file)")
+	startAt := flag.String("start-at", "", "column and row the snake's head starts at, counting from 1, e.g. 5,10 (overrides the config file)")
+	heading := flag.String("heading", "", "way the snake starts off: up, right, down or left (overrides the config file)")
+	flag.BoolVar(&settings.ScoreDecay, "decay", false, "score drains over time, faster as the snake grows")
+	flag.BoolVar(&settings.Hazards, "hazards", false, "rival blocks, turning lasers and hot zones appear during play")
+	flag.BoolVar(&settings.Relative, "relative", false, "left and right turn the snake from its heading; up and down do nothing")
+	demo := flag.Bool("demo", false, "let the autopilot play, restarting after each game")
+	levelSelect := flag.Bool("levels", false, "pick from the built-in levels and track which ones you've completed")
+	puzzleMode := flag.Bool("puzzle", false, "solve turn-based puzzles in as few moves as possible")
+	campaignMode := flag.Bool("campaign", false, "play the campaign's stages in order, carrying on where you left off")
+	versus := flag.Bool("versus", false, "two players on one keyboard: arrows for P1, WASD for P2")
+	hotseat := flag.String("hotseat", "", "pass-and-play tournament: comma-separated player names, taking turns on the same seed")
+	levelName := flag.String("level", "", "level map to play: "+strings.Join(builtinLevelNames(), ", ")+" or a map file")
+	theme := flag.String("theme", "", "symbols and colors: "+strings.Join(assetNames("themes"), ", ")+" (overrides the config file)")
+	palette := flag.String("palette", "", "colors over the theme's: "+strings.Join(assetNames("palettes"), ", ")+" (overrides the config file)")
+	configPath := flag.String("config", defaultConfigPath(), "config file with board, speed, food, symbol, color and key settings")
+	boardWidth := flag.Int("width", 0, "board width in cells (overrides the config file)")
+	boardHeight := flag.Int("height", 0, "board height in cells (overrides the config file)")
+	boardPreset := flag.String("preset", "", "board size with speeds to suit it: "+boardPresetNames()+" (overrides the config file)")
+	startSpeed := flag.Int("speed", 0, "milliseconds per tick at level 1 (overrides the config file)")
+	rendererName := flag.String("renderer", defaultRenderer, "terminal library to draw with: "+strings.Join(rendererNames(), ", "))
+	resume := flag.Bool("resume", false, "carry on with the game saved with the save key")
+	hostAddr := flag.String("host", "", "host a versus game over the network, waiting on this address (e.g. :8080)")
+	joinAddr := flag.String("join", "", "join a versus game hosted at this address (e.g. example.com:8080)")
+	goalFlag := flag.String("goal", "", "session goal: a score to beat, optionally how many times, e.g. 80x3")
+	leaderboardURL := flag.String("leaderboard", "", "send scores to the online leaderboard at this URL (overrides the config file)")
+	soundBackend := flag.String("sound", "", "how the game sounds: "+strings.Join(audioBackendNames(), ", ")+" (overrides the config file)")
+	controlAddr := flag.String("control", "", "let other programs watch and drive the game over JSON-RPC on a loopback host:port or unix:path")
+	logMoves := flag.String("log-moves", "", "append a line for each turn, food eaten and notice to a file, or to an open file descriptor with fd:N")
+	practice := flag.Bool("practice", false, "practice: undo up to 3 crashes a game, after the first is scored")
+	ghost := flag.Bool("ghost", false, "race a ghost of your best run with the same seed and setup")
+	smooth := flag.Bool("smooth", false, "draw the snakes moving between cells with half blocks (overrides the config file)")
+	blank := flag.Bool("blank-cells", false, "leave empty cells blank instead of drawing the theme's texture (overrides the config file)")
+	clock := flag.String("clock", "", "clock the sidebar shows: "+strings.Join(clockModes, ", ")+" (overrides the config file)")
+	zone := flag.String("timezone", "", "zone to show the daily rollover in, like Europe/Berlin (overrides the config file)")
+	keyLayout := flag.String("key-layout", "", "keys to play with: "+strings.Join(keyLayoutNames(), " or ")+", under the config file's own (overrides the config file)")
+	mouse := flag.String("mouse", "", "what clicks do: menus picks menu items, steer also turns the snake towards them, off leaves the mouse to the terminal (overrides the config file)")
+	gamepad := flag.String("gamepad", "", "read a game controller: a joystick device like /dev/input/js0, or auto for the first one plugged in (overrides the config file)")
+	aspect := flag.Float64("aspect", 0, "vertical slowdown for tall terminal cells (overrides the config file)")
+	speedrun := flag.Bool("speedrun", false, "show a speedrun timer with splits compared against your personal bests (overrides the config file)")
+	splitEveryFlag := flag.Int("split-every", 0, "points between speedrun splits outside the campaign (overrides the config file)")
+	pluginFlag := flag.String("plugins", "", "comma-separated plugins to load: "+strings.Join(pluginNames(), ", ")+" (overrides the config file)")
+	pluginLogTarget := flag.String("plugin-log", "", "where plugins write: a file, appended to, or an open file descriptor with fd:N (default standard error on exit)")
+	record := flag.String("record", "", "record the screen to an asciicast file, to play back with asciinema")
+	flag.Parse()
+
+	// Flags the user actually set win over the config file
+	set := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	if assetErr != nil {
+		fmt.Fprintf(os.Stderr, "go-snake: assets %s: %v\n", userAssetDir, assetErr)
+		os.Exit(2)
+	}
+	// With profiles to choose from and none given, ask who's playing
+	if names, err := profileNames(); err == nil && len(names) > 0 && !set["player"] && !*demo {
+		name, picked, err := pickProfile(*rendererName, set["renderer"], names)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			os.Exit(1)
+		}
+		if !picked {
+			return
+		}
+		*profileName = name
+	}
+	if err := useProfile(*profileName); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake: -player:", err)
+		os.Exit(2)
+	}
+	if *profileName != "" && !set["name"] {
+		*playerName = *profileName
+	}
+	// Calibration sets the defaults the config file and flags can override.
+	// Problems with the profile are reported once the terminal is restored.
+	profile, profileErr := LoadProfile()
+	defer func() {
+		if profileErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: profile:", profileErr)
+		}
+	}()
+	if cal := profile.Calibration; cal != nil && cal.Aspect > 0 {
+		aspectRatio = cal.Aspect
+	}
+	defaultAspect := aspectRatio
+	config, err := LoadConfig(*configPath, set["config"], *theme, *palette)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		os.Exit(2)
+	}
+	if set["preset"] {
+		config.Board.Preset = *boardPreset
+	}
+	if err := config.Board.usePreset(); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		os.Exit(2)
+	}
+	if set["width"] {
+		config.Board.Width = *boardWidth
+	}
+	if set["height"] {
+		config.Board.Height = *boardHeight
+	}
+	if *daily {
+		// Everyone plays the daily challenge on the same board
+		config.Board = BoardConfig{Preset: dailyBoard}
+		config.Board.usePreset()
+	}
+	if set["speed"] {
+		config.Speed.Start = *startSpeed
+	}
+	if set["aspect"] {
+		config.Speed.AspectRatio = *aspect
+	}
+	if set["leaderboard"] {
+		config.Leaderboard.URL = *leaderboardURL
+	}
+	if set["sound"] {
+		config.Sound.Backend = *soundBackend
+	}
+	if set["smooth"] {
+		config.Smooth = *smooth
+	}
+	if set["blank-cells"] {
+		config.Blank = *blank
+	}
+	if set["mouse"] {
+		config.Mouse = *mouse
+	}
+	if set["clock"] {
+		config.Clock = *clock
+	}
+	if set["timezone"] {
+		config.Zone = *zone
+	}
+	if set["key-layout"] {
+		config.Layout = *keyLayout
+	}
+	if set["gamepad"] {
+		config.Gamepad = *gamepad
+	}
+	if set["speedrun"] {
+		config.Speedrun.Timer = *speedrun
+	}
+	if set["split-every"] {
+		config.Speedrun.SplitEvery = *splitEveryFlag
+	}
+	if set["plugins"] {
+		config.Plugins = nil
+		if *pluginFlag != "" {
+			config.Plugins = strings.Split(*pluginFlag, ",")
+		}
+	}
+	if set["start-length"] {
+		if *startLength < 1 {
+			fmt.Fprintln(os.Stderr, "go-snake: -start-length must be at least 1")
+			os.Exit(2)
+		}
+		config.Start.flags.Length = *startLength
+	}
+	if set["start-at"] {
+		x, y, err := parseStartAt(*startAt)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			os.Exit(2)
+		}
+		config.Start.flags.X, config.Start.flags.Y = x, y
+	}
+	if set["heading"] {
+		if _, ok := directionByName(*heading); !ok {
+			fmt.Fprintf(os.Stderr, "go-snake: -heading must be up, right, down or left, got %q\n", *heading)
+			os.Exit(2)
+		}
+		config.Start.flags.Heading = *heading
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		os.Exit(2)
+	}
+	config.Apply()
+	keys, err := NewKeyBindings(config.Keys)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "go-snake: config %s: %v\n", *configPath, err)
+		os.Exit(2)
+	}
+	activeKeys = keys
+	leaderboard, err := newLeaderboard(config.Leaderboard.URL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		os.Exit(2)
+	}
+	reports, err := newTelemetry(config.Telemetry.URL)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		os.Exit(2)
+	}
+	audio, err := newAudio(config.Sound)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake: sound:", err)
+		os.Exit(2)
+	}
+	defer func() { sound.audio.Close() }() // The settings menu may switch backends
+	sound.audio = audio
+	settingsMenu := NewSettingsMenu(config, *configPath, set["config"], *palette, keys)
 
-// Game represents the state of the game
-type Game struct {
-	snake              []Point
-	food               Point
-	foodType           int // Index of current food type in foodSymbols
-	direction          Direction
-	score              int
-	highScore          int
-	gameOver           bool
-	foodTimer          int  // Countdown until food disappears
-	foodVisible        bool // Is food currently visible?
-	foodRespawnCounter int  // Countdown until next food appears
-}
+	if settings.SpawnDistance < 0 {
+		fmt.Fprintln(os.Stderr, "go-snake: -spawn-distance can't be negative")
+		os.Exit(2)
+	}
 
-// Initialize a new game
-func NewGame() *Game {
-	g := &Game{
-		snake:              make([]Point, initialSize),
-		direction:          Right,
-		score:              0,     // Explicitly initialize score to 0
-		foodVisible:        false, // Start with no food
-		foodRespawnCounter: 0,     // Spawn food immediately
+	var level *Level
+	if *levelName != "" {
+		var err error
+		if level, err = LoadLevel(*levelName); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			os.Exit(2)
+		}
 	}
 
-	// Initialize snake in the middle of the board
-	for i := 0; i < initialSize; i++ {
-		g.snake[i] = Point{
-			X: width/2 - i,
-			Y: height / 2,
+	players := 1
+	if *versus || *hostAddr != "" {
+		players = maxPlayers
+		if level != nil {
+			if err := level.CheckSpawns(players); err != nil {
+				fmt.Fprintln(os.Stderr, "go-snake: level can't be used for versus:", err)
+				os.Exit(2)
+			}
+		}
+	}
+	if rivalBot(settings.Mode) != nil && level != nil {
+		if err := level.CheckSpawns(maxPlayers); err != nil {
+			fmt.Fprintf(os.Stderr, "go-snake: level can't be used for %s: %v\n", settings.Mode, err)
+			os.Exit(2)
+		}
+	}
+	// A start placement has to fit the board and level it's played on.
+	// Puzzles set their own start.
+	startLevel := ""
+	if level != nil {
+		startLevel = level.Name
+	}
+	if start := startConfig.placement(settings.Mode, startLevel); start != (StartPlacement{}) && !*puzzleMode {
+		snakes := players
+		if rivalBot(settings.Mode) != nil {
+			snakes = maxPlayers
+		}
+		if err := checkPlacement(start, level, snakes); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: start:", err)
+			os.Exit(2)
 		}
 	}
 
-	// Place initial food
-	g.PlaceFood()
+	preset, err := difficultyByName(*difficultyName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+	difficulty := config.Speed.adjust(profile.Calibration.adjust(preset))
 
-	return g
-}
+	if !validMode(settings.Mode) {
+		fmt.Fprintf(os.Stderr, "go-snake: unknown mode %q\n", settings.Mode)
+		flag.Usage()
+		os.Exit(2)
+	}
 
-// Place food at a random location not occupied by the snake
-func (g *Game) PlaceFood() {
-	// Select random food type
-	g.foodType = rand.Intn(len(foodSymbols))
+	if *puzzleMode && (*versus || *weekly || *levelName != "" || *demo) {
+		fmt.Fprintln(os.Stderr, "go-snake: -puzzle can't be combined with -versus, -weekly, -level or -demo")
+		os.Exit(2)
+	}
+	if *levelSelect && (*levelName != "" || *puzzleMode || *weekly) {
+		fmt.Fprintln(os.Stderr, "go-snake: -levels can't be combined with -level, -puzzle or -weekly")
+		os.Exit(2)
+	}
+	if *campaignMode && (*levelName != "" || *levelSelect || *puzzleMode || *weekly || *versus || *demo) {
+		fmt.Fprintln(os.Stderr, "go-snake: -campaign can't be combined with -level, -levels, -puzzle, -weekly, -versus or -demo")
+		os.Exit(2)
+	}
+	if *hotseat != "" && (*versus || *levelSelect || *puzzleMode || *campaignMode || *weekly || *demo || *practice || *ghost) {
+		fmt.Fprintln(os.Stderr, "go-snake: -hotseat can't be combined with -versus, -levels, -puzzle, -campaign, -weekly, -demo, -practice or -ghost")
+		os.Exit(2)
+	}
+	if rivalBot(settings.Mode) != nil && (*levelSelect || *puzzleMode || *campaignMode || *hotseat != "" || *weekly || *daily) {
+		fmt.Fprintf(os.Stderr, "go-snake: -mode %s can't be combined with -levels, -puzzle, -campaign, -hotseat, -weekly or -daily\n", settings.Mode)
+		os.Exit(2)
+	}
+	if *demo && *weekly {
+		fmt.Fprintln(os.Stderr, "go-snake: -demo can't be used with -weekly")
+		os.Exit(2)
+	}
 
-	// Set a random timer for this food
-	g.foodTimer = rand.Intn(maxFoodTime-minFoodTime) + minFoodTime
+	if *resume && (*puzzleMode || *levelSelect || *campaignMode || *hotseat != "" || *demo || *weekly || *versus || *levelName != "" || set["seed"]) {
+		fmt.Fprintln(os.Stderr, "go-snake: -resume carries on a saved game and can't be combined with options that start a new one")
+		os.Exit(2)
+	}
 
-	// Make food visible
-	g.foodVisible = true
+	if *hostAddr != "" && (*puzzleMode || *levelSelect || *campaignMode || *hotseat != "" || *demo || *weekly || *resume || *joinAddr != "") {
+		fmt.Fprintln(os.Stderr, "go-snake: -host can't be combined with -puzzle, -levels, -campaign, -hotseat, -demo, -weekly, -resume or -join")
+		os.Exit(2)
+	}
+	if *joinAddr != "" && (*puzzleMode || *levelSelect || *campaignMode || *hotseat != "" || *demo || *weekly || *resume || *versus || *levelName != "" || set["seed"]) {
+		fmt.Fprintln(os.Stderr, "go-snake: -join plays the host's game and can't be combined with options that start a new one")
+		os.Exit(2)
+	}
 
-	for {
-		g.food = Point{
-			X: rand.Intn(width),
-			Y: rand.Intn(height),
+	// Goals only last the session; the play streak lives in the profile
+	session := &Session{Streak: profile.Streak}
+	if *goalFlag != "" {
+		goal, err := parseSessionGoal(*goalFlag)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			os.Exit(2)
 		}
+		session.Goal = &goal
+	}
 
-		// Check if food is on snake
-		collision := false
-		for _, p := range g.snake {
-			if p.X == g.food.X && p.Y == g.food.Y {
-				collision = true
-				break
-			}
+	if set["seed"] && *weekly {
+		fmt.Fprintln(os.Stderr, "go-snake: -seed can't be used with -weekly, which has its own seed")
+		os.Exit(2)
+	}
+	if *daily && (*weekly || *levelName != "" || *levelSelect || *puzzleMode || *campaignMode || *hotseat != "" || *versus || *demo || *practice || *resume || *hostAddr != "" || *joinAddr != "" || set["seed"] || set["preset"] || set["width"] || set["height"]) {
+		fmt.Fprintln(os.Stderr, "go-snake: -daily can't be combined with -weekly, -level, -levels, -puzzle, -campaign, -hotseat, -versus, -demo, -practice, -resume, -host, -join, -seed, -preset, -width or -height")
+		os.Exit(2)
+	}
+
+	// Problems with the score file are reported once the terminal is restored
+	scores, scoresErr := LoadHighScores()
+	defer func() {
+		if scoresErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: high scores:", scoresErr)
 		}
+	}()
 
-		if !collision {
-			break
+	// Lifetime stats are kept alongside the session's
+	lifetime, statsErr := LoadLifetimeStats()
+	defer func() {
+		if statsErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: stats:", statsErr)
 		}
+	}()
+	session.Lifetime = lifetime
+
+	// Daily challenge attempts are kept apart from other games, so each day
+	// has one that counts
+	var dailyLog *DailyLog
+	var dailyErr error
+	if *daily {
+		dailyLog, dailyErr = LoadDailyLog()
 	}
-}
+	defer func() {
+		if dailyErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: daily:", dailyErr)
+		}
+	}()
 
-// Update game state
-func (g *Game) Update() {
-	if g.gameOver {
-		return
+	// Games are logged as they are played for screen readers and other tools
+	var moveLog *MoveLog
+	if *logMoves != "" {
+		if moveLog, err = OpenMoveLog(*logMoves); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: log moves:", err)
+			os.Exit(2)
+		}
+		defer func() {
+			if err := moveLog.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, "go-snake: log moves:", err)
+			}
+		}()
 	}
 
-	// Food timer management
-	if g.foodVisible {
-		// Countdown food timer
-		g.foodTimer--
-		if g.foodTimer <= 0 {
-			// Food has disappeared
-			g.foodVisible = false
-			g.foodRespawnCounter = foodRespawnTime
+	// Plugins write to their own log, or to standard error once the
+	// terminal is restored
+	var loadedPlugins []Plugin
+	if len(config.Plugins) > 0 {
+		var pluginLog *MoveLog
+		if *pluginLogTarget != "" {
+			if pluginLog, err = OpenMoveLog(*pluginLogTarget); err != nil {
+				fmt.Fprintln(os.Stderr, "go-snake: plugin log:", err)
+				os.Exit(2)
+			}
+		} else {
+			buf := &bytes.Buffer{}
+			pluginLog = &MoveLog{w: memoryLog{buf}}
+			defer func() { os.Stderr.Write(buf.Bytes()) }()
 		}
-	} else {
-		// Food is not visible, count down to respawn
-		g.foodRespawnCounter--
-		if g.foodRespawnCounter <= 0 {
-			// Time to respawn food
-			g.PlaceFood()
+		defer func() {
+			if err := pluginLog.Close(); err != nil {
+				fmt.Fprintln(os.Stderr, "go-snake: plugin log:", err)
+			}
+		}()
+		loadedPlugins = loadPlugins(config.Plugins, pluginLog)
+	}
+
+	// Other programs can drive the game over a local socket
+	var control *ControlServer
+	var controlRequests chan *ControlRequest
+	if *controlAddr != "" {
+		if control, err = ListenControl(*controlAddr); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: control:", err)
+			os.Exit(2)
 		}
+		defer control.Close()
+		controlRequests = control.Requests
 	}
 
-	// Calculate new head position
-	head := g.snake[0]
-	var newHead Point
+	// Runs to race are only kept when racing them
+	var ghosts *GhostRuns
+	var ghostsErr error
+	defer func() {
+		if ghostsErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: ghosts:", ghostsErr)
+		}
+	}()
+	if *ghost {
+		ghosts, ghostsErr = LoadGhostRuns()
+	}
 
-	switch g.direction {
-	case Up:
-		newHead = Point{X: head.X, Y: head.Y - 1}
-	case Right:
-		newHead = Point{X: head.X + 1, Y: head.Y}
-	case Down:
-		newHead = Point{X: head.X, Y: head.Y + 1}
-	case Left:
-		newHead = Point{X: head.X - 1, Y: head.Y}
+	// Personal best splits are only kept when timing runs
+	var splits *SplitRecords
+	var splitsErr error
+	var run *Speedrun // Run being timed, which carries on over a campaign's stages
+	defer func() {
+		if splitsErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: splits:", splitsErr)
+		}
+	}()
+	if speedrunTimer && !*demo {
+		splits, splitsErr = LoadSplitRecords()
 	}
 
-	// Implement wraparound for walls
-	if newHead.X < 0 {
-		newHead.X = width - 1
-	} else if newHead.X >= width {
-		newHead.X = 0
+	// Puzzle mode starts on the puzzle select screen
+	var puzzles *PuzzleMenu
+	var puzzlesErr error
+	defer func() {
+		if puzzlesErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: puzzles:", puzzlesErr)
+		}
+	}()
+	if *puzzleMode {
+		records, err := LoadPuzzleRecords()
+		puzzlesErr = err
+		if puzzles, err = NewPuzzleMenu(records); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: puzzle doesn't fit the board:", err)
+			os.Exit(2)
+		}
 	}
 
-	if newHead.Y < 0 {
-		newHead.Y = height - 1
-	} else if newHead.Y >= height {
-		newHead.Y = 0
+	// Level select mode starts on the level select screen
+	var levels *LevelMenu
+	var levelsErr error
+	defer func() {
+		if levelsErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: levels:", levelsErr)
+		}
+	}()
+	if *levelSelect {
+		records, err := LoadLevelRecords()
+		levelsErr = err
+		if levels, err = NewLevelMenu(records); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: level doesn't fit the board:", err)
+			os.Exit(2)
+		}
+		level = levels.Level()
 	}
 
-	// Check self collision
-	for _, p := range g.snake {
-		if p.X == newHead.X && p.Y == newHead.Y {
-			g.gameOver = true
-			return
+	// The campaign carries on from the stage saved in the profile
+	var campaign *Campaign
+	if *campaignMode {
+		if campaign, err = NewCampaign(profile); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: campaign level doesn't fit the board:", err)
+			os.Exit(2)
 		}
 	}
 
-	// Add new head to snake
-	g.snake = append([]Point{newHead}, g.snake...)
+	// A hot-seat tournament plays every turn of a round on the same seed,
+	// and its last ranking is printed once the terminal is restored
+	var tournament *Tournament
+	if *hotseat != "" {
+		tournamentSeed := *seed
+		if !set["seed"] {
+			tournamentSeed = rand.Int63n(1e9)
+		}
+		if tournament, err = NewTournament(*hotseat, tournamentSeed); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: hotseat:", err)
+			os.Exit(2)
+		}
+		defer func() {
+			fmt.Print(tournament.Summary())
+		}()
+	}
 
-	// Check food collision only if food is visible
-	if g.foodVisible && newHead.X == g.food.X && newHead.Y == g.food.Y {
-		// Award points based on food type
-		pointsEarned := foodValues[g.foodType]
-		g.score += pointsEarned
+	// A saved game brings its own settings, which later games keep
+	var resumed *Game
+	if *resume {
+		if resumed, err = LoadSavedGame(); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: resume:", err)
+			os.Exit(2)
+		}
+		players = len(resumed.snakes)
+		if resumed.rival {
+			players--
+		}
+		*weekly = resumed.challenge != ""
+		difficulty = resumed.difficulty
+	}
 
-		// Flash score notification
-		// (Could extend this in the future to show +N points briefly)
+	// Scorecards are printed with "go-snake replay card"
+	var cardErr error
+	defer func() {
+		if cardErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: scorecard:", cardErr)
+		}
+	}()
 
-		// Update high score if current score is higher
-		if g.score > g.highScore {
-			g.highScore = g.score
+	// Saving quits once the game is on disk; problems are reported on exit
+	saved := false
+	var saveErr error
+	defer func() {
+		if saveErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: save:", saveErr)
+		} else if saved {
+			fmt.Println("Game saved. Run go-snake -resume to carry on.")
 		}
+	}()
 
-		// Place new food
-		g.PlaceFood()
-	} else {
-		// Remove tail if no food was eaten
-		g.snake = g.snake[:len(g.snake)-1]
+	// Network games meet before the terminal is taken over. The joining
+	// player plays on the host's board in the host's mode.
+	var host *Host
+	if *hostAddr != "" {
+		if host, err = HostGame(*hostAddr, *playerName); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: host:", err)
+			os.Exit(1)
+		}
+		defer host.Close()
+	}
+	var joined *netConn
+	var welcome netMessage
+	var clientErr error
+	if *joinAddr != "" {
+		if joined, welcome, err = JoinGame(*joinAddr, *playerName); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: join:", err)
+			os.Exit(1)
+		}
+		width, height = welcome.Width, welcome.Height
+		defer func() {
+			if errors.Is(clientErr, errHostLeft) {
+				fmt.Printf("%s left the game.\n", welcome.Name)
+			} else if clientErr != nil {
+				fmt.Fprintln(os.Stderr, "go-snake:", clientErr)
+			}
+		}()
 	}
-}
-
-// Draw the game
-func (g *Game) Draw() {
-	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
-
-	// Clear sidebar area explicitly to prevent artifacts
-	clearSidebarArea()
 
-	// Draw sidebar with minimal info
-	drawSidebar(g)
+	// A gamepad is read alongside the keyboard once the game is up
+	var pad *Gamepad
+	if config.Gamepad != "" {
+		if pad, err = OpenGamepad(config.Gamepad); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: gamepad:", err)
+			os.Exit(2)
+		}
+	}
 
-	// Draw border with offset for sidebar
-	for i := 0; i < width+2; i++ {
-		termbox.SetCell(i+sidebarWidth, 0, symbolBorderHorizontal, termbox.ColorWhite, termbox.ColorDefault)
-		termbox.SetCell(i+sidebarWidth, height+1, symbolBorderHorizontal, termbox.ColorWhite, termbox.ColorDefault)
+	if screen, err = openRenderer(*rendererName, set["renderer"]); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		os.Exit(2)
+	}
+	// Recording watches everything the renderer draws. Problems writing it
+	// are reported once the terminal is restored.
+	if *record != "" {
+		recorder, err := NewRecorder(screen, *record)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: record:", err)
+			os.Exit(2)
+		}
+		screen = recorder
+		defer func() {
+			if err := recorder.Err(); err != nil {
+				fmt.Fprintln(os.Stderr, "go-snake: record:", err)
+			}
+		}()
+	}
+	// Screenshots are taken of the last frame the dirty renderer sent
+	dirty := NewDirtyRenderer(screen)
+	screen = dirty
+	err = screen.Init()
+	if err != nil {
+		panic(err)
 	}
-	for i := 0; i < height+2; i++ {
-		termbox.SetCell(sidebarWidth, i, symbolBorderVertical, termbox.ColorWhite, termbox.ColorDefault)
-		termbox.SetCell(width+sidebarWidth+1, i, symbolBorderVertical, termbox.ColorWhite, termbox.ColorDefault)
+	defer screen.Close()
+	if mouseMode != mouseOff {
+		screen.EnableMouse()
 	}
-	termbox.SetCell(sidebarWidth, 0, symbolBorderTopLeft, termbox.ColorWhite, termbox.ColorDefault)
-	termbox.SetCell(width+sidebarWidth+1, 0, symbolBorderTopRight, termbox.ColorWhite, termbox.ColorDefault)
-	termbox.SetCell(sidebarWidth, height+1, symbolBorderBottomLeft, termbox.ColorWhite, termbox.ColorDefault)
-	termbox.SetCell(width+sidebarWidth+1, height+1, symbolBorderBottomRight, termbox.ColorWhite, termbox.ColorDefault)
+	// The config file's sidebar setting wins over the layout key's last
+	// choice
+	sidebarMode := profile.Sidebar
+	if config.Sidebar != "" {
+		sidebarMode = config.Sidebar
+	}
+	setSidebar(sidebarMode)
+	fitLayout(screen.Size())
 
-	// Fill game field with empty cell symbols
-	for x := 0; x < width; x++ {
-		for y := 0; y < height; y++ {
-			termbox.SetCell(x+sidebarWidth+1, y+1, symbolEmptyCell, termbox.ColorDarkGray, termbox.ColorDefault)
-		}
+	if joined != nil {
+		clientErr = runClient(joined, welcome, keys)
+		return
 	}
 
-	// Draw snake with offset for sidebar
-	for i, p := range g.snake {
-		symbol := symbolSnakeBody
-		if i == 0 {
-			// First segment is the head
-			symbol = symbolSnakeHead
+	// Every game publishes its state on a socket of its own, for a companion
+	// dashboard in another pane
+	dashboard, dashboardErr := ListenDashboard()
+	defer func() {
+		if dashboardErr != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: dashboard:", dashboardErr)
 		}
-		termbox.SetCell(p.X+sidebarWidth+1, p.Y+1, symbol, termbox.ColorGreen, termbox.ColorDefault)
+	}()
+	var dashboardRequests chan *ControlRequest
+	if dashboard != nil {
+		defer dashboard.Close()
+		dashboardRequests = dashboard.Requests
 	}
 
-	// Draw food if visible, with color indicating timer
-	if g.foodVisible {
-		// Calculate color based on food timer
-		var fg termbox.Attribute = termbox.ColorRed
+	// Ordinary games have a title menu; special modes have their own screens
+	var menu *TitleMenu
+	if puzzles == nil && levels == nil && campaign == nil && tournament == nil && !*demo && host == nil {
+		menu = NewTitleMenu(profile.LastGame)
+		menu.FixedBoard = level != nil || *daily
+	}
 
-		// Change color as timer runs down
-		if g.foodTimer < minFoodTime/3 {
-			fg = termbox.ColorRed | termbox.AttrBlink // Blinking when about to disappear
-		} else if g.foodTimer < minFoodTime/2 {
-			fg = termbox.ColorRed | termbox.AttrBold // Bold red when getting low
+	// Set up the board and rules of a game from a seed with the current
+	// settings, in last's place when there's a finished game to reuse. Two
+	// games set up from one seed under the same rules version play out the
+	// same given the same inputs.
+	setupGame := func(last *Game, gameSeed int64, rules int) *Game {
+		mode := settings.Mode
+		switch {
+		case *weekly:
+			mode = modeWeekly
+		case *daily:
+			mode = modeDaily
+		}
+		var challenge string
+		spawn := newSpawnPolicy(settings)
+		switch mode {
+		case modeWeekly:
+			challenge = weeklyID(time.Now())
+			gameSeed = weeklySeed(challenge)
+		case modeDaily:
+			// The day's board is everyone's, so food turns up as usual
+			challenge = dailyDate(time.Now())
+			gameSeed = dailySeed(challenge)
+			spawn = newSpawnPolicy(Settings{})
 		}
 
-		termbox.SetCell(g.food.X+sidebarWidth+1, g.food.Y+1, foodSymbols[g.foodType], fg, termbox.ColorDefault)
+		if campaign != nil {
+			level = campaign.Level()
+		}
+		// Territory and light cycles are played against a bot when there's
+		// no one else to play
+		snakes := players
+		if rivalBot(mode) != nil && campaign == nil && tournament == nil {
+			snakes = maxPlayers
+		}
+		g := last
+		if g == nil {
+			g = &Game{}
+		}
+		g.resetUnder(rules, level, spawn, snakes, gameSeed)
+		if start := startConfig.placement(mode, g.levelName); start != (StartPlacement{}) && mode != modeDaily {
+			if err := g.placeSnakes(start, level); err != nil {
+				g.notify("Start left as usual: " + err.Error())
+			}
+		}
+		g.setMode(mode)
+		if snakes > players {
+			g.rival = true
+			g.setController(snakes-1, rivalBot(mode))
+		}
+		g.foodTick = settings.FoodTick
+		g.difficulty = difficulty
+		if settings.ScoreDecay {
+			g.mods |= ModScoreDecay
+		}
+		if settings.Hazards {
+			g.mods |= ModHazards
+		}
+		g.relative = settings.Relative
+		switch mode {
+		case modeWeekly:
+			g.challenge = challenge
+			g.mods = weeklyModifiers(weeklySeed(challenge), g.rules)
+			g.resetFood() // Re-roll the first food with the modifiers applied
+		case modeDaily:
+			g.challenge = challenge
+			g.dailyLog = dailyLog
+			g.setDaily(dailyRules(challenge))
+		}
+		if campaign != nil {
+			g.startStage(campaign)
+		}
+		return g
 	}
 
-	// Game over message (centered in game area)
-	if g.gameOver {
-		gameOverX := sidebarWidth + width/2
-		gameOverMsg := "Game Over!\n\rPress 'q' to quit or 'r' to restart."
-		scoreMsg := fmt.Sprintf("Final Score: %d", g.score)
+	// Start a game using the current settings, reusing last if it's given
+	startGame := func(last *Game) *Game {
+		if puzzles != nil {
+			g := puzzles.Start()
+			g.relative = settings.Relative
+			return g
+		}
 
-		for i, ch := range []rune(gameOverMsg) {
-			termbox.SetCell(gameOverX-len(gameOverMsg)/2+i, height/2, ch, termbox.ColorRed, termbox.ColorDefault)
+		// Every game from one seed gets the same food sequence. A weekly
+		// challenge seeds from the week so everyone gets the same one.
+		gameSeed := *seed
+		if tournament != nil {
+			gameSeed = tournament.Seed
+		} else if !set["seed"] {
+			gameSeed = rand.Int63n(1e9)
+		}
+		g := setupGame(last, gameSeed, rulesVersion)
+		if g.challenge != "" {
+			g.state = StatePaused
+			g.showChallenge = true
+		}
+		if g.campaign != nil {
+			g.state = StatePaused
+			g.showIntro = true
+		}
+		if tournament != nil {
+			g.startTurn(tournament)
 		}
 
-		for i, ch := range []rune(scoreMsg) {
-			termbox.SetCell(gameOverX-len(scoreMsg)/2+i, height/2+1, ch, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault)
+		// Record the run to race next time, and race the best one so far
+		if ghosts != nil && !g.Versus() && !*demo {
+			g.replay = &Replay{Seed: g.seed, Rules: g.rules}
+			if run, ok := ghosts.Best[ghostKey(g, settings)]; ok {
+				// A run recorded by a newer go-snake can't be raced
+				if rules, err := checkRules(run.Replay.Rules); err == nil {
+					if gh, err := newGhost(setupGame(nil, gameSeed, rules), &run.Replay); err == nil {
+						g.ghost = gh
+					}
+				}
+			}
 		}
+		// Time the run, going on with a campaign's until it starts over
+		if splits != nil && !g.Versus() {
+			if run != nil && g.campaign != nil && g.campaign.Stage > 0 {
+				run.Continue(g)
+			} else {
+				run = NewSpeedrun(g, splits)
+			}
+			g.speedrun = run
+		}
+		// The last few ticks can be rewound once the game is over, except
+		// on a board shared over the network
+		if !*demo && host == nil {
+			g.keepHistory = true
+			if *practice && !g.Versus() {
+				g.undos = practiceUndos
+			}
+		}
+		if *demo {
+			for i := 0; i < players; i++ {
+				g.setController(i, Bot{})
+			}
+		} else {
+			g.countdown = startCountdown
+		}
+		if host != nil {
+			// The bot takes over once the joining player has left
+			host.NewGame()
+			if host.Left {
+				g.setController(remoteSnake, Bot{})
+			} else {
+				g.setController(remoteSnake, &Remote{})
+			}
+		}
+		// Versus players both have to agree to pause, so neither can freeze
+		// the game at a bad moment for the other
+		if !*demo && (*versus || host != nil && !host.Left) {
+			g.pauseVote = NewPauseVote(maxPlayers)
+		}
+		g.moveLog = moveLog
+		g.plugins = loadedPlugins
+		g.scores = scores
+		g.session = session
+		g.highScore = scores.Best(g.mode)
+		g.levels = levels
+		g.menu = menu
+		g.settingsMenu = settingsMenu
+		return g
+	}
+	newGame := func() *Game {
+		return startGame(nil)
 	}
 
-	termbox.Flush()
-}
+	var game *Game
+	recorded := false
+	var demoRestart <-chan time.Time // Fires when a finished demo game should make way for the next
+	var playback *ReplayPlayer       // Recording being watched, if any
+	eventQueue := make(chan Event)
 
-// Clear the entire sidebar area to prevent artifacts
-func clearSidebarArea() {
-	for y := 0; y < height+4; y++ { // +4 to include score area below game
-		for x := 0; x < sidebarWidth; x++ {
-			termbox.SetCell(x, y, ' ', termbox.ColorDefault, termbox.ColorDefault)
+	go func() {
+		for {
+			eventQueue <- screen.PollEvent()
 		}
+	}()
+	var padGone chan error
+	if pad != nil {
+		padGone = make(chan error, 1)
+		go pad.Run(eventQueue, padGone)
 	}
-}
 
-// Draw the sidebar with scores and food information
-func drawSidebar(g *Game) {
-	// Draw vertical separator line
-	for i := 0; i < height+2; i++ {
-		termbox.SetCell(sidebarWidth-1, i, '│', termbox.ColorWhite, termbox.ColorDefault)
+	// Inputs from a joining player; nil channels never receive offline
+	var remoteInputs chan netMessage
+	var remoteGone chan error
+	if host != nil {
+		remoteInputs, remoteGone = host.Inputs, host.Gone
 	}
+	// Online leaderboard results arrive in the background
+	globalScores := make(chan *GlobalScores)
 
-	// Draw minimal score display
-	scoreStr := []rune(fmt.Sprintf("SCORE: %d", g.score))
-	for i, ch := range scoreStr {
-		termbox.SetCell(2+i, 2, ch, termbox.ColorYellow|termbox.AttrBold, termbox.ColorDefault)
+	draw := func() {
+		game.Draw()
+		if host != nil {
+			host.Send(game)
+		}
 	}
 
-	// Draw food value table header with minimal styling
-	tableHeader := " "
-	for i, ch := range []rune(tableHeader) {
-		termbox.SetCell(sidebarWidth/2-len(tableHeader)/2+i, 5, ch, termbox.ColorWhite, termbox.ColorDefault)
+	// The ticker only runs while a game is being played, so a paused game,
+	// the menus and the game over screen sit idle until a key is pressed.
+	// Puzzles are turn based and don't tick at all. With smooth motion,
+	// frames between ticks draw the snakes part way through their moves.
+	ticker := NewSchedule()
+	defer ticker.Stop()
+	frameTicker := time.NewTicker(frameInterval)
+	frameTicker.Stop()
+	defer frameTicker.Stop()
+	var updateInterval time.Duration
+	var tickedAt time.Time // When the last tick was, or the ticker started
+	resetTicker := func() {
+		ticker.Stop()
+		frameTicker.Stop()
+		updateInterval, tickedAt = game.updateInterval(), time.Now()
+		if game.state == StatePlaying && game.puzzle == nil && !game.showLevels {
+			ticker.Start(updateInterval)
+			if smoothMotion {
+				frameTicker.Reset(frameInterval)
+			}
+		}
 	}
 
-	// Draw food symbols and their values in a compact format
-	for i := 0; i < len(foodSymbols); i++ {
-		// Draw food symbol
-		termbox.SetCell(4, 7+i, foodSymbols[i], termbox.ColorRed, termbox.ColorDefault)
+	// Replays step at their own pace, and not at all while paused or done
+	retimePlayback := func() {
+		ticker.Stop()
+		if !playback.Paused && !playback.Done(game) {
+			ticker.Start(playback.StepTime())
+		}
+	}
 
-		// Draw equals sign
-		termbox.SetCell(6, 7+i, '=', termbox.ColorWhite, termbox.ColorDefault)
+	// Switch to a new game, or back to the title menu with a fresh game
+	// behind it for the next start
+	play := func(g *Game) {
+		game, recorded = g, false
+		resetTicker()
+		if g.state != StateMenu && g != resumed {
+			g.logMove(nil, "new game, mode %s, seed %d, heading %s", g.mode, g.seed, directionNames[g.Player().direction])
+		}
+		if menu != nil && g.state != StateMenu && g.challenge == "" && g != resumed {
+			if err := profile.Remember(settings, preset.Name); err != nil {
+				profileErr = err
+			}
+			menu.Last = profile.LastGame
+		}
+	}
+	openMenu := func() {
+		g := newGame()
+		g.state = StateMenu
+		play(g)
+	}
 
-		// Draw points value
-		valueStr := []rune(fmt.Sprintf("%d", foodValues[i]))
-		for j := 0; j < len(valueStr); j++ {
-			termbox.SetCell(8+j, 7+i, valueStr[j], termbox.ColorYellow, termbox.ColorDefault)
+	// Play again in the game just played rather than allocating another;
+	// nothing else holds on to it. A resumed game is left be, so play can
+	// still tell it apart.
+	restart := func() {
+		if game == resumed {
+			play(newGame())
+			return
 		}
+		play(startGame(game))
 	}
-}
 
-func main() {
-	rand.Seed(time.Now().UnixNano())
+	openSettings := func() {
+		game.showSettings = true
+		settingsMenu.Speed, settingsMenu.Note = difficulty.StartSpeed, ""
+	}
 
-	err := termbox.Init()
-	if err != nil {
-		panic(err)
+	// Switch board presets from the title menu, at the preset's pace
+	switchBoard := func(delta int) {
+		useBoardPreset(cycleBoardPreset(delta))
+		difficulty = config.Speed.adjust(profile.Calibration.adjust(preset))
+		openMenu()
 	}
-	defer termbox.Close()
 
-	game := NewGame()
-	eventQueue := make(chan termbox.Event)
+	switch {
+	case resumed != nil:
+		resumed.moveLog = moveLog
+		resumed.plugins = loadedPlugins
+		resumed.logMove(nil, "resumed game, mode %s, seed %d, score %d", resumed.mode, resumed.seed, resumed.Player().score)
+		resumed.scores = scores
+		resumed.session = session
+		resumed.highScore = scores.Best(resumed.mode)
+		resumed.menu = menu
+		resumed.settingsMenu = settingsMenu
+		play(resumed)
+	case menu != nil:
+		openMenu()
+	case puzzles != nil:
+		play(newGame())
+		game.showPuzzles = true
+	case levels != nil:
+		game = newGame()
+		game.showLevels = true
+		play(game)
+	default:
+		play(newGame())
+	}
+	draw()
 
-	go func() {
-		for {
-			eventQueue <- termbox.PollEvent()
+	for {
+		// Nothing ticks while idle, so wake up to clear notices as they go
+		var toastGone <-chan time.Time
+		if game.state != StatePlaying {
+			if until, ok := game.nextToastGone(); ok {
+				toastGone = time.After(time.Until(until))
+			}
 		}
-	}()
-
-	// Initialize with horizontal speed (will be adjusted based on direction)
-	updateInterval := time.Duration(baseSpeed) * time.Millisecond
-	ticker := time.NewTicker(updateInterval)
-	defer ticker.Stop()
 
-	highScore := 0 // Track high score across games
-
-	for {
 		select {
+		case <-toastGone:
+			game.Draw()
+		case r := <-controlRequests:
+			if control.Handle(game, r) {
+				resetTicker()
+			}
+			draw()
+		case r := <-dashboardRequests:
+			dashboard.Handle(game, r)
+		case <-demoRestart:
+			demoRestart = nil
+			if game.state == StateGameOver {
+				play(newGame())
+				draw()
+			}
 		case ev := <-eventQueue:
-			if ev.Type == termbox.EventKey {
-				oldDirection := game.direction
-				switch ev.Key {
-				case termbox.KeyArrowUp:
-					if game.direction != Down {
-						game.direction = Up
+			if ev.Type == EventResize {
+				fitLayout(ev.Width, ev.Height)
+				game.Draw()
+				continue
+			}
+			// Clicks and the wheel stand in for keys on menus, and clicks on
+			// the board can steer player 1
+			var steer Direction
+			steering := false
+			if ev.Type == EventMouse {
+				if key, ok := game.mouseKey(ev); ok {
+					ev = key
+				} else if ev.Key == KeyMouseLeft {
+					steer, steering = game.clickKey(ev.MouseX, ev.MouseY)
+				}
+			}
+			if ev.Type != EventKey && !steering {
+				continue
+			}
+			move := func(versus bool) (int, Direction, bool) {
+				if steering {
+					return 0, steer, true
+				}
+				return keys.Move(ev, versus)
+			}
+			if keys.Has(ev, ActionLayout) {
+				if err := profile.RememberSidebar(toggleLayout()); err != nil {
+					profileErr = err
+				}
+				game.Draw()
+				continue
+			}
+			if keys.Has(ev, ActionScreenshot) && !(game.showSettings && settingsMenu.Listening) {
+				if path, err := saveScreenshot(dirty); err != nil {
+					game.notify("Screenshot not saved: " + err.Error())
+				} else {
+					game.notify("Screenshot saved to " + shortPath(path) + ".{ans,txt}")
+				}
+				game.Draw()
+				continue
+			}
+			if game.showSettings {
+				// The settings menu takes all keys while it is open
+				switch {
+				case settingsMenu.Listening:
+					settingsMenu.bind(ev)
+				case keys.Has(ev, ActionSettings) || ev.Key == KeyEsc:
+					game.showSettings = false
+					err := settingsMenu.save()
+					if game.state == StateMenu {
+						openMenu() // Pick up a new mode for the next game
 					}
-				case termbox.KeyArrowRight:
-					if game.direction != Left {
-						game.direction = Right
+					if err != nil {
+						game.notify("Settings not saved: " + err.Error())
 					}
-				case termbox.KeyArrowDown:
-					if game.direction != Up {
-						game.direction = Down
+				case ev.Key == KeyEnter:
+					settingsMenu.enter(game)
+				case ev.Key == KeyBackspace2 && settingsMenu.Row == settingsKeys:
+					settingsMenu.resetKeys()
+				default:
+					if _, dir, ok := keys.Move(ev, false); ok {
+						settingsMenu.move(game, dir)
 					}
-				case termbox.KeyArrowLeft:
-					if game.direction != Right {
-						game.direction = Left
+				}
+				difficulty = config.Speed.adjust(profile.Calibration.adjust(preset))
+				settingsMenu.Speed = difficulty.StartSpeed
+				game.Draw()
+				continue
+			}
+			if cal := game.calibrating; cal != nil {
+				switch {
+				case keys.Has(ev, ActionQuit):
+					game.calibrating = nil
+					resetTicker()
+				case cal.Done():
+					if ev.Key == KeyEnter {
+						// Save the result and use it from the next game on
+						profile.Calibration = &cal.Result
+						if err := profile.Save(); err != nil {
+							profileErr = err
+						}
+						if aspectRatio == defaultAspect {
+							// Neither the config file nor a flag overrides it
+							aspectRatio, defaultAspect = cal.Result.Aspect, cal.Result.Aspect
+						}
+						difficulty = config.Speed.adjust(cal.Result.adjust(preset))
+						game.calibrating = nil
+						openMenu()
+					}
+				case ev.Key == KeyEnter:
+					cal.Confirm()
+				default:
+					if _, dir, ok := keys.Move(ev, false); ok && (dir == Left || dir == Right) {
+						cal.Resize(map[Direction]int{Left: -1, Right: 1}[dir])
+					} else {
+						cal.Press(time.Now())
+					}
+				}
+				game.Draw()
+				continue
+			}
+			if game.state == StateMenu {
+				switch {
+				case game.showScores && (ev.Key == KeyArrowUp || ev.Key == KeyArrowDown):
+					game.scrollScores(map[Key]int{KeyArrowUp: -1, KeyArrowDown: 1}[ev.Key])
+				case game.showScores || game.showStats:
+					// Any key closes the high score and stats screens
+					game.showScores, game.showStats = false, false
+				case keys.Has(ev, ActionQuit):
+					return
+				case ev.Key == KeyEnter:
+					switch menu.Selected {
+					case menuAgain:
+						// Set up as last time, then start
+						if last, err := difficultyByName(menu.Last.Difficulty); err == nil {
+							preset = last
+							difficulty = config.Speed.adjust(profile.Calibration.adjust(preset))
+						}
+						settings = menu.Last.Settings
+						play(newGame())
+					case menuNewGame:
+						play(newGame())
+					case menuMode:
+						settings.cycleMode(1)
+						openMenu()
+					case menuBoard:
+						switchBoard(1)
+					case menuScores:
+						game.openScores()
+					case menuStats:
+						game.showStats = true
+					case menuSettings:
+						openSettings()
+					case menuCalibrate:
+						game.calibrating = NewCalibrator()
+						ticker.Start(calibrateTick)
+					case menuQuit:
+						return
+					}
+				default:
+					if _, dir, ok := keys.Move(ev, false); ok {
+						delta := 1
+						if dir == Left {
+							delta = -1
+						}
+						if menu.Selected == menuMode && (dir == Left || dir == Right) {
+							settings.cycleMode(delta)
+							openMenu()
+						} else if menu.Selected == menuBoard && (dir == Left || dir == Right) {
+							switchBoard(delta)
+						} else {
+							menu.move(dir)
+						}
 					}
-				case termbox.KeyEsc:
+				}
+				game.Draw()
+				continue
+			}
+			if game.showLevels {
+				if keys.Has(ev, ActionQuit) {
 					return
+				} else if ev.Key == KeyEnter {
+					level = levels.Level()
+					play(newGame())
+				} else if _, dir, ok := keys.Move(ev, false); ok {
+					levels.move(dir)
 				}
+				game.Draw()
+				continue
+			}
+			if game.puzzle != nil {
+				// Puzzles are turn based and only tick while watching a replay
+				switch {
+				case game.showPuzzles:
+					if keys.Has(ev, ActionQuit) {
+						return
+					} else if ev.Key == KeyEnter {
+						play(newGame())
+					} else if keys.Has(ev, ActionReplay) {
+						if watch, player := puzzles.Watch(); watch != nil {
+							game, playback = watch, player
+							retimePlayback()
+						}
+					} else if _, dir, ok := keys.Move(ev, false); ok {
+						puzzles.move(dir)
+					}
+				case game.watching:
+					// Arrows jump between bookmarks and change the speed;
+					// comma and period step back and forward a tick
+					switch {
+					case keys.Has(ev, ActionQuit) || ev.Key == KeyEnter:
+						ticker.Stop()
+						playback, game.playback = nil, nil
+						game.watching = false
+						game.showPuzzles = true
+					case keys.Has(ev, ActionPause):
+						playback.Paused = !playback.Paused
+						retimePlayback()
+					case ev.Ch == ',' || ev.Ch == '.':
+						step := map[rune]int{',': -1, '.': 1}[ev.Ch]
+						playback.Paused = true
+						playback.Seek(game, game.ticks+step)
+						retimePlayback()
+					default:
+						if _, dir, ok := keys.Move(ev, false); ok {
+							switch dir {
+							case Left:
+								playback.JumpBookmark(game, -1)
+							case Right:
+								playback.JumpBookmark(game, 1)
+							case Up:
+								playback.ChangeSpeed(1)
+							case Down:
+								playback.ChangeSpeed(-1)
+							}
+							retimePlayback()
+						}
+					}
+				case keys.Has(ev, ActionQuit):
+					return
+				case keys.Has(ev, ActionRestart):
+					play(newGame())
+				case keys.Has(ev, ActionUndo):
+					game.undoMove()
+				case game.state == StateGameOver:
+					if ev.Key == KeyEnter {
+						game.showPuzzles = true
+					}
+				default:
+					if _, dir, ok := move(false); ok && game.puzzleKey(dir) {
+						sound.Play(game.sounds)
+						if game.state == StateGameOver {
+							if err := game.recordPuzzle(); err != nil {
+								puzzlesErr = err
+							}
+						}
+					}
+				}
+				game.Draw()
+				continue
+			}
+			if keys.Has(ev, ActionQuit) {
+				return
+			}
+			if keys.Has(ev, ActionSave) && (game.state == StatePlaying || game.state == StatePaused) && !*demo && game.campaign == nil && game.hotseat == nil && game.mode != modeDaily {
+				if saveErr = game.Save(); saveErr == nil {
+					saved = true
+					return
+				}
+				continue
+			}
 
-				// If direction changed between horizontal/vertical, adjust the ticker
-				if directionChanged(oldDirection, game.direction) {
+			switch game.state {
+			case StatePlaying, StatePaused:
+				if v := game.pauseVote; v != nil && !game.showChallenge && (keys.Has(ev, ActionPause) || keys.Has(ev, ActionDecline)) {
+					player, kind := 0, v.pauseMessage(0, time.Now())
+					switch {
+					case keys.Has(ev, ActionDecline) && host == nil:
+						player, kind = 1, msgPauseDecline // On one keyboard player 2 answers player 1
+					case keys.Has(ev, ActionDecline):
+						kind = msgPauseDecline
+					case kind == "" && host == nil:
+						player, kind = 1, msgPauseConfirm
+					}
+					if game.votePause(player, kind, time.Now()) {
+						resetTicker()
+					}
+				} else if keys.Has(ev, ActionPause) {
+					// Stopping the ticker freezes movement and the food timers
+					if game.state == StatePaused {
+						game.state = StatePlaying
+					} else {
+						game.state = StatePaused
+					}
+					game.showChallenge, game.showIntro, game.showCalendar = false, false, false
+					resetTicker()
+				} else if game.state == StatePaused {
+					// Only quitting, restarting, settings and the menu work
+					// while paused. A hot-seat turn can't be restarted or
+					// its settings changed, to keep it fair.
+					switch {
+					case keys.Has(ev, ActionRestart) && game.hotseat == nil:
+						restart()
+					case keys.Has(ev, ActionMenu) && menu != nil:
+						openMenu()
+					case keys.Has(ev, ActionStats) && game.showChallenge && game.dailyLog != nil:
+						game.showCalendar = !game.showCalendar
+					case keys.Has(ev, ActionSettings) && game.hotseat == nil:
+						openSettings()
+					case keys.Has(ev, ActionMute):
+						sound.toggleMute(game)
+					}
+				} else if keys.Has(ev, ActionMute) {
+					sound.toggleMute(game)
+				} else if keys.Has(ev, ActionAutopilot) && game.hotseat == nil {
+					// Hand player 1 over to the bot, or take control back
+					if _, isBot := game.controllers[0].(Bot); isBot {
+						game.setController(0, &Human{})
+					} else {
+						game.setController(0, Bot{})
+					}
+				} else if player, dir, ok := move(game.Versus() && host == nil); ok {
+					// Over the network both sets of keys steer player 1
+					game.press(player, dir)
+					if game.countdown > 0 {
+						draw()
+					}
+					continue
+				}
+			case StateGameOver:
+				switch {
+				case keys.Has(ev, ActionRestart) && game.hotseat == nil:
+					// High score carries over through the leaderboard
+					restart()
+				case keys.Has(ev, ActionMenu) && menu != nil:
+					openMenu()
+				case keys.Has(ev, ActionRewind):
+					game.stepRewind(1)
+				case game.rewind > 0 && (ev.Key == KeyArrowLeft || ev.Key == KeyArrowRight):
+					game.stepRewind(map[Key]int{KeyArrowLeft: 1, KeyArrowRight: -1}[ev.Key])
+				case keys.Has(ev, ActionUndo) && game.undoCrash():
+					resetTicker()
+				case game.showScores && (ev.Key == KeyArrowUp || ev.Key == KeyArrowDown):
+					game.scrollScores(map[Key]int{KeyArrowUp: -1, KeyArrowDown: 1}[ev.Key])
+				case keys.Has(ev, ActionScores):
+					if game.showScores {
+						game.showScores = false
+					} else {
+						game.openScores()
+					}
+					game.showGlobal = false
+				case keys.Has(ev, ActionGlobal) && game.global != nil:
+					game.showGlobal = !game.showGlobal
+					game.showScores = false
+				case keys.Has(ev, ActionStats) && game.countsForStats():
+					game.showGameStats = !game.showGameStats
+					game.showScores, game.showGlobal = false, false
+				case keys.Has(ev, ActionCard):
+					if cardErr = game.saveScorecard(); cardErr == nil {
+						game.cardSaved = true
+					}
+				case keys.Has(ev, ActionSettings) && game.hotseat == nil:
+					openSettings()
+					game.showScores = false
+				case ev.Key == KeyEnter && game.levels != nil:
+					game.showLevels = true
+					game.showScores = false
+				case ev.Key == KeyEnter && game.campaign != nil:
+					// On to the next stage, or another go at this one
+					play(newGame())
+				case ev.Key == KeyEnter && game.hotseat != nil:
+					// On to the next player, or a new round once all have
+					// played
+					if tournament.Done() {
+						nextSeed := *seed
+						if !set["seed"] {
+							nextSeed = rand.Int63n(1e9)
+						}
+						tournament.NextRound(nextSeed)
+					}
+					play(newGame())
+				case keys.Has(ev, ActionMute):
+					sound.toggleMute(game)
+				}
+			}
+			draw()
+		case gs := <-globalScores:
+			// Results for an earlier game are dropped
+			if game.global != nil && game.global.Mine == gs.Mine {
+				game.global = gs
+				game.Draw()
+			}
+		case m := <-remoteInputs:
+			if m.Type != "input" {
+				if game.pauseVote != nil && (game.state == StatePlaying || game.state == StatePaused) && game.votePause(remoteSnake, m.Type, time.Now()) {
+					resetTicker()
+				}
+				draw()
+				break
+			}
+			game.remoteInput(m)
+			if game.countdown > 0 {
+				draw()
+			}
+		case <-remoteGone:
+			host.Left = true
+			game.forfeit()
+			draw()
+		case <-padGone:
+			padGone = nil
+			game.notify("Gamepad unplugged")
+			draw()
+		case <-ticker.C:
+			if game.calibrating != nil {
+				ticker.Next(calibrateTick)
+				game.Draw()
+				continue
+			}
+			if playback != nil {
+				if playback.Step(game) {
+					ticker.Next(playback.StepTime())
+				} else {
 					ticker.Stop()
-					updateInterval = getUpdateInterval(game.direction)
-					ticker = time.NewTicker(updateInterval)
 				}
+				game.Draw()
+				continue
+			}
 
-				if ev.Ch == 'q' {
-					return
-				} else if ev.Ch == 'r' && game.gameOver {
-					// Preserve high score when starting a new game
-					highScore = max(highScore, game.highScore)
-					game = NewGame()
-					game.highScore = highScore
+			// The first tick of a daily challenge takes the day's official
+			// attempt, if it's still there
+			if game.dailyLog != nil && game.daily == nil {
+				game.daily, err = game.dailyLog.Begin(game.challenge, activeProfile, time.Now())
+				if err != nil {
+					dailyErr = err
 				}
 			}
-		case <-ticker.C:
+
+			tickedAt = time.Now()
 			game.Update()
-			game.Draw()
+			if game.daily != nil && !game.daily.Finished {
+				if err := game.dailyLog.Record(game.daily, game.Player().direction, game.Player().score); err != nil {
+					dailyErr = err
+				}
+			}
+			if control != nil {
+				control.Tick(game)
+			}
+			if dashboard != nil {
+				dashboard.Tick(game)
+			}
+			if !*demo {
+				sound.Play(game.sounds)
+			}
+			if game.state == StateGameOver && !recorded {
+				recorded = true
+				if *demo {
+					demoRestart = time.After(demoRestartDelay)
+				}
+				// Hot-seat scores go under the name of whoever's turn it was
+				name := *playerName
+				if game.hotseat != nil {
+					name = game.hotseat.Name()
+					game.recordTurn()
+				}
+				if err := game.recordScore(name); err != nil {
+					scoresErr = err
+				}
+				if game.daily != nil {
+					if err := game.dailyLog.Finish(game.daily, game.Player().score); err != nil {
+						dailyErr = err
+					}
+				}
+				if score, ok := game.globalScore(name); ok && leaderboard != nil && !*demo {
+					game.global = &GlobalScores{Loading: true, Mine: score}
+					submitGlobalScore(leaderboard, score, globalScores)
+				}
+				// The settings menu can turn telemetry on and off mid-session
+				if r, ok := game.telemetryReport(); ok && reports != nil && config.Telemetry.Enabled && !*demo {
+					reports.send(r)
+				}
+				if err := game.recordLevel(); err != nil {
+					levelsErr = err
+				}
+				if err := game.recordCampaign(); err != nil {
+					profileErr = err
+				}
+				if !*demo {
+					session.record(game)
+					if game.speedrun != nil && !game.botAssisted {
+						if err := splits.Record(game.speedrun); err != nil {
+							splitsErr = err
+						}
+					}
+					if ghosts != nil && game.replay != nil && game.puzzle == nil && !game.botAssisted {
+						if err := ghosts.Record(ghostKey(game, settings), game); err != nil {
+							ghostsErr = err
+						}
+					}
+					if game.countsForStats() {
+						if err := lifetime.Record(game.gameStats()); err != nil {
+							statsErr = err
+						}
+					}
+					if err := profile.Played(time.Now()); err != nil {
+						profileErr = err
+					}
+					session.Streak = profile.Streak
+				}
+			}
+			// The next tick is due after the interval for the new level and
+			// heading, and the game ending stops the ticks
+			if game.state == StatePlaying {
+				updateInterval = game.updateInterval()
+				ticker.Next(updateInterval)
+			} else {
+				resetTicker()
+			}
+			draw()
+		case <-frameTicker.C:
+			if playback != nil || !game.smoothing() {
+				continue
+			}
+			// Only a frame that moves the snakes on needs drawing
+			shown := game.halfStepped()
+			game.motion = float64(time.Since(tickedAt)) / float64(updateInterval)
+			if game.halfStepped() != shown {
+				game.Draw()
+			}
 		}
 	}
 }
 
-// Helper function to check if direction changed between horizontal and vertical
-func directionChanged(old, new Direction) bool {
-	return (old == Up || old == Down) != (new == Up || new == Down)
-}
-
-// Get the appropriate update interval based on direction
-func getUpdateInterval(dir Direction) time.Duration {
-	if dir == Left || dir == Right {
-		// Horizontal movement
-		return time.Duration(baseSpeed) * time.Millisecond
-	} else {
-		// Vertical movement - adjust for aspect ratio
-		return time.Duration(float64(baseSpeed)*aspectRatio) * time.Millisecond
-	}
-}
+// How long a finished demo game stays on screen before the next one starts
+const demoRestartDelay = 3 * time.Second
 
 // Helper function to get the maximum of two integers
 func max(a, b int) int {
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Title menu items, in display order
+const (
+	menuAgain = iota // Only shown once a game has been played
+	menuNewGame
+	menuMode
+	menuBoard // Left out when a level fixes the board
+	menuScores
+	menuStats
+	menuSettings
+	menuCalibrate
+	menuQuit
+	menuItems // Number of items
+)
+
+// TitleMenu is the menu shown before the first game and between games
+type TitleMenu struct {
+	Selected   int
+	Last       *LastGame // Setup of the last game played, nil if none
+	FixedBoard bool      // Is the board's size set by the level being played?
+}
+
+// Open the title menu, on playing again when there is a last game
+func NewTitleMenu(last *LastGame) *TitleMenu {
+	m := &TitleMenu{Selected: menuNewGame, Last: last}
+	if last != nil {
+		m.Selected = menuAgain
+	}
+	return m
+}
+
+// Items on the menu, in display order
+func (m *TitleMenu) items() []int {
+	first := menuNewGame
+	if m.Last != nil {
+		first = menuAgain
+	}
+	var items []int
+	for i := first; i < menuItems; i++ {
+		if i == menuBoard && m.FixedBoard {
+			continue
+		}
+		items = append(items, i)
+	}
+	return items
+}
+
+// Move the selection up or down
+func (m *TitleMenu) move(dir Direction) {
+	items := m.items()
+	i := slices.Index(items, m.Selected)
+	switch dir {
+	case Up:
+		i = (i + len(items) - 1) % len(items)
+	case Down:
+		i = (i + 1) % len(items)
+	}
+	m.Selected = items[i]
+}
+
+// Draw the title menu over the game area
+func drawTitleMenu(m *TitleMenu, s *Settings) {
+	centerX := boardLeft + 1 + viewWidth/2
+
+	title := "G O - S N A K E"
+	drawCentered(2, title, colorScore|AttrBold)
+
+	again := ""
+	if m.Last != nil {
+		again = "Again: " + m.Last.String()
+	}
+	lines := []string{
+		again,
+		"New Game",
+		fmt.Sprintf("Mode: < %s >", strings.ToUpper(s.Mode)),
+		fmt.Sprintf("Board: < %s >", boardLabel()),
+		"High Scores",
+		"Stats",
+		"Settings",
+		"Calibrate",
+		"Quit",
+	}
+	var shown []string
+	selected := 0
+	for _, item := range m.items() {
+		if item == m.Selected {
+			selected = len(shown)
+		}
+		shown = append(shown, lines[item])
+	}
+	drawList(centerX-8, 5, viewHeight-5, shown, selected, func(i int) { m.Selected = m.items()[i] })
+
+	hint := "Arrows to choose, Enter to select"
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Migration upgrades a file's contents by one format version
+type Migration struct {
+	Note  string                         // What changes, as go-snake migrate lists it
+	Apply func(doc map[string]any) error // Changes the settings or records, nil if only the version does
+}
+
+// FileFormat is a file go-snake keeps from one release to the next, with the
+// steps that bring each older version of it up to date. Files from before
+// formats had versions are version 0.
+//
+// Bump Version whenever a change would lose or misread what an older file
+// holds: a renamed or moved key, a value with a new meaning. Add the step
+// from the old version to Steps at the same time, so older files are
+// upgraded as they're loaded and by go-snake migrate.
+type FileFormat struct {
+	Name    string
+	Version int         // Version written now
+	Steps   []Migration // Steps[i] upgrades version i to i+1
+}
+
+// Formats of the files go-snake migrate upgrades
+var (
+	configFormat = FileFormat{Name: "config", Version: 1, Steps: []Migration{
+		{Note: "add a format version"},
+	}}
+	scoresFormat = FileFormat{Name: "high scores", Version: 1, Steps: []Migration{
+		{Note: "add a format version, so an older go-snake won't overwrite it"},
+	}}
+	statsFormat = FileFormat{Name: "stats", Version: 1, Steps: []Migration{
+		{Note: "add a format version, so an older go-snake won't overwrite it"},
+	}}
+)
+
+// The version a decoded file says it is, 0 if it doesn't say
+func docVersion(doc map[string]any) int {
+	switch v := doc["version"].(type) {
+	case float64: // JSON
+		return int(v)
+	case int64: // TOML
+		return int(v)
+	}
+	return 0
+}
+
+// Check a decoded file's version, refusing one newer than this go-snake
+// knows, since rewriting it could drop what it doesn't understand
+func (f *FileFormat) check(doc map[string]any) (int, error) {
+	version := docVersion(doc)
+	if version < 0 || version > f.Version {
+		return 0, fmt.Errorf("%s format version %d is newer than this go-snake knows (%d); update it", f.Name, version, f.Version)
+	}
+	return version, nil
+}
+
+// Bring a decoded file up to date, returning the steps taken and whether
+// any of them changed more than the version
+func (f *FileFormat) upgrade(doc map[string]any) (steps []Migration, changed bool, err error) {
+	version, err := f.check(doc)
+	if err != nil {
+		return nil, false, err
+	}
+	steps = f.Steps[version:]
+	for _, m := range steps {
+		if m.Apply == nil {
+			continue
+		}
+		if err := m.Apply(doc); err != nil {
+			return nil, false, fmt.Errorf("%s: %s: %w", f.Name, m.Note, err)
+		}
+		changed = true
+	}
+	if len(steps) > 0 {
+		doc["version"] = f.Version
+	}
+	return steps, changed, nil
+}
+
+// Bring a JSON file's contents up to date as it's loaded. They're left as
+// they are when only the version would change.
+func (f *FileFormat) upgradeJSON(data []byte) ([]byte, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	_, changed, err := f.upgrade(doc)
+	if err != nil || !changed {
+		return data, err
+	}
+	return json.Marshal(doc)
+}
+
+// Bring a TOML file's contents up to date as it's loaded, like upgradeJSON
+func (f *FileFormat) upgradeTOML(data []byte) ([]byte, error) {
+	doc := map[string]any{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, err
+	}
+	_, changed, err := f.upgrade(doc)
+	if err != nil || !changed {
+		return data, err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Matches the version line at the top of a TOML file
+var tomlVersionLine = regexp.MustCompile(`(?m)^version\s*=.*$`)
+
+// Write a new version into a TOML file's text, keeping its comments and
+// layout
+func stampTOML(data []byte, version int) []byte {
+	line := fmt.Sprintf("version = %d", version)
+	if tomlVersionLine.Match(data) {
+		return tomlVersionLine.ReplaceAll(data, []byte(line))
+	}
+	return append([]byte(line+"\n\n"), data...)
+}
+
+// Upgrade one file on disk, first copying it to a backup named after the
+// version it was. Reports what it did, or with dryRun what it would do.
+func migrateFile(f *FileFormat, path string, isTOML, dryRun bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Printf("%s: no file at %s\n", f.Name, path)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	doc := map[string]any{}
+	if isTOML {
+		_, err = toml.Decode(string(data), &doc)
+	} else {
+		err = json.Unmarshal(data, &doc)
+	}
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", path, err)
+	}
+	from := docVersion(doc)
+	steps, changed, err := f.upgrade(doc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(steps) == 0 {
+		fmt.Printf("%s: %s is up to date (version %d)\n", f.Name, path, from)
+		return nil
+	}
+	fmt.Printf("%s: upgrading %s from version %d to %d\n", f.Name, path, from, f.Version)
+	for _, m := range steps {
+		fmt.Printf("  - %s\n", m.Note)
+	}
+	if dryRun {
+		return nil
+	}
+
+	var upgraded []byte
+	switch {
+	case isTOML && !changed:
+		upgraded = stampTOML(data, f.Version)
+	case isTOML:
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+			return err
+		}
+		upgraded = buf.Bytes()
+		fmt.Println("  comments aren't carried over; the backup still has them")
+	default:
+		if upgraded, err = json.MarshalIndent(doc, "", "  "); err != nil {
+			return err
+		}
+	}
+
+	// Never overwrite an earlier backup: it may be the only copy left
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	b, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if errors.Is(err, fs.ErrExist) {
+		return fmt.Errorf("backup %s already exists; move it out of the way first", backup)
+	} else if err != nil {
+		return err
+	}
+	if _, err := b.Write(data); err != nil {
+		b.Close()
+		return err
+	}
+	if err := b.Close(); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(path, upgraded, info.Mode().Perm()); err != nil {
+		return err
+	}
+	fmt.Printf("  backed up to %s\n", backup)
+	return nil
+}
+
+// Run the migrate subcommand: upgrade the config, high score and stats
+// files to the current formats
+func migrateCommand(args []string) int {
+	flags := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-snake migrate [-config path] [-dry-run]")
+		flags.PrintDefaults()
+	}
+	configPath := flags.String("config", defaultConfigPath(), "config file to upgrade")
+	dryRun := flags.Bool("dry-run", false, "list what would change without writing anything")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+
+	files := []struct {
+		format *FileFormat
+		path   func() (string, error)
+		isTOML bool
+	}{
+		{&configFormat, func() (string, error) { return *configPath, nil }, true},
+		{&scoresFormat, scoresPath, false},
+		{&statsFormat, statsPath, false},
+	}
+	status := 0
+	for _, file := range files {
+		path, err := file.path()
+		if err == nil && path == "" {
+			continue
+		}
+		if err == nil {
+			err = withFileLock(path, func() error {
+				return migrateFile(file.format, path, file.isTOML, *dryRun)
+			})
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			status = 1
+		}
+	}
+	return status
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"time"
+)
+
+// Timed and survival mode constants
+const (
+	timeAttackLength    = 2 * time.Minute  // Length of a timed game
+	hazardInterval      = 5 * time.Second  // Time between obstacles in survival mode
+	hazardRampUp        = time.Minute      // Survival adds one more obstacle per wave this often
+	hazardHeadClearance = 4                // Obstacles keep at least this far from snake heads
+	timeWarning         = 10 * time.Second // Timer turns red with this much left
+)
+
+// Apply the rules of timed, survival, arcade, royale, tron and territory
+// games after each move
+func (g *Game) updateMode() {
+	if g.state != StatePlaying {
+		return
+	}
+	switch g.mode {
+	case modeTimed:
+		if g.clock >= timeAttackLength {
+			g.timeUp()
+		}
+	case modeSurvival:
+		// A wave of obstacles each interval, growing as the game goes on
+		for g.clock >= g.nextHazard {
+			g.nextHazard += hazardInterval
+			for i := 0; i <= int(g.clock/hazardRampUp); i++ {
+				g.placeHazard()
+			}
+		}
+	case modeArcade:
+		g.updatePortals()
+	case modeRoyale:
+		g.updateArena()
+	case modeTron:
+		// A light cycle scores the length of the trail it has laid
+		for _, s := range g.snakes {
+			s.score = len(s.body) - g.startLength
+		}
+	case modeTerritory:
+		g.payTerritory()
+		if g.clock >= territoryLength {
+			g.timeUp()
+		}
+	}
+}
+
+// Set up a new game for a mode. Light cycles don't eat, so tron games are
+// cleared of food.
+func (g *Game) setMode(mode string) {
+	g.mode = mode
+	if mode == modeTron {
+		g.foods = nil
+	}
+}
+
+// The bot a second snake is played by in modes that need one, when there's
+// no second player, or nil in modes played alone
+func rivalBot(mode string) Player {
+	switch mode {
+	case modeTerritory:
+		return PainterBot{}
+	case modeTron:
+		return Bot{} // With no food to go for, it keeps to the most open space
+	}
+	return nil
+}
+
+// End a timed or territory game. The best score wins in versus mode.
+func (g *Game) timeUp() {
+	g.endGame()
+	g.winner = -1
+	best := -1
+	for i, s := range g.snakes {
+		if s.score > best {
+			best, g.winner = s.score, i
+		} else if s.score == best {
+			g.winner = -1
+		}
+	}
+}
+
+// Turn a random free cell away from every snake's head into an obstacle
+func (g *Game) placeHazard() {
+	var free []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Point{X: x, Y: y}
+			if g.walls[p] || g.isPortal(p) || g.occupied(p) || g.hasFood(p) || g.nearHead(p) || g.nearHazard(p) {
+				continue
+			}
+			free = append(free, p)
+		}
+	}
+	if len(free) == 0 {
+		return
+	}
+	if g.walls == nil {
+		g.walls = make(map[Point]bool)
+	}
+	g.walls[free[g.rng.Intn(len(free))]] = true
+}
+
+// Check whether a cell is too close to a live snake's head for an obstacle
+func (g *Game) nearHead(p Point) bool {
+	for _, s := range g.snakes {
+		if s.alive && g.distance(s.Head(), p) < hazardHeadClearance {
+			return true
+		}
+	}
+	return false
+}
+
+// Draw the time left in a timed game, or until the next obstacles in
+// survival or the arena closing in royale, or how a territory game stands,
+// at the top of the sidebar
+func drawModeStatus(sb *Sidebar, g *Game) {
+	switch g.mode {
+	case modeTimed:
+		left := max(int((timeAttackLength-g.clock+time.Second-1)/time.Second), 0)
+		fg := colorScore | AttrBold
+		if time.Duration(left)*time.Second <= timeWarning {
+			fg = colorFood | AttrBold
+		}
+		sb.Textf(fg, "TIME: %d:%02d", left/60, left%60)
+	case modeSurvival:
+		secs := int((g.nextHazard - g.clock + time.Second - 1) / time.Second)
+		sb.Textf(colorText, "OBSTACLES IN: %ds", secs)
+	case modeRoyale:
+		drawArenaStatus(sb, g)
+	case modeTerritory:
+		drawTerritoryStatus(sb, g)
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Modifier constants
+const (
+	fogRadius      = 5 // Cells around the head that stay visible in fog
+	weeklyModCount = 2 // Modifiers rolled for each weekly challenge
+)
+
+// Modifiers is a set of rule mutations applied on top of a game mode.
+// Modifiers compose by OR-ing them together.
+type Modifiers uint
+
+const (
+	ModMirror     Modifiers = 1 << iota // Direction keys are reversed
+	ModFog                              // Only the area around the head is visible
+	ModFastDecay                        // Food expires twice as fast
+	ModScoreDecay                       // Score drains away, faster for longer snakes
+	ModHazards                          // Moving hazards appear during play
+)
+
+// Every modifier with its display text, in announcement order. Weekly
+// challenges roll from weeklyPools, not from this.
+var modifierInfo = []struct {
+	mod         Modifiers
+	name        string
+	description string
+}{
+	{ModMirror, "Mirror", "Controls are reversed"},
+	{ModFog, "Fog", "You only see near your head"},
+	{ModFastDecay, "Fast decay", "Food spoils twice as fast"},
+	{ModScoreDecay, "Score decay", "Your score drains as you grow"},
+	{ModHazards, "Hazards", "Rivals, lasers and hot zones appear"},
+}
+
+// Has reports whether all of the given modifiers are set
+func (m Modifiers) Has(mod Modifiers) bool {
+	return m&mod == mod
+}
+
+// Names of the active modifiers
+func (m Modifiers) Names() []string {
+	var names []string
+	for _, info := range modifierInfo {
+		if m.Has(info.mod) {
+			names = append(names, info.name)
+		}
+	}
+	return names
+}
+
+func (m Modifiers) String() string {
+	if m == 0 {
+		return "none"
+	}
+	return strings.Join(m.Names(), ", ")
+}
+
+// Apply mirror controls to a requested direction
+func (m Modifiers) steer(dir Direction) Direction {
+	if m.Has(ModMirror) {
+		return (dir + 2) % 4
+	}
+	return dir
+}
+
+// Adjust a freshly rolled food lifetime
+func (m Modifiers) foodTime(ticks int) int {
+	if m.Has(ModFastDecay) {
+		return max(ticks/2, 1)
+	}
+	return ticks
+}
+
+// Check whether a cell is hidden by fog
+func (m Modifiers) hidden(head, p Point) bool {
+	if !m.Has(ModFog) {
+		return false
+	}
+	dx, dy := head.X-p.X, head.Y-p.Y
+	return dx*dx+dy*dy > fogRadius*fogRadius
+}
+
+// Return the ISO week a weekly challenge belongs to, e.g. "2026-W42".
+// Weeks are counted in UTC like the daily challenge.
+func weeklyID(now time.Time) string {
+	year, week := now.UTC().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// Derive the RNG seed for a weekly challenge
+func weeklySeed(id string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("go-snake weekly " + id))
+	return int64(h.Sum64() >> 1)
+}
+
+// The modifiers weekly challenges are rolled from, by the first rules
+// version to use each list. A list is frozen once released, since adding
+// to it or reordering it hands weeks already played other modifiers: a new
+// modifier joins the weekly roll with a new rules version and a new list.
+var weeklyPools = []struct {
+	rules int
+	mods  []Modifiers
+}{
+	{1, []Modifiers{ModMirror, ModFog, ModFastDecay, ModScoreDecay, ModHazards}},
+}
+
+// Return the modifiers weekly challenges are rolled from under a rules
+// version
+func weeklyPool(rules int) []Modifiers {
+	pool := weeklyPools[0].mods
+	for _, p := range weeklyPools {
+		if p.rules <= rules {
+			pool = p.mods
+		}
+	}
+	return pool
+}
+
+// Roll the modifiers for a weekly challenge from its seed
+func weeklyModifiers(seed int64, rules int) Modifiers {
+	r := rand.New(rand.NewSource(seed))
+	pool := weeklyPool(rules)
+	var mods Modifiers
+	for _, i := range r.Perm(len(pool))[:weeklyModCount] {
+		mods |= pool[i]
+	}
+	return mods
+}
+
+// Draw the weekly challenge announcement over the game area
+func drawChallenge(id string, mods Modifiers) {
+	title := "WEEKLY CHALLENGE " + id
+	drawCentered(3, title, ColorYellow|AttrBold)
+
+	y := 5
+	for _, info := range modifierInfo {
+		if !mods.Has(info.mod) {
+			continue
+		}
+		line := info.name + ": " + info.description
+		drawCentered(y, line, ColorCyan)
+		y++
+	}
+
+	start := fmt.Sprintf("Press %s to start", keysHint(ActionPause))
+	drawCentered(y+1, start, ColorWhite)
+}
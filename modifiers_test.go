@@ -0,0 +1,23 @@
+package main
+
+import "testing"
+
+// A week keeps the modifiers it was rolled with under the rules it was
+// played under, whatever modifiers are added to the game after it
+func TestWeeklyModifiersFrozen(t *testing.T) {
+	weeks := []struct {
+		id   string
+		mods Modifiers
+	}{
+		{"2024-W01", ModFastDecay | ModScoreDecay},
+		{"2026-W42", ModMirror | ModHazards},
+		{"2027-W09", ModMirror | ModScoreDecay},
+	}
+	for _, w := range weeks {
+		for _, rules := range []int{1, 4} {
+			if got := weeklyModifiers(weeklySeed(w.id), rules); got != w.mods {
+				t.Errorf("week %s under rules %d rolled %v, want %v", w.id, rules, got, w.mods)
+			}
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"math"
+)
+
+// Mouse settings, from the config file or -mouse
+const (
+	mouseOff   = "off"
+	mouseMenus = "menus" // Clicks pick menu items and the wheel scrolls
+	mouseSteer = "steer" // Clicks on the board steer the snake as well
+)
+
+// How the game uses the mouse
+var mouseMode = mouseMenus
+
+// Check whether a mouse setting is known
+func validMouse(mode string) bool {
+	return mode == mouseOff || mode == mouseMenus || mode == mouseSteer
+}
+
+// ListArea is where a list of menu lines is on screen, so a click can pick
+// one of them
+type ListArea struct {
+	Rect            // Rows the list takes, one line to a row
+	First int       // Line in the top row
+	Count int       // Lines in all
+	Pick  func(int) // Selects a line
+}
+
+// The list drawn in the last frame, nil if there wasn't one
+var shownList *ListArea
+
+// The line of the list a click at x, y lands on
+func (l *ListArea) lineAt(x, y int) (int, bool) {
+	if x < l.X || x >= l.X+l.W || y < l.Y || y >= l.Y+l.H {
+		return 0, false
+	}
+	i := l.First + y - l.Y
+	return i, i < l.Count
+}
+
+// Turn a click or a turn of the wheel into the key press it stands for on
+// the screen that's up, with ok false when it stands for none. Clicking a
+// list line selects it and presses Enter, and the wheel presses the up and
+// down arrows. Clicks that steer the snake are left to clickKey.
+func (g *Game) mouseKey(ev Event) (Event, bool) {
+	key := Event{Type: EventKey}
+	switch ev.Key {
+	case KeyMouseWheelUp:
+		key.Key = KeyArrowUp
+		return key, true
+	case KeyMouseWheelDown:
+		key.Key = KeyArrowDown
+		return key, true
+	case KeyMouseLeft:
+	default:
+		return key, false
+	}
+
+	switch {
+	case shownList != nil:
+		i, ok := shownList.lineAt(ev.MouseX, ev.MouseY)
+		if !ok {
+			return key, false
+		}
+		shownList.Pick(i)
+		key.Key = KeyEnter
+	case g.state == StateMenu && (g.showScores || g.showStats):
+		// Any key closes these screens
+		key.Key = KeyEsc
+	default:
+		return key, false
+	}
+	return key, true
+}
+
+// The direction key that turns player 1 towards a cell clicked on the
+// board, with ok false when the click isn't on the board or there's no
+// key for it. The click is split into quarters about the head, allowing
+// for cells being taller than they're wide, and goes the way of the
+// quarter it's in whatever the key does to the snake's heading: with
+// relative steering, clicking to the snake's left turns it left.
+func (g *Game) clickKey(x, y int) (Direction, bool) {
+	area := boardArea()
+	if mouseMode != mouseSteer || g.state != StatePlaying || g.calibrating != nil || g.showSettings {
+		return 0, false
+	}
+	if x < area.X || x >= area.X+area.W || y < area.Y || y >= area.Y+area.H {
+		return 0, false
+	}
+	head := g.Player().Head()
+	dx, dy := float64(x-area.X-head.X+cameraX), float64(y-area.Y-head.Y+cameraY)*aspectRatio
+	if dx == 0 && dy == 0 {
+		return 0, false
+	}
+	toward := func(horizontal bool) (Direction, bool) {
+		switch {
+		case horizontal && dx != 0:
+			return map[bool]Direction{true: Right, false: Left}[dx > 0], true
+		case !horizontal && dy != 0:
+			return map[bool]Direction{true: Down, false: Up}[dy > 0], true
+		}
+		return 0, false
+	}
+	horizontal := math.Abs(dx) >= math.Abs(dy)
+	want, ok := toward(horizontal)
+
+	// Behind the snake, it turns whichever way is nearer the click instead
+	heading := g.Player().direction
+	if h, isHuman := g.controllers[0].(*Human); isHuman {
+		heading = h.heading(heading)
+	}
+	if want == heading.Opposite() {
+		want, ok = toward(!horizontal)
+	}
+	if !ok {
+		return 0, false
+	}
+	for _, key := range []Direction{Up, Right, Down, Left} {
+		if dir, ok := g.keyHeading(0, key); ok && dir == want {
+			return key, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Direction names for the move log
+var directionNames = map[Direction]string{Up: "up", Right: "right", Down: "down", Left: "left"}
+
+// MoveLog writes a plain line for everything that happens in a game: turns,
+// food eaten, notices and the end. It is meant for screen readers and
+// other tools to follow a game without reading the screen, and for
+// looking back over one afterwards.
+type MoveLog struct {
+	w   io.WriteCloser
+	err error // First write that failed
+}
+
+// Open a move log: a file, appended to, or an open file descriptor given
+// as "fd:N"
+func OpenMoveLog(target string) (*MoveLog, error) {
+	if fd, ok := strings.CutPrefix(target, "fd:"); ok {
+		n, err := strconv.Atoi(fd)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("bad file descriptor %q", fd)
+		}
+		return &MoveLog{w: os.NewFile(uintptr(n), target)}, nil
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &MoveLog{w: f}, nil
+}
+
+// Write a line, keeping the first error for Close to report
+func (l *MoveLog) Printf(format string, args ...any) {
+	if _, err := fmt.Fprintf(l.w, format+"\n", args...); err != nil && l.err == nil {
+		l.err = err
+	}
+}
+
+// Close the log, reporting the first write that failed
+func (l *MoveLog) Close() error {
+	err := l.w.Close()
+	if l.err != nil {
+		return l.err
+	}
+	return err
+}
+
+// Log what just happened in the game, if it is being logged. Lines start
+// with the tick, and in versus mode say which snake they are about.
+func (g *Game) logMove(s *Snake, format string, args ...any) {
+	if g.moveLog == nil {
+		return
+	}
+	prefix := fmt.Sprintf("tick %d: ", g.ticks)
+	if s != nil && g.Versus() {
+		prefix += s.name + " "
+	}
+	g.moveLog.Printf(prefix+format, args...)
+}
+
+// Log a snake's turn, left or right of the way it was heading
+func (g *Game) logTurn(s *Snake, from Direction) {
+	turn := "right"
+	if s.direction == from.TurnLeft() {
+		turn = "left"
+	}
+	head := s.Head()
+	g.logMove(s, "turned %s at (%d,%d), heading %s", turn, head.X, head.Y, directionNames[s.direction])
+}
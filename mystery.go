@@ -0,0 +1,93 @@
+package main
+
+// Mystery box constants
+const (
+	mysteryJackpot     = 10 // Points the jackpot is worth
+	mysteryRevealTicks = 4  // Ticks the reveal spins through outcomes before it settles
+	mysteryRules       = 2  // First rules version with mystery boxes
+)
+
+// When mystery boxes turn up, from the config's food.mystery
+const (
+	mysteryOn     = "on"     // In every game
+	mysteryCasual = "casual" // Except in competitive games
+	mysteryOff    = "off"    // Never
+)
+
+var mysteryFoods = mysteryOn
+
+func validMystery(mode string) bool {
+	return mode == mysteryOn || mode == mysteryCasual || mode == mysteryOff
+}
+
+// MysteryOutcome is one of the things a mystery box can turn out to be.
+// It gives points, another special food's effect or a board event.
+type MysteryOutcome struct {
+	Label  string // Shown where the box was opened
+	Points int
+	Food   string      // Special food whose effect it has, empty for none
+	Event  RandomEvent // Board event it starts, nil for none
+	Weight int         // Relative chance among outcomes
+}
+
+// What a mystery box can hold. Add a row to add an outcome.
+var mysteryOutcomes = []MysteryOutcome{
+	{Label: "JACKPOT", Points: mysteryJackpot, Weight: 2},
+	{Label: "SHRINK", Food: "scissors", Weight: 2},
+	{Label: "FAST", Food: "chili", Weight: 2},
+	{Label: "SLOW", Food: "snail", Weight: 2},
+	{Label: "FRENZY", Event: FoodFrenzy{}, Weight: 1},
+}
+
+// Competitive games are those played against someone else's score: versus,
+// hot-seat and weekly challenge games
+func (g *Game) Competitive() bool {
+	return g.Versus() || g.hotseat != nil || g.challenge != ""
+}
+
+// Can mystery boxes turn up in this game?
+func (g *Game) mysteryAllowed() bool {
+	switch {
+	case g.rules < mysteryRules, mysteryFoods == mysteryOff:
+		return false
+	case mysteryFoods == mysteryCasual:
+		return !g.Competitive()
+	}
+	return true
+}
+
+// Can an outcome happen now? Only one board event runs at a time.
+func (g *Game) mysteryPossible(o *MysteryOutcome) bool {
+	return o.Event == nil || g.event == nil
+}
+
+// Open a mystery box a snake just ate, picking what's in it and revealing
+// it where the snake's head is
+func (g *Game) openMystery(s *Snake) {
+	total := 0
+	for i := range mysteryOutcomes {
+		if g.mysteryPossible(&mysteryOutcomes[i]) {
+			total += mysteryOutcomes[i].Weight
+		}
+	}
+	r := g.rng.Intn(total)
+	for i := range mysteryOutcomes {
+		o := &mysteryOutcomes[i]
+		if !g.mysteryPossible(o) {
+			continue
+		}
+		if r < o.Weight {
+			g.popups = append(g.popups, Popup{At: s.Head(), Text: o.Label, Fg: ColorMagenta, Spin: mysteryRevealTicks})
+			switch {
+			case o.Food != "":
+				g.eatSpecialFood(s, specialFoodByName(o.Food))
+			case o.Event != nil:
+				g.startEvent(o.Event)
+			}
+			g.award(s, o.Points)
+			g.logMove(s, "opened a mystery box: %s", o.Label)
+			return
+		}
+		r -= o.Weight
+	}
+}
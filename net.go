@@ -0,0 +1,558 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Network play constants
+const (
+	netHandshakeTimeout = 10 * time.Second // Longest wait for the other side to say hello
+	remoteQueueLength   = 3                // Turns held for the remote snake, oldest first
+	remoteSnake         = 1                // The joining player steers snake 2
+)
+
+// netMessage is one line of the network protocol. The joining player says
+// hello, the host welcomes them with the board, then the host streams a
+// frame each tick and the client sends inputs.
+type netMessage struct {
+	Type   string    `json:"type"` // hello, welcome, frame, input or a pause vote message
+	Name   string    `json:"name,omitempty"`
+	Width  int       `json:"width,omitempty"`
+	Height int       `json:"height,omitempty"`
+	Mode   string    `json:"mode,omitempty"`
+	Frame  *NetFrame `json:"frame,omitempty"`
+	Dir    Direction `json:"dir,omitempty"`
+	Dash   bool      `json:"dash,omitempty"` // Input was a double tap
+}
+
+// Pause vote messages, sent by the joining player: asking to pause,
+// agreeing to or refusing the host's request, and resuming an agreed pause.
+// The host counts them, and frames carry how the vote stands.
+const (
+	msgPauseRequest = "pause_request"
+	msgPauseConfirm = "pause_confirm"
+	msgPauseDecline = "pause_decline"
+	msgPauseResume  = "pause_resume"
+)
+
+// NetFrame is the state of the host's board after a tick. Frames only
+// carry what changed since the last one sent, apart from the first frame of
+// each game, which is complete.
+type NetFrame struct {
+	Game      int           `json:"game"` // Counts games, so the client knows to start afresh
+	State     State         `json:"state"`
+	Winner    int           `json:"winner"`
+	Level     int           `json:"level"`
+	Clock     time.Duration `json:"clock"`
+	Countdown time.Duration `json:"countdown,omitempty"`
+	Closed    int           `json:"closed,omitempty"` // Rings the arena has closed in royale mode
+	Seed      int64         `json:"seed"`
+	Snakes    []netSnake    `json:"snakes"`
+	Hazards   []Hazard      `json:"hazards,omitempty"`
+	Board     *netBoard     `json:"board,omitempty"` // Nil when unchanged
+	Paint     []int8        `json:"paint,omitempty"` // Territory held, by cell; only when Painted won't do
+	Painted   []netPaint    `json:"painted,omitempty"`
+	Vote      *netVote      `json:"vote,omitempty"`
+}
+
+// A cell of territory that changed hands since the last frame sent
+type netPaint struct {
+	Cell   int  `json:"cell"` // Index into the board, row by row
+	Holder int8 `json:"holder"`
+}
+
+// How the pause vote stands on the host
+type netVote struct {
+	State     PauseVoteState `json:"state"`
+	Requester int            `json:"requester"`
+	Left      time.Duration  `json:"left,omitempty"` // Until a pending request lapses
+}
+
+// One snake in a frame. A snake that moved one cell only sends its new head
+// and length; otherwise it sends its whole body, or nothing if it hasn't
+// moved at all.
+type netSnake struct {
+	Body      []Point        `json:"body,omitempty"`
+	Head      *Point         `json:"head,omitempty"`
+	Len       int            `json:"len"`
+	Direction Direction      `json:"direction"`
+	Score     int            `json:"score"`
+	Alive     bool           `json:"alive"`
+	Effects   map[Effect]int `json:"effects,omitempty"`
+}
+
+// Food, walls and portals, which change less often than the snakes
+type netBoard struct {
+	Foods        []savedFood  `json:"foods"`
+	FrenzyFood   []FrenzyFood `json:"frenzy_food,omitempty"`
+	Crumbs       []Crumb      `json:"crumbs,omitempty"`
+	Walls        []Point      `json:"walls,omitempty"`
+	Portals      []Portal     `json:"portals,omitempty"`
+	NextFoodType int          `json:"next_food_type"`
+}
+
+// Take a complete frame of the game
+func newNetFrame(g *Game, game int) *NetFrame {
+	f := &NetFrame{
+		Game:      game,
+		State:     g.state,
+		Winner:    g.winner,
+		Level:     g.level,
+		Clock:     g.clock,
+		Countdown: g.countdown,
+		Closed:    g.closed,
+		Seed:      g.seed,
+		Hazards:   slices.Clone(g.hazards),
+		Board:     &netBoard{FrenzyFood: slices.Clone(g.frenzyFood), Crumbs: slices.Clone(g.crumbs), Portals: slices.Clone(g.portals), NextFoodType: g.nextFoodType},
+		Paint:     slices.Clone(g.paint),
+	}
+	if v := g.pauseVote; v != nil {
+		f.Vote = &netVote{State: v.State(), Requester: v.requester(), Left: v.Remaining(time.Now())}
+	}
+	for _, s := range g.snakes {
+		f.Snakes = append(f.Snakes, netSnake{
+			Body:      slices.Clone(s.body),
+			Len:       len(s.body),
+			Direction: s.direction,
+			Score:     s.score,
+			Alive:     s.alive,
+			Effects:   maps.Clone(s.effects),
+		})
+	}
+	for _, food := range g.foods {
+		saved := savedFood{At: food.At, Type: food.Type, Timer: food.Timer}
+		if food.Special != nil {
+			saved.Special = food.Special.Name
+		}
+		f.Board.Foods = append(f.Board.Foods, saved)
+	}
+	for p := range g.walls {
+		f.Board.Walls = append(f.Board.Walls, p)
+	}
+	slices.SortFunc(f.Board.Walls, func(a, b Point) int {
+		return cmp.Or(a.Y-b.Y, a.X-b.X)
+	})
+	return f
+}
+
+// Cut a complete frame down to what changed since the last one sent
+func (f *NetFrame) diff(last *NetFrame) *NetFrame {
+	if last == nil || last.Game != f.Game || len(last.Snakes) != len(f.Snakes) {
+		return f
+	}
+	d := *f
+	d.Snakes = make([]netSnake, len(f.Snakes))
+	for i, s := range f.Snakes {
+		d.Snakes[i] = s.diff(last.Snakes[i].Body)
+	}
+	if reflect.DeepEqual(f.Board, last.Board) {
+		d.Board = nil
+	}
+	// Territory goes cell by cell against the last frame sent, so frames
+	// skipped on a slow link are caught up rather than lost
+	if len(f.Paint) == len(last.Paint) {
+		d.Paint = nil
+		for i, holder := range f.Paint {
+			if holder != last.Paint[i] {
+				d.Painted = append(d.Painted, netPaint{Cell: i, Holder: holder})
+			}
+		}
+	}
+	return &d
+}
+
+// Cut a snake down to its new head when it moved one cell from old
+func (s netSnake) diff(old []Point) netSnake {
+	body := s.Body
+	switch {
+	case slices.Equal(body, old):
+		s.Body = nil
+	case len(body) > 0 && len(body)-1 <= len(old) && slices.Equal(body[1:], old[:len(body)-1]):
+		s.Head, s.Body = &body[0], nil
+	}
+	return s
+}
+
+// Check a frame's snakes can be applied to the client's copy of the game,
+// so a bad frame from the host can't crash it
+func (f *NetFrame) check(g *Game) error {
+	for i, ns := range f.Snakes {
+		if i >= len(g.snakes) {
+			break
+		}
+		switch {
+		case !ns.Direction.Valid():
+			return fmt.Errorf("snake %d heads %d", i+1, ns.Direction)
+		case ns.Body != nil && len(ns.Body) == 0:
+			return fmt.Errorf("snake %d has no body", i+1)
+		case ns.Body == nil && ns.Head != nil && (ns.Len < 1 || ns.Len > len(g.snakes[i].body)+1):
+			return fmt.Errorf("snake %d grew from %d to %d segments in a move", i+1, len(g.snakes[i].body), ns.Len)
+		}
+	}
+	return nil
+}
+
+// Apply a frame to the client's copy of the game, unless it's one that
+// can't be
+func (f *NetFrame) apply(g *Game) error {
+	if err := f.check(g); err != nil {
+		return err
+	}
+	g.state, g.winner, g.level, g.clock, g.countdown = f.State, f.Winner, f.Level, f.Clock, f.Countdown
+	g.seed, g.closed = f.Seed, f.Closed
+	g.hazards = slices.DeleteFunc(f.Hazards, func(h Hazard) bool { return hazardKindByName(h.Kind) == nil })
+	for i, ns := range f.Snakes {
+		if i >= len(g.snakes) {
+			break
+		}
+		s := g.snakes[i]
+		switch {
+		case ns.Body != nil:
+			s.body = ns.Body
+		case ns.Head != nil:
+			s.body = append([]Point{*ns.Head}, s.body...)[:ns.Len]
+		}
+		s.direction, s.score, s.alive, s.effects = ns.Direction, ns.Score, ns.Alive, ns.Effects
+	}
+	if f.Paint != nil {
+		g.paint = f.Paint
+	}
+	for _, c := range f.Painted {
+		if c.Cell >= 0 && c.Cell < len(g.paint) {
+			g.paint[c.Cell] = c.Holder
+		}
+	}
+	if v := f.Vote; v != nil {
+		if g.pauseVote == nil {
+			g.pauseVote = NewPauseVote(maxPlayers)
+		}
+		g.pauseVote.mirror(v.State, v.Requester, v.Left, time.Now())
+	}
+	if b := f.Board; b != nil {
+		g.foods = nil
+		for _, saved := range b.Foods {
+			g.foods = append(g.foods, Food{At: saved.At, Type: saved.Type, Timer: saved.Timer, Special: specialFoodByName(saved.Special)})
+		}
+		g.frenzyFood, g.crumbs, g.nextFoodType = b.FrenzyFood, b.Crumbs, b.NextFoodType
+		g.walls = make(map[Point]bool, len(b.Walls))
+		for _, p := range b.Walls {
+			g.walls[p] = true
+		}
+		g.portals = b.Portals
+	}
+	return nil
+}
+
+// netConn sends and receives protocol lines over a connection
+type netConn struct {
+	conn net.Conn
+	enc  *json.Encoder
+	dec  *json.Decoder
+}
+
+func newNetConn(conn net.Conn) *netConn {
+	return &netConn{conn: conn, enc: json.NewEncoder(conn), dec: json.NewDecoder(bufio.NewReader(conn))}
+}
+
+func (c *netConn) send(m netMessage) error {
+	return c.enc.Encode(m)
+}
+
+// Receive the next message, which must be of one of the given types
+func (c *netConn) expect(kinds ...string) (netMessage, error) {
+	var m netMessage
+	if err := c.dec.Decode(&m); err != nil {
+		return m, err
+	}
+	if !slices.Contains(kinds, m.Type) {
+		return m, fmt.Errorf("expected %s message, got %q", strings.Join(kinds, " or "), m.Type)
+	}
+	return m, nil
+}
+
+// Remote steers the joining player's snake on the host. Turns are queued
+// rather than the last one winning, so two quick turns that arrive in the
+// same tick over a slow link both happen.
+type Remote struct {
+	queue []Direction
+}
+
+func (r *Remote) Steer(g *Game, snake int) (Direction, bool) {
+	if len(r.queue) == 0 {
+		return 0, false
+	}
+	dir := r.queue[0]
+	r.queue = r.queue[1:]
+	return dir, true
+}
+
+// Queue a turn, dropping the oldest when the queue is full
+func (r *Remote) push(dir Direction) {
+	if len(r.queue) == remoteQueueLength {
+		r.queue = r.queue[1:]
+	}
+	r.queue = append(r.queue, dir)
+}
+
+// Host is the hosting side of a network game
+type Host struct {
+	conn   *netConn
+	Name   string          // The joining player's name
+	Inputs chan netMessage // Inputs and pause votes from the joining player
+	Gone   chan error      // Receives once the joining player leaves
+	Left   bool            // Has the joining player left?
+	frames chan *NetFrame
+	game   int // Games played so far, for telling frames apart
+	once   sync.Once
+}
+
+// Wait on addr for a player to join, and welcome them to a board of the
+// current size and mode
+func HostGame(addr, name string) (*Host, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer ln.Close()
+	fmt.Printf("Waiting for a player to join on %s...\n", ln.Addr())
+	conn, err := ln.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	h := &Host{
+		conn:   newNetConn(conn),
+		Inputs: make(chan netMessage, remoteQueueLength),
+		Gone:   make(chan error, 1),
+		frames: make(chan *NetFrame, 1),
+	}
+	conn.SetDeadline(time.Now().Add(netHandshakeTimeout))
+	hello, err := h.conn.expect("hello")
+	if err == nil {
+		h.Name = hello.Name
+		err = h.conn.send(netMessage{Type: "welcome", Name: name, Width: width, Height: height, Mode: settings.Mode})
+	}
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("player from %s didn't join: %w", conn.RemoteAddr(), err)
+	}
+	conn.SetDeadline(time.Time{})
+	fmt.Printf("%s joined from %s. Starting!\n", h.Name, conn.RemoteAddr())
+
+	go h.read()
+	go h.write()
+	return h, nil
+}
+
+// Pass inputs and pause votes on to the game until the connection drops.
+// Inputs heading nowhere are dropped.
+func (h *Host) read() {
+	for {
+		m, err := h.conn.expect("input", msgPauseRequest, msgPauseConfirm, msgPauseDecline, msgPauseResume)
+		if err != nil {
+			h.leave(err)
+			return
+		}
+		if m.Type == "input" && !m.Dir.Valid() {
+			continue
+		}
+		h.Inputs <- m
+	}
+}
+
+// Send each frame, cut down to what changed since the last one. Only the
+// newest frame waits to be sent, so a slow link skips frames rather than
+// falling behind.
+func (h *Host) write() {
+	var last *NetFrame
+	for f := range h.frames {
+		if err := h.conn.send(netMessage{Type: "frame", Frame: f.diff(last)}); err != nil {
+			h.leave(err)
+			return
+		}
+		last = f
+	}
+}
+
+func (h *Host) leave(err error) {
+	h.once.Do(func() {
+		h.conn.conn.Close()
+		h.Gone <- err
+	})
+}
+
+// NewGame starts numbering frames for a new game
+func (h *Host) NewGame() {
+	h.game++
+}
+
+// Send queues a frame of the game for the joining player
+func (h *Host) Send(g *Game) {
+	if h.Left {
+		return
+	}
+	f := newNetFrame(g, h.game)
+	select {
+	case <-h.frames:
+	default:
+	}
+	h.frames <- f
+}
+
+// Close ends the game for the joining player
+func (h *Host) Close() {
+	h.conn.conn.Close()
+}
+
+// Apply an input from the joining player. Before the start it picks their
+// first move, and a double tap on their heading dashes.
+func (g *Game) remoteInput(m netMessage) {
+	s := g.snakes[remoteSnake]
+	remote, ok := g.controllers[remoteSnake].(*Remote)
+	if !ok || !s.alive {
+		return
+	}
+	if g.countdown > 0 {
+		s.face(m.Dir)
+		return
+	}
+	if m.Dash && m.Dir == s.direction {
+		g.dash(remoteSnake)
+	}
+	remote.push(m.Dir)
+}
+
+// End the game when the joining player leaves, giving it to the host
+func (g *Game) forfeit() {
+	if g.state == StateGameOver {
+		return
+	}
+	g.snakes[remoteSnake].alive, g.snakes[remoteSnake].died = false, causeForfeit
+	g.state, g.winner, g.left = StateGameOver, 0, true
+}
+
+// errHostLeft is returned by runClient when the host ends the game
+var errHostLeft = errors.New("the host left the game")
+
+// Join a hosted game at addr, returning the connection and the welcome
+func JoinGame(addr, name string) (*netConn, netMessage, error) {
+	conn, err := net.DialTimeout("tcp", addr, netHandshakeTimeout)
+	if err != nil {
+		return nil, netMessage{}, err
+	}
+	c := newNetConn(conn)
+	conn.SetDeadline(time.Now().Add(netHandshakeTimeout))
+	err = c.send(netMessage{Type: "hello", Name: name})
+	var welcome netMessage
+	if err == nil {
+		welcome, err = c.expect("welcome")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, welcome, err
+	}
+	conn.SetDeadline(time.Time{})
+	return c, welcome, nil
+}
+
+// Play a joined game: mirror the host's frames and send key presses back.
+// Returns an error if the connection to the host is lost.
+func runClient(c *netConn, welcome netMessage, keys *KeyBindings) error {
+	frames := make(chan *NetFrame)
+	lost := make(chan error, 1)
+	go func() {
+		for {
+			m, err := c.expect("frame")
+			if err != nil {
+				lost <- err
+				return
+			}
+			frames <- m.Frame
+		}
+	}()
+	events := make(chan Event)
+	go func() {
+		for {
+			events <- screen.PollEvent()
+		}
+	}()
+
+	g := NewGame(nil, nil, maxPlayers, 0)
+	g.mode, g.relative, g.remote = welcome.Mode, settings.Relative, true
+	me := &Human{}
+	gameNum := -1
+	g.Draw()
+	for {
+		select {
+		case f := <-frames:
+			if f.Game != gameNum {
+				// A new game: start from the complete first frame
+				gameNum = f.Game
+				g = NewGame(nil, nil, maxPlayers, f.Seed)
+				g.mode, g.relative, g.remote = welcome.Mode, settings.Relative, true
+			}
+			if err := f.apply(g); err != nil {
+				c.conn.Close()
+				return fmt.Errorf("bad frame from the host: %w", err)
+			}
+			g.Draw()
+		case err := <-lost:
+			if errors.Is(err, io.EOF) {
+				return errHostLeft
+			}
+			return fmt.Errorf("lost connection to the host: %w", err)
+		case ev := <-events:
+			if ev.Type == EventResize {
+				fitLayout(ev.Width, ev.Height)
+				g.Draw()
+				continue
+			}
+			if ev.Type != EventKey {
+				continue
+			}
+			if keys.Has(ev, ActionLayout) {
+				toggleLayout()
+				g.Draw()
+				continue
+			}
+			if keys.Has(ev, ActionQuit) {
+				c.conn.Close()
+				return nil
+			}
+			// Pausing is up to the host's vote, which the next frame shows
+			if v := g.pauseVote; v != nil && (keys.Has(ev, ActionPause) || keys.Has(ev, ActionDecline)) {
+				kind := v.pauseMessage(remoteSnake, time.Now())
+				if keys.Has(ev, ActionDecline) {
+					kind = msgPauseDecline
+				}
+				if kind == "" {
+					continue
+				}
+				if err := c.send(netMessage{Type: kind}); err != nil {
+					return fmt.Errorf("lost connection to the host: %w", err)
+				}
+				continue
+			}
+			if _, key, ok := keys.Move(ev, false); ok {
+				if dir, ok := g.keyHeading(remoteSnake, key); ok {
+					dash := me.doubleTap(dir, time.Now()) && dir == g.snakes[remoteSnake].direction
+					if err := c.send(netMessage{Type: "input", Dir: dir, Dash: dash}); err != nil {
+						return fmt.Errorf("lost connection to the host: %w", err)
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"slices"
+	"testing"
+	"time"
+)
+
+// Host a game over a pipe, with the joining side reading and dropping
+// every frame
+func newTestHost(t *testing.T) *Host {
+	t.Helper()
+	ours, theirs := net.Pipe()
+	t.Cleanup(func() {
+		ours.Close()
+		theirs.Close()
+	})
+	go io.Copy(io.Discard, theirs)
+	h := &Host{
+		conn:   newNetConn(ours),
+		Inputs: make(chan netMessage, remoteQueueLength),
+		Gone:   make(chan error, 1),
+		frames: make(chan *NetFrame, 1),
+	}
+	go h.write()
+	return h
+}
+
+// A frame keeps the board as it was when it was taken, while the game
+// plays on. Run with -race to catch the writer reading what a tick changes.
+func TestFrameDoesNotShareGameState(t *testing.T) {
+	h := newTestHost(t)
+	g := NewGame(nil, newSpawnPolicy(settings), maxPlayers, 1)
+	g.countdown = 0
+	if g.startEvent(FoodFrenzy{}); len(g.frenzyFood) == 0 {
+		t.Fatal("the frenzy scattered no food")
+	}
+	for i := 0; i < 200 && g.state == StatePlaying; i++ {
+		f := newNetFrame(g, 0)
+		want, err := json.Marshal(f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Send(g)
+		g.Update()
+		if got, _ := json.Marshal(f); string(got) != string(want) {
+			t.Fatalf("tick %d changed a frame already taken", g.ticks)
+		}
+		if g.event == nil {
+			g.startEvent(FoodFrenzy{})
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+// Crumbs in a frame stay put as the game's crumbs age
+func TestFrameKeepsCrumbs(t *testing.T) {
+	g := NewGame(nil, newSpawnPolicy(settings), 1, 1)
+	g.steps = 1
+	g.crumbs = []Crumb{{At: Point{1, 1}, Timer: 1, Burst: 1}, {At: Point{2, 1}, Timer: 5, Burst: 2}}
+	f := newNetFrame(g, 0)
+	want := slices.Clone(f.Board.Crumbs)
+	g.ageCrumbs()
+	if !slices.Equal(f.Board.Crumbs, want) {
+		t.Fatalf("aging the crumbs changed a frame already taken: %v, want %v", f.Board.Crumbs, want)
+	}
+}
+
+// Only inputs in one of the four directions reach the game
+func TestHostDropsBadInputs(t *testing.T) {
+	ours, theirs := net.Pipe()
+	defer ours.Close()
+	defer theirs.Close()
+	h := &Host{conn: newNetConn(ours), Inputs: make(chan netMessage, remoteQueueLength), Gone: make(chan error, 1)}
+	go h.read()
+	client := newNetConn(theirs)
+	tests := []struct {
+		msg  netMessage
+		pass bool
+	}{
+		{netMessage{Type: "input", Dir: Left}, true},
+		{netMessage{Type: "input", Dir: 7}, false},
+		{netMessage{Type: "input", Dir: -1}, false},
+		{netMessage{Type: msgPauseRequest}, true},
+		{netMessage{Type: "input", Dir: Up, Dash: true}, true},
+	}
+	for _, tt := range tests {
+		if err := client.send(tt.msg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tt := range tests {
+		if !tt.pass {
+			continue
+		}
+		select {
+		case m := <-h.Inputs:
+			if m != tt.msg {
+				t.Errorf("got %+v, want %+v", m, tt.msg)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%+v never arrived", tt.msg)
+		}
+	}
+}
+
+// A snake that moved one cell is sent as its new head, one that didn't
+// move as nothing, and anything else as its whole body
+func TestSnakeDiff(t *testing.T) {
+	old := []Point{{3, 1}, {2, 1}, {1, 1}}
+	tests := []struct {
+		name string
+		body []Point
+		head bool
+		full bool
+	}{
+		{"unmoved", []Point{{3, 1}, {2, 1}, {1, 1}}, false, false},
+		{"moved", []Point{{4, 1}, {3, 1}, {2, 1}}, true, false},
+		{"grew", []Point{{4, 1}, {3, 1}, {2, 1}, {1, 1}}, true, false},
+		{"shrank", []Point{{4, 1}, {3, 1}}, true, false},
+		{"dashed", []Point{{5, 1}, {4, 1}, {3, 1}}, false, true},
+		{"respawned", []Point{{9, 9}, {8, 9}, {7, 9}}, false, true},
+	}
+	for _, tt := range tests {
+		d := netSnake{Body: tt.body, Len: len(tt.body)}.diff(old)
+		if (d.Head != nil) != tt.head || (d.Body != nil) != tt.full {
+			t.Errorf("%s: sent head %v, body %v", tt.name, d.Head, d.Body)
+		}
+		if d.Head != nil && *d.Head != tt.body[0] {
+			t.Errorf("%s: sent head %v, want %v", tt.name, *d.Head, tt.body[0])
+		}
+	}
+}
+
+// The joining side, applying each frame cut down against the last, keeps
+// the same snakes, food and territory as the host
+func TestFramesMirrorHost(t *testing.T) {
+	for _, mode := range []string{modeWrap, modeTerritory} {
+		g := NewGame(nil, newSpawnPolicy(settings), maxPlayers, 7)
+		g.setMode(mode)
+		client := NewGame(nil, nil, maxPlayers, 7)
+		var last *NetFrame
+		for i := 0; i < 100 && g.state != StateGameOver; i++ {
+			g.press(0, Direction(i/5%4))
+			g.Update()
+			f := newNetFrame(g, 0)
+			data, err := json.Marshal(f.diff(last))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sent NetFrame
+			if err := json.Unmarshal(data, &sent); err != nil {
+				t.Fatal(err)
+			}
+			if err := sent.apply(client); err != nil {
+				t.Fatalf("%s, tick %d: %v", mode, g.ticks, err)
+			}
+			last = f
+			for j, s := range g.snakes {
+				if !slices.Equal(client.snakes[j].body, s.body) {
+					t.Fatalf("%s, tick %d: snake %d is %v on the client, %v on the host", mode, g.ticks, j+1, client.snakes[j].body, s.body)
+				}
+			}
+			if !slices.Equal(client.paint, g.paint) {
+				t.Fatalf("%s, tick %d: territory differs", mode, g.ticks)
+			}
+			if len(client.foods) != len(g.foods) {
+				t.Fatalf("%s, tick %d: %d food on the client, %d on the host", mode, g.ticks, len(client.foods), len(g.foods))
+			}
+		}
+	}
+}
+
+// Frames the client can't apply are refused, and leave its game as it was
+func TestBadFrames(t *testing.T) {
+	head := Point{5, 5}
+	tests := []struct {
+		name  string
+		snake netSnake
+	}{
+		{"empty body", netSnake{Body: []Point{}, Len: 0}},
+		{"no length", netSnake{Head: &head, Len: 0}},
+		{"too long", netSnake{Head: &head, Len: 50}},
+		{"bad heading", netSnake{Head: &head, Len: 2, Direction: 7}},
+	}
+	for _, tt := range tests {
+		g := NewGame(nil, nil, maxPlayers, 1)
+		before := slices.Clone(g.snakes[0].body)
+		f := &NetFrame{State: StateGameOver, Snakes: []netSnake{tt.snake}}
+		if err := f.apply(g); err == nil {
+			t.Errorf("%s: applied", tt.name)
+		}
+		if !slices.Equal(g.snakes[0].body, before) || g.state == StateGameOver {
+			t.Errorf("%s: changed the game", tt.name)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import "fmt"
+
+// Notification constants
+const (
+	popupTicks      = 6  // How long a score popup floats before it's gone
+	popupRiseTicks  = 2  // Ticks a popup takes to rise a row
+	effectWarnTicks = 20 // Steps left on a power-up when it's about to wear off
+)
+
+// Popup is a score floating up from where it was won, fading as it goes
+type Popup struct {
+	At    Point // Cell the points were won on
+	Text  string
+	Ticks int // Ticks since it appeared
+	Fg    Attribute
+	Spin  int // Ticks it spins through mystery box outcomes before showing Text
+}
+
+// Float the points a snake just won or lost up from the cell it won them on
+func (g *Game) popScore(s *Snake, at Point, points int) {
+	if points == 0 {
+		return
+	}
+	fg := colorScore
+	if g.Versus() {
+		fg = s.color
+	}
+	if points < 0 {
+		fg = colorFood
+	}
+	// A mystery box being revealed there shows the points once it settles
+	for i := range g.popups {
+		if p := &g.popups[i]; p.Spin > 0 && p.Ticks == 0 && p.At == at {
+			p.Text += fmt.Sprintf(" %+d", points)
+			return
+		}
+	}
+	g.popups = append(g.popups, Popup{At: at, Text: fmt.Sprintf("%+d", points), Fg: fg})
+}
+
+// Age the popups by a tick, dropping those that have faded
+func (g *Game) agePopups() {
+	left := g.popups[:0]
+	for _, p := range g.popups {
+		if p.Ticks++; p.Ticks < p.Spin+popupTicks {
+			left = append(left, p)
+		}
+	}
+	g.popups = left
+}
+
+// Draw the popups over the board. Each starts just above its cell, rises a
+// row every few ticks and fades from bold to dim, staying inside the board.
+// A mystery box's reveal flicks through the outcomes in place first.
+func drawPopups(g *Game) {
+	head := g.Player().Head()
+	for _, p := range g.popups {
+		if g.mods.hidden(head, p.At) {
+			continue
+		}
+		if p.Ticks < p.Spin {
+			p.Text = "?" + mysteryOutcomes[(p.Ticks+p.At.X)%len(mysteryOutcomes)].Label + "?"
+			p.Ticks = 0
+		} else {
+			p.Ticks -= p.Spin
+		}
+		y := p.At.Y - cameraY - 1 - p.Ticks/popupRiseTicks
+		if y < 0 || y >= viewHeight {
+			continue
+		}
+		w := textWidth(p.Text)
+		x := min(max(p.At.X-cameraX-(w-1)/2, 0), max(viewWidth-w, 0))
+
+		fg := p.Fg
+		switch {
+		case p.Ticks < popupTicks/3:
+			fg |= AttrBold
+		case p.Ticks >= popupTicks*2/3:
+			fg |= AttrDim
+		}
+		drawTextIn(x+boardLeft+1, y+1, viewWidth-x, p.Text, fg)
+	}
+}
+
+// Warn when a snake's power-ups are about to wear off, as the steps just
+// counted down take them to the warning
+func (g *Game) warnEffects(s *Snake, steps int) {
+	for _, e := range []Effect{EffectSpeedUp, EffectSlowDown, EffectInvincible, EffectMultiplier} {
+		if left := s.effects[e]; left > effectWarnTicks || left+steps <= effectWarnTicks {
+			continue
+		}
+		msg := effectLabels[e] + " wearing off"
+		if g.Versus() {
+			msg = s.name + ": " + msg
+		}
+		g.notify(msg)
+	}
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pause vote constants
+const (
+	pauseVoteTimeout = 30 * time.Second // How long a pause request waits for the other player
+)
+
+// PauseVoteState represents where a multiplayer pause request stands
+type PauseVoteState int
+
+const (
+	PauseVoteIdle     PauseVoteState = iota // No pause requested
+	PauseVotePending                        // One player asked, waiting for the other
+	PauseVoteAccepted                       // Both players confirmed, game is paused
+)
+
+// PauseVote tracks a pause request in versus modes. A pause only takes effect
+// once every player has confirmed it, so one player can't freeze the game at
+// a critical moment for the other. Unanswered requests expire after
+// pauseVoteTimeout.
+type PauseVote struct {
+	state     PauseVoteState
+	players   int          // Number of players that must confirm
+	votes     map[int]bool // Player index -> confirmed
+	requested time.Time    // When the pending request was made
+}
+
+// Create a pause vote for the given number of players
+func NewPauseVote(players int) *PauseVote {
+	return &PauseVote{
+		players: players,
+		votes:   make(map[int]bool),
+	}
+}
+
+// Request starts a pause vote on behalf of a player. It counts as that
+// player's confirmation. Returns false if a vote is already running.
+func (v *PauseVote) Request(player int, now time.Time) bool {
+	if v.state != PauseVoteIdle {
+		return false
+	}
+	v.state = PauseVotePending
+	v.requested = now
+	v.votes = map[int]bool{player: true}
+	v.settle()
+	return true
+}
+
+// Confirm records a player's agreement to a pending pause
+func (v *PauseVote) Confirm(player int, now time.Time) {
+	v.Expire(now)
+	if v.state != PauseVotePending {
+		return
+	}
+	v.votes[player] = true
+	v.settle()
+}
+
+// Decline cancels a pending pause request
+func (v *PauseVote) Decline(player int) {
+	if v.state == PauseVotePending {
+		v.reset()
+	}
+}
+
+// Resume ends an accepted pause. Any single player may resume.
+func (v *PauseVote) Resume() {
+	if v.state == PauseVoteAccepted {
+		v.reset()
+	}
+}
+
+// Expire drops a pending request that has waited longer than the timeout
+func (v *PauseVote) Expire(now time.Time) {
+	if v.state == PauseVotePending && now.Sub(v.requested) >= pauseVoteTimeout {
+		v.reset()
+	}
+}
+
+// Remaining returns how long a pending request has left before it times out
+func (v *PauseVote) Remaining(now time.Time) time.Duration {
+	if v.state != PauseVotePending {
+		return 0
+	}
+	left := pauseVoteTimeout - now.Sub(v.requested)
+	if left < 0 {
+		return 0
+	}
+	return left
+}
+
+// State returns the current vote state
+func (v *PauseVote) State() PauseVoteState {
+	return v.state
+}
+
+// Paused reports whether all players agreed to pause
+func (v *PauseVote) Paused() bool {
+	return v.state == PauseVoteAccepted
+}
+
+// Move to accepted once every player has voted
+func (v *PauseVote) settle() {
+	if len(v.votes) >= v.players {
+		v.state = PauseVoteAccepted
+	}
+}
+
+// Take on how a vote stands elsewhere, as the joining player of a network
+// game does from the host's frames
+func (v *PauseVote) mirror(state PauseVoteState, requester int, left time.Duration, now time.Time) {
+	v.reset()
+	v.state = state
+	if state == PauseVotePending {
+		v.votes[requester] = true
+		v.requested = now.Add(left - pauseVoteTimeout)
+	}
+}
+
+// The player who asked for a pending pause
+func (v *PauseVote) requester() int {
+	for player := range v.votes {
+		return player
+	}
+	return -1
+}
+
+// Which vote message a player's pause key sends: a request, agreement with
+// the other player's, or resuming an agreed pause. Nothing is sent while
+// the player waits on their own request.
+func (v *PauseVote) pauseMessage(player int, now time.Time) string {
+	v.Expire(now)
+	switch {
+	case v.state == PauseVoteAccepted:
+		return msgPauseResume
+	case v.state == PauseVotePending && v.votes[player]:
+		return ""
+	case v.state == PauseVotePending:
+		return msgPauseConfirm
+	}
+	return msgPauseRequest
+}
+
+// Count a player's pause vote message, pausing or resuming the game as the
+// vote settles. Reports whether the game paused or resumed.
+func (g *Game) votePause(player int, kind string, now time.Time) bool {
+	v := g.pauseVote
+	v.Expire(now)
+	was, asked := v.State(), v.requester()
+	switch kind {
+	case msgPauseRequest:
+		v.Request(player, now)
+	case msgPauseConfirm:
+		v.Confirm(player, now)
+	case msgPauseDecline:
+		v.Decline(player)
+	case msgPauseResume:
+		v.Resume()
+	}
+	switch name := g.snakes[player].name; {
+	case v.State() == PauseVotePending && was == PauseVoteIdle:
+		g.notify(name + " asks to pause")
+	case v.State() == PauseVoteIdle && was == PauseVotePending && player == asked:
+		g.notify(name + " took back the pause request")
+	case v.State() == PauseVoteIdle && was == PauseVotePending:
+		g.notify(name + " refused to pause")
+	}
+	if v.Paused() == (g.state == StatePaused) {
+		return false
+	}
+	if v.Paused() {
+		g.state = StatePaused
+	} else {
+		g.state = StatePlaying
+	}
+	return true
+}
+
+// Show a pending pause request over the board's top border, with the
+// keys to answer it and how long it has left. The player who asked is
+// told they're waiting instead.
+func drawPauseVote(g *Game) {
+	v := g.pauseVote
+	now := time.Now()
+	if v == nil || v.State() != PauseVotePending || v.Remaining(now) == 0 {
+		return
+	}
+	left := int(v.Remaining(now).Seconds() + 0.5)
+	msg := fmt.Sprintf(" %s asks to pause: %s to agree, %s to refuse (%ds) ",
+		g.snakes[v.requester()].name, keyHint(ActionPause), keyHint(ActionDecline), left)
+	me := -1 // Both players, sharing a keyboard
+	if g.remote {
+		me = remoteSnake
+	} else if _, hosting := g.controllers[remoteSnake].(*Remote); hosting {
+		me = 0
+	}
+	if me >= 0 && v.votes[me] {
+		msg = fmt.Sprintf(" Waiting for %s to agree to pause (%ds) ", g.snakes[1-me].name, left)
+	}
+	drawCentered(0, msg, colorScore|AttrBold)
+}
+
+func (v *PauseVote) reset() {
+	v.state = PauseVoteIdle
+	v.votes = make(map[int]bool)
+	v.requested = time.Time{}
+}
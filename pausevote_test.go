@@ -0,0 +1,87 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Versus games pause only once both players agree, and either can resume
+func TestPauseVote(t *testing.T) {
+	type vote struct {
+		player int
+		kind   string
+		after  time.Duration // Since the last vote
+		state  State
+		vote   PauseVoteState
+	}
+	tests := []struct {
+		name  string
+		votes []vote
+	}{
+		{"agreed", []vote{
+			{0, msgPauseRequest, 0, StatePlaying, PauseVotePending},
+			{1, msgPauseConfirm, time.Second, StatePaused, PauseVoteAccepted},
+			{1, msgPauseResume, time.Minute, StatePlaying, PauseVoteIdle},
+		}},
+		{"refused", []vote{
+			{1, msgPauseRequest, 0, StatePlaying, PauseVotePending},
+			{0, msgPauseDecline, time.Second, StatePlaying, PauseVoteIdle},
+			{0, msgPauseConfirm, time.Second, StatePlaying, PauseVoteIdle},
+		}},
+		{"taken back", []vote{
+			{0, msgPauseRequest, 0, StatePlaying, PauseVotePending},
+			{0, msgPauseDecline, time.Second, StatePlaying, PauseVoteIdle},
+		}},
+		{"asked twice", []vote{
+			{0, msgPauseRequest, 0, StatePlaying, PauseVotePending},
+			{0, msgPauseConfirm, time.Second, StatePlaying, PauseVotePending},
+			{1, msgPauseRequest, time.Second, StatePlaying, PauseVotePending},
+		}},
+		{"too late", []vote{
+			{0, msgPauseRequest, 0, StatePlaying, PauseVotePending},
+			{1, msgPauseConfirm, pauseVoteTimeout, StatePlaying, PauseVoteIdle},
+		}},
+		{"nothing to resume", []vote{
+			{0, msgPauseResume, 0, StatePlaying, PauseVoteIdle},
+		}},
+	}
+	for _, tt := range tests {
+		g := NewGame(nil, nil, maxPlayers, 1)
+		g.pauseVote = NewPauseVote(maxPlayers)
+		now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+		for i, v := range tt.votes {
+			now = now.Add(v.after)
+			g.votePause(v.player, v.kind, now)
+			if g.state != v.state || g.pauseVote.State() != v.vote {
+				t.Errorf("%s, vote %d: game %s with the vote at %d, want %s at %d", tt.name, i+1, stateNames[g.state], g.pauseVote.State(), stateNames[v.state], v.vote)
+			}
+		}
+	}
+}
+
+// Each player's pause key sends what the vote calls for
+func TestPauseMessage(t *testing.T) {
+	now := time.Date(2026, 10, 17, 9, 0, 0, 0, time.UTC)
+	v := NewPauseVote(maxPlayers)
+	steps := []struct {
+		player int
+		want   string
+	}{
+		{0, msgPauseRequest},
+		{0, ""}, // Waiting on the other player
+		{1, msgPauseConfirm},
+		{0, msgPauseResume},
+	}
+	for i, s := range steps {
+		got := v.pauseMessage(s.player, now)
+		if got != s.want {
+			t.Fatalf("step %d: player %d's key sends %q, want %q", i+1, s.player+1, got, s.want)
+		}
+		switch got {
+		case msgPauseRequest:
+			v.Request(s.player, now)
+		case msgPauseConfirm:
+			v.Confirm(s.player, now)
+		}
+	}
+}
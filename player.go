@@ -0,0 +1,266 @@
+package main
+
+import (
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// Player steers a snake. The game asks each snake's player for a direction
+// once per tick, so humans and bots are interchangeable.
+type Player interface {
+	// Steer returns the direction the snake should turn to before its next
+	// move, or false to keep going straight
+	Steer(g *Game, snake int) (Direction, bool)
+}
+
+// Player constants
+const (
+	inputQueueLength = 3 // Turns a human can get ahead of the snake
+
+	chaosKeyOdds  = 4  // The chaos bot presses any key at all one tick in this many
+	chaosDashOdds = 10 // and tries to dash one tick in this many
+)
+
+// Human is a player at the keyboard. Turns are queued and the snake takes
+// one per tick, so quick presses within a tick all happen in order rather
+// than the last one winning.
+type Human struct {
+	queue   []Direction // Turns still to take, oldest first
+	lastKey Direction   // Last direction pressed, for spotting double taps
+	lastAt  time.Time   // When it was pressed
+}
+
+// Press queues a turn for a snake heading the given way. A turn that
+// carries straight on from the one before it, or doubles back on it, is
+// dropped: it would do nothing, or be refused when its tick came and waste
+// a place in the queue. So Up, Left, Down pressed heading up turns left,
+// then down, and never back into the body.
+func (h *Human) Press(dir, heading Direction) {
+	heading = h.heading(heading)
+	if dir == heading || dir == heading.Opposite() || len(h.queue) == inputQueueLength {
+		return
+	}
+	h.queue = append(h.queue, dir)
+}
+
+// The way a snake heading the given way will be going once the queued
+// turns are taken
+func (h *Human) heading(current Direction) Direction {
+	if n := len(h.queue); n > 0 {
+		return h.queue[n-1]
+	}
+	return current
+}
+
+func (h *Human) Steer(g *Game, snake int) (Direction, bool) {
+	if len(h.queue) == 0 {
+		return 0, false
+	}
+	dir := h.queue[0]
+	h.queue = h.queue[1:]
+	return dir, true
+}
+
+// Bots by name, for "go-snake simulate". Each gets a seed of its own for
+// any randomness, so it doesn't change the game's food. To try out a new
+// strategy, write a Player and add it here.
+var bots = map[string]func(seed int64) Player{
+	"autopilot": func(int64) Player { return Bot{} },
+	"painter":   func(int64) Player { return PainterBot{} },
+	"random":    func(seed int64) Player { return &RandomBot{rng: rand.New(rand.NewSource(seed))} },
+	"chaos":     func(seed int64) Player { return &ChaosBot{RandomBot{rng: rand.New(rand.NewSource(seed))}} },
+}
+
+// Names of the bots, sorted
+func botNames() []string {
+	names := make([]string, 0, len(bots))
+	for name := range bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Bot is the built-in autopilot. It heads for the nearest food along the
+// shortest path, but only when it would still have room to move once there;
+// otherwise it turns towards the most open space to stay alive.
+type Bot struct{}
+
+func (Bot) Steer(g *Game, snake int) (Direction, bool) {
+	return g.steerTowards(snake, g.foodAt)
+}
+
+// PainterBot plays territory. It heads for the nearest cell it doesn't hold
+// yet, taking the same care as the autopilot to keep room to move.
+type PainterBot struct{}
+
+func (PainterBot) Steer(g *Game, snake int) (Direction, bool) {
+	return g.steerTowards(snake, func(p Point) bool { return g.owner(p) != snake })
+}
+
+// Steer a snake along the shortest path to the nearest cell it wants, when
+// it would still have room to move once there, or else towards the most
+// open space
+func (g *Game) steerTowards(snake int, want func(Point) bool) (Direction, bool) {
+	s := g.snakes[snake]
+	blocked := g.blockedCells()
+
+	if dir, ok := g.pathTo(s.Head(), blocked, want); ok && dir != s.direction.Opposite() {
+		if next, ok := g.move(s.Head(), dir); ok && g.openSpace(next, blocked) >= len(s.body) {
+			return dir, true
+		}
+	}
+
+	// No safe route to food: pick the move with the most room behind it
+	best, bestSpace := s.direction, -1
+	for dir := Up; dir <= Left; dir++ {
+		if dir == s.direction.Opposite() {
+			continue
+		}
+		next, ok := g.move(s.Head(), dir)
+		if !ok || blocked[next] {
+			continue
+		}
+		if space := g.openSpace(next, blocked); space > bestSpace {
+			best, bestSpace = dir, space
+		}
+	}
+	return best, true
+}
+
+// Cells a snake can't move into on the next tick. Tails count too, since
+// the game checks collisions before tails move on. Poison is avoided as if
+// it were a wall.
+func (g *Game) blockedCells() map[Point]bool {
+	blocked := make(map[Point]bool, len(g.walls))
+	for p := range g.walls {
+		blocked[p] = true
+	}
+	for _, f := range g.foods {
+		if f.Special != nil && f.Special.Effect == EffectPoison {
+			blocked[f.At] = true
+		}
+	}
+	for _, s := range g.snakes {
+		for _, p := range s.body {
+			blocked[p] = true
+		}
+	}
+	// Hazards move before snakes do, so cells about to turn deadly are
+	// avoided too
+	deadly, warned := g.hazardCells()
+	for p := range deadly {
+		blocked[p] = true
+	}
+	for p := range warned {
+		blocked[p] = true
+	}
+	return blocked
+}
+
+// First step of the shortest path from a cell to any cell wanted, such as
+// food, found by breadth-first search
+func (g *Game) pathTo(from Point, blocked map[Point]bool, want func(Point) bool) (Direction, bool) {
+	firstStep := map[Point]Direction{}
+	queue := []Point{from}
+	seen := map[Point]bool{from: true}
+
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		if p != from && want(p) {
+			return firstStep[p], true
+		}
+		for dir := Up; dir <= Left; dir++ {
+			next, ok := g.move(p, dir)
+			if !ok || seen[next] || blocked[next] {
+				continue
+			}
+			seen[next] = true
+			if p == from {
+				firstStep[next] = dir
+			} else {
+				firstStep[next] = firstStep[p]
+			}
+			queue = append(queue, next)
+		}
+	}
+	return Up, false
+}
+
+// Check whether a cell holds food a snake can eat, including puzzle food
+func (g *Game) foodAt(p Point) bool {
+	if g.hasFood(p) {
+		return true
+	}
+	if g.puzzle != nil {
+		for _, f := range g.puzzle.Food {
+			if f == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Number of free cells reachable from a cell, counting the cell itself
+func (g *Game) openSpace(from Point, blocked map[Point]bool) int {
+	if blocked[from] {
+		return 0
+	}
+	seen := map[Point]bool{from: true}
+	queue := []Point{from}
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		for dir := Up; dir <= Left; dir++ {
+			next, ok := g.move(p, dir)
+			if !ok || seen[next] || blocked[next] {
+				continue
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+	return len(seen)
+}
+
+// RandomBot makes any move that doesn't crash straight away, as a baseline
+// for other bots to beat
+type RandomBot struct {
+	rng *rand.Rand
+}
+
+func (b *RandomBot) Steer(g *Game, snake int) (Direction, bool) {
+	s := g.snakes[snake]
+	blocked := g.blockedCells()
+	var safe []Direction
+	for dir := Up; dir <= Left; dir++ {
+		if next, ok := g.move(s.Head(), dir); ok && !blocked[next] && dir != s.direction.Opposite() {
+			safe = append(safe, dir)
+		}
+	}
+	if len(safe) == 0 {
+		return s.direction, false
+	}
+	return safe[b.rng.Intn(len(safe))], true
+}
+
+// ChaosBot presses keys no sensible player would: now and then any
+// direction at all, reversals included, or a dash, and otherwise a move
+// that doesn't crash so games last. It is for shaking out engine bugs with
+// a debug build rather than for scoring.
+type ChaosBot struct {
+	RandomBot
+}
+
+func (b *ChaosBot) Steer(g *Game, snake int) (Direction, bool) {
+	if b.rng.Intn(chaosDashOdds) == 0 {
+		g.dash(snake)
+	}
+	if b.rng.Intn(chaosKeyOdds) == 0 {
+		return Direction(b.rng.Intn(4)), true
+	}
+	return b.RandomBot.Steer(g, snake)
+}
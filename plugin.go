@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Plugin adds behaviour to games from outside the game loop: custom
+// scoring, logging, overlays for streaming. The game calls its hooks as
+// things happen; a plugin embeds Hooks and overrides the ones it needs.
+// Hooks run on the game loop, between ticks, so they see the game as it is
+// and should return quickly.
+type Plugin interface {
+	OnTick(g *Game)                                         // After every tick
+	OnFoodEaten(g *Game, s *Snake, symbol rune, points int) // After a snake eats, with what it scored
+	OnLevelUp(g *Game, level int)                           // After the game speeds up a level
+	OnGameOver(g *Game)                                     // Once, on the tick the game ends
+}
+
+// Hooks does nothing on every hook, for plugins to embed
+type Hooks struct{}
+
+func (Hooks) OnTick(*Game)                         {}
+func (Hooks) OnFoodEaten(*Game, *Snake, rune, int) {}
+func (Hooks) OnLevelUp(*Game, int)                 {}
+func (Hooks) OnGameOver(*Game)                     {}
+
+// Plugins by name, for the -plugins flag. A fork adds its own with
+// RegisterPlugin from an init function in a file of its own. Each is made
+// once per run with the log its lines go to, and sees every game played.
+var plugins = map[string]func(log *MoveLog) Plugin{}
+
+// Make a plugin available by name
+func RegisterPlugin(name string, newPlugin func(log *MoveLog) Plugin) {
+	if _, ok := plugins[name]; ok {
+		panic("go-snake: plugin " + name + " registered twice")
+	}
+	plugins[name] = newPlugin
+}
+
+// Names of the registered plugins, sorted
+func pluginNames() []string {
+	names := make([]string, 0, len(plugins))
+	for name := range plugins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Check every plugin named is registered
+func checkPlugins(names []string) error {
+	for _, name := range names {
+		if _, ok := plugins[name]; !ok {
+			return fmt.Errorf("unknown plugin %q (have %s)", name, strings.Join(pluginNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// A plugin log kept in memory, to print once the terminal is back
+type memoryLog struct {
+	*bytes.Buffer
+}
+
+func (memoryLog) Close() error {
+	return nil
+}
+
+// Make the named plugins, writing to log. Call checkPlugins first.
+func loadPlugins(names []string, log *MoveLog) []Plugin {
+	var loaded []Plugin
+	for _, name := range names {
+		loaded = append(loaded, plugins[name](log))
+	}
+	return loaded
+}
@@ -0,0 +1,72 @@
+package main
+
+import "time"
+
+// The built-in plugins, which double as examples for writing more
+func init() {
+	RegisterPlugin("score-log", func(log *MoveLog) Plugin { return &ScoreLogger{log: log} })
+	RegisterPlugin("tick-profiler", func(log *MoveLog) Plugin { return &TickProfiler{log: log} })
+}
+
+// ScoreLogger logs every change to player 1's score and how each game
+// ended, for keeping score outside the game
+type ScoreLogger struct {
+	Hooks
+	log *MoveLog
+}
+
+func (l *ScoreLogger) OnFoodEaten(g *Game, s *Snake, symbol rune, points int) {
+	if s == g.Player() {
+		l.log.Printf("score-log: tick %d: %c %+d, score %d", g.ticks, symbol, points, s.score)
+	}
+}
+
+func (l *ScoreLogger) OnLevelUp(g *Game, level int) {
+	l.log.Printf("score-log: tick %d: level %d at score %d", g.ticks, level, g.Player().score)
+}
+
+func (l *ScoreLogger) OnGameOver(g *Game) {
+	l.log.Printf("score-log: game over: %s, seed %d, score %d in %s", g.mode, g.seed, g.Player().score, clockText(g.clock))
+}
+
+// Tick profiler constants
+const (
+	profilerPauseGap = 3 // A gap this many intervals long was a pause, not a slow tick
+)
+
+// TickProfiler times the gaps between ticks against the interval they
+// were due at, and once a game is over logs how close the game loop kept
+// to time: how late ticks came on average, and the latest
+type TickProfiler struct {
+	Hooks
+	log   *MoveLog
+	last  time.Time     // When the last tick came
+	ticks int           // Gaps timed this game
+	late  time.Duration // Lateness added up over the game
+	worst time.Duration // Latest tick this game
+}
+
+func (p *TickProfiler) OnTick(g *Game) {
+	now := time.Now()
+	due := g.updateInterval()
+	if gap := now.Sub(p.last); g.ticks > 1 && gap < profilerPauseGap*due {
+		late := gap - due
+		if late < 0 {
+			late = 0
+		}
+		p.late += late
+		if late > p.worst {
+			p.worst = late
+		}
+		p.ticks++
+	}
+	p.last = now
+}
+
+func (p *TickProfiler) OnGameOver(g *Game) {
+	if p.ticks > 0 {
+		p.log.Printf("tick-profiler: %d ticks, %s late on average, %s at worst",
+			p.ticks, (p.late / time.Duration(p.ticks)).Round(time.Microsecond), p.worst.Round(time.Microsecond))
+	}
+	p.ticks, p.late, p.worst = 0, 0, 0
+}
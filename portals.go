@@ -0,0 +1,109 @@
+package main
+
+import (
+	"time"
+)
+
+// Portal constants
+const (
+	arcadePortalInterval = time.Minute // Arcade mode opens another pair of portals this often
+	maxArcadePortals     = 4           // Pairs of portals arcade mode opens at most
+	portalMinDistance    = 10          // The two ends of a random pair are at least this far apart
+)
+
+// Portal is a pair of linked cells. A head moving onto either end comes out
+// of the other, still heading the same way, and carries on from there.
+type Portal struct {
+	A Point `json:"a"`
+	B Point `json:"b"`
+}
+
+// Portal glyph and the color of each pair, in turn
+var (
+	symbolPortal = '◎'
+	portalColors = []Attribute{ColorLightMagenta, ColorLightCyan, ColorLightYellow, ColorLightBlue}
+)
+
+// The other end of the portal at p, with ok false if there's no portal there
+func (g *Game) portalExit(p Point) (exit Point, ok bool) {
+	for _, pt := range g.portals {
+		switch p {
+		case pt.A:
+			return pt.B, true
+		case pt.B:
+			return pt.A, true
+		}
+	}
+	return p, false
+}
+
+// Check whether either end of a portal is at p
+func (g *Game) isPortal(p Point) bool {
+	_, ok := g.portalExit(p)
+	return ok
+}
+
+// Where a head moving onto p ends up: the other end of a portal, or p
+func (g *Game) throughPortal(p Point) Point {
+	exit, _ := g.portalExit(p)
+	return exit
+}
+
+// Open random pairs of portals in arcade mode, one at the start and another
+// each interval, up to the most there can be
+func (g *Game) updatePortals() {
+	want := min(1+int(g.clock/arcadePortalInterval), maxArcadePortals)
+	for len(g.portals) < want {
+		if !g.placePortals() {
+			break
+		}
+	}
+}
+
+// Open a pair of portals on free cells away from snake heads, far enough
+// apart that going through one is worth it. Reports false if there's no
+// room.
+func (g *Game) placePortals() bool {
+	var free []Point
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Point{X: x, Y: y}
+			if g.walls[p] || g.occupied(p) || g.hasFood(p) || g.isPortal(p) || g.nearHead(p) || g.nearHazard(p) {
+				continue
+			}
+			free = append(free, p)
+		}
+	}
+	if len(free) < 2 {
+		return false
+	}
+	a := free[g.rng.Intn(len(free))]
+	var far []Point
+	for _, p := range free {
+		if g.distance(a, p) >= portalMinDistance {
+			far = append(far, p)
+		}
+	}
+	if len(far) == 0 {
+		return false
+	}
+	pt := Portal{A: a, B: far[g.rng.Intn(len(far))]}
+	g.portals = append(g.portals, pt)
+	g.logMove(nil, "portals at (%d,%d) and (%d,%d)", pt.A.X, pt.A.Y, pt.B.X, pt.B.Y)
+	return true
+}
+
+// Draw both ends of each portal in the pair's color
+func drawPortals(g *Game, head Point) {
+	for i, pt := range g.portals {
+		fg := portalColors[i%len(portalColors)] | AttrBold
+		if g.state == StatePaused {
+			fg = ColorDarkGray
+		}
+		for _, p := range []Point{pt.A, pt.B} {
+			if !g.mods.hidden(head, p) {
+				setBoardCell(p, symbolPortal, fg)
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// BoardPreset is a named board size, with a pace to suit it
+type BoardPreset struct {
+	Name   string
+	Width  int
+	Height int
+	Pace   float64 // Tick interval multiplier on the difficulty's speeds
+}
+
+// Board presets, from smallest to biggest. Small boards play slower, to
+// leave time to turn in them; big ones faster, so crossing them doesn't
+// drag. A board too big for the terminal is followed with the camera.
+var boardPresets = []BoardPreset{
+	{Name: "tiny", Width: 20, Height: 10, Pace: 1.25},
+	{Name: "classic", Width: 40, Height: 15, Pace: 1},
+	{Name: "huge", Width: 120, Height: 40, Pace: 0.7},
+}
+
+// Pace of the board preset in use, 1 for a board of any other size
+var boardPace = 1.0
+
+// Look up a board preset by name
+func boardPresetByName(name string) (BoardPreset, error) {
+	for _, p := range boardPresets {
+		if strings.EqualFold(p.Name, name) {
+			return p, nil
+		}
+	}
+	return BoardPreset{}, fmt.Errorf("unknown board preset %q (want %s)", name, boardPresetNames())
+}
+
+// Names of the board presets, e.g. "tiny, classic or huge"
+func boardPresetNames() string {
+	names := make([]string, len(boardPresets))
+	for i, p := range boardPresets {
+		names[i] = p.Name
+	}
+	return strings.Join(names[:len(names)-1], ", ") + " or " + names[len(names)-1]
+}
+
+// Index of the preset the board is, or -1 for a custom size
+func currentBoardPreset() int {
+	for i, p := range boardPresets {
+		if p.Width == width && p.Height == height {
+			return i
+		}
+	}
+	return -1
+}
+
+// Label for the board's size on the title menu, e.g. "CLASSIC 40x15"
+func boardLabel() string {
+	label := fmt.Sprintf("%dx%d", width, height)
+	if i := currentBoardPreset(); i >= 0 {
+		label = strings.ToUpper(boardPresets[i].Name) + " " + label
+	}
+	return label
+}
+
+// Step to the previous or next board preset. A custom size steps to the
+// first or last.
+func cycleBoardPreset(delta int) BoardPreset {
+	i := currentBoardPreset()
+	switch {
+	case i < 0 && delta > 0:
+		i = 0
+	case i < 0:
+		i = len(boardPresets) - 1
+	default:
+		i = (i + delta + len(boardPresets)) % len(boardPresets)
+	}
+	return boardPresets[i]
+}
+
+// Play on a preset's board from the next game, refitting the screen to it
+func useBoardPreset(p BoardPreset) {
+	width, height = p.Width, p.Height
+	boardPace = p.Pace
+	fitLayout(screenCols, screenRows)
+}
+
+// Speed a difficulty up or slow it down to the board's pace
+func paced(d Difficulty) Difficulty {
+	d.StartSpeed = int(math.Round(float64(d.StartSpeed) * boardPace))
+	d.MinSpeed = int(math.Round(float64(d.MinSpeed) * boardPace))
+	return d
+}
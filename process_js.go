@@ -0,0 +1,11 @@
+//go:build js
+
+package main
+
+import "time"
+
+// The browser build is the only go-snake in its sandbox, so any other
+// process it hears of is gone
+func processRunning(pid int, started time.Time) bool {
+	return false
+}
@@ -0,0 +1,78 @@
+//go:build !js && !windows
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Process constants
+const (
+	processStartSlack = 3 * time.Second // How far a process's start can be from the one recorded for it
+	procClockTicks    = 100             // Units of a process's start time in /proc, as on every Linux
+)
+
+// Report whether a process is still running, and is the one started at
+// started rather than a later one given the same ID
+func processRunning(pid int, started time.Time) bool {
+	if pid <= 0 {
+		return false
+	}
+	if err := syscall.Kill(pid, 0); err != nil && !errors.Is(err, syscall.EPERM) {
+		return false
+	}
+	if at, ok := processStartTime(pid); ok {
+		if d := at.Sub(started); d > processStartSlack || d < -processStartSlack {
+			return false
+		}
+	}
+	return true
+}
+
+// When a process started, from /proc where there is one
+func processStartTime(pid int) (time.Time, bool) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return time.Time{}, false
+	}
+	// The command name in brackets may hold spaces; the start time is the
+	// 20th field after it
+	fields := strings.Fields(string(stat[bytes.LastIndexByte(stat, ')')+1:]))
+	if len(fields) < 20 {
+		return time.Time{}, false
+	}
+	ticks, err := strconv.ParseInt(fields[19], 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	boot, ok := bootTime()
+	if !ok {
+		return time.Time{}, false
+	}
+	return boot.Add(time.Duration(ticks) * time.Second / procClockTicks), true
+}
+
+// When the machine booted, from /proc/stat
+func bootTime() (time.Time, bool) {
+	stat, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}, false
+	}
+	for _, line := range strings.Split(string(stat), "\n") {
+		if rest, ok := strings.CutPrefix(line, "btime "); ok {
+			secs, err := strconv.ParseInt(strings.TrimSpace(rest), 10, 64)
+			if err != nil {
+				return time.Time{}, false
+			}
+			return time.Unix(secs, 0), true
+		}
+	}
+	return time.Time{}, false
+}
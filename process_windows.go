@@ -0,0 +1,22 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"time"
+)
+
+// Report whether a process is still running. Its start time isn't checked
+// here, so a later process given the same ID passes for it.
+func processRunning(pid int, started time.Time) bool {
+	if pid <= 0 {
+		return false
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Profile constants
+const (
+	profileFileName = "profile.json"
+)
+
+// Profile holds what the game has learned about the player and their
+// terminal
+type Profile struct {
+	Calibration *Calibration      `json:"calibration,omitempty"` // Nil until calibrated
+	LastGame    *LastGame         `json:"last_game,omitempty"`   // Nil until a game is played
+	Streak      *Streak           `json:"streak,omitempty"`      // Nil until a game is played
+	Sidebar     string            `json:"sidebar,omitempty"`     // Sidebar last picked with the layout key, show or hide
+	Campaign    *CampaignProgress `json:"campaign,omitempty"`    // Nil until a campaign stage is cleared
+	path        string
+}
+
+// LastGame is how the last game was set up, so the title menu can start
+// another one the same way
+type LastGame struct {
+	Settings   Settings `json:"settings"` // Mode and modifiers
+	Difficulty string   `json:"difficulty"`
+}
+
+// Label for the title menu, e.g. "WRAP / HARD"
+func (lg *LastGame) String() string {
+	parts := []string{strings.ToUpper(lg.Settings.Mode), strings.ToUpper(lg.Difficulty)}
+	if lg.Settings.ScoreDecay {
+		parts = append(parts, "DECAY")
+	}
+	if lg.Settings.Hazards {
+		parts = append(parts, "HAZARDS")
+	}
+	return strings.Join(parts, " / ")
+}
+
+// Remember how a game was set up. Only ordinary games count: puzzles,
+// levels, challenges and demos have their own ways to pick up again.
+func (p *Profile) Remember(s Settings, difficulty string) error {
+	last := &LastGame{Settings: s, Difficulty: difficulty}
+	if p.LastGame != nil && *p.LastGame == *last {
+		return nil
+	}
+	p.LastGame = last
+	return p.Save()
+}
+
+// Remember whether the sidebar was shown or hidden with the layout key
+func (p *Profile) RememberSidebar(mode string) error {
+	if p.Sidebar == mode {
+		return nil
+	}
+	p.Sidebar = mode
+	return p.Save()
+}
+
+// Move the campaign on past a cleared stage. Clearing the last one
+// completes the campaign, which then starts again from the first.
+func (p *Profile) AdvanceCampaign(cleared int) error {
+	if p.Campaign == nil {
+		p.Campaign = &CampaignProgress{}
+	}
+	p.Campaign.Stage = cleared + 1
+	if p.Campaign.Stage >= len(campaignStages) {
+		p.Campaign.Stage, p.Campaign.Completed = 0, true
+	}
+	return p.Save()
+}
+
+// Load the profile from the data directory. The profile returned is usable,
+// if empty, even when loading fails.
+func LoadProfile() (*Profile, error) {
+	profile := &Profile{}
+	dir, err := dataDir()
+	if err != nil {
+		return profile, err
+	}
+	profile.path = filepath.Join(dir, profileFileName)
+
+	data, err := os.ReadFile(profile.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return profile, nil
+	} else if err != nil {
+		return profile, err
+	}
+
+	if err := json.Unmarshal(data, profile); err != nil {
+		return &Profile{path: profile.path}, fmt.Errorf("parse %s: %w", profile.path, err)
+	}
+	return profile, nil
+}
+
+// Save writes the profile back to disk
+func (p *Profile) Save() error {
+	if p.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(p.path, data, 0o644)
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+	"unicode"
+)
+
+// Player profile constants
+const (
+	profilesDirName   = "players"
+	maxProfileNameLen = 32
+	defaultProfile    = "(default)" // How the profile kept in the data directory itself is listed
+)
+
+// Player profile in use, empty for the default one. Each profile keeps its
+// high scores, stats, records, settings and campaign progress in its own
+// directory under the default one's, where dataDir points while it's in use.
+var activeProfile string
+
+// Check a profile name can be used as a directory name everywhere
+func validProfileName(name string) error {
+	if name == "" || len(name) > maxProfileNameLen {
+		return fmt.Errorf("profile names must be 1 to %d characters, got %q", maxProfileNameLen, name)
+	}
+	for _, r := range name {
+		if r > unicode.MaxASCII || !(unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_') {
+			return fmt.Errorf("profile names can only have letters, digits, - and _, got %q", name)
+		}
+	}
+	return nil
+}
+
+// Directory the profiles are kept in
+func profilesDir() (string, error) {
+	dir, err := baseDataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, profilesDirName), nil
+}
+
+// Names of the profiles there are, in order
+func profileNames() ([]string, error) {
+	dir, err := profilesDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() && validProfileName(e.Name()) == nil {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Switch to a profile, creating it if it's new, so the data loaded and
+// saved from then on is its own. An empty name is the default profile.
+func useProfile(name string) error {
+	if name == "" {
+		activeProfile = ""
+		return nil
+	}
+	if err := validProfileName(name); err != nil {
+		return err
+	}
+	dir, err := profilesDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(dir, name), 0o755); err != nil {
+		return err
+	}
+	activeProfile = name
+	return nil
+}
+
+// ProfileSummary is a line of the profile list
+type ProfileSummary struct {
+	Name   string
+	Best   int       // Best high score
+	Games  int       // Games played
+	Played time.Time // When the last score was set, zero if none has been
+}
+
+// Sum up a profile from the files in its directory
+func summarizeProfile(name, dir string) (ProfileSummary, error) {
+	sum := ProfileSummary{Name: name}
+	hs, err := readHighScores(filepath.Join(dir, scoresFileName))
+	if err != nil {
+		return sum, err
+	}
+	for _, e := range hs.Entries {
+		sum.Best = max(sum.Best, e.Score)
+		if e.Date.After(sum.Played) {
+			sum.Played = e.Date
+		}
+	}
+	ls, err := readLifetimeStats(filepath.Join(dir, statsFileName))
+	if err != nil {
+		return sum, err
+	}
+	sum.Games = ls.Games
+	return sum, nil
+}
+
+// Run "go-snake profiles": list the player profiles, or delete one and
+// everything it has saved
+func profilesCommand(args []string) int {
+	flags := flag.NewFlagSet("profiles", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-snake profiles [-delete name]")
+		flags.PrintDefaults()
+	}
+	remove := flags.String("delete", "", "delete this profile with its high scores, stats and progress")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() != 0 {
+		flags.Usage()
+		return 2
+	}
+	dir, err := profilesDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+
+	if *remove != "" {
+		if err := validProfileName(*remove); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			return 2
+		}
+		path := filepath.Join(dir, *remove)
+		if _, err := os.Stat(path); err != nil {
+			fmt.Fprintf(os.Stderr, "go-snake: no profile named %q\n", *remove)
+			return 1
+		}
+		if err := os.RemoveAll(path); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			return 1
+		}
+		fmt.Printf("Deleted profile %s\n", *remove)
+		return 0
+	}
+
+	names, err := profileNames()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	base, err := baseDataDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	status := 0
+	fmt.Printf("%-*s %6s %6s  %s\n", maxProfileNameLen, "PROFILE", "BEST", "GAMES", "LAST SCORE")
+	for _, name := range append([]string{defaultProfile}, names...) {
+		path := filepath.Join(dir, name)
+		if name == defaultProfile {
+			path = base
+		}
+		sum, err := summarizeProfile(name, path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			status = 1
+			continue
+		}
+		played := "-"
+		if !sum.Played.IsZero() {
+			played = sum.Played.Format("2006-01-02")
+		}
+		fmt.Printf("%-*s %6d %6d  %s\n", maxProfileNameLen, sum.Name, sum.Best, sum.Games, played)
+	}
+	return status
+}
+
+// ProfilePicker is the screen for choosing who's playing, shown at startup
+// once there are profiles to choose from. The default profile comes first
+// and a new one can be typed in last.
+type ProfilePicker struct {
+	Names    []string // Profiles to pick from, the default one first
+	Selected int      // Index into Names, or len(Names) for a new profile
+	Typing   bool     // Is a new profile's name being typed?
+	Name     []rune   // New profile's name so far
+	Err      error    // Why the name typed can't be used
+}
+
+// Handle a key, returning the profile picked and whether one has been.
+// Esc or q on the list quits, which picks nothing with quit set.
+func (p *ProfilePicker) key(ev Event) (name string, picked, quit bool) {
+	if ev.Key == KeyCtrlC {
+		return "", false, true
+	}
+	if p.Typing {
+		switch {
+		case ev.Key == KeyEnter:
+			name := string(p.Name)
+			if p.Err = validProfileName(name); p.Err == nil {
+				return name, true, false
+			}
+		case ev.Key == KeyEsc:
+			p.Typing, p.Name, p.Err = false, nil, nil
+		case ev.Key == KeyBackspace || ev.Key == KeyBackspace2:
+			if len(p.Name) > 0 {
+				p.Name = p.Name[:len(p.Name)-1]
+			}
+		case ev.Ch != 0 && len(p.Name) < maxProfileNameLen:
+			p.Name = append(p.Name, ev.Ch)
+		}
+		return "", false, false
+	}
+	switch {
+	case ev.Key == KeyArrowUp:
+		p.Selected = (p.Selected + len(p.Names)) % (len(p.Names) + 1)
+	case ev.Key == KeyArrowDown:
+		p.Selected = (p.Selected + 1) % (len(p.Names) + 1)
+	case ev.Key == KeyEnter || ev.Key == KeySpace:
+		switch {
+		case p.Selected == len(p.Names):
+			p.Typing = true
+		case p.Selected == 0:
+			return "", true, false
+		default:
+			return p.Names[p.Selected], true, false
+		}
+	case ev.Key == KeyEsc || ev.Ch == 'q':
+		return "", false, true
+	}
+	return "", false, false
+}
+
+// Draw the picker centered on the screen
+func (p *ProfilePicker) draw() {
+	screen.Clear()
+	cols, rows := screen.Size()
+	area := Rect{W: cols, H: rows}
+	top := max((rows-len(p.Names)-7)/2, 0)
+	area.Center(top, "WHO'S PLAYING?", colorScore|AttrBold)
+
+	lines := append(append([]string(nil), p.Names...), "New player")
+	if p.Typing {
+		lines[len(p.Names)] = "New player: " + string(p.Name) + "_"
+	}
+	w := 0
+	for _, l := range lines {
+		w = max(w, textWidth(l)+2)
+	}
+	x := max((cols-w)/2, 0)
+	for i, l := range lines {
+		fg, cursor := colorText, "  "
+		if i == p.Selected {
+			fg, cursor = ColorGreen|AttrBold, "> "
+		}
+		drawTextIn(x, top+2+i, cols-x, cursor+l, fg)
+	}
+
+	hint := "Arrows to choose, Enter to select, q to quit"
+	if p.Typing {
+		hint = "Type a name, Enter to create it, Esc to go back"
+	}
+	if p.Err != nil {
+		area.Center(top+len(lines)+3, p.Err.Error(), ColorRed)
+	}
+	area.Center(top+len(lines)+5, hint, ColorDarkGray)
+	screen.Flush()
+}
+
+// Show the profile picker on its own until a profile is picked. It returns
+// the name picked, empty for the default profile, and false if the player
+// quit instead.
+func pickProfile(rendererName string, explicit bool, names []string) (string, bool, error) {
+	r, err := openRenderer(rendererName, explicit)
+	if err != nil {
+		return "", false, err
+	}
+	screen = r
+	if err := screen.Init(); err != nil {
+		return "", false, err
+	}
+	defer screen.Close()
+
+	// Events are read here rather than on their own goroutine, which would
+	// go on reading them once the game has the screen
+	p := &ProfilePicker{Names: append([]string{defaultProfile}, names...)}
+	for {
+		p.draw()
+		ev := screen.PollEvent()
+		switch ev.Type {
+		case EventKey:
+			if name, picked, quit := p.key(ev); picked || quit {
+				return name, picked, nil
+			}
+		case EventError:
+			return "", false, ev.Err
+		case EventInterrupt:
+			return "", false, nil
+		}
+	}
+}
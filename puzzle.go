@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Puzzle constants
+const (
+	puzzlesFileName = "puzzles.json"
+	replayStepTime  = 150 * time.Millisecond // Pace of solution playback
+	maxStars        = 3
+)
+
+// Puzzle is a fixed board to clear of food in as few moves as possible
+type Puzzle struct {
+	Name string
+	Par  int    // Moves in the best known solution
+	Map  string // Level map, with '*' marking food
+}
+
+// Built-in puzzles and the user's own, easiest first. Maps use the level
+// symbols plus '*' for food, and must be walled in since puzzle boards still
+// wrap. Each starts with a "; par N" line.
+var builtinPuzzles = loadBuiltinPuzzles()
+
+func loadBuiltinPuzzles() []Puzzle {
+	var puzzles []Puzzle
+	for _, f := range contentFiles("puzzles") {
+		p := Puzzle{Name: strings.ReplaceAll(f.Name, "-", " "), Map: f.Text}
+		if _, err := fmt.Sscanf(f.Text, "; par %d", &p.Par); err != nil {
+			assetError(fmt.Errorf("puzzle %s: missing par: %w", f.Name, err))
+			continue
+		}
+		puzzles = append(puzzles, p)
+	}
+	return puzzles
+}
+
+// Level parses the puzzle's map
+func (p *Puzzle) Level() (*Level, error) {
+	level, err := ParseLevel(p.Name, strings.NewReader(p.Map))
+	if err != nil {
+		return nil, err
+	}
+	if len(level.Food) == 0 {
+		return nil, fmt.Errorf("%s: no food (%c) to eat", p.Name, levelFood)
+	}
+	return level, nil
+}
+
+// Stars awarded for solving a puzzle in the given number of moves: three
+// at or under par, two within half as many again, and one otherwise
+func stars(moves, par int) int {
+	switch {
+	case moves <= par:
+		return 3
+	case moves <= par+par/2:
+		return 2
+	}
+	return 1
+}
+
+// PuzzleRun is the state of the puzzle being played
+type PuzzleRun struct {
+	Puzzle  *Puzzle
+	Food    []Point // Food still on the board
+	Moves   int
+	Solved  bool
+	NewBest bool             // Did this solution beat the saved one?
+	history []puzzleSnapshot // State before each move, for undo
+}
+
+// Everything a puzzle move can change
+type puzzleSnapshot struct {
+	body      []Point
+	direction Direction
+	score     int
+	alive     bool
+	food      []Point
+	moves     int
+	ticks     int
+	level     int
+	inputs    int // Length of the replay's input list
+}
+
+// Eat the food at p, returning whether there was some
+func (r *PuzzleRun) eat(p Point) bool {
+	for i, f := range r.Food {
+		if f == p {
+			r.Food = append(r.Food[:i], r.Food[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Make one puzzle move. Puzzles are turn based: the snake only moves when
+// a direction key is pressed. Reversing into the body isn't a move at all.
+func (g *Game) puzzleMove(dir Direction) bool {
+	if g.state == StateGameOver || dir == g.Player().direction.Opposite() {
+		return false
+	}
+	g.saveSnapshot()
+	g.steer(0, dir)
+	g.Update()
+	if g.replay != nil {
+		g.replay.Ticks = g.ticks
+		g.recordCheckpoint()
+	}
+	return true
+}
+
+// Make a puzzle move for a direction key, see keyHeading
+func (g *Game) puzzleKey(key Direction) bool {
+	dir, ok := g.keyHeading(0, key)
+	return ok && g.puzzleMove(dir)
+}
+
+// Remember the state before a puzzle move
+func (g *Game) saveSnapshot() {
+	s := g.Player()
+	snap := puzzleSnapshot{
+		body:      append([]Point(nil), s.body...),
+		direction: s.direction,
+		score:     s.score,
+		alive:     s.alive,
+		food:      append([]Point(nil), g.puzzle.Food...),
+		moves:     g.puzzle.Moves,
+		ticks:     g.ticks,
+		level:     g.level,
+	}
+	if g.replay != nil {
+		snap.inputs = len(g.replay.Inputs)
+	}
+	g.puzzle.history = append(g.puzzle.history, snap)
+}
+
+// Take back the last puzzle move, including a fatal one. A solved puzzle
+// stays solved. Reports false when there is nothing to undo.
+func (g *Game) undoMove() bool {
+	run := g.puzzle
+	if run == nil || run.Solved || len(run.history) == 0 {
+		return false
+	}
+	snap := run.history[len(run.history)-1]
+	run.history = run.history[:len(run.history)-1]
+
+	s := g.Player()
+	s.body, s.direction, s.score, s.alive = snap.body, snap.direction, snap.score, snap.alive
+	run.Food, run.Moves = snap.food, snap.moves
+	g.ticks, g.level = snap.ticks, snap.level
+	g.state = StatePlaying
+	if g.replay != nil {
+		g.replay.Inputs = g.replay.Inputs[:snap.inputs]
+		g.replay.Ticks = g.ticks
+		g.trimCheckpoints()
+	}
+	return true
+}
+
+// Save a solved puzzle if it beats the best solution so far
+func (g *Game) recordPuzzle() error {
+	if g.puzzle == nil || !g.puzzle.Solved || g.replay == nil || g.puzzles == nil {
+		return nil
+	}
+	records := g.puzzles.Records
+	name := g.puzzle.Puzzle.Name
+	if best, ok := records.Best[name]; ok && best.Moves <= g.puzzle.Moves {
+		return nil
+	}
+
+	g.puzzle.NewBest = true
+	records.Best[name] = PuzzleRecord{
+		Moves:  g.puzzle.Moves,
+		Stars:  stars(g.puzzle.Moves, g.puzzle.Puzzle.Par),
+		Date:   time.Now(),
+		Replay: *g.replay,
+	}
+	return records.Save()
+}
+
+// PuzzleRecord is the best solution found for a puzzle
+type PuzzleRecord struct {
+	Moves  int       `json:"moves"`
+	Stars  int       `json:"stars"`
+	Date   time.Time `json:"date"`
+	Replay Replay    `json:"replay"`
+}
+
+// PuzzleRecords stores the best solution to each puzzle
+type PuzzleRecords struct {
+	Best map[string]PuzzleRecord `json:"best"` // By puzzle name
+	path string
+}
+
+// Load the puzzle records from the data directory. The records returned are
+// usable, if empty, even when loading fails.
+func LoadPuzzleRecords() (*PuzzleRecords, error) {
+	records := &PuzzleRecords{Best: make(map[string]PuzzleRecord)}
+	dir, err := dataDir()
+	if err != nil {
+		return records, err
+	}
+	return readPuzzleRecords(filepath.Join(dir, puzzlesFileName))
+}
+
+// Load puzzle records from a file, which needn't exist yet
+func readPuzzleRecords(path string) (*PuzzleRecords, error) {
+	records := &PuzzleRecords{Best: make(map[string]PuzzleRecord), path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	} else if err != nil {
+		return records, err
+	}
+
+	if err := json.Unmarshal(data, records); err != nil {
+		return &PuzzleRecords{Best: make(map[string]PuzzleRecord), path: path},
+			fmt.Errorf("parse %s: %w", path, err)
+	}
+	if records.Best == nil {
+		records.Best = make(map[string]PuzzleRecord)
+	}
+	return records, nil
+}
+
+// Save writes the records back to disk
+func (pr *PuzzleRecords) Save() error {
+	if pr.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(pr.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(pr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(pr.path, data, 0o644)
+}
+
+// PuzzleMenu is the puzzle select screen
+type PuzzleMenu struct {
+	Records  *PuzzleRecords
+	Selected int
+	levels   []*Level // Parsed map of each built-in puzzle
+}
+
+// Build the puzzle select screen, checking every puzzle fits the board
+func NewPuzzleMenu(records *PuzzleRecords) (*PuzzleMenu, error) {
+	m := &PuzzleMenu{Records: records}
+	for i := range builtinPuzzles {
+		level, err := builtinPuzzles[i].Level()
+		if err != nil {
+			return nil, err
+		}
+		m.levels = append(m.levels, level)
+	}
+	return m, nil
+}
+
+// Move the selection up or down
+func (m *PuzzleMenu) move(dir Direction) {
+	switch dir {
+	case Up:
+		m.Selected = (m.Selected + len(builtinPuzzles) - 1) % len(builtinPuzzles)
+	case Down:
+		m.Selected = (m.Selected + 1) % len(builtinPuzzles)
+	}
+}
+
+// Start a game of the selected puzzle
+func (m *PuzzleMenu) Start() *Game {
+	p := &builtinPuzzles[m.Selected]
+	level := m.levels[m.Selected]
+
+	g := NewGame(level, nil, 1, 0)
+	g.mode = modePuzzle
+	g.foods = nil // Only the puzzle's own food is in play
+	g.puzzle = &PuzzleRun{Puzzle: p, Food: append([]Point(nil), level.Food...)}
+	g.puzzles = m
+	g.replay = &Replay{Puzzle: p.Name, Rules: g.rules}
+	return g
+}
+
+// Start playing back the best solution to the selected puzzle, under the
+// rules it was found under, or return nil if it hasn't been solved yet or
+// was solved by a newer go-snake
+func (m *PuzzleMenu) Watch() (*Game, *ReplayPlayer) {
+	best, ok := m.Records.Best[builtinPuzzles[m.Selected].Name]
+	if !ok {
+		return nil, nil
+	}
+	rules, err := checkRules(best.Replay.Rules)
+	if err != nil {
+		return nil, nil
+	}
+	g, scratch := m.Start(), m.Start()
+	g.replay, scratch.replay = nil, nil
+	g.rules, scratch.rules = rules, rules
+	g.watching = true
+	g.playback = NewReplayPlayer(&best.Replay, g, scratch)
+	return g, g.playback
+}
+
+// Draw the puzzle select screen over the game area
+func drawPuzzleMenu(m *PuzzleMenu) {
+	solved := 0
+	lines := make([]string, len(builtinPuzzles))
+	for i, p := range builtinPuzzles {
+		best := " -"
+		rating := strings.Repeat(string(symbolStarEmpty), maxStars)
+		if rec, ok := m.Records.Best[p.Name]; ok {
+			best = fmt.Sprintf("%2d", rec.Moves)
+			rating = strings.Repeat(string(symbolStar), rec.Stars) + strings.Repeat(string(symbolStarEmpty), maxStars-rec.Stars)
+			solved++
+		}
+		lines[i] = fmt.Sprintf("%-13.13s par %2d best %s %s", p.Name, p.Par, best, rating)
+	}
+
+	title := fmt.Sprintf("PUZZLES  %d/%d SOLVED", solved, len(builtinPuzzles))
+	drawCentered(2, title, colorScore|AttrBold)
+	drawMenuList(lines, m.Selected, func(i int) { m.Selected = i })
+
+	hint := fmt.Sprintf("Enter to play, %s to watch best", keyHint(ActionReplay))
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
+
+// Draw the sidebar while a puzzle is being played
+func drawPuzzleSidebar(sb *Sidebar, g *Game) {
+	run := g.puzzle
+	sb.Blank()
+	sb.Blank()
+	sb.Textf(colorScore|AttrBold, "MOVES: %d", run.Moves)
+	sb.Textf(colorText, "PAR: %d", run.Puzzle.Par)
+	sb.Textf(colorText, "FOOD LEFT: %d", len(run.Food))
+	sb.Blank()
+	sb.Text("PUZZLE: "+strings.ToUpper(run.Puzzle.Name), colorText)
+	if g.watching {
+		sb.Blank()
+		sb.Text("WATCHING BEST", colorFood|AttrBold)
+		sb.Blank()
+		drawReplayInputs(sb, g)
+	}
+}
+
+// Draw the end of a puzzle over the game area
+func drawPuzzleResult(g *Game) {
+	run := g.puzzle
+
+	msg, fg := "STUCK!", colorFood|AttrBold
+	if run.Solved {
+		msg = fmt.Sprintf("SOLVED in %d moves (par %d)", run.Moves, run.Puzzle.Par)
+		fg = colorScore | AttrBold
+	}
+	drawCentered(viewHeight/2-1, msg, fg)
+
+	if run.Solved {
+		n := stars(run.Moves, run.Puzzle.Par)
+		rating := strings.Repeat(string(symbolStar), n) + strings.Repeat(string(symbolStarEmpty), maxStars-n)
+		if run.NewBest {
+			rating += "  New best!"
+		}
+		drawCentered(viewHeight/2, rating, colorScore)
+	}
+
+	hint := fmt.Sprintf("%s to undo, %s to retry, Enter for puzzles", keyHint(ActionUndo), keyHint(ActionRestart))
+	if g.watching {
+		hint = "Enter for puzzles"
+	} else if run.Solved {
+		hint = fmt.Sprintf("%s to retry, Enter for puzzles", keyHint(ActionRestart))
+	}
+	drawCentered(viewHeight/2+2, hint, colorText)
+}
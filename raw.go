@@ -0,0 +1,284 @@
+//go:build !js
+
+package main
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"unicode/utf8"
+
+	"golang.org/x/term"
+)
+
+// rawRenderer draws with plain escape sequences on a terminal put in raw
+// mode itself: termios on Unix, the console API on Windows. It needs no
+// terminfo and opens no tty of its own, so it works where termbox and tcell
+// can't start, such as a bare Windows console or a container with no
+// terminal database. It's the fallback when the renderer picked can't start.
+type rawRenderer struct {
+	in, out  *os.File
+	restore  *term.State
+	undoVT   func() // Puts the console back as it was, on Windows
+	frame    ansiFrame
+	buf      strings.Builder
+	events   chan Event
+	signals  chan os.Signal
+	mouse    bool
+	quitOnce sync.Once
+}
+
+// Escape sequences the raw renderer sends
+const (
+	rawEnter      = "\x1b[?1049h\x1b[?25l\x1b[0m\x1b[2J" // Alternate screen, cursor hidden, cleared
+	rawLeave      = "\x1b[0m\x1b[?25h\x1b[?1049l"        // Back to the normal screen, with the cursor
+	rawMouseOn    = "\x1b[?1000h\x1b[?1006h"             // Report button presses, with SGR coordinates
+	rawMouseOff   = "\x1b[?1006l\x1b[?1000l"
+	rawEventQueue = 64 // Input events read ahead of the game
+)
+
+func (r *rawRenderer) Init() error {
+	r.in, r.out = os.Stdin, os.Stdout
+	if !term.IsTerminal(int(r.in.Fd())) || !term.IsTerminal(int(r.out.Fd())) {
+		return errors.New("raw renderer: standard input and output must be a terminal")
+	}
+	undo, err := enableVT(r.out)
+	if err != nil {
+		return err
+	}
+	r.undoVT = undo
+	if r.restore, err = term.MakeRaw(int(r.in.Fd())); err != nil {
+		undo()
+		return err
+	}
+	r.out.WriteString(rawEnter)
+	r.frame.resize(r.Size())
+
+	// A terminal closed or a kill sent while in raw mode would otherwise
+	// leave the shell without echo
+	r.signals = make(chan os.Signal, 1)
+	signal.Notify(r.signals, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		if _, ok := <-r.signals; ok {
+			r.Close()
+			os.Exit(1)
+		}
+	}()
+
+	r.events = make(chan Event, rawEventQueue)
+	go r.read()
+	go watchResize(r.events, r.Size)
+	return nil
+}
+
+// Close puts the terminal back as it was. It's safe to call more than once,
+// so a signal arriving while the game exits does no harm.
+func (r *rawRenderer) Close() {
+	r.quitOnce.Do(func() {
+		signal.Stop(r.signals)
+		close(r.signals)
+		if r.mouse {
+			r.out.WriteString(rawMouseOff)
+		}
+		r.out.WriteString(rawLeave)
+		term.Restore(int(r.in.Fd()), r.restore)
+		r.undoVT()
+	})
+}
+
+func (r *rawRenderer) Size() (int, int) {
+	cols, rows, err := term.GetSize(int(r.out.Fd()))
+	if err != nil {
+		return 80, 24
+	}
+	return cols, rows
+}
+
+func (r *rawRenderer) EnableMouse() {
+	r.mouse = true
+	r.out.WriteString(rawMouseOn)
+}
+
+// Clear blanks the frame, at the terminal's new size if it has changed
+func (r *rawRenderer) Clear() {
+	if cols, rows := r.Size(); cols != r.frame.cols || rows != r.frame.rows {
+		r.frame.resize(cols, rows)
+		r.out.WriteString("\x1b[0m\x1b[2J")
+	}
+	r.frame.clear()
+}
+
+func (r *rawRenderer) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	r.frame.set(x, y, ch, fg, bg)
+}
+
+// Flush writes the cells that changed in one go, so the terminal never
+// shows half a frame
+func (r *rawRenderer) Flush() {
+	r.buf.Reset()
+	r.frame.write(&r.buf)
+	if r.buf.Len() > 0 {
+		r.out.WriteString(r.buf.String())
+	}
+}
+
+func (r *rawRenderer) PollEvent() Event {
+	return <-r.events
+}
+
+// Read input and turn it into events until it runs out. Escape sequences
+// arrive whole in a read, so an Esc at the end of one is the key itself.
+func (r *rawRenderer) read() {
+	buf := make([]byte, 256)
+	for {
+		n, err := r.in.Read(buf)
+		if err != nil {
+			r.events <- Event{Type: EventError, Err: err}
+			return
+		}
+		for _, ev := range parseInput(buf[:n]) {
+			r.events <- ev
+		}
+	}
+}
+
+// Keys sent as CSI sequences ending in a letter, like ESC [ A, and as SS3
+// sequences, like ESC O A
+var rawLetterKeys = map[byte]Key{
+	'A': KeyArrowUp,
+	'B': KeyArrowDown,
+	'C': KeyArrowRight,
+	'D': KeyArrowLeft,
+	'H': KeyHome,
+	'F': KeyEnd,
+	'E': KeyCenter, // Keypad 5 with NumLock off, G on the Linux console
+	'G': KeyCenter,
+	'P': KeyF1,
+	'Q': KeyF2,
+	'R': KeyF3,
+	'S': KeyF4,
+	'Z': KeyTab, // Shift+Tab
+}
+
+// Keys sent as CSI sequences ending in ~, like ESC [ 5 ~, by number
+var rawTildeKeys = map[int]Key{
+	1: KeyHome, 2: KeyInsert, 3: KeyDelete, 4: KeyEnd, 5: KeyPgup, 6: KeyPgdn, 7: KeyHome, 8: KeyEnd,
+	11: KeyF1, 12: KeyF2, 13: KeyF3, 14: KeyF4, 15: KeyF5, 17: KeyF6, 18: KeyF7,
+	19: KeyF8, 20: KeyF9, 21: KeyF10, 23: KeyF11, 24: KeyF12,
+}
+
+// Turn a read of terminal input into events. Sequences the game has no use
+// for, like mouse motion, are dropped.
+func parseInput(data []byte) []Event {
+	var events []Event
+	for len(data) > 0 {
+		ev, n, ok := parseEvent(data)
+		if ok {
+			events = append(events, ev)
+		}
+		data = data[n:]
+	}
+	return events
+}
+
+// Parse the event at the start of some input, returning how many bytes it
+// took and whether it's one the game knows
+func parseEvent(data []byte) (Event, int, bool) {
+	key := Event{Type: EventKey}
+	switch b := data[0]; {
+	case b == 0x1b && len(data) == 1:
+		key.Key = KeyEsc
+		return key, 1, true
+	case b == 0x1b && data[1] == '[':
+		return parseCSI(data)
+	case b == 0x1b && data[1] == 'O' && len(data) > 2 && data[2] >= 'p' && data[2] <= 'y':
+		// Keypad digits in application keypad mode
+		key.Ch = rune('0' + data[2] - 'p')
+		return key, 3, true
+	case b == 0x1b && data[1] == 'O' && len(data) > 2:
+		key.Key = rawLetterKeys[data[2]]
+		return key, 3, key.Key != 0
+	case b == 0x1b:
+		// Alt held with a key
+		ev, n, ok := parseEvent(data[1:])
+		ev.Mod |= ModAlt
+		return ev, n + 1, ok
+	case b == ' ':
+		key.Key = KeySpace
+		return key, 1, true
+	case b < 0x20 || b == 0x7f:
+		key.Key = Key(b)
+		return key, 1, true
+	}
+	ch, n := utf8.DecodeRune(data)
+	key.Ch = ch
+	return key, n, ch != utf8.RuneError
+}
+
+// Parse a CSI sequence, ESC [ then parameters and a final letter: a key, or
+// a mouse report with -mouse on
+func parseCSI(data []byte) (Event, int, bool) {
+	end := 2
+	for end < len(data) && (data[end] < 0x40 || data[end] > 0x7e) {
+		end++
+	}
+	if end == len(data) {
+		// Cut short, so take the Esc on its own
+		return Event{Type: EventKey, Key: KeyEsc}, 1, true
+	}
+	params, final := string(data[2:end]), data[end]
+	n := end + 1
+	if strings.HasPrefix(params, "<") {
+		ev, ok := parseMouse(params[1:], final)
+		return ev, n, ok
+	}
+	ev := Event{Type: EventKey}
+	if final == '~' {
+		num, _, _ := strings.Cut(params, ";")
+		code, _ := strconv.Atoi(num)
+		ev.Key = rawTildeKeys[code]
+	} else {
+		ev.Key = rawLetterKeys[final]
+	}
+	return ev, n, ev.Key != 0
+}
+
+// Parse an SGR mouse report, "button;column;row" then M for a press or m
+// for a release. Motion is dropped, as tcell and termbox leave it out
+// without asking for it.
+func parseMouse(params string, final byte) (Event, bool) {
+	fields := strings.Split(params, ";")
+	if len(fields) != 3 {
+		return Event{}, false
+	}
+	var nums [3]int
+	for i, f := range fields {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return Event{}, false
+		}
+		nums[i] = n
+	}
+	button := nums[0]
+	ev := Event{Type: EventMouse, MouseX: nums[1] - 1, MouseY: nums[2] - 1}
+	switch {
+	case button&32 != 0:
+		return ev, false
+	case final == 'm':
+		ev.Key = KeyMouseRelease
+	case button&64 != 0 && button&1 == 0:
+		ev.Key = KeyMouseWheelUp
+	case button&64 != 0:
+		ev.Key = KeyMouseWheelDown
+	default:
+		ev.Key = []Key{KeyMouseLeft, KeyMouseMiddle, KeyMouseRight, KeyMouseRelease}[button&3]
+	}
+	if button&8 != 0 {
+		ev.Mod |= ModAlt
+	}
+	return ev, true
+}
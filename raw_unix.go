@@ -0,0 +1,24 @@
+//go:build !js && !windows
+
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Unix terminals take escape sequences as they are
+func enableVT(*os.File) (func(), error) {
+	return func() {}, nil
+}
+
+// Send a resize event each time the terminal says it has changed size
+func watchResize(events chan<- Event, size func() (int, int)) {
+	winch := make(chan os.Signal, 1)
+	signal.Notify(winch, syscall.SIGWINCH)
+	for range winch {
+		w, h := size()
+		events <- Event{Type: EventResize, Width: w, Height: h}
+	}
+}
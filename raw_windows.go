@@ -0,0 +1,39 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// How often the console is checked for a new size, as Windows has no
+// signal for it
+const resizePoll = 250 * time.Millisecond
+
+// Have the console act on escape sequences written to it, returning how to
+// put it back. Raw mode already has it send them for keys.
+func enableVT(out *os.File) (func(), error) {
+	h := windows.Handle(out.Fd())
+	var mode uint32
+	if err := windows.GetConsoleMode(h, &mode); err != nil {
+		return nil, err
+	}
+	if err := windows.SetConsoleMode(h, mode|windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING); err != nil {
+		return nil, err
+	}
+	return func() { windows.SetConsoleMode(h, mode) }, nil
+}
+
+// Send a resize event each time the console is found to have changed size
+func watchResize(events chan<- Event, size func() (int, int)) {
+	w, h := size()
+	for range time.Tick(resizePoll) {
+		if nw, nh := size(); nw != w || nh != h {
+			w, h = nw, nh
+			events <- Event{Type: EventResize, Width: w, Height: h}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Recorder passes drawing through to another renderer, keeping a copy of
+// the screen so each frame it flushes can also be written to an asciicast v2
+// file, for asciinema and other players to play back. Only the cells that
+// changed since the last frame are written, timed from the start of the
+// recording.
+type Recorder struct {
+	Renderer
+	f     *os.File
+	w     *bufio.Writer
+	start time.Time
+	frame ansiFrame
+	out   strings.Builder
+	err   error // First write that failed
+}
+
+// Record what r draws to a new file at path
+func NewRecorder(r Renderer, path string) (*Recorder, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{Renderer: r, f: f, w: bufio.NewWriter(f)}, nil
+}
+
+// Start the renderer, then the recording at the screen's size
+func (r *Recorder) Init() error {
+	if err := r.Renderer.Init(); err != nil {
+		return err
+	}
+	cols, rows := r.Renderer.Size()
+	r.start = time.Now()
+	header, _ := json.Marshal(map[string]any{
+		"version":   2,
+		"width":     cols,
+		"height":    rows,
+		"timestamp": r.start.Unix(),
+		"title":     "go-snake",
+		"env":       map[string]string{"TERM": "xterm-256color"},
+	})
+	r.write(string(header) + "\n")
+	r.resize(cols, rows)
+	return nil
+}
+
+// Stop the renderer and finish the recording
+func (r *Recorder) Close() {
+	r.Renderer.Close()
+	r.event("o", "\x1b[0m\x1b[?25h")
+	if err := r.w.Flush(); err != nil && r.err == nil {
+		r.err = err
+	}
+	if err := r.f.Close(); err != nil && r.err == nil {
+		r.err = err
+	}
+}
+
+// Err reports the first write to the recording that failed
+func (r *Recorder) Err() error {
+	return r.err
+}
+
+// Clear starts a frame, at the screen's new size if it has changed
+func (r *Recorder) Clear() {
+	r.Renderer.Clear()
+	if cols, rows := r.Renderer.Size(); cols != r.frame.cols || rows != r.frame.rows {
+		r.event("r", fmt.Sprintf("%dx%d", cols, rows))
+		r.resize(cols, rows)
+	}
+	r.frame.clear()
+}
+
+func (r *Recorder) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	r.Renderer.SetCell(x, y, ch, fg, bg)
+	r.frame.set(x, y, ch, fg, bg)
+}
+
+// Show the frame, and write the cells that changed as terminal output
+func (r *Recorder) Flush() {
+	r.Renderer.Flush()
+	r.out.Reset()
+	r.frame.write(&r.out)
+	if r.out.Len() > 0 {
+		r.event("o", r.out.String())
+	}
+}
+
+// Size the frames, forgetting what was shown so the next frame is written
+// in full
+func (r *Recorder) resize(cols, rows int) {
+	r.frame.resize(cols, rows)
+	r.event("o", "\x1b[?25l\x1b[0m\x1b[2J")
+}
+
+// Write an event of the recording, timed from its start
+func (r *Recorder) event(kind, data string) {
+	text, _ := json.Marshal(data)
+	r.write(fmt.Sprintf("[%.6f, %q, %s]\n", time.Since(r.start).Seconds(), kind, text))
+}
+
+// Write to the recording, keeping the first error for Err to report
+func (r *Recorder) write(s string) {
+	if _, err := r.w.WriteString(s); err != nil && r.err == nil {
+		r.err = err
+	}
+}
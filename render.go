@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Renderer draws cells to the screen and reads its input. Colors and events
+// use the game's own types (see term.go) whichever renderer is in use, so
+// the rest of the game doesn't depend on a terminal library and builds for
+// the browser as well.
+type Renderer interface {
+	Init() error
+	Close()
+	Size() (int, int)
+	Clear()
+	SetCell(x, y int, ch rune, fg, bg Attribute) // Wide runes cover the next cell too
+	Flush()
+	PollEvent() Event
+	EnableMouse() // Report clicks and the mouse wheel as mouse events
+}
+
+// Renderers by name, for the -renderer flag. Each platform's renderers add
+// themselves.
+var renderers = map[string]func() Renderer{}
+
+// The renderer the game draws with
+var screen Renderer
+
+// Look up a renderer by name
+func rendererByName(name string) (Renderer, error) {
+	if r, ok := renderers[name]; ok {
+		return r(), nil
+	}
+	return nil, fmt.Errorf("unknown renderer %q (have %s)", name, strings.Join(rendererNames(), ", "))
+}
+
+// Open a renderer by name. Unless it was picked explicitly, one that can't
+// start falls back to the platform's fallback renderer.
+func openRenderer(name string, explicit bool) (Renderer, error) {
+	r, err := rendererByName(name)
+	if err != nil || explicit || fallbackRenderer == "" || name == fallbackRenderer {
+		return r, err
+	}
+	return &fallbackStart{Renderer: r, fallback: renderers[fallbackRenderer]}, nil
+}
+
+// fallbackStart starts a renderer, or another in its place if that one
+// can't start
+type fallbackStart struct {
+	Renderer
+	fallback func() Renderer
+}
+
+// Start the renderer or its fallback, reporting the first's error if
+// neither starts
+func (r *fallbackStart) Init() error {
+	err := r.Renderer.Init()
+	if err == nil {
+		return nil
+	}
+	fb := r.fallback()
+	if fb.Init() != nil {
+		return err
+	}
+	r.Renderer = fb
+	return nil
+}
+
+// Names of the available renderers, sorted
+func rendererNames() []string {
+	names := make([]string, 0, len(renderers))
+	for name := range renderers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
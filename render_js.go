@@ -0,0 +1,229 @@
+//go:build js
+
+package main
+
+import (
+	"errors"
+	"html"
+	"strconv"
+	"strings"
+	"syscall/js"
+	"unicode"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Browser constants
+const (
+	domElementID = "go-snake" // The <pre> element the game draws into
+	domEventBuf  = 64         // Key presses held until the game reads them
+)
+
+// Renderer used unless one is picked, and none to fall back on
+const (
+	defaultRenderer  = "dom"
+	fallbackRenderer = ""
+)
+
+func init() {
+	renderers["dom"] = func() Renderer { return &domRenderer{} }
+}
+
+// A cell of the DOM renderer's grid
+type domCell struct {
+	ch     rune
+	fg, bg Attribute
+}
+
+// domRenderer draws into a <pre> element on the page as rows of colored
+// spans, and reads key presses from the page. It is the renderer of the
+// WebAssembly build. Colors are CSS classes, f1-f16 for the foreground and
+// b1-b16 for the background, so the page picks the actual colors.
+type domRenderer struct {
+	pre        js.Value
+	cells      []domCell
+	cols, rows int
+	events     chan Event
+	onKey      js.Func
+	html       strings.Builder // Reused for building the page's markup
+}
+
+func (r *domRenderer) Init() error {
+	doc := js.Global().Get("document")
+	r.pre = doc.Call("getElementById", domElementID)
+	if r.pre.IsNull() {
+		return errors.New(`page has no <pre id="` + domElementID + `"> to draw in`)
+	}
+
+	// The grid covers the sidebar and the board with its border, and the
+	// status line under the board in case the layout key folds the sidebar
+	// away
+	r.cols, r.rows = sidebarWidth+width+2, height+3
+	r.cells = make([]domCell, r.cols*r.rows)
+
+	r.events = make(chan Event, domEventBuf)
+	r.onKey = js.FuncOf(func(this js.Value, args []js.Value) any {
+		ev, ok := domKeyEvent(args[0])
+		if !ok {
+			return nil
+		}
+		args[0].Call("preventDefault") // Keep the arrows from scrolling the page
+		select {
+		case r.events <- ev:
+		default: // The game is behind; drop the key as a full terminal would
+		}
+		return nil
+	})
+	doc.Call("addEventListener", "keydown", r.onKey)
+	return nil
+}
+
+// Stop reading keys, leaving the last frame on the page
+func (r *domRenderer) Close() {
+	js.Global().Get("document").Call("removeEventListener", "keydown", r.onKey)
+	r.onKey.Release()
+}
+
+func (r *domRenderer) Size() (int, int) { return r.cols, r.rows }
+
+func (r *domRenderer) Clear() {
+	clear(r.cells)
+}
+
+// A wide rune takes its cell and the next one, like the tcell renderer
+func (r *domRenderer) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	if x < 0 || y < 0 || x >= r.cols || y >= r.rows {
+		return
+	}
+	r.cells[y*r.cols+x] = domCell{ch, fg, bg}
+}
+
+// Rebuild the <pre>'s markup, one span for each run of cells in the same
+// colors
+func (r *domRenderer) Flush() {
+	b := &r.html
+	b.Reset()
+	for y := 0; y < r.rows; y++ {
+		row := r.cells[y*r.cols : (y+1)*r.cols]
+		open := false
+		var fg, bg Attribute
+		for x := 0; x < len(row); x++ {
+			c := row[x]
+			if c.ch == 0 {
+				c.ch = ' '
+			}
+			if !open || c.fg != fg || c.bg != bg {
+				if open {
+					b.WriteString("</span>")
+				}
+				fg, bg = c.fg, c.bg
+				b.WriteString(`<span class="`)
+				b.WriteString(domClasses(fg, bg))
+				b.WriteString(`">`)
+				open = true
+			}
+			b.WriteString(html.EscapeString(string(c.ch)))
+			if runewidth.RuneWidth(c.ch) == 2 {
+				x++ // The next cell is hidden under this one
+			}
+		}
+		if open {
+			b.WriteString("</span>")
+		}
+		b.WriteByte('\n')
+	}
+	r.pre.Set("innerHTML", b.String())
+}
+
+func (r *domRenderer) PollEvent() Event {
+	return <-r.events
+}
+
+// The page is played with the keyboard only
+func (r *domRenderer) EnableMouse() {}
+
+// CSS classes for a cell's colors and styles
+func domClasses(fg, bg Attribute) string {
+	style := fg.Style()
+	fg, bg = fg.Color(), bg.Color()
+	if style&AttrReverse != 0 {
+		fg, bg = bg, fg
+	}
+	var classes []string
+	if fg != ColorDefault {
+		classes = append(classes, "f"+strconv.Itoa(int(fg)))
+	}
+	if bg != ColorDefault {
+		classes = append(classes, "b"+strconv.Itoa(int(bg)))
+	}
+	for _, s := range []struct {
+		attr  Attribute
+		class string
+	}{{AttrBold, "bold"}, {AttrDim, "dim"}, {AttrUnderline, "underline"}, {AttrBlink, "blink"}} {
+		if style&s.attr != 0 {
+			classes = append(classes, s.class)
+		}
+	}
+	return strings.Join(classes, " ")
+}
+
+// Browser key names for keys with no character
+var domKeys = map[string]Key{
+	"ArrowUp":    KeyArrowUp,
+	"ArrowDown":  KeyArrowDown,
+	"ArrowLeft":  KeyArrowLeft,
+	"ArrowRight": KeyArrowRight,
+	"Home":       KeyHome,
+	"End":        KeyEnd,
+	"PageUp":     KeyPgup,
+	"PageDown":   KeyPgdn,
+	"Insert":     KeyInsert,
+	"Delete":     KeyDelete,
+	"Tab":        KeyTab,
+	"Enter":      KeyEnter,
+	"Escape":     KeyEsc,
+	"Backspace":  KeyBackspace2,
+	" ":          KeySpace,
+	"F1":         KeyF1,
+	"F2":         KeyF2,
+	"F3":         KeyF3,
+	"F4":         KeyF4,
+	"F5":         KeyF5,
+	"F6":         KeyF6,
+	"F7":         KeyF7,
+	"F8":         KeyF8,
+	"F9":         KeyF9,
+	"F10":        KeyF10,
+	"F11":        KeyF11,
+	"F12":        KeyF12,
+	"Clear":      KeyCenter, // Keypad 5 with NumLock off
+}
+
+// Translate a keydown event. Ctrl with a letter is the control character,
+// as in a terminal. Keys the game can't use, like Shift on its own, are
+// left to the browser.
+func domKeyEvent(e js.Value) (Event, bool) {
+	ev := Event{Type: EventKey}
+	if e.Get("altKey").Bool() {
+		ev.Mod = ModAlt
+	}
+	name := e.Get("key").String()
+	if key, ok := domKeys[name]; ok {
+		ev.Key = key
+		return ev, true
+	}
+	runes := []rune(name)
+	if len(runes) != 1 || e.Get("metaKey").Bool() {
+		return ev, false
+	}
+	ch := runes[0]
+	if e.Get("ctrlKey").Bool() {
+		if ch = unicode.ToLower(ch); ch < 'a' || ch > 'z' {
+			return ev, false
+		}
+		ev.Key = Key(ch - 'a' + 1)
+		return ev, true
+	}
+	ev.Ch = ch
+	return ev, true
+}
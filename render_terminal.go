@@ -0,0 +1,55 @@
+//go:build !js
+
+package main
+
+import (
+	"github.com/nsf/termbox-go"
+)
+
+// Renderer used unless one is picked, and the one used if that can't start
+const (
+	defaultRenderer  = "tcell"
+	fallbackRenderer = "raw"
+)
+
+func init() {
+	renderers["tcell"] = func() Renderer { return &tcellRenderer{} }
+	renderers["termbox"] = func() Renderer { return termboxRenderer{} }
+	renderers["raw"] = func() Renderer { return &rawRenderer{} }
+}
+
+// termboxRenderer draws with termbox-go, whose colors and keys the game's
+// own match
+type termboxRenderer struct{}
+
+func (termboxRenderer) Init() error      { return termbox.Init() }
+func (termboxRenderer) Close()           { termbox.Close() }
+func (termboxRenderer) Size() (int, int) { return termbox.Size() }
+func (termboxRenderer) Flush()           { termbox.Flush() }
+
+func (termboxRenderer) EnableMouse() {
+	termbox.SetInputMode(termbox.InputEsc | termbox.InputMouse)
+}
+
+func (termboxRenderer) Clear() {
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+}
+
+func (termboxRenderer) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	termbox.SetCell(x, y, ch, termbox.Attribute(fg), termbox.Attribute(bg))
+}
+
+func (termboxRenderer) PollEvent() Event {
+	ev := termbox.PollEvent()
+	return Event{
+		Type:   EventType(ev.Type),
+		Mod:    Modifier(ev.Mod),
+		Key:    Key(ev.Key),
+		Ch:     ev.Ch,
+		Width:  ev.Width,
+		Height: ev.Height,
+		MouseX: ev.MouseX,
+		MouseY: ev.MouseY,
+		Err:    ev.Err,
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"time"
+)
+
+// Replay constants
+const (
+	checkpointInterval = 10 // Ticks between checkpoints saved in a replay
+)
+
+// Playback speeds, as multiples of the normal pace
+var replaySpeeds = []float64{0.5, 1, 2, 4}
+
+// Replay is a recording of a game's inputs, enough to play it back exactly
+type Replay struct {
+	Seed        int64              `json:"seed"`
+	Rules       int                `json:"rules,omitempty"`   // Version of the rules it was played under, 0 for the first
+	Puzzle      string             `json:"puzzle,omitempty"`  // Puzzle played, empty outside puzzle mode
+	Ticks       int                `json:"ticks"`             // Length of the game
+	Heading     Direction          `json:"heading,omitempty"` // Way player 1 set off, which can be picked before the start
+	Inputs      []ReplayInput      `json:"inputs"`
+	Checkpoints []ReplayCheckpoint `json:"checkpoints,omitempty"` // Every few ticks, for seeking
+}
+
+// ReplayCheckpoint is the state of a puzzle game at one tick, so playback
+// can jump there without replaying everything before it
+type ReplayCheckpoint struct {
+	Tick      int       `json:"tick"`
+	Body      []Point   `json:"body"`
+	Direction Direction `json:"direction"`
+	Score     int       `json:"score"`
+	Alive     bool      `json:"alive"`
+	Food      []Point   `json:"food"`
+	Moves     int       `json:"moves"`
+	Level     int       `json:"level"`
+}
+
+// Bookmark is a moment worth jumping to in a replay
+type Bookmark struct {
+	Tick  int
+	Label string
+}
+
+// ReplayInput is one steering input and the tick it took effect on
+type ReplayInput struct {
+	Tick   int       `json:"tick"`
+	Player int       `json:"player,omitempty"`
+	Dir    Direction `json:"dir"`
+	Dash   bool      `json:"dash,omitempty"` // A dash made before the tick, rather than a turn
+}
+
+// Steer a snake and record the input in the game's replay
+func (g *Game) steer(player int, dir Direction) {
+	s := g.snakes[player]
+	if player == 0 && dir != s.direction && dir != s.direction.Opposite() {
+		g.stats.Turns++
+	}
+	from := s.direction
+	s.Turn(dir)
+	if s.direction != from {
+		g.logTurn(s, from)
+	}
+	if g.replay != nil {
+		g.replay.Inputs = append(g.replay.Inputs, ReplayInput{Tick: g.ticks, Player: player, Dir: dir})
+	}
+}
+
+// Add a checkpoint to the game's replay every few ticks
+func (g *Game) recordCheckpoint() {
+	if g.replay == nil || g.ticks%checkpointInterval != 0 {
+		return
+	}
+	g.replay.Checkpoints = append(g.replay.Checkpoints, g.checkpoint())
+}
+
+// Drop checkpoints past the current tick, after a move is undone
+func (g *Game) trimCheckpoints() {
+	cps := g.replay.Checkpoints
+	for len(cps) > 0 && cps[len(cps)-1].Tick > g.ticks {
+		cps = cps[:len(cps)-1]
+	}
+	g.replay.Checkpoints = cps
+}
+
+// Capture the state of a puzzle game
+func (g *Game) checkpoint() ReplayCheckpoint {
+	s := g.Player()
+	return ReplayCheckpoint{
+		Tick:      g.ticks,
+		Body:      append([]Point(nil), s.body...),
+		Direction: s.direction,
+		Score:     s.score,
+		Alive:     s.alive,
+		Food:      append([]Point(nil), g.puzzle.Food...),
+		Moves:     g.puzzle.Moves,
+		Level:     g.level,
+	}
+}
+
+// Put a puzzle game back the way a checkpoint found it
+func (g *Game) restore(cp ReplayCheckpoint) {
+	s := g.Player()
+	s.body, s.direction, s.score, s.alive = append([]Point(nil), cp.Body...), cp.Direction, cp.Score, cp.Alive
+	g.puzzle.Food, g.puzzle.Moves, g.puzzle.Solved = append([]Point(nil), cp.Food...), cp.Moves, false
+	g.ticks, g.level = cp.Tick, cp.Level
+	g.state = StatePlaying
+}
+
+// ReplayPlayer feeds a replay's inputs back into a fresh game, one tick
+// at a time. Playback can be sped up, slowed down, paused, and moved to any
+// tick or bookmark.
+type ReplayPlayer struct {
+	replay    *Replay
+	next      int              // Index of the next input to apply
+	start     ReplayCheckpoint // State at tick 0
+	speed     int              // Index into replaySpeeds
+	Paused    bool
+	Bookmarks []Bookmark // Moments found by scanning the replay, in order
+}
+
+// Get ready to play a replay back into g, which must be fresh. The replay
+// is played through once on scratch, a game just like g, to find the
+// bookmarks and any checkpoints an older recording lacks.
+func NewReplayPlayer(r *Replay, g, scratch *Game) *ReplayPlayer {
+	rp := &ReplayPlayer{replay: r, speed: 1, start: g.checkpoint()}
+	rp.scan(scratch)
+	return rp
+}
+
+// Play the replay through, bookmarking food eaten and close calls: turns
+// made with the way ahead blocked
+func (rp *ReplayPlayer) scan(g *Game) {
+	scan := &ReplayPlayer{replay: rp.replay}
+	record := len(rp.replay.Checkpoints) == 0
+	for {
+		s := g.Player()
+		ahead, ok := g.move(s.Head(), s.direction)
+		blocked := !ok || g.blockedCells()[ahead]
+		heading, food := s.direction, len(g.puzzle.Food)
+		if !scan.Step(g) {
+			return
+		}
+		switch {
+		case len(g.puzzle.Food) < food:
+			rp.Bookmarks = append(rp.Bookmarks, Bookmark{Tick: g.ticks, Label: "FOOD"})
+		case blocked && s.alive && s.direction != heading:
+			rp.Bookmarks = append(rp.Bookmarks, Bookmark{Tick: g.ticks, Label: "CLOSE CALL"})
+		}
+		if record && g.ticks%checkpointInterval == 0 {
+			rp.replay.Checkpoints = append(rp.replay.Checkpoints, g.checkpoint())
+		}
+	}
+}
+
+// Time between steps at the current speed
+func (rp *ReplayPlayer) StepTime() time.Duration {
+	return time.Duration(float64(replayStepTime) / replaySpeeds[rp.speed])
+}
+
+// Change the playback speed by delta steps
+func (rp *ReplayPlayer) ChangeSpeed(delta int) {
+	rp.speed = min(max(rp.speed+delta, 0), len(replaySpeeds)-1)
+}
+
+// Done reports whether playback has reached the end
+func (rp *ReplayPlayer) Done(g *Game) bool {
+	return g.state == StateGameOver || g.ticks >= rp.replay.Ticks
+}
+
+// Seek moves playback to a tick, from the last checkpoint before it
+func (rp *ReplayPlayer) Seek(g *Game, tick int) {
+	tick = min(max(tick, 0), rp.replay.Ticks)
+	cp := rp.start
+	for _, c := range rp.replay.Checkpoints {
+		if c.Tick <= tick && c.Tick > cp.Tick {
+			cp = c
+		}
+	}
+	g.restore(cp)
+	rp.next = 0
+	for rp.next < len(rp.replay.Inputs) && rp.replay.Inputs[rp.next].Tick < cp.Tick {
+		rp.next++
+	}
+	for g.ticks < tick && rp.Step(g) {
+	}
+	if g.state == StatePlaying && g.ticks >= rp.replay.Ticks && len(g.puzzle.Food) == 0 {
+		g.puzzle.Solved = true
+		g.state = StateGameOver
+	}
+}
+
+// Jump to the next bookmark after the current tick, or the last one before
+// it when dir is negative. Reports false if there is none that way.
+func (rp *ReplayPlayer) JumpBookmark(g *Game, dir int) bool {
+	if dir > 0 {
+		for _, b := range rp.Bookmarks {
+			if b.Tick > g.ticks {
+				rp.Seek(g, b.Tick)
+				return true
+			}
+		}
+		return false
+	}
+	for i := len(rp.Bookmarks) - 1; i >= 0; i-- {
+		if b := rp.Bookmarks[i]; b.Tick < g.ticks {
+			rp.Seek(g, b.Tick)
+			return true
+		}
+	}
+	return false
+}
+
+// Step applies the inputs recorded for the game's current tick and
+// advances it. Reports false once the game has ended or the recording runs
+// out.
+func (rp *ReplayPlayer) Step(g *Game) bool {
+	if g.state == StateGameOver || g.ticks >= rp.replay.Ticks {
+		return false
+	}
+	g.replayed = g.replayed[:0]
+	for rp.next < len(rp.replay.Inputs) && rp.replay.Inputs[rp.next].Tick == g.ticks {
+		in := rp.replay.Inputs[rp.next]
+		rp.next++
+		if in.Dash {
+			continue
+		}
+		g.snakes[in.Player].Turn(in.Dir)
+		g.replayed = append(g.replayed, in.Dir)
+	}
+	g.Update()
+	return true
+}
+
+// Draw the keys pressed on the last replay step as a pad of arrows in the
+// sidebar, lighting up the ones that were pressed, then where playback is
+func drawReplayInputs(sb *Sidebar, g *Game) {
+	sb.Text("INPUT", colorText)
+	x, y := sb.Block(4) // The pad and a gap below it
+	y--                 // Arrow rows count from 1
+	arrows := []struct {
+		dir  Direction
+		x, y int
+		ch   rune
+	}{
+		{Up, 4, 1, '^'},
+		{Left, 2, 2, '<'},
+		{Right, 6, 2, '>'},
+		{Down, 4, 3, 'v'},
+	}
+	for _, a := range arrows {
+		fg := ColorDarkGray
+		for _, dir := range g.replayed {
+			if dir == a.dir {
+				fg = colorScore | AttrBold
+			}
+		}
+		screen.SetCell(x-2+a.x, y+a.y, a.ch, fg, ColorDefault)
+	}
+
+	rp := g.playback
+	if rp == nil {
+		return
+	}
+	sb.Textf(colorText, "TICK %d/%d %gx", g.ticks, rp.replay.Ticks, replaySpeeds[rp.speed])
+	if rp.Paused {
+		sb.Text("PAUSED", colorFood|AttrBold)
+	} else {
+		sb.Blank()
+	}
+	for _, b := range rp.Bookmarks {
+		if b.Tick > g.ticks {
+			sb.Textf(colorScore, "NEXT: %s @%d", b.Label, b.Tick)
+			break
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import "fmt"
+
+// Rewind constants
+const (
+	rewindTicks       = 50 // Ticks kept to rewind through after a game ends
+	practiceUndos     = 3  // Crashes that can be undone in a practice game
+	practiceUndoTicks = 5  // Ticks an undo goes back unless a tick is picked
+)
+
+// Keep the state before this tick, dropping the oldest once there are
+// enough to rewind through
+func (g *Game) remember() {
+	if !g.keepHistory {
+		return
+	}
+	if len(g.history) == rewindTicks {
+		g.history = append(g.history[:0], g.history[1:]...)
+	}
+	g.history = append(g.history, g.snapshot())
+}
+
+// Step through the final ticks of a finished game: back a tick for a
+// positive delta, forward for a negative one. The end is kept as the last
+// state, so stepping forward past the first tick goes back to it.
+func (g *Game) stepRewind(delta int) bool {
+	if g.state != StateGameOver || len(g.history) == 0 {
+		return false
+	}
+	if g.rewind == 0 {
+		if delta <= 0 {
+			return false
+		}
+		g.history = append(g.history, g.snapshot())
+	}
+	g.rewind = min(max(g.rewind+delta, 0), len(g.history)-1)
+	g.restoreSnapshot(&g.history[len(g.history)-1-g.rewind])
+	g.popups = nil
+	g.showScores, g.showGlobal, g.showGameStats = false, false, false
+	if g.rewind == 0 {
+		g.history = g.history[:len(g.history)-1]
+	}
+	return true
+}
+
+// Undo the crash in a practice game, picking up from the tick being
+// rewound to or a few ticks before the end. The game waits paused, and
+// only counts the score it had when it first ended.
+func (g *Game) undoCrash() bool {
+	if g.state != StateGameOver || g.undos <= 0 || g.Versus() || len(g.history) == 0 || (g.campaign != nil && g.campaign.Cleared) {
+		return false
+	}
+	if g.rewind == 0 {
+		g.stepRewind(practiceUndoTicks)
+	}
+	g.history = g.history[:len(g.history)-1-g.rewind]
+	g.rewind = 0
+	g.undos--
+	g.state = StatePaused
+	g.scoreRank = -1
+	g.global = nil
+
+	// The run no longer matches a recording, and the keyboard starts afresh
+	g.ghost, g.replay = nil, nil
+	if _, ok := g.controllers[0].(*Human); ok {
+		g.setController(0, &Human{})
+	}
+	g.notify(fmt.Sprintf("Crash undone, %d left", g.undos))
+	return true
+}
+
+// Show how far back the board is, over its bottom border
+func drawRewind(g *Game) {
+	back := keyHint(ActionRewind)
+	msg := fmt.Sprintf(" REWIND -%d: %s back, arrows step ", g.rewind, back)
+	if g.undos > 0 && !g.Versus() {
+		msg = fmt.Sprintf(" REWIND -%d: %s/arrows, %s undo (%d) ", g.rewind, back, keyHint(ActionUndo), g.undos)
+	}
+	drawCentered(viewHeight+1, msg, colorScore|AttrBold)
+}
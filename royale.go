@@ -0,0 +1,124 @@
+package main
+
+import (
+	"slices"
+	"time"
+)
+
+// Royale mode constants
+const (
+	royaleShrinkEvery = 15 * time.Second // Game time between the arena closing in a ring
+	royaleWarning     = 3 * time.Second  // The ring about to close blinks this long beforehand
+	royaleMinSize     = 6                // The arena stops closing in before it's narrower than this
+)
+
+// Most rings of the board that can close, leaving at least royaleMinSize
+// cells across and down
+func arenaLimit() int {
+	return max((min(width, height)-royaleMinSize)/2, 0)
+}
+
+// Check whether a cell is outside the arena left once some rings around the
+// board have closed
+func (g *Game) outsideArena(p Point, rings int) bool {
+	return p.X < rings || p.Y < rings || p.X >= width-rings || p.Y >= height-rings
+}
+
+// Add the closed rings to the cells hazards make deadly, and the ring
+// closing next to those they soon will
+func (g *Game) arenaCells(deadly, warned map[Point]bool) {
+	if g.mode != modeRoyale {
+		return
+	}
+	closing := g.closed
+	if g.closed < arenaLimit() && time.Duration(g.closed+1)*royaleShrinkEvery-g.clock <= royaleWarning {
+		closing++
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Point{X: x, Y: y}
+			if g.outsideArena(p, g.closed) {
+				deadly[p] = true
+			} else if g.outsideArena(p, closing) {
+				warned[p] = true
+			}
+		}
+	}
+}
+
+// Close the arena in a ring at a time as time goes on. Snakes whose heads
+// are caught outside die, and food outside moves in, with another food
+// each time so there's more to eat the less room there is.
+func (g *Game) updateArena() {
+	closed := g.closed
+	for g.closed < arenaLimit() && g.clock >= time.Duration(g.closed+1)*royaleShrinkEvery {
+		g.closed++
+	}
+	if g.closed == closed {
+		return
+	}
+	g.notify("The arena closes in!")
+
+	for _, s := range g.snakes {
+		if head := s.Head(); s.alive && g.outsideArena(head, g.closed) {
+			s.alive, s.died = false, causeArena
+			g.queueSound(SoundCrash, 0)
+			g.logMove(s, "caught outside the arena at (%d,%d)", head.X, head.Y)
+		}
+	}
+
+	outside := func(p Point) bool { return g.outsideArena(p, g.closed) }
+	moved := 0
+	left := g.foods[:0]
+	for _, f := range g.foods {
+		if outside(f.At) {
+			moved++
+			continue
+		}
+		left = append(left, f)
+	}
+	g.foods = left
+	for i := 0; i < moved+g.closed-closed; i++ {
+		g.PlaceFood()
+	}
+	g.frenzyFood = slices.DeleteFunc(g.frenzyFood, func(f FrenzyFood) bool { return outside(f.At) })
+	g.crumbs = slices.DeleteFunc(g.crumbs, func(c Crumb) bool { return outside(c.At) })
+	g.checkGameOver()
+}
+
+// Draw the closed rings as walls, and the ring closing next blinking
+func drawArena(g *Game, head Point) {
+	if g.mode != modeRoyale {
+		return
+	}
+	deadly, warned := map[Point]bool{}, map[Point]bool{}
+	g.arenaCells(deadly, warned)
+	draw := func(cells map[Point]bool, fg Attribute) {
+		if g.state == StatePaused {
+			fg = ColorDarkGray
+		}
+		for p := range cells {
+			if !g.mods.hidden(head, p) {
+				setBoardCell(p, symbolWall, fg)
+			}
+		}
+	}
+	draw(deadly, colorHazard)
+	draw(warned, colorHazardWarning|AttrBlink)
+}
+
+// Draw the time until the arena next closes in, or its size once it's as
+// small as it gets
+func drawArenaStatus(sb *Sidebar, g *Game) {
+	if g.closed >= arenaLimit() {
+		sb.Textf(colorText, "ARENA: %dx%d", width-2*g.closed, height-2*g.closed)
+		return
+	}
+	left := time.Duration(g.closed+1)*royaleShrinkEvery - g.clock
+	secs := int((left + time.Second - 1) / time.Second)
+	fg := colorText
+	if left <= royaleWarning {
+		fg = colorFood | AttrBold
+	}
+	sb.Textf(fg, "CLOSING IN: %ds", secs)
+}
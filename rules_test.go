@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCheckRules(t *testing.T) {
+	tests := []struct {
+		version int
+		want    int
+		ok      bool
+	}{
+		{0, 1, true}, // Recorded before rules had versions
+		{1, 1, true},
+		{mysteryRules, mysteryRules, true},
+		{rulesVersion, rulesVersion, true},
+		{rulesVersion + 1, 0, false},
+	}
+	for _, tt := range tests {
+		got, err := checkRules(tt.version)
+		if got != tt.want || (err == nil) != tt.ok {
+			t.Errorf("checkRules(%d) = %d, %v; want %d", tt.version, got, err, tt.want)
+		}
+	}
+}
+
+// A saved game goes on under the rules it was started under
+func TestSavedGameKeepsRules(t *testing.T) {
+	for rules := 1; rules <= rulesVersion; rules++ {
+		g := &Game{}
+		g.resetUnder(rules, nil, UniformSpawn{}, 1, 1)
+		data, err := json.Marshal(g.snapshot())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var sg SavedGame
+		if err := json.Unmarshal(data, &sg); err != nil {
+			t.Fatal(err)
+		}
+		if sg.Rules, err = checkRules(sg.Rules); err != nil {
+			t.Fatal(err)
+		}
+		resumed := NewGame(nil, UniformSpawn{}, 1, 1)
+		resumed.restoreSnapshot(&sg)
+		if resumed.rules != rules {
+			t.Errorf("game saved under rules %d resumed under %d", rules, resumed.rules)
+		}
+	}
+}
+
+// A game set up under rules from before mystery boxes never has one, not
+// even among the food it starts with
+func TestOldRulesStartWithOldFood(t *testing.T) {
+	mystery := func(g *Game) bool {
+		for _, f := range g.foods {
+			if f.Special != nil && f.Special.Effect == EffectMystery {
+				return true
+			}
+		}
+		return false
+	}
+	seen := false
+	for seed := int64(1); seed <= 2000; seed++ {
+		g := &Game{}
+		g.resetUnder(mysteryRules-1, nil, UniformSpawn{}, 1, seed)
+		if mystery(g) {
+			t.Fatalf("seed %d starts with a mystery box under rules %d", seed, g.rules)
+		}
+		seen = seen || mystery(NewGame(nil, UniformSpawn{}, 1, seed))
+	}
+	if !seen {
+		t.Fatal("no seed starts with a mystery box under the current rules, so nothing was checked")
+	}
+}
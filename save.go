@@ -0,0 +1,310 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Save file constants
+const (
+	saveFileName = "save.json"
+)
+
+// countingSource is a random source that counts the numbers drawn from it,
+// so a resumed game can wind a fresh source on to the same point
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.src.Seed(seed)
+	s.draws = 0
+}
+
+// Skip ahead past n draws
+func (s *countingSource) skip(n uint64) {
+	for s.draws < n {
+		s.Uint64()
+	}
+}
+
+// SavedGame is a game in progress written to disk to be resumed later
+type SavedGame struct {
+	Date         time.Time     `json:"date"`
+	Seed         int64         `json:"seed"`
+	Rules        int           `json:"rules,omitempty"` // Version of the rules the game is played under, 0 for the first
+	Draws        uint64        `json:"draws"`           // Numbers drawn from the seeded source so far
+	Width        int           `json:"width"`
+	Height       int           `json:"height"`
+	Mode         string        `json:"mode"`
+	Settings     Settings      `json:"settings"`
+	Difficulty   Difficulty    `json:"difficulty"`
+	Mods         Modifiers     `json:"mods"`
+	Challenge    string        `json:"challenge,omitempty"`
+	LevelName    string        `json:"level_name,omitempty"`
+	Walls        []Point       `json:"walls,omitempty"`
+	Portals      []Portal      `json:"portals,omitempty"`
+	Snakes       []savedSnake  `json:"snakes"`
+	Foods        []savedFood   `json:"foods"`
+	FoodRespawns []int         `json:"food_respawns,omitempty"`
+	FoodMix      []int         `json:"food_mix,omitempty"`
+	NextFoodType int           `json:"next_food_type"`
+	RecentFood   []Point       `json:"recent_food,omitempty"`
+	FrenzyFood   []FrenzyFood  `json:"frenzy_food,omitempty"`
+	Crumbs       []Crumb       `json:"crumbs,omitempty"`
+	Bursts       int           `json:"bursts,omitempty"`
+	Hazards      []Hazard      `json:"hazards,omitempty"`
+	Event        string        `json:"event,omitempty"` // Name of the running event
+	EventTicks   int           `json:"event_ticks,omitempty"`
+	Banner       int           `json:"banner,omitempty"`
+	Level        int           `json:"level"`
+	Ticks        int           `json:"ticks"`
+	Clock        time.Duration `json:"clock"`
+	Wall         time.Duration `json:"wall,omitempty"` // Wall-clock time played, pauses and all
+	NextHazard   time.Duration `json:"next_hazard"`
+	NextPayout   time.Duration `json:"next_payout,omitempty"`
+	Paint        []int8        `json:"paint,omitempty"` // Territory held, by cell
+	Closed       int           `json:"closed,omitempty"`
+	StartLength  int           `json:"start_length,omitempty"` // Segments the snakes started with, 0 for the usual
+	BotAssisted  bool          `json:"bot_assisted,omitempty"`
+	Rival        bool          `json:"rival,omitempty"` // Is the last snake a bot standing in for a second player?
+	Stats        GameStats     `json:"stats"`
+}
+
+// One snake in a saved game
+type savedSnake struct {
+	Body        []Point        `json:"body"`
+	Direction   Direction      `json:"direction"`
+	Score       int            `json:"score"`
+	FrenzyScore int            `json:"frenzy_score,omitempty"`
+	Alive       bool           `json:"alive"`
+	Decay       float64        `json:"decay,omitempty"`
+	Effects     map[Effect]int `json:"effects,omitempty"`
+}
+
+// One food in a saved game, with special foods saved by name
+type savedFood struct {
+	At      Point  `json:"at"`
+	Type    int    `json:"type"`
+	Special string `json:"special,omitempty"`
+	Timer   int    `json:"timer"`
+}
+
+// Path of the save file in the data directory
+func savePath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, saveFileName), nil
+}
+
+// Save writes the game to the save file, replacing any saved before
+func (g *Game) Save() error {
+	if g.puzzle != nil {
+		return errors.New("puzzles can't be saved")
+	}
+	sg := g.snapshot()
+	path, err := savePath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sg, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0o644)
+}
+
+// Capture the game's state, copied so that playing on doesn't change it
+func (g *Game) snapshot() SavedGame {
+	sg := SavedGame{
+		Date:         time.Now(),
+		Seed:         g.seed,
+		Rules:        g.rules,
+		Draws:        g.source.draws,
+		Width:        width,
+		Height:       height,
+		Mode:         g.mode,
+		Settings:     settings,
+		Difficulty:   g.difficulty,
+		Mods:         g.mods,
+		Challenge:    g.challenge,
+		LevelName:    g.levelName,
+		Portals:      slices.Clone(g.portals),
+		FoodRespawns: slices.Clone(g.foodRespawns),
+		FoodMix:      slices.Clone(g.foodMix),
+		NextFoodType: g.nextFoodType,
+		RecentFood:   slices.Clone(g.recentFood),
+		FrenzyFood:   slices.Clone(g.frenzyFood),
+		Crumbs:       slices.Clone(g.crumbs),
+		Bursts:       g.bursts,
+		Hazards:      slices.Clone(g.hazards),
+		EventTicks:   g.eventTicks,
+		Banner:       g.banner,
+		Level:        g.level,
+		Ticks:        g.ticks,
+		Clock:        g.clock,
+		Wall:         g.wallTime(),
+		NextHazard:   g.nextHazard,
+		NextPayout:   g.nextPayout,
+		Paint:        slices.Clone(g.paint),
+		Closed:       g.closed,
+		StartLength:  g.startLength,
+		BotAssisted:  g.botAssisted,
+		Stats:        g.stats,
+	}
+	sg.Stats.Eaten = maps.Clone(g.stats.Eaten)
+	sg.Rival = g.rival
+	for p := range g.walls {
+		sg.Walls = append(sg.Walls, p)
+	}
+	for _, s := range g.snakes {
+		sg.Snakes = append(sg.Snakes, savedSnake{
+			Body:        slices.Clone(s.body),
+			Direction:   s.direction,
+			Score:       s.score,
+			FrenzyScore: s.frenzyScore,
+			Alive:       s.alive,
+			Decay:       s.decay,
+			Effects:     maps.Clone(s.effects),
+		})
+	}
+	for _, f := range g.foods {
+		saved := savedFood{At: f.At, Type: f.Type, Timer: f.Timer}
+		if f.Special != nil {
+			saved.Special = f.Special.Name
+		}
+		sg.Foods = append(sg.Foods, saved)
+	}
+	if g.event != nil {
+		sg.Event = g.event.Name()
+	}
+	return sg
+}
+
+// Load the saved game and remove the save file, so each save is resumed
+// once. Settings saved with the game replace the current ones.
+func LoadSavedGame() (*Game, error) {
+	path, err := savePath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, errors.New("no saved game to resume")
+	} else if err != nil {
+		return nil, err
+	}
+	var sg SavedGame
+	if err := json.Unmarshal(data, &sg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if sg.Width != width || sg.Height != height {
+		return nil, fmt.Errorf("saved game is %dx%d but the board is %dx%d", sg.Width, sg.Height, width, height)
+	}
+	if len(sg.Snakes) == 0 || len(sg.Snakes) > maxPlayers {
+		return nil, fmt.Errorf("%s: saved game has %d snakes", path, len(sg.Snakes))
+	}
+	if sg.Paint != nil && len(sg.Paint) != sg.Width*sg.Height {
+		return nil, fmt.Errorf("%s: saved game has territory for %d cells", path, len(sg.Paint))
+	}
+	if sg.Rules, err = checkRules(sg.Rules); err != nil {
+		return nil, fmt.Errorf("saved game %w", err)
+	}
+
+	settings = sg.Settings
+	g := NewGame(nil, newSpawnPolicy(settings), len(sg.Snakes), sg.Seed)
+	g.foodTick = settings.FoodTick
+	g.relative = settings.Relative
+	g.restoreSnapshot(&sg)
+	// The wall clock carries on from the save. Rewinding doesn't take it
+	// back, so restoring a snapshot leaves it be.
+	g.wall = sg.Wall
+	if sg.Rival && rivalBot(g.mode) != nil {
+		g.rival = true
+		g.setController(len(g.snakes)-1, rivalBot(g.mode))
+	}
+
+	// A resumed game starts paused so the player can get ready
+	g.state = StatePaused
+	return g, os.Remove(path)
+}
+
+// Put the game back the way a snapshot found it. The game's state and
+// settings are left as they are.
+func (g *Game) restoreSnapshot(sg *SavedGame) {
+	// Wind a fresh source on past every number the game had drawn
+	g.source = newCountingSource(sg.Seed)
+	g.rng = rand.New(g.source)
+	g.source.skip(sg.Draws)
+	g.rules = sg.Rules
+	g.mode = sg.Mode
+	g.difficulty = sg.Difficulty
+	g.mods = sg.Mods
+	g.challenge = sg.Challenge
+	g.levelName = sg.LevelName
+	g.walls = make(map[Point]bool, len(sg.Walls))
+	for _, p := range sg.Walls {
+		g.walls[p] = true
+	}
+	g.portals = slices.Clone(sg.Portals)
+	for i, saved := range sg.Snakes {
+		s := g.snakes[i]
+		s.body, s.direction, s.alive = slices.Clone(saved.Body), saved.Direction, saved.Alive
+		s.score, s.frenzyScore, s.decay = saved.Score, saved.FrenzyScore, saved.Decay
+		s.effects = maps.Clone(saved.Effects)
+	}
+	g.foods = nil
+	for _, saved := range sg.Foods {
+		f := Food{At: saved.At, Type: saved.Type, Timer: saved.Timer, Special: specialFoodByName(saved.Special)}
+		g.foods = append(g.foods, f)
+	}
+	g.foodRespawns = slices.Clone(sg.FoodRespawns)
+	g.foodMix = slices.Clone(sg.FoodMix)
+	g.nextFoodType = sg.NextFoodType
+	g.recentFood = slices.Clone(sg.RecentFood)
+	g.frenzyFood = slices.Clone(sg.FrenzyFood)
+	g.crumbs, g.bursts = slices.Clone(sg.Crumbs), sg.Bursts
+	g.hazards = slices.DeleteFunc(slices.Clone(sg.Hazards), func(h Hazard) bool { return hazardKindByName(h.Kind) == nil })
+	g.event, g.eventTicks, g.banner = nil, sg.EventTicks, sg.Banner
+	for _, ec := range randomEvents {
+		if ec.event.Name() == sg.Event {
+			g.event = ec.event
+		}
+	}
+	g.level, g.ticks, g.clock, g.nextHazard = sg.Level, sg.Ticks, sg.Clock, sg.NextHazard
+	g.nextPayout, g.paint, g.closed = sg.NextPayout, slices.Clone(sg.Paint), sg.Closed
+	if sg.StartLength > 0 {
+		g.startLength = sg.StartLength
+	}
+	g.botAssisted = sg.BotAssisted
+	g.stats = sg.Stats
+	g.stats.Eaten = maps.Clone(sg.Stats.Eaten)
+}
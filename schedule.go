@@ -0,0 +1,107 @@
+package main
+
+import "time"
+
+// Schedule constants
+const (
+	maxTickLag = 250 * time.Millisecond // Furthest behind a schedule gets before it starts afresh
+)
+
+// Schedule times ticks against deadlines rather than a free-running ticker.
+// Each tick is due an interval after the last one was due, not after it was
+// handled, so time spent handling ticks doesn't add up over a long game. The
+// interval can change from tick to tick without restarting the schedule, so
+// a new speed takes effect from the very next tick.
+type Schedule struct {
+	C     <-chan time.Time // Receives each tick
+	clock Clock
+	timer Timer
+	due   time.Time // When the next tick is due, zero while stopped
+}
+
+// Clock tells the time and sets timers for a schedule. The game runs on
+// the real clock; tests stand in one they move on by hand.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is what a schedule needs of a timer: a time.Timer on the real clock
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// The real clock
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) NewTimer(d time.Duration) Timer {
+	return realTimer{time.NewTimer(d)}
+}
+
+type realTimer struct {
+	t *time.Timer
+}
+
+func (r realTimer) C() <-chan time.Time {
+	return r.t.C
+}
+
+func (r realTimer) Stop() bool {
+	return r.t.Stop()
+}
+
+func (r realTimer) Reset(d time.Duration) bool {
+	return r.t.Reset(d)
+}
+
+// Make a schedule on the real clock, stopped until started
+func NewSchedule() *Schedule {
+	return newScheduleOn(realClock{})
+}
+
+// Make a schedule on the given clock, stopped until started
+func newScheduleOn(clock Clock) *Schedule {
+	t := clock.NewTimer(time.Hour)
+	t.Stop()
+	return &Schedule{C: t.C(), clock: clock, timer: t}
+}
+
+// Start ticking, with the first tick an interval from now
+func (s *Schedule) Start(interval time.Duration) {
+	s.Stop()
+	s.due = s.clock.Now().Add(interval)
+	s.timer.Reset(interval)
+}
+
+// Stop ticking. A tick already sent but not received is dropped.
+func (s *Schedule) Stop() {
+	if !s.timer.Stop() {
+		select {
+		case <-s.timer.C():
+		default:
+		}
+	}
+	s.due = time.Time{}
+}
+
+// Schedule the tick after the one just received, an interval after that
+// one was due. Ticks that are late fire straight away to catch up, but a
+// schedule that has fallen well behind, say after the machine slept,
+// starts afresh from now rather than rushing through every tick it missed.
+func (s *Schedule) Next(interval time.Duration) {
+	if s.due.IsZero() {
+		return
+	}
+	now := s.clock.Now()
+	s.due = s.due.Add(interval)
+	if now.Sub(s.due) > maxTickLag {
+		s.due = now.Add(interval)
+	}
+	s.timer.Reset(s.due.Sub(now))
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// A clock that only moves when a test moves it on
+type fakeClock struct {
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock  *fakeClock
+	c      chan time.Time
+	when   time.Time
+	active bool
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) Timer {
+	t := &fakeTimer{clock: c, c: make(chan time.Time, 1)}
+	c.timers = append(c.timers, t)
+	t.Reset(d)
+	return t
+}
+
+// Move the clock on, firing the timers that come due
+func (c *fakeClock) advance(d time.Duration) {
+	c.now = c.now.Add(d)
+	for _, t := range c.timers {
+		t.fire()
+	}
+}
+
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+func (t *fakeTimer) Stop() bool {
+	was := t.active
+	t.active = false
+	return was
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	was := t.active
+	t.when, t.active = t.clock.now.Add(d), true
+	t.fire()
+	return was
+}
+
+func (t *fakeTimer) fire() {
+	if !t.active || t.when.After(t.clock.now) {
+		return
+	}
+	t.active = false
+	select {
+	case t.c <- t.clock.now:
+	default:
+	}
+}
+
+// Move the clock on a millisecond at a time until the schedule ticks,
+// returning how long after start it did
+func nextTick(t *testing.T, c *fakeClock, s *Schedule, start time.Time) time.Duration {
+	t.Helper()
+	for i := 0; i < 5000; i++ {
+		select {
+		case <-s.C:
+			return c.now.Sub(start)
+		default:
+		}
+		c.advance(time.Millisecond)
+	}
+	t.Fatal("the schedule never ticked")
+	return 0
+}
+
+func newTestSchedule() (*fakeClock, *Schedule, time.Time) {
+	c := &fakeClock{now: time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)}
+	return c, newScheduleOn(c), c.now
+}
+
+// Ticks come due an interval after the last was due, however long each
+// one takes to handle
+func TestScheduleDoesNotDrift(t *testing.T) {
+	c, s, start := newTestSchedule()
+	s.Start(100 * time.Millisecond)
+	for i := 1; i <= 50; i++ {
+		if at := nextTick(t, c, s, start); at != time.Duration(i)*100*time.Millisecond {
+			t.Fatalf("tick %d came at %v, want %v", i, at, time.Duration(i)*100*time.Millisecond)
+		}
+		c.advance(30 * time.Millisecond) // Handling the tick
+		s.Next(100 * time.Millisecond)
+	}
+}
+
+// A new interval takes effect from the very next tick
+func TestScheduleIntervalChange(t *testing.T) {
+	c, s, start := newTestSchedule()
+	s.Start(100 * time.Millisecond)
+	want := []time.Duration{100, 150, 200, 300}
+	intervals := []time.Duration{50, 50, 100}
+	for i, w := range want {
+		if at := nextTick(t, c, s, start); at != w*time.Millisecond {
+			t.Fatalf("tick %d came at %v, want %v", i+1, at, w*time.Millisecond)
+		}
+		if i < len(intervals) {
+			s.Next(intervals[i] * time.Millisecond)
+		}
+	}
+}
+
+// A tick that's a little late fires straight away, and the next one is
+// back on time
+func TestScheduleCatchesUp(t *testing.T) {
+	c, s, start := newTestSchedule()
+	s.Start(100 * time.Millisecond)
+	nextTick(t, c, s, start)
+	c.advance(150 * time.Millisecond) // A slow tick, due again at 200ms
+	s.Next(100 * time.Millisecond)
+	if at := nextTick(t, c, s, start); at != 250*time.Millisecond {
+		t.Fatalf("late tick came at %v, want at once at 250ms", at)
+	}
+	s.Next(100 * time.Millisecond)
+	if at := nextTick(t, c, s, start); at != 300*time.Millisecond {
+		t.Fatalf("tick after catching up came at %v, want 300ms", at)
+	}
+}
+
+// A schedule that falls well behind skips the ticks it missed and starts
+// afresh from now
+func TestScheduleSkipsMissedTicks(t *testing.T) {
+	c, s, start := newTestSchedule()
+	s.Start(100 * time.Millisecond)
+	nextTick(t, c, s, start)
+	c.advance(time.Second) // Say the machine slept
+	s.Next(100 * time.Millisecond)
+	if at := nextTick(t, c, s, start); at != 1200*time.Millisecond {
+		t.Fatalf("tick after the stall came at %v, want 1.2s", at)
+	}
+}
+
+// Stopping drops a tick that was sent but not received
+func TestScheduleStop(t *testing.T) {
+	c, s, _ := newTestSchedule()
+	s.Start(100 * time.Millisecond)
+	c.advance(100 * time.Millisecond)
+	s.Stop()
+	select {
+	case <-s.C:
+		t.Fatal("a stopped schedule still ticked")
+	default:
+	}
+	s.Next(100 * time.Millisecond)
+	c.advance(time.Second)
+	select {
+	case <-s.C:
+		t.Fatal("Next restarted a stopped schedule")
+	default:
+	}
+}
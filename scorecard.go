@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Scorecard constants
+const (
+	scorecardFileName = "scorecard.txt"
+	thumbnailWidth    = 20 // Columns in the board thumbnail
+	thumbnailHeight   = 5  // Rows in the board thumbnail
+)
+
+// Scorecard sums up a finished game as plain ASCII text that survives being
+// pasted into a chat or an issue
+func (g *Game) Scorecard() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "GO-SNAKE %s  %s\n", strings.ToUpper(g.mode), time.Now().Format("2006-01-02"))
+	for _, s := range g.snakes {
+		label := "Score"
+		if g.Versus() {
+			label = s.name
+		}
+		fmt.Fprintf(&b, "%s: %d", label, s.score)
+		if g.Versus() && g.winner >= 0 && g.snakes[g.winner] == s {
+			b.WriteString(" (winner)")
+		}
+		b.WriteString("   ")
+	}
+	fmt.Fprintf(&b, "Level: %d   Time: %s   Wall: %s   Ticks: %d\n", g.level, clockText(g.clock), clockText(g.wallTime()), g.ticks)
+	if g.challenge != "" {
+		fmt.Fprintf(&b, "Challenge: %s\n", g.challenge)
+	} else {
+		fmt.Fprintf(&b, "Seed: %d\n", g.seed)
+	}
+	if g.levelName != "" {
+		fmt.Fprintf(&b, "Map: %s\n", g.levelName)
+	}
+
+	rows := g.thumbnail()
+	border := "+" + strings.Repeat("-", len(rows[0])) + "+\n"
+	b.WriteString(border)
+	for _, row := range rows {
+		b.WriteString("|" + row + "|\n")
+	}
+	b.WriteString(border)
+	return b.String()
+}
+
+// Shrink the board to a small grid of characters. Each character stands for
+// a block of cells and shows the most important thing in it: a head, then
+// body, food, wall, or nothing.
+func (g *Game) thumbnail() []string {
+	cols, rows := min(thumbnailWidth, width), min(thumbnailHeight, height)
+	rank := map[byte]int{' ': 0, '#': 1, '*': 2, 'o': 3, '@': 4}
+	grid := make([][]byte, rows)
+	for y := range grid {
+		grid[y] = []byte(strings.Repeat(" ", cols))
+	}
+	mark := func(p Point, ch byte) {
+		x, y := p.X*cols/width, p.Y*rows/height
+		if rank[ch] > rank[grid[y][x]] {
+			grid[y][x] = ch
+		}
+	}
+	for p := range g.walls {
+		mark(p, '#')
+	}
+	for _, f := range g.foods {
+		mark(f.At, '*')
+	}
+	for _, s := range g.snakes {
+		for i, p := range s.body {
+			if i == 0 {
+				mark(p, '@')
+			} else {
+				mark(p, 'o')
+			}
+		}
+	}
+	lines := make([]string, rows)
+	for y, row := range grid {
+		lines[y] = string(row)
+	}
+	return lines
+}
+
+// Path of the saved scorecard in the data directory
+func scorecardPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, scorecardFileName), nil
+}
+
+// Save the game's scorecard, replacing the last one
+func (g *Game) saveScorecard() error {
+	path, err := scorecardPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(g.Scorecard()), 0o644)
+}
+
+// Run the replay subcommand. "go-snake replay card" prints the last saved
+// scorecard. Returns the exit status.
+func replayCommand(args []string) int {
+	if len(args) != 1 || args[0] != "card" {
+		fmt.Fprintln(os.Stderr, "usage: go-snake replay card")
+		return 2
+	}
+	path, err := scorecardPath()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(os.Stderr, "go-snake: no scorecard yet; press 'c' on the game over screen to save one")
+		return 1
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	os.Stdout.Write(data)
+	return 0
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// High score constants
+const (
+	maxHighScores  = 10 // Number of entries kept on the leaderboard
+	scoresFileName = "scores.json"
+)
+
+// ScoreEntry is a single leaderboard record
+type ScoreEntry struct {
+	Name       string    `json:"name"`
+	Score      int       `json:"score"`
+	Date       time.Time `json:"date"`
+	Width      int       `json:"width"`
+	Height     int       `json:"height"`
+	Mode       string    `json:"mode"`
+	Difficulty string    `json:"difficulty,omitempty"` // Empty for scores from before it was kept
+}
+
+// HighScores is the persistent top-N leaderboard
+type HighScores struct {
+	Version int          `json:"version"` // Format version, see scoresFormat
+	Entries []ScoreEntry `json:"entries"`
+}
+
+// Return the data directory of the player profile in use
+func dataDir() (string, error) {
+	dir, err := baseDataDir()
+	if err != nil || activeProfile == "" {
+		return dir, err
+	}
+	return filepath.Join(dir, profilesDirName, activeProfile), nil
+}
+
+// Return the per-user data directory for go-snake, following XDG on Unix
+// and the platform conventions on Windows and macOS. The default profile's
+// data is kept in it.
+func baseDataDir() (string, error) {
+	if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+		return filepath.Join(dir, "go-snake"), nil
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
+			return filepath.Join(dir, "go-snake"), nil
+		}
+		if dir := os.Getenv("APPDATA"); dir != "" {
+			return filepath.Join(dir, "go-snake"), nil
+		}
+	case "darwin":
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, "Library", "Application Support", "go-snake"), nil
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".local", "share", "go-snake"), nil
+}
+
+// Return the full path of the high score file
+func scoresPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, scoresFileName), nil
+}
+
+// Load the leaderboard from disk. A missing file is an empty leaderboard.
+func LoadHighScores() (*HighScores, error) {
+	path, err := scoresPath()
+	if err != nil {
+		return &HighScores{}, err
+	}
+	hs, err := readHighScores(path)
+	if err != nil {
+		return &HighScores{}, err
+	}
+	return hs, nil
+}
+
+// Read a leaderboard file, empty if there isn't one
+func readHighScores(path string) (*HighScores, error) {
+	hs := &HighScores{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return hs, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if data, err = scoresFormat.upgradeJSON(data); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, hs); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	hs.sort()
+	return hs, nil
+}
+
+// Save the leaderboard to disk, creating the data directory if needed.
+// Scores another go-snake has saved since this one loaded them are merged
+// in first, so neither loses the other's.
+func (hs *HighScores) Save() error {
+	path, err := scoresPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	return withFileLock(path, func() error {
+		saved, err := readHighScores(path)
+		if err != nil {
+			return err
+		}
+		hs.merge(saved)
+		hs.Version = scoresFormat.Version
+		data, err := json.MarshalIndent(hs, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(path, data, 0o644)
+	})
+}
+
+// Add the entries of another copy of the leaderboard that this one lacks,
+// keeping the best of each mode
+func (hs *HighScores) merge(other *HighScores) {
+	for _, e := range other.Entries {
+		if !slices.ContainsFunc(hs.Entries, e.same) {
+			hs.Add(e)
+		}
+	}
+}
+
+// Check whether two entries record the same game, even when one has been
+// through the file and lost its clock reading
+func (e ScoreEntry) same(o ScoreEntry) bool {
+	return e.Date.Equal(o.Date) && e.Name == o.Name && e.Score == o.Score && e.Mode == o.Mode && e.Difficulty == o.Difficulty
+}
+
+// ForMode returns the leaderboard for a single game mode, best first
+func (hs *HighScores) ForMode(mode string) []ScoreEntry {
+	var entries []ScoreEntry
+	for _, e := range hs.Entries {
+		if e.Mode == mode {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Qualifies reports whether a score would make it onto the mode's leaderboard
+func (hs *HighScores) Qualifies(score int, mode string) bool {
+	if score <= 0 {
+		return false
+	}
+	entries := hs.ForMode(mode)
+	return len(entries) < maxHighScores || score > entries[len(entries)-1].Score
+}
+
+// Add inserts an entry, keeping only the top maxHighScores of each mode.
+// Returns the entry's rank (0-based) within its mode or -1 if it did not
+// qualify.
+func (hs *HighScores) Add(entry ScoreEntry) int {
+	if !hs.Qualifies(entry.Score, entry.Mode) {
+		return -1
+	}
+
+	hs.Entries = append(hs.Entries, entry)
+	hs.sort()
+
+	// Drop entries that fell off their mode's table
+	kept := hs.Entries[:0]
+	counts := make(map[string]int)
+	for _, e := range hs.Entries {
+		if counts[e.Mode] < maxHighScores {
+			kept = append(kept, e)
+		}
+		counts[e.Mode]++
+	}
+	hs.Entries = kept
+	return hs.Rank(entry)
+}
+
+// Rank returns an entry's rank (0-based) within its mode, or -1 if it isn't
+// on the leaderboard
+func (hs *HighScores) Rank(entry ScoreEntry) int {
+	for i, e := range hs.ForMode(entry.Mode) {
+		if e.same(entry) {
+			return i
+		}
+	}
+	return -1
+}
+
+// Best returns the top score for a mode, or 0 if it has no entries
+func (hs *HighScores) Best(mode string) int {
+	entries := hs.ForMode(mode)
+	if len(entries) == 0 {
+		return 0
+	}
+	return entries[0].Score
+}
+
+// Highest score first, older entries win ties
+func (hs *HighScores) sort() {
+	sort.SliceStable(hs.Entries, func(i, j int) bool {
+		if hs.Entries[i].Score != hs.Entries[j].Score {
+			return hs.Entries[i].Score > hs.Entries[j].Score
+		}
+		return hs.Entries[i].Date.Before(hs.Entries[j].Date)
+	})
+}
+
+// Rows of the high score table there's room for under its header
+func scoreRows() int {
+	return max(viewHeight-6, 1)
+}
+
+// Open the high score screen, scrolled to show this game's entry
+func (g *Game) openScores() {
+	g.showScores = true
+	g.scoreTop = 0
+	g.scrollScores(g.scoreRank - scoreRows() + 1)
+}
+
+// Scroll the high score table by delta rows, keeping it in range
+func (g *Game) scrollScores(delta int) {
+	if g.scores == nil {
+		return
+	}
+	n := len(g.scores.ForMode(g.mode))
+	g.scoreTop = max(min(g.scoreTop+delta, n-scoreRows()), 0)
+}
+
+// Draw the high score screen for a mode over the game area: rank, name,
+// score, mode and difficulty, and date in columns, scrolled from top
+func drawHighScores(hs *HighScores, mode string, highlight, top int) {
+	area := boardArea()
+	title := fmt.Sprintf("HIGH SCORES (%s)", mode)
+	drawCentered(2, title, ColorYellow|AttrBold)
+
+	entries := hs.ForMode(mode)
+	if len(entries) == 0 {
+		drawText(area.X+1, 4, "No scores yet", colorText)
+	}
+	rows := make([][]string, len(entries))
+	for i, e := range entries {
+		played := e.Mode
+		if e.Difficulty != "" {
+			played += "/" + e.Difficulty
+		}
+		rows[i] = []string{strconv.Itoa(i + 1), e.Name, strconv.Itoa(e.Score), played, e.Date.Format("2006-01-02")}
+	}
+	if len(rows) > 0 {
+		table := Rect{X: area.X + 1, Y: 4, W: area.W - 2, H: scoreRows() + 1}
+		drawTable(table, []string{"#", "NAME", "SCORE", "MODE", "DATE"},
+			[]Align{AlignRight, AlignLeft, AlignRight, AlignLeft, AlignLeft}, rows, top, highlight, 1)
+	}
+
+	hint := fmt.Sprintf("Press %s to go back", keyHint(ActionScores))
+	if len(entries) > scoreRows() {
+		hint = fmt.Sprintf("%d-%d of %d, arrows scroll, %s back", top+1, min(top+scoreRows(), len(entries)), len(entries), keyHint(ActionScores))
+	}
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
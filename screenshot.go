@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Screenshots are saved under the data directory, each as two files of the
+// same name: .ans, the frame in color for cat or less -R to show again, and
+// .txt, plain text to paste into a bug report
+const screenshotsDirName = "screenshots"
+
+// The last frame shown, a line per row, as text with the escape sequences
+// that color it and as plain text. Trailing blanks are left off the plain
+// lines.
+func (r *DirtyRenderer) capture() (colored, plain string) {
+	var ansi, text strings.Builder
+	for y := 0; y < r.rows; y++ {
+		var line []rune
+		style := ""
+		for x := 0; x < r.cols; x++ {
+			c := r.shown[y*r.cols+x]
+			if c.ch == 0 {
+				c.ch = ' '
+			}
+			if s := ansiStyle(c.fg, c.bg); s != style {
+				ansi.WriteString(s)
+				style = s
+			}
+			ansi.WriteRune(c.ch)
+			line = append(line, c.ch)
+			if runewidth.RuneWidth(c.ch) == 2 {
+				x++ // The next cell is hidden under this one
+			}
+		}
+		ansi.WriteString("\x1b[0m\n")
+		text.WriteString(strings.TrimRight(string(line), " "))
+		text.WriteByte('\n')
+	}
+	return ansi.String(), text.String()
+}
+
+// Save the last frame shown as a screenshot, returning the path it was
+// saved to without its extension
+func saveScreenshot(r *DirtyRenderer) (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	dir = filepath.Join(dir, screenshotsDirName)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	// Two taken in the same second are numbered rather than overwritten
+	stamp := filepath.Join(dir, "go-snake-"+time.Now().Format("20060102-150405"))
+	base := stamp
+	for n := 2; ; n++ {
+		if _, err := os.Stat(base + ".txt"); err != nil {
+			break
+		}
+		base = fmt.Sprintf("%s-%d", stamp, n)
+	}
+	colored, plain := r.capture()
+	if err := os.WriteFile(base+".ans", []byte(colored), 0o644); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(base+".txt", []byte(plain), 0o644); err != nil {
+		return "", err
+	}
+	return base, nil
+}
+
+// A path with the home directory written as ~, to keep it short on screen
+func shortPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
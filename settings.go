@@ -0,0 +1,438 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Modes that can be picked in the settings menu, in display order
+var settingModes = []string{modeWrap, modeWalls, modeTimed, modeSurvival, modeArcade, modeRoyale, modeTron, modeTerritory}
+
+// Settings holds the options that apply to the next game started
+type Settings struct {
+	Mode          string
+	FoodTick      bool // Beep in the last seconds before food expires
+	SpawnDistance int  // Minimum food distance from the head, 0 for none
+	SpawnSpread   bool // Bias food away from recent spawns
+	SpawnOpen     bool // Keep food out of dead ends and from behind tails
+	SpawnBalanced bool // Keep food about the same distance from the head as the snake grows
+	ScoreDecay    bool // Play with the score decay modifier
+	Hazards       bool // Play with the hazards modifier
+	Relative      bool // Left and right turn the snake instead of pointing it
+}
+
+// Active settings, from flags and the in-game settings menu
+var settings = Settings{Mode: modeWrap}
+
+// One-line description of each mode for the settings menu
+var modeDescriptions = map[string]string{
+	modeWrap:      "Snake wraps around the edges",
+	modeWalls:     "Touching the border is fatal",
+	modeTimed:     fmt.Sprintf("Score all you can in %d minutes", int(timeAttackLength/time.Minute)),
+	modeSurvival:  "Obstacles appear as time goes on",
+	modeArcade:    "Portals open as time goes on",
+	modeRoyale:    "The arena closes in as time goes on",
+	modeTron:      "Trails never shrink; outlast your rival",
+	modeTerritory: "Paint the board; hold the most to win",
+}
+
+// One-line description of each clock for the settings menu
+var clockDescriptions = map[string]string{
+	clockPlay:  "Time played, stopping while paused",
+	clockWall:  "Time since the start, pauses and all",
+	clockTicks: "Ticks played",
+	clockOff:   "No clock in the sidebar",
+}
+
+// Check whether a mode name is known
+func validMode(mode string) bool {
+	for _, m := range settingModes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Step to the previous or next game mode
+func (s *Settings) cycleMode(delta int) {
+	current := 0
+	for i, m := range settingModes {
+		if m == s.Mode {
+			current = i
+		}
+	}
+	next := (current + delta + len(settingModes)) % len(settingModes)
+	s.Mode = settingModes[next]
+}
+
+// Rows of the settings menu, in display order
+const (
+	settingsMode = iota
+	settingsTheme
+	settingsSpeed
+	settingsSound
+	settingsClock
+	settingsLayout
+	settingsKeys
+	settingsTelemetry
+	settingsRows // Number of rows
+)
+
+// Settings menu constants
+const (
+	settingsSpeedStep = 10  // Milliseconds a step of the speed changes the tick by
+	settingsSpeedMin  = 20  // Fastest starting tick the menu goes to, in milliseconds
+	settingsSpeedMax  = 400 // Slowest
+	settingsWidth     = 34  // Cells the menu's lines are laid out in
+)
+
+// SettingsMenu is the settings screen. The mode is for the next game, like
+// the title menu's; the theme, sound, clock, key layout, keys and telemetry
+// change as soon as they're picked, and the speed from the next game. All
+// but the mode are written back to the config file once the menu is closed.
+type SettingsMenu struct {
+	Row       int
+	Action    int    // Action the keys row shows, by index into actions
+	Listening bool   // Waiting for a key to bind to the action?
+	Speed     int    // Milliseconds per tick at level 1 in the next game
+	Note      string // Problem or clash to show in place of the row's description
+
+	config   *Config
+	path     string       // Config file to save to, "" for none
+	explicit bool         // Was the config file given with -config?
+	palette  string       // Palette from -palette, over the config file's
+	keys     *KeyBindings // Bindings in use, rebuilt in place
+	actions  []string
+	changed  map[string]configSetting // Settings to save, by name
+}
+
+// Open the settings menu on the config in use and the file it came from
+func NewSettingsMenu(config *Config, path string, explicit bool, palette string, keys *KeyBindings) *SettingsMenu {
+	return &SettingsMenu{
+		config:   config,
+		path:     path,
+		explicit: explicit,
+		palette:  palette,
+		keys:     keys,
+		actions:  orderedActionNames(),
+		changed:  make(map[string]configSetting),
+	}
+}
+
+// Move between rows, or change the row's setting
+func (m *SettingsMenu) move(g *Game, dir Direction) {
+	m.Note = ""
+	switch dir {
+	case Up:
+		m.Row = (m.Row + settingsRows - 1) % settingsRows
+	case Down:
+		m.Row = (m.Row + 1) % settingsRows
+	case Left:
+		m.change(g, -1)
+	case Right:
+		m.change(g, 1)
+	}
+}
+
+// Enter changes the row's setting, or on the keys row waits for a key to
+// bind to the action shown
+func (m *SettingsMenu) enter(g *Game) {
+	m.Note = ""
+	if m.Row == settingsKeys {
+		m.Listening = true
+		return
+	}
+	m.change(g, 1)
+}
+
+// Step the row's setting to the previous or next value
+func (m *SettingsMenu) change(g *Game, delta int) {
+	switch m.Row {
+	case settingsMode:
+		settings.cycleMode(delta)
+	case settingsTheme:
+		m.useTheme(g, cycleName(assetNames("themes"), m.config.Theme, delta))
+	case settingsSpeed:
+		// Right is faster, so a shorter tick
+		low := max(settingsSpeedMin, m.config.Speed.Min)
+		start := min(max(m.Speed-delta*settingsSpeedStep, low), settingsSpeedMax)
+		m.config.Speed.Start = start
+		m.Speed = start
+		m.set("speed", "start", start)
+	case settingsSound:
+		m.useSound(cycleName(audioBackendNames(), m.config.Sound.Backend, delta))
+	case settingsClock:
+		m.config.Clock = cycleName(clockModes, m.config.Clock, delta)
+		clockMode = m.config.Clock
+		m.set("", "clock", m.config.Clock)
+	case settingsLayout:
+		m.useLayout(cycleName(keyLayoutNames(), m.config.Layout, delta))
+	case settingsKeys:
+		m.Action = (m.Action + delta + len(m.actions)) % len(m.actions)
+	case settingsTelemetry:
+		m.config.Telemetry.Enabled = !m.config.Telemetry.Enabled
+		m.set("telemetry", "enabled", m.config.Telemetry.Enabled)
+	}
+}
+
+// The name before or after one in a list, or the first if it isn't there
+func cycleName(names []string, name string, delta int) string {
+	i := slices.Index(names, name)
+	if i < 0 {
+		return names[0]
+	}
+	return names[(i+delta+len(names))%len(names)]
+}
+
+// Draw with a theme from now on, over the config file's own symbols and
+// colors as when it's loaded
+func (m *SettingsMenu) useTheme(g *Game, name string) {
+	look, err := LoadConfig(m.path, m.explicit, name, m.palette)
+	if err != nil {
+		m.Note = err.Error()
+		return
+	}
+	m.config.Theme = name
+	m.config.Symbols, m.config.Colors = look.Symbols, look.Colors
+	m.config.Food.Symbols = look.Food.Symbols
+	m.config.applyLook()
+	for i, s := range g.snakes {
+		if i < len(playerColors) {
+			s.color = playerColors[i]
+		}
+	}
+	m.set("", "theme", name)
+}
+
+// Sound with an audio backend from now on, playing a sample of it
+func (m *SettingsMenu) useSound(backend string) {
+	cfg := m.config.Sound
+	cfg.Backend = backend
+	audio, err := newAudio(cfg)
+	if err != nil {
+		m.Note = err.Error()
+		return
+	}
+	sound.audio.Close()
+	sound.audio = audio
+	m.config.Sound = cfg
+	m.set("sound", "backend", backend)
+	sound.Play([]Sound{{Kind: SoundEat, Value: 10}})
+}
+
+// Steer and pause with a key layout from now on, under the config file's
+// own keys
+func (m *SettingsMenu) useLayout(name string) {
+	last := m.config.Layout
+	useKeyLayout(name)
+	kb, err := NewKeyBindings(m.config.Keys)
+	if err != nil {
+		useKeyLayout(last)
+		m.Note = err.Error()
+		return
+	}
+	*m.keys = *kb
+	m.config.Layout = name
+	m.set("", "key_layout", name)
+}
+
+// Bind the key pressed to the action on the keys row, in place of its keys.
+// Esc leaves them be.
+func (m *SettingsMenu) bind(ev Event) {
+	m.Listening = false
+	if ev.Key == KeyEsc {
+		return
+	}
+	key, ok := keyName(ev)
+	if !ok {
+		m.Note = "That key can't be bound"
+		return
+	}
+	m.rebind([]string{key})
+	if m.Note != "" {
+		return
+	}
+	var others []string
+	for _, name := range m.actions {
+		if name != m.actions[m.Action] && slices.Contains(boundKeys(m.config.Keys, name), key) {
+			others = append(others, name)
+		}
+	}
+	if len(others) > 0 {
+		m.Note = fmt.Sprintf("%s also does %s", key, strings.Join(others, ", "))
+	}
+}
+
+// Put the action on the keys row back on its default keys, or the key
+// layout's
+func (m *SettingsMenu) resetKeys() {
+	m.Note = ""
+	m.rebind(baseKeys[m.actions[m.Action]])
+}
+
+// Give the action on the keys row new keys, taking effect at once
+func (m *SettingsMenu) rebind(keys []string) {
+	name := m.actions[m.Action]
+	cfg := make(map[string][]string, len(m.config.Keys)+1)
+	for action, k := range m.config.Keys {
+		cfg[action] = k
+	}
+	cfg[name] = keys
+	kb, err := NewKeyBindings(cfg)
+	if err != nil {
+		m.Note = err.Error()
+		return
+	}
+	*m.keys = *kb
+	m.config.Keys = cfg
+	m.set("keys", name, keys)
+}
+
+// Note a setting to save when the menu closes
+func (m *SettingsMenu) set(table, key string, value any) {
+	s := configSetting{Table: table, Key: key, Value: value}
+	m.changed[s.name()] = s
+}
+
+// Write the settings changed since the menu was last saved to the config
+// file
+func (m *SettingsMenu) save() error {
+	names := make([]string, 0, len(m.changed))
+	for name := range m.changed {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	changes := make([]configSetting, len(names))
+	for i, name := range names {
+		changes[i] = m.changed[name]
+	}
+	if err := saveConfigSettings(m.path, changes); err != nil {
+		return err
+	}
+	clear(m.changed)
+	return nil
+}
+
+// Description of the row's setting, under the rows
+func (m *SettingsMenu) description(s *Settings) string {
+	switch m.Row {
+	case settingsMode:
+		return modeDescriptions[s.Mode]
+	case settingsTheme:
+		return "Symbols and colors, as below"
+	case settingsSpeed:
+		return "A tick at level 1; right is faster"
+	case settingsSound:
+		return "Plays a sample as it changes"
+	case settingsClock:
+		return clockDescriptions[m.config.Clock]
+	case settingsLayout:
+		if m.config.Layout == "numpad" {
+			return "8 4 6 2 steer, 5 pauses, 0 restarts"
+		}
+		return "Arrows, WASD or hjkl steer"
+	case settingsKeys:
+		if m.Listening {
+			return "Esc to leave the keys be"
+		}
+		return "Enter to rebind, Backspace to reset"
+	case settingsTelemetry:
+		switch {
+		case !m.config.Telemetry.Enabled:
+			return "Nothing about your games is sent"
+		case m.config.Telemetry.URL == "":
+			return "On, but telemetry.url isn't set"
+		}
+		return "Sends mode, rough score, death, time"
+	}
+	return ""
+}
+
+// Draw the settings menu over the game area
+func drawSettings(s *Settings, m *SettingsMenu) {
+	area := boardArea()
+	drawCentered(1, "SETTINGS", ColorYellow|AttrBold)
+
+	action := m.actions[m.Action]
+	keys := fmt.Sprintf("Keys: < %s: %s >", action, strings.Join(boundKeys(m.config.Keys, action), ", "))
+	if m.Listening {
+		keys = fmt.Sprintf("Keys: %s: press a key", action)
+	}
+	telemetry := "OFF"
+	if m.config.Telemetry.Enabled {
+		telemetry = "ON"
+	}
+	lines := []string{
+		fmt.Sprintf("Mode: < %s >", strings.ToUpper(s.Mode)),
+		fmt.Sprintf("Theme: < %s >", strings.ToUpper(m.config.Theme)),
+		fmt.Sprintf("Speed: < %d ms >", m.Speed),
+		fmt.Sprintf("Sound: < %s >", strings.ToUpper(m.config.Sound.Backend)),
+		fmt.Sprintf("Clock: < %s >", strings.ToUpper(m.config.Clock)),
+		fmt.Sprintf("Layout: < %s >", strings.ToUpper(m.config.Layout)),
+		keys,
+		fmt.Sprintf("Telemetry: < %s >", telemetry),
+	}
+	top := 3
+	drawList(area.X+max((area.W-settingsWidth)/2, 0), top, settingsRows, lines, m.Row, func(i int) { m.Row = i })
+
+	// Spread out on boards with the room
+	gap := 0
+	if viewHeight >= 13 {
+		gap = 1
+	}
+	if m.Note != "" {
+		drawCentered(top+settingsRows+gap, m.Note, colorScore)
+	} else {
+		drawCentered(top+settingsRows+gap, m.description(s), ColorWhite)
+	}
+	drawLookPreview(top + settingsRows + 1 + 2*gap)
+
+	hint := "Arrows change, Esc saves and goes back"
+	if m.path == "" {
+		hint = "Arrows change, Esc to go back"
+	}
+	drawCentered(viewHeight, hint, ColorDarkGray)
+}
+
+// Draw a strip of walls, food and snakes in the theme in use
+func drawLookPreview(y int) {
+	type cell struct {
+		ch rune
+		fg Attribute
+	}
+	var cells []cell
+	add := func(ch rune, fg Attribute, n int) {
+		for i := 0; i < n; i++ {
+			cells = append(cells, cell{ch, fg})
+		}
+	}
+	add(symbolWall, colorWall, 2)
+	add(symbolEmptyCell, colorEmpty, 2)
+	add(symbolSnakeBody, playerColors[0], 3)
+	add(symbolSnakeHead, playerColors[0], 1)
+	add(symbolEmptyCell, colorEmpty, 2)
+	for i, ch := range foodSymbols[:min(len(foodSymbols), 3)] {
+		if i > 0 {
+			add(symbolEmptyCell, colorEmpty, 1)
+		}
+		add(ch, colorFood, 1)
+	}
+	add(symbolEmptyCell, colorEmpty, 2)
+	add(symbolSnakeHead, playerColors[1], 1)
+	add(symbolSnakeBody, playerColors[1], 3)
+	add(symbolEmptyCell, colorEmpty, 2)
+	add(symbolWall, colorWall, 2)
+
+	area := boardArea()
+	x := area.X + max((area.W-len(cells))/2, 0)
+	for i, c := range cells[:min(len(cells), area.W)] {
+		if c.ch == symbolEmptyCell {
+			c.ch = emptySymbol(Point{X: i})
+		}
+		screen.SetCell(x+i, y, c.ch, c.fg, ColorDefault)
+	}
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Sidebar layout constants
+const (
+	sidebarLeft    = 2 // Column the sidebar's text starts in
+	sidebarBarFill = '#'
+	sidebarBarGap  = '-'
+)
+
+// Sidebar lays out the sidebar a row at a time from the top. Each element
+// takes the next free row, so elements never collide however many there
+// are, and text is cut off at the sidebar's edge. Rows past the bottom are
+// dropped.
+type Sidebar struct {
+	row    int    // Next free row
+	width  int    // Columns available for text
+	bottom int    // Last row that can be drawn on
+	buf    []byte // Reused for formatting, so drawing doesn't allocate
+}
+
+// Shared between frames for its formatting buffer
+var sidebar = &Sidebar{}
+
+// Start laying out the sidebar from the top
+func (s *Sidebar) reset() *Sidebar {
+	s.row = 0
+	s.width = sidebarWidth - 1 - sidebarLeft
+	s.bottom = viewHeight + 1
+	return s
+}
+
+// Full reports whether there are no rows left
+func (s *Sidebar) Full() bool {
+	return s.row > s.bottom
+}
+
+// Text draws a row of text
+func (s *Sidebar) Text(text string, fg Attribute) {
+	if !s.Full() {
+		s.set(text)
+		s.cell(sidebarLeft, s.width, fg, AlignLeft)
+	}
+	s.row++
+}
+
+// Textf draws a row of formatted text
+func (s *Sidebar) Textf(fg Attribute, format string, args ...any) {
+	if !s.Full() {
+		s.buf = fmt.Appendf(s.buf[:0], format, args...)
+		s.cell(sidebarLeft, s.width, fg, AlignLeft)
+	}
+	s.row++
+}
+
+// Blank leaves a row empty, keeping the rows below it in place while
+// something that comes and goes isn't shown
+func (s *Sidebar) Blank() {
+	s.row++
+}
+
+// Columnf draws formatted text in column i of a row split into n equal
+// columns, for showing each player side by side. Call Next once the row
+// is filled.
+func (s *Sidebar) Columnf(i, n int, fg Attribute, format string, args ...any) {
+	w := (s.width + 1) / n
+	if !s.Full() {
+		s.buf = fmt.Appendf(s.buf[:0], format, args...)
+		s.cell(sidebarLeft+i*w, w-1, fg, AlignLeft)
+	}
+}
+
+// Next moves on from a row filled with Columnf
+func (s *Sidebar) Next() {
+	s.row++
+}
+
+// Bar draws a labelled progress bar, e.g. "GOAL ####-- 12/25"
+func (s *Sidebar) Bar(label string, value, total int, fg Attribute) {
+	if s.Full() || total <= 0 {
+		s.row++
+		return
+	}
+	s.set(label)
+	x := s.cell(sidebarLeft, s.width, fg, AlignLeft) + 1
+	s.buf = fmt.Appendf(s.buf[:0], " %d/%d", min(value, total), total)
+	room := sidebarLeft + s.width - x - len(s.buf)
+	drawProgressBar(x, s.row, room, value, total, fg)
+	s.cell(x+max(room, 0), len(s.buf), fg, AlignLeft)
+	s.row++
+}
+
+// Block reserves rows for drawing by hand, returning where they start
+func (s *Sidebar) Block(rows int) (x, y int) {
+	x, y = sidebarLeft, s.row
+	s.row += rows
+	return x, y
+}
+
+// Align places text within a column
+type Align int
+
+const (
+	AlignLeft Align = iota
+	AlignRight
+)
+
+// TableColumn is one column of a sidebar table
+type TableColumn struct {
+	Width int
+	Align Align
+}
+
+// Table draws rows of cells lined up in fixed columns
+type Table struct {
+	s      *Sidebar
+	indent int // Columns left blank before the first
+	cols   []TableColumn
+}
+
+// Start a table with the given columns, indented from the sidebar's edge
+func (s *Sidebar) Table(indent int, cols ...TableColumn) Table {
+	return Table{s: s, indent: indent, cols: cols}
+}
+
+// Row draws a row of the table, one cell per column
+func (t Table) Row(cells ...TableCell) {
+	s := t.s
+	if !s.Full() {
+		x := sidebarLeft + t.indent
+		for i, c := range cells {
+			if i >= len(t.cols) {
+				break
+			}
+			col := t.cols[i]
+			switch {
+			case c.Symbol != 0:
+				s.buf = utf8.AppendRune(s.buf[:0], c.Symbol)
+			case c.Text == "":
+				s.buf = strconv.AppendInt(s.buf[:0], int64(c.Number), 10)
+			default:
+				s.set(c.Text)
+			}
+			s.cell(x, min(col.Width, sidebarLeft+s.width-x), c.Fg, col.Align)
+			x += col.Width
+		}
+	}
+	s.row++
+}
+
+// TableCell is one cell of a table row: a symbol, some text, or else a
+// number
+type TableCell struct {
+	Symbol rune
+	Text   string
+	Number int
+	Fg     Attribute
+}
+
+// Draw the text in buf in the current row within room columns from x, cut
+// off if it doesn't fit. Wide runes take two columns. Returns the column
+// after the text.
+func (s *Sidebar) cell(x, room int, fg Attribute, align Align) int {
+	n := 0
+	for _, ch := range string(s.buf) { // Ranging over the conversion doesn't copy
+		n += runewidth.RuneWidth(ch)
+	}
+	if align == AlignRight && n < room {
+		x += room - n
+	}
+	end := x + min(n, max(room, 0))
+	i := 0
+	for _, ch := range string(s.buf) {
+		w := runewidth.RuneWidth(ch)
+		if x+i+w > end {
+			break
+		}
+		screen.SetCell(x+i, s.row, ch, fg, ColorDefault)
+		i += w
+	}
+	return end
+}
+
+// Put text in the formatting buffer
+func (s *Sidebar) set(text string) {
+	s.buf = append(s.buf[:0], text...)
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Simulation constants
+const (
+	simulateGames    = 100
+	simulateMaxTicks = 100000 // Ticks before a game that never ends is stopped
+)
+
+// SimResult is how one simulated game went
+type SimResult struct {
+	Game    int     `json:"game"`
+	Seed    int64   `json:"seed"`
+	Score   int     `json:"score"`
+	Length  int     `json:"length"`
+	Food    int     `json:"food"`
+	Ticks   int     `json:"ticks"`
+	Seconds float64 `json:"seconds"`          // Game time, as if played at normal speed
+	Stopped bool    `json:"stopped"`          // Was it stopped at the tick limit rather than lost?
+	Winner  string  `json:"winner,omitempty"` // "bot", "rival" or "draw", against a rival
+}
+
+// Who won a simulated game against a rival
+const (
+	simWin  = "bot"
+	simLoss = "rival"
+	simDraw = "draw"
+)
+
+// SimSummary sums up a run of simulated games
+type SimSummary struct {
+	Bot        string     `json:"bot"`
+	Mode       string     `json:"mode"`
+	Games      int        `json:"games"`
+	MeanScore  float64    `json:"mean_score"`
+	Median     float64    `json:"median_score"`
+	StdDev     float64    `json:"stddev_score"`
+	MinScore   int        `json:"min_score"`
+	MaxScore   int        `json:"max_score"`
+	MeanLength float64    `json:"mean_length"`
+	MeanTicks  float64    `json:"mean_ticks"`
+	Stopped    int        `json:"stopped"`
+	Versus     *SimVersus `json:"versus,omitempty"` // Against a rival
+}
+
+// SimVersus is how a bot did against a rival over a run
+type SimVersus struct {
+	Rival  string `json:"rival"`
+	Wins   int    `json:"wins"`
+	Losses int    `json:"losses"`
+	Draws  int    `json:"draws"`
+}
+
+// Play a game through in g, reset for it, with a bot steering and nothing
+// drawn, as fast as it will go. With a rival, a second snake plays against
+// it, and a game still going at the tick limit is a draw.
+func simulateGame(g *Game, bot, rival Player, mode string, d Difficulty, mods Modifiers, seed int64, maxTicks int) SimResult {
+	players := 1
+	if rival != nil {
+		players = 2
+	}
+	g.Reset(nil, newSpawnPolicy(settings), players, seed)
+	if start := startConfig.placement(mode, ""); start != (StartPlacement{}) {
+		g.placeSnakes(start, nil) // Checked before the run
+	}
+	g.setMode(mode)
+	g.difficulty = d
+	g.mods = mods
+	g.setController(0, bot)
+	if rival != nil {
+		g.setController(1, rival)
+	}
+	for g.state == StatePlaying && g.ticks < maxTicks {
+		g.Update()
+	}
+	res := SimResult{
+		Seed:    seed,
+		Score:   g.Player().score,
+		Length:  len(g.Player().body),
+		Food:    g.stats.Food(),
+		Ticks:   g.ticks,
+		Seconds: g.clock.Seconds(),
+		Stopped: g.state != StateGameOver,
+	}
+	if rival != nil {
+		switch {
+		case res.Stopped || g.winner < 0:
+			res.Winner = simDraw
+		case g.winner == 0:
+			res.Winner = simWin
+		default:
+			res.Winner = simLoss
+		}
+	}
+	return res
+}
+
+// Sum up the results of a run, against a rival if one is named
+func summarize(bot, rival, mode string, results []SimResult) SimSummary {
+	sum := SimSummary{Bot: bot, Mode: mode, Games: len(results)}
+	if rival != "" {
+		sum.Versus = &SimVersus{Rival: rival}
+	}
+	if len(results) == 0 {
+		return sum
+	}
+	scores := make([]int, len(results))
+	sum.MinScore = math.MaxInt
+	for i, r := range results {
+		scores[i] = r.Score
+		sum.MeanScore += float64(r.Score)
+		sum.MeanLength += float64(r.Length)
+		sum.MeanTicks += float64(r.Ticks)
+		sum.MinScore = min(sum.MinScore, r.Score)
+		sum.MaxScore = max(sum.MaxScore, r.Score)
+		if r.Stopped {
+			sum.Stopped++
+		}
+		if sum.Versus != nil {
+			switch r.Winner {
+			case simWin:
+				sum.Versus.Wins++
+			case simLoss:
+				sum.Versus.Losses++
+			case simDraw:
+				sum.Versus.Draws++
+			}
+		}
+	}
+	n := float64(len(results))
+	sum.MeanScore /= n
+	sum.MeanLength /= n
+	sum.MeanTicks /= n
+
+	sort.Ints(scores)
+	sum.Median = float64(scores[len(scores)/2])
+	if len(scores)%2 == 0 {
+		sum.Median = float64(scores[len(scores)/2-1]+scores[len(scores)/2]) / 2
+	}
+	for _, s := range scores {
+		sum.StdDev += (float64(s) - sum.MeanScore) * (float64(s) - sum.MeanScore)
+	}
+	sum.StdDev = math.Sqrt(sum.StdDev / n)
+	return sum
+}
+
+// Write each game's result as a CSV row, under a header. Games against a
+// rival say who won.
+func writeSimCSV(w io.Writer, results []SimResult, rival bool) error {
+	cw := csv.NewWriter(w)
+	header := []string{"game", "seed", "score", "length", "food", "ticks", "seconds", "stopped"}
+	if rival {
+		header = append(header, "winner")
+	}
+	cw.Write(header)
+	for _, r := range results {
+		row := []string{
+			strconv.Itoa(r.Game),
+			strconv.FormatInt(r.Seed, 10),
+			strconv.Itoa(r.Score),
+			strconv.Itoa(r.Length),
+			strconv.Itoa(r.Food),
+			strconv.Itoa(r.Ticks),
+			strconv.FormatFloat(r.Seconds, 'f', 1, 64),
+			strconv.FormatBool(r.Stopped),
+		}
+		if rival {
+			row = append(row, r.Winner)
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Run the simulate subcommand: play games headless with a bot and report
+// how it did, to compare strategies. Game i plays seed+i, so a run can be
+// repeated exactly. Against a rival, the exit status says which bot won
+// more games, for tournament scripts.
+func simulateCommand(args []string) int {
+	flags := flag.NewFlagSet("simulate", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-snake simulate [-bot autopilot] [-rival bot] [-games 100] [-format csv|json] [flags]")
+		flags.PrintDefaults()
+	}
+	botName := flags.String("bot", "autopilot", "bot to play: "+strings.Join(botNames(), ", "))
+	rivalName := flags.String("rival", "", "bot to play against on a second snake; the exit status is 0 if -bot won more games, "+strconv.Itoa(exitLoss)+" if fewer and "+strconv.Itoa(exitDraw)+" if as many")
+	games := flags.Int("games", simulateGames, "number of games to play")
+	seed := flags.Int64("seed", 1, "seed of the first game; each game after it uses the next")
+	mode := flags.String("mode", modeWrap, "game mode: wrap, walls, timed, survival, arcade, royale, tron or territory")
+	difficultyName := flags.String("difficulty", "normal", "difficulty: easy, normal, hard or insane")
+	maxTicks := flags.Int("max-ticks", simulateMaxTicks, "ticks before a game is stopped")
+	format := flags.String("format", "csv", "output: csv, one row per game with the summary on stderr, or json")
+	boardWidth := flags.Int("width", 0, "board width in cells (overrides the config file)")
+	boardHeight := flags.Int("height", 0, "board height in cells (overrides the config file)")
+	boardPreset := flags.String("preset", "", "board size with speeds to suit it: "+boardPresetNames()+" (overrides the config file)")
+	hazards := flags.Bool("hazards", false, "play with moving hazards")
+	startLength := flags.Int("start-length", 0, "segments the snake starts with (overrides the config file)")
+	flags.IntVar(&settings.SpawnDistance, "spawn-distance", 0, "keep food at least this many cells from the head and out of its path (0 = off)")
+	flags.BoolVar(&settings.SpawnSpread, "spawn-spread", false, "bias food away from where it recently appeared")
+	flags.BoolVar(&settings.SpawnOpen, "spawn-open", false, "bias food towards open ground, never in a dead end or right behind the tail")
+	flags.BoolVar(&settings.SpawnBalanced, "spawn-balanced", false, "keep food about the same distance from the head however long the snake grows")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	set := map[string]bool{}
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	newBot, ok := bots[*botName]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "go-snake: unknown bot %q (have %s)\n", *botName, strings.Join(botNames(), ", "))
+		return 2
+	}
+	var newRival func(seed int64) Player
+	if *rivalName != "" {
+		if newRival, ok = bots[*rivalName]; !ok {
+			fmt.Fprintf(os.Stderr, "go-snake: unknown bot %q (have %s)\n", *rivalName, strings.Join(botNames(), ", "))
+			return 2
+		}
+	}
+	if !validMode(*mode) {
+		fmt.Fprintf(os.Stderr, "go-snake: unknown mode %q\n", *mode)
+		return 2
+	}
+	if *format != "csv" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "go-snake: unknown format %q (want csv or json)\n", *format)
+		return 2
+	}
+	if settings.SpawnDistance < 0 {
+		fmt.Fprintln(os.Stderr, "go-snake: -spawn-distance can't be negative")
+		return 2
+	}
+	if *games < 1 || *maxTicks < 1 {
+		fmt.Fprintln(os.Stderr, "go-snake: -games and -max-ticks must be at least 1")
+		return 2
+	}
+	preset, err := difficultyByName(*difficultyName)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 2
+	}
+
+	// Games play by the config file's rules, as they would in the terminal
+	config, err := LoadConfig(defaultConfigPath(), false, "", "")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 2
+	}
+	if set["preset"] {
+		config.Board.Preset = *boardPreset
+	}
+	if err := config.Board.usePreset(); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 2
+	}
+	if set["width"] {
+		config.Board.Width = *boardWidth
+	}
+	if set["height"] {
+		config.Board.Height = *boardHeight
+	}
+	if set["start-length"] {
+		if *startLength < 1 {
+			fmt.Fprintln(os.Stderr, "go-snake: -start-length must be at least 1")
+			return 2
+		}
+		config.Start.flags.Length = *startLength
+	}
+	if err := config.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 2
+	}
+	config.Apply()
+	players := 1
+	if newRival != nil {
+		players = 2
+	}
+	if start := startConfig.placement(*mode, ""); start != (StartPlacement{}) {
+		if err := checkPlacement(start, nil, players); err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake: start:", err)
+			return 2
+		}
+	}
+	difficulty := config.Speed.adjust(preset)
+	var mods Modifiers
+	if *hazards {
+		mods |= ModHazards
+	}
+
+	// Games share nothing, so they play on every core at once, each core
+	// reusing one game's memory for all the games it plays
+	results := make([]SimResult, *games)
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range runtime.GOMAXPROCS(0) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g := &Game{}
+			for i := range next {
+				gameSeed := *seed + int64(i)
+				var rival Player
+				if newRival != nil {
+					rival = newRival(gameSeed)
+				}
+				results[i] = simulateGame(g, newBot(gameSeed), rival, *mode, difficulty, mods, gameSeed, *maxTicks)
+				results[i].Game = i + 1
+			}
+		}()
+	}
+	for i := range results {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	sum := summarize(*botName, *rivalName, *mode, results)
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(struct {
+			Summary SimSummary  `json:"summary"`
+			Games   []SimResult `json:"games"`
+		}{sum, results}, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			return 1
+		}
+		fmt.Println(string(data))
+		return sum.exitStatus()
+	}
+	if err := writeSimCSV(os.Stdout, results, newRival != nil); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	fmt.Fprintf(os.Stderr, "%s, %s: %d games, score mean %.1f median %g sd %.1f min %d max %d, mean ticks %.0f, %d stopped\n",
+		sum.Bot, sum.Mode, sum.Games, sum.MeanScore, sum.Median, sum.StdDev, sum.MinScore, sum.MaxScore, sum.MeanTicks, sum.Stopped)
+	if v := sum.Versus; v != nil {
+		fmt.Fprintf(os.Stderr, "%s against %s: %d won, %d lost, %d drawn\n", sum.Bot, v.Rival, v.Wins, v.Losses, v.Draws)
+	}
+	return sum.exitStatus()
+}
+
+// Exit status for a run: 0 unless the bot lost more games to its rival
+// than it won, or won as many
+func (sum SimSummary) exitStatus() int {
+	switch v := sum.Versus; {
+	case v == nil || v.Wins > v.Losses:
+		return 0
+	case v.Wins < v.Losses:
+		return exitLoss
+	}
+	return exitDraw
+}
@@ -0,0 +1,81 @@
+package main
+
+import "time"
+
+// Smooth motion constants
+const (
+	frameInterval = time.Second / 30 // Time between frames drawn between ticks
+	halfStep      = 0.5              // How far through a move the snakes are drawn half a cell on
+)
+
+// Draw the snakes moving between ticks? Set with -smooth or in the config
+// file.
+var smoothMotion bool
+
+// Half blocks filling the side of a cell facing each direction
+var halfBlocks = map[Direction]rune{Up: '▀', Right: '▐', Down: '▄', Left: '▌'}
+
+// Does the game move between ticks, so frames in between can show the
+// snakes part way through their moves? Replays, puzzles and games copied
+// from a host are drawn a tick at a time.
+func (g *Game) smoothing() bool {
+	return smoothMotion && g.state == StatePlaying && g.countdown <= 0 &&
+		g.puzzle == nil && !g.showLevels && g.calibrating == nil && !g.watching && !g.remote
+}
+
+// Should the snakes be drawn half a cell on from where they are?
+func (g *Game) halfStepped() bool {
+	return g.motion >= halfStep && g.smoothing()
+}
+
+// The cell a step from p, wrapping around the board's edges. ok is false
+// if the board ends there, as in walls mode.
+func (g *Game) stepFrom(p Point, dir Direction) (next Point, ok bool) {
+	switch dir {
+	case Up:
+		p.Y--
+	case Right:
+		p.X++
+	case Down:
+		p.Y++
+	case Left:
+		p.X--
+	}
+	if g.Bordered() && (p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height) {
+		return p, false
+	}
+	return Point{X: (p.X + width) % width, Y: (p.Y + height) % height}, true
+}
+
+// Draw a snake half a cell on: the head half into the cell it's moving to,
+// and the tail half out of its cell. The head takes the turn queued for the
+// next tick, if there is one. Nothing is drawn ahead of a crash, and a tail
+// stays put when the snake is about to eat.
+func (g *Game) drawHalfStep(i int, fg Attribute) {
+	s := g.snakes[i]
+	dir := s.direction
+	if h, ok := g.controllers[i].(*Human); ok && len(h.queue) > 0 && h.queue[0] != dir.Opposite() {
+		dir = h.queue[0]
+	}
+	ahead, ok := g.stepFrom(s.Head(), dir)
+	if !ok || g.walls[ahead] || g.isPortal(ahead) || g.occupied(ahead) {
+		return
+	}
+	eating := g.hasFood(ahead)
+	head := g.Player().Head()
+	if !eating && !g.mods.hidden(head, ahead) {
+		setBoardCell(ahead, halfBlocks[dir.Opposite()], fg)
+	}
+
+	n := len(s.body)
+	tail := s.body[n-1]
+	if eating || n < 2 || g.mods.hidden(head, tail) {
+		return
+	}
+	for _, d := range []Direction{Up, Right, Down, Left} {
+		if p, ok := g.stepFrom(tail, d); ok && p == s.body[n-2] {
+			setBoardCell(tail, halfBlocks[d], fg)
+			return
+		}
+	}
+}
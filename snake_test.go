@@ -0,0 +1,102 @@
+package main
+
+import (
+	"testing"
+	"testing/quick"
+)
+
+// Play a wrap mode game on the given seed, pressing one key a tick, and
+// check each tick's move with check. Stops at the end of the keys or of
+// the game.
+func playKeys(t *testing.T, seed int64, keys []uint8, check func(before, after *Snake, ate bool) bool) bool {
+	t.Helper()
+	g := NewGame(nil, newSpawnPolicy(settings), 1, seed)
+	for _, key := range keys {
+		if g.state != StatePlaying {
+			break
+		}
+		g.press(0, Direction(key%4))
+		s := g.Player()
+		before := &Snake{body: append([]Point(nil), s.body...), direction: s.direction, dashing: s.dashing, alive: s.alive}
+		eaten := g.stats.Food()
+		g.Update()
+		if before.alive && s.alive && !check(before, s, g.stats.Food() > eaten) {
+			t.Logf("seed %d, tick %d: body %v became %v", seed, g.ticks, before.body, s.body)
+			return false
+		}
+	}
+	return true
+}
+
+// The head moves exactly one cell a tick, or two when dashing, the way the
+// snake is heading and wrapping round the edges onto the board. It never
+// reverses into its body.
+func TestHeadMovesOneCell(t *testing.T) {
+	step := map[Direction]Point{Up: {0, -1}, Right: {1, 0}, Down: {0, 1}, Left: {-1, 0}}
+	prop := func(seed int64, keys []uint8) bool {
+		return playKeys(t, seed, keys, func(before, after *Snake, ate bool) bool {
+			cells := 1
+			if before.dashing {
+				cells = 2
+			}
+			d := step[after.direction]
+			want := Point{X: (before.Head().X + cells*d.X + width) % width, Y: (before.Head().Y + cells*d.Y + height) % height}
+			return after.Head() == want && after.direction != before.direction.Opposite()
+		})
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Every segment stays on the board, however the snake wraps
+func TestWrapStaysOnBoard(t *testing.T) {
+	prop := func(seed int64, keys []uint8) bool {
+		return playKeys(t, seed, keys, func(before, after *Snake, ate bool) bool {
+			for _, p := range after.body {
+				if p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+					return false
+				}
+			}
+			return true
+		})
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// The length only changes on a tick the snake eats, which grows it, or
+// shrinks it for poison
+func TestLengthChangesOnlyByEating(t *testing.T) {
+	prop := func(seed int64, keys []uint8) bool {
+		return playKeys(t, seed, keys, func(before, after *Snake, ate bool) bool {
+			return len(after.body) == len(before.body) || ate
+		})
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// A living snake without a shield never overlaps itself
+func TestNoSelfOverlap(t *testing.T) {
+	prop := func(seed int64, keys []uint8) bool {
+		return playKeys(t, seed, keys, func(before, after *Snake, ate bool) bool {
+			if after.Has(EffectInvincible) {
+				return true
+			}
+			seen := map[Point]bool{}
+			for _, p := range after.body {
+				if seen[p] {
+					return false
+				}
+				seen[p] = true
+			}
+			return true
+		})
+	}
+	if err := quick.Check(prop, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SoundKind is something in the game worth a sound
+type SoundKind int
+
+const (
+	SoundEat      SoundKind = iota // A snake ate food; the value sets the pitch
+	SoundLevelUp                   // The game went up a level
+	SoundCrash                     // A snake crashed
+	SoundGameOver                  // The game ended
+	SoundTick                      // A second ticked by on expiring food
+)
+
+// Sound names as used in the [sound] section of the config file. The food
+// tick has its own setting, food_tick.
+var soundNames = map[string]SoundKind{
+	"eat":       SoundEat,
+	"level_up":  SoundLevelUp,
+	"crash":     SoundCrash,
+	"game_over": SoundGameOver,
+}
+
+// Sorted sound names, for error messages
+func sortedSoundNames() []string {
+	names := make([]string, 0, len(soundNames))
+	for name := range soundNames {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sound is one sound for the game to make
+type Sound struct {
+	Kind  SoundKind
+	Value int // Points scored, for SoundEat
+}
+
+// Audio makes the game's sounds
+type Audio interface {
+	Play(s Sound)
+	Close()
+}
+
+// Audio backends by name, for the config file and the -sound flag. The
+// tones backend needs a build with the oto tag and adds itself.
+var audioBackends = map[string]func(cfg SoundConfig) (Audio, error){
+	"bell": func(cfg SoundConfig) (Audio, error) { return newBellAudio(cfg.Bell), nil },
+	"off":  func(SoundConfig) (Audio, error) { return silentAudio{}, nil },
+}
+
+// Sorted audio backend names, for help and error messages
+func audioBackendNames() []string {
+	names := make([]string, 0, len(audioBackends))
+	for name := range audioBackends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Start the configured audio backend
+func newAudio(cfg SoundConfig) (Audio, error) {
+	open, ok := audioBackends[cfg.Backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown sound backend %q (want one of %s)", cfg.Backend, strings.Join(audioBackendNames(), ", "))
+	}
+	return open(cfg)
+}
+
+// bellAudio rings the terminal bell. It has only one sound, so it rings
+// for the sounds picked in the config and the food tick.
+type bellAudio struct {
+	ring map[SoundKind]bool
+}
+
+func newBellAudio(names []string) bellAudio {
+	a := bellAudio{ring: map[SoundKind]bool{SoundTick: true}}
+	for _, name := range names {
+		a.ring[soundNames[name]] = true
+	}
+	return a
+}
+
+func (a bellAudio) Play(s Sound) {
+	if a.ring[s.Kind] {
+		bell()
+	}
+}
+
+func (bellAudio) Close() {}
+
+// silentAudio makes no sound at all
+type silentAudio struct{}
+
+func (silentAudio) Play(Sound) {}
+func (silentAudio) Close()     {}
+
+// SoundPlayer plays the game's sounds on an audio backend, unless muted
+type SoundPlayer struct {
+	audio Audio
+	Muted bool
+}
+
+// The game's sounds; silent until the config is loaded
+var sound = &SoundPlayer{audio: silentAudio{}}
+
+// Play the sounds from a game update. Several rings of the bell in one
+// update would sound as one, so each kind plays once.
+func (p *SoundPlayer) Play(sounds []Sound) {
+	if p.Muted {
+		return
+	}
+	var played [SoundTick + 1]bool
+	for _, s := range sounds {
+		if !played[s.Kind] {
+			played[s.Kind] = true
+			p.audio.Play(s)
+		}
+	}
+}
+
+// Mute or unmute, saying which in a toast over the game
+func (p *SoundPlayer) toggleMute(g *Game) {
+	p.Muted = !p.Muted
+	if p.Muted {
+		g.notify("Sound off")
+	} else {
+		g.notify("Sound on")
+	}
+}
+
+// Note a sound for the game loop to play after this update
+func (g *Game) queueSound(kind SoundKind, value int) {
+	g.sounds = append(g.sounds, Sound{Kind: kind, Value: value})
+}
+
+// Ring the terminal bell
+func bell() {
+	fmt.Fprint(os.Stdout, "\a")
+}
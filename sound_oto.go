@@ -0,0 +1,116 @@
+//go:build oto
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"sync"
+
+	"github.com/ebitengine/oto/v3"
+)
+
+// Tone synthesis constants
+const (
+	toneSampleRate = 44100
+	toneVolume     = 0.25                 // Of full scale, so tones aren't harsh
+	toneBase       = 440.0                // Pitch of the cheapest food, in Hz
+	toneFade       = toneSampleRate / 200 // Samples of fade in and out (5ms), to avoid clicks
+)
+
+func init() {
+	audioBackends["tones"] = newToneAudio
+}
+
+// A note of a tone: a pitch held for a time. A zero pitch is noise.
+type note struct {
+	hz   float64
+	secs float64
+}
+
+// toneAudio plays a distinct tone for each sound through the sound card
+// with oto. Better food plays higher, levelling up plays a rising run, and
+// crashes and the game's end fall away.
+type toneAudio struct {
+	ctx     *oto.Context
+	mu      sync.Mutex
+	playing []*oto.Player // Kept until finished so they aren't collected mid-sound
+}
+
+func newToneAudio(SoundConfig) (Audio, error) {
+	ctx, ready, err := oto.NewContext(&oto.NewContextOptions{
+		SampleRate:   toneSampleRate,
+		ChannelCount: 1,
+		Format:       oto.FormatSignedInt16LE,
+	})
+	if err != nil {
+		return nil, err
+	}
+	<-ready
+	return &toneAudio{ctx: ctx}, nil
+}
+
+func (a *toneAudio) Play(s Sound) {
+	var notes []note
+	switch s.Kind {
+	case SoundEat:
+		// A semitone up for each point
+		notes = []note{{toneBase * math.Pow(2, float64(s.Value-1)/12), 0.06}}
+	case SoundLevelUp:
+		notes = []note{{523, 0.07}, {659, 0.07}, {784, 0.07}, {1047, 0.12}}
+	case SoundCrash:
+		notes = []note{{0, 0.12}}
+	case SoundGameOver:
+		notes = []note{{392, 0.15}, {330, 0.15}, {262, 0.3}}
+	case SoundTick:
+		notes = []note{{1760, 0.02}}
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	left := a.playing[:0]
+	for _, p := range a.playing {
+		if p.IsPlaying() {
+			left = append(left, p)
+		} else {
+			p.Close()
+		}
+	}
+	p := a.ctx.NewPlayer(bytes.NewReader(synthesize(notes)))
+	p.Play()
+	a.playing = append(left, p)
+}
+
+func (a *toneAudio) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, p := range a.playing {
+		p.Close()
+	}
+	a.playing = nil
+}
+
+// Render notes one after another as 16-bit mono samples: square waves for
+// pitches, which sound suitably retro, and noise for the rest
+func synthesize(notes []note) []byte {
+	var buf []byte
+	seed := uint32(1)
+	for _, n := range notes {
+		count := int(n.secs * toneSampleRate)
+		for i := 0; i < count; i++ {
+			var v float64
+			if n.hz == 0 {
+				seed = seed*1664525 + 1013904223
+				v = float64(seed>>16)/32768 - 1
+			} else if math.Mod(float64(i)*n.hz/toneSampleRate, 1) < 0.5 {
+				v = 1
+			} else {
+				v = -1
+			}
+			v *= toneVolume * min(1, float64(i)/toneFade, float64(count-i)/toneFade)
+			sample := int16(v * math.MaxInt16)
+			buf = append(buf, byte(sample), byte(sample>>8))
+		}
+	}
+	return buf
+}
@@ -0,0 +1,321 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// Spawn constants
+const (
+	recentFoodMemory = 5 // Number of past food positions remembered for spreading spawns
+	minBalancedReach = 3 // Shortest distance BalancedSpawn aims for, on tiny boards
+)
+
+// SpawnPolicy decides how likely food is to appear on a free cell.
+// A weight of 0 rules the cell out; higher weights make it more likely.
+type SpawnPolicy interface {
+	Weight(g *Game, p Point) float64
+}
+
+// SpawnPolicies combines several policies by multiplying their weights
+type SpawnPolicies []SpawnPolicy
+
+func (ps SpawnPolicies) Weight(g *Game, p Point) float64 {
+	w := 1.0
+	for _, policy := range ps {
+		if w *= policy.Weight(g, p); w == 0 {
+			break
+		}
+	}
+	return w
+}
+
+// UniformSpawn gives every free cell the same chance
+type UniformSpawn struct{}
+
+func (UniformSpawn) Weight(g *Game, p Point) float64 {
+	return 1
+}
+
+// FairSpawn keeps food out of reach of trivial pickups: never within
+// MinDistance cells of a head, and never on the straight line a snake is
+// about to travel
+type FairSpawn struct {
+	MinDistance int
+	PathLength  int // How many cells ahead of the head count as its path
+}
+
+func (f FairSpawn) Weight(g *Game, p Point) float64 {
+	for _, s := range g.snakes {
+		if !s.alive {
+			continue
+		}
+		if g.distance(s.Head(), p) < f.MinDistance {
+			return 0
+		}
+
+		ahead := s.Head()
+		for i := 0; i < f.PathLength; i++ {
+			var ok bool
+			if ahead, ok = g.move(ahead, s.direction); !ok {
+				break
+			}
+			if ahead == p {
+				return 0
+			}
+		}
+	}
+	return 1
+}
+
+// SpreadSpawn biases food away from where it recently appeared so it
+// doesn't keep landing in the same corner
+type SpreadSpawn struct{}
+
+func (SpreadSpawn) Weight(g *Game, p Point) float64 {
+	w := 1.0
+	for _, recent := range g.recentFood {
+		d := float64(g.distance(recent, p))
+		w *= d / (d + 3) // Cells near a recent spawn get a fraction of the weight
+	}
+	return w
+}
+
+// OpenSpawn biases food towards open ground. A cell with fewer than two
+// free ways in is a dead end, where a snake that went for the food would
+// have nowhere to go but back, so food never goes there, nor right behind
+// a tail, where the snake would have to come all the way round for it.
+type OpenSpawn struct{}
+
+func (OpenSpawn) Weight(g *Game, p Point) float64 {
+	for _, s := range g.snakes {
+		tail := s.body[len(s.body)-1]
+		if s.alive && len(s.body) > 1 && g.distance(tail, p) == 1 {
+			return 0
+		}
+	}
+	free := 0
+	for dir := Up; dir <= Left; dir++ {
+		if next, ok := g.move(p, dir); ok && !g.walls[next] && !g.occupied(next) {
+			free++
+		}
+	}
+	if free < 2 {
+		return 0
+	}
+	return float64(free - 1) // Open cells are likelier than corridors
+}
+
+// BalancedSpawn keeps food about the same distance from the nearest head
+// however long the snake grows, so the trip to the next food neither
+// shrinks as the body fills the board nor grows from one food to the next.
+// Cells Target moves away are likeliest, falling off either side of it.
+type BalancedSpawn struct {
+	Target int
+}
+
+func (b BalancedSpawn) Weight(g *Game, p Point) float64 {
+	nearest := -1
+	for _, s := range g.snakes {
+		if d := g.distance(s.Head(), p); s.alive && (nearest < 0 || d < nearest) {
+			nearest = d
+		}
+	}
+	if nearest < 0 {
+		return 1
+	}
+	spread := float64(b.Target) / 2
+	off := (float64(nearest) - float64(b.Target)) / spread
+	return math.Exp(-off * off / 2)
+}
+
+// Distance BalancedSpawn keeps food at: about the average distance of a
+// cell from the head on an empty board
+func balancedReach() int {
+	return max((width+height)/4, minBalancedReach)
+}
+
+// Build the spawn policy from the settings
+func newSpawnPolicy(s Settings) SpawnPolicy {
+	policies := SpawnPolicies{UniformSpawn{}}
+	if s.SpawnDistance > 0 {
+		policies = append(policies, FairSpawn{MinDistance: s.SpawnDistance, PathLength: 2 * s.SpawnDistance})
+	}
+	if s.SpawnSpread {
+		policies = append(policies, SpreadSpawn{})
+	}
+	if s.SpawnOpen {
+		policies = append(policies, OpenSpawn{})
+	}
+	if s.SpawnBalanced {
+		policies = append(policies, BalancedSpawn{Target: balancedReach()})
+	}
+	return policies
+}
+
+// Room the search for a cell for food keeps from one food to the next, and
+// from one game to the next, so placing food allocates next to nothing
+type spawnScratch struct {
+	occupied, reachable     map[Point]bool
+	queue, free, candidates []Point
+	weights                 []float64
+}
+
+// Find a free cell for food using the game's spawn policy. Food only goes
+// where a snake can actually get to, so it never lands in a sealed pocket of
+// a maze. If no free cell is reachable, any free cell will do; if the policy
+// rules out every candidate, any candidate will do. Reports false when the
+// board is full.
+func (g *Game) freeFoodCell() (Point, bool) {
+	sc := &g.scratch
+	if sc.occupied == nil {
+		sc.occupied, sc.reachable = make(map[Point]bool), make(map[Point]bool)
+	}
+	occupied := sc.occupied
+	clear(occupied)
+	for _, s := range g.snakes {
+		for _, p := range s.body {
+			occupied[p] = true
+		}
+	}
+	reachable := g.reachable(occupied)
+	hazards, warned := g.hazardCells()
+
+	free, candidates := sc.free[:0], sc.candidates[:0]
+	defer func() { sc.free, sc.candidates = free, candidates }()
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			p := Point{X: x, Y: y}
+			if occupied[p] || g.walls[p] || g.isPortal(p) || g.hasFood(p) || hazards[p] || warned[p] {
+				continue
+			}
+			free = append(free, p)
+			if reachable[p] {
+				candidates = append(candidates, p)
+			}
+		}
+	}
+
+	if len(free) == 0 {
+		return Point{}, false
+	}
+	if len(candidates) == 0 {
+		candidates = free
+	}
+
+	weights := slices.Grow(sc.weights[:0], len(candidates))[:len(candidates)]
+	sc.weights = weights
+	total := 0.0
+	for i, p := range candidates {
+		weights[i] = 1
+		if g.spawn != nil {
+			weights[i] = g.spawn.Weight(g, p)
+		}
+		total += weights[i]
+	}
+	if total == 0 {
+		return candidates[g.rng.Intn(len(candidates))], true
+	}
+
+	r := g.rng.Float64() * total
+	for i, w := range weights {
+		if r < w {
+			return candidates[i], true
+		}
+		r -= w
+	}
+	return candidates[len(candidates)-1], true
+}
+
+// Find every cell a live snake's head can reach without passing through
+// walls or bodies, following the board edge rules of the current mode. The
+// set is the game's scratch space, so it only lasts until the next call.
+func (g *Game) reachable(occupied map[Point]bool) map[Point]bool {
+	seen, queue := g.scratch.reachable, g.scratch.queue[:0]
+	defer func() { g.scratch.queue = queue }()
+	clear(seen)
+	for _, s := range g.snakes {
+		if s.alive {
+			queue = append(queue, s.Head())
+			seen[s.Head()] = true
+		}
+	}
+
+	for i := 0; i < len(queue); i++ {
+		p := queue[i]
+		for dir := Up; dir <= Left; dir++ {
+			next, ok := g.move(p, dir)
+			if !ok || seen[next] || occupied[next] || g.walls[next] {
+				continue
+			}
+			seen[next] = true
+			queue = append(queue, next)
+		}
+	}
+	return seen
+}
+
+// Check whether a cell already holds food
+func (g *Game) hasFood(p Point) bool {
+	for _, f := range g.foods {
+		if f.At == p {
+			return true
+		}
+	}
+	for _, f := range g.frenzyFood {
+		if f.At == p {
+			return true
+		}
+	}
+	for _, c := range g.crumbs {
+		if c.At == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Remember where food appeared for SpreadSpawn
+func (g *Game) rememberFood(p Point) {
+	g.recentFood = append(g.recentFood, p)
+	if len(g.recentFood) > recentFoodMemory {
+		g.recentFood = g.recentFood[1:]
+	}
+}
+
+// Number of moves between two cells, taking wraparound into account
+func (g *Game) distance(a, b Point) int {
+	dx, dy := abs(a.X-b.X), abs(a.Y-b.Y)
+	if !g.Bordered() {
+		dx = min(dx, width-dx)
+		dy = min(dy, height-dy)
+	}
+	return dx + dy
+}
+
+// Cell one move away in a direction. The move wraps around the board,
+// except in walls and tron modes where leaving the board is not a move at all.
+func (g *Game) move(p Point, dir Direction) (Point, bool) {
+	switch dir {
+	case Up:
+		p.Y--
+	case Right:
+		p.X++
+	case Down:
+		p.Y++
+	case Left:
+		p.X--
+	}
+	if g.Bordered() && (p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height) {
+		return p, false
+	}
+	return g.throughPortal(Point{X: (p.X + width) % width, Y: (p.Y + height) % height}), true
+}
+
+// Helper function to get the absolute value of an integer
+func abs(a int) int {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
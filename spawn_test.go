@@ -0,0 +1,58 @@
+package main
+
+import "testing"
+
+// Food only goes on free cells, and never where its policy rules out
+func TestSpawnPolicies(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy SpawnPolicy
+		ok     func(g *Game, p Point) bool
+	}{
+		{"uniform", UniformSpawn{}, nil},
+		{"fair", FairSpawn{MinDistance: 5, PathLength: 10}, func(g *Game, p Point) bool {
+			return g.distance(g.Player().Head(), p) >= 5
+		}},
+		{"spread", SpreadSpawn{}, nil},
+		{"open", OpenSpawn{}, func(g *Game, p Point) bool {
+			return OpenSpawn{}.Weight(g, p) > 0
+		}},
+		{"balanced", BalancedSpawn{Target: balancedReach()}, nil},
+	}
+	for _, tt := range tests {
+		g := NewGame(nil, tt.policy, 1, 3)
+		for i := 0; i < 200; i++ {
+			p, ok := g.freeFoodCell()
+			if !ok {
+				t.Fatalf("%s: no cell on an open board", tt.name)
+			}
+			if g.occupied(p) || g.walls[p] || g.hasFood(p) || p.X < 0 || p.X >= width || p.Y < 0 || p.Y >= height {
+				t.Fatalf("%s: food at %v, which isn't free", tt.name, p)
+			}
+			if tt.ok != nil && !tt.ok(g, p) {
+				t.Fatalf("%s: food at %v, which the policy rules out", tt.name, p)
+			}
+			g.rememberFood(p)
+		}
+	}
+}
+
+// A full board gets no food rather than food on top of a snake
+func TestNoFoodOnFullBoard(t *testing.T) {
+	g := NewGame(nil, UniformSpawn{}, 1, 1)
+	g.foods, g.walls = nil, map[Point]bool{}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if p := (Point{X: x, Y: y}); !g.occupied(p) {
+				g.walls[p] = true
+			}
+		}
+	}
+	if p, ok := g.freeFoodCell(); ok {
+		t.Fatalf("found a free cell at %v", p)
+	}
+	g.PlaceFood()
+	if len(g.foods) != 0 {
+		t.Fatalf("placed food at %v", g.foods[0].At)
+	}
+}
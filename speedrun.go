@@ -0,0 +1,214 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Speedrun constants
+const (
+	splitsFileName    = "splits.json"
+	defaultSplitEvery = 25 // Points between splits
+)
+
+// Speedrun settings, from the config file's [speedrun]
+var (
+	speedrunTimer = false             // Time games to the millisecond, with splits
+	splitEvery    = defaultSplitEvery // Points between splits outside the campaign
+)
+
+// SplitRecords keeps the personal best splits of each kind of run
+type SplitRecords struct {
+	Best map[string][]time.Duration `json:"best"` // By splitCategory
+	path string
+}
+
+// Load the personal bests from the data directory. The records returned
+// are usable, if empty, even when loading fails.
+func LoadSplitRecords() (*SplitRecords, error) {
+	records := &SplitRecords{Best: make(map[string][]time.Duration)}
+	dir, err := dataDir()
+	if err != nil {
+		return records, err
+	}
+	records.path = filepath.Join(dir, splitsFileName)
+
+	data, err := os.ReadFile(records.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return records, nil
+	} else if err != nil {
+		return records, err
+	}
+
+	if err := json.Unmarshal(data, records); err != nil {
+		return &SplitRecords{Best: make(map[string][]time.Duration), path: records.path},
+			fmt.Errorf("parse %s: %w", records.path, err)
+	}
+	if records.Best == nil {
+		records.Best = make(map[string][]time.Duration)
+	}
+	return records, nil
+}
+
+// Keep a run's splits if they beat the personal best for its category, and
+// save. A run that gets further is better, and of two that get as far the
+// one that got there sooner.
+func (sr *SplitRecords) Record(run *Speedrun) error {
+	if !splitsBeat(run.Splits, sr.Best[run.Category]) {
+		return nil
+	}
+	sr.Best[run.Category] = append([]time.Duration(nil), run.Splits...)
+	return sr.Save()
+}
+
+// Do splits a beat splits b?
+func splitsBeat(a, b []time.Duration) bool {
+	if len(a) != len(b) {
+		return len(a) > len(b)
+	}
+	return len(a) > 0 && a[len(a)-1] < b[len(b)-1]
+}
+
+// Save writes the records back to disk
+func (sr *SplitRecords) Save() error {
+	if sr.path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(sr.path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sr, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(sr.path, data, 0o644)
+}
+
+// Speedrun times a run to the millisecond of game time, so pauses don't
+// count, and notes a split every few points or, in the campaign, at each
+// stage cleared. A campaign run carries on over its stages' games.
+type Speedrun struct {
+	Category string          // Kind of run, whose splits are compared
+	Splits   []time.Duration // Time into the run each split was reached
+	Best     []time.Duration // Personal best splits for the category, nil if none
+	Offset   time.Duration   // Time spent in the run's earlier games
+	First    int             // Campaign stage the run started on
+	game     *Game           // Game being timed
+}
+
+// What kind of run a game makes, e.g. "wrap normal 40x20 every 25". Runs
+// are only compared with runs of the same kind.
+func splitCategory(g *Game) string {
+	if g.campaign != nil {
+		return fmt.Sprintf("campaign from stage %d %s", g.campaign.Stage+1, g.difficulty.Name)
+	}
+	category := fmt.Sprintf("%s %s %dx%d every %d", g.mode, g.difficulty.Name, width, height, splitEvery)
+	if g.levelName != "" {
+		category += " " + g.levelName
+	}
+	if g.mods != 0 {
+		category += fmt.Sprintf(" mods %d", g.mods)
+	}
+	return category
+}
+
+// Start timing a run with g, racing the personal best for its kind
+func NewSpeedrun(g *Game, records *SplitRecords) *Speedrun {
+	run := &Speedrun{Category: splitCategory(g), game: g}
+	if g.campaign != nil {
+		run.First = g.campaign.Stage
+	}
+	if records != nil {
+		run.Best = records.Best[run.Category]
+	}
+	return run
+}
+
+// Carry a campaign run on into the game for its next stage, or another go
+// at the last one
+func (r *Speedrun) Continue(g *Game) {
+	r.Offset += r.game.clock
+	r.game = g
+}
+
+// Time into the run
+func (r *Speedrun) Time() time.Duration {
+	return r.Offset + r.game.clock
+}
+
+// How far ahead (negative) or behind the personal best split i was
+// reached, if the best got that far
+func (r *Speedrun) Delta(i int) (time.Duration, bool) {
+	if i < 0 || i >= len(r.Splits) || i >= len(r.Best) {
+		return 0, false
+	}
+	return r.Splits[i] - r.Best[i], true
+}
+
+// Note any splits reached this tick
+func (g *Game) updateSpeedrun() {
+	r := g.speedrun
+	if r == nil {
+		return
+	}
+	var due int
+	if g.campaign != nil {
+		if g.campaign.Cleared {
+			due = g.campaign.Stage - r.First + 1
+		}
+	} else {
+		due = g.Player().score / splitEvery
+	}
+	for len(r.Splits) < due {
+		r.Splits = append(r.Splits, r.Time())
+		msg := fmt.Sprintf("Split %d: %s", len(r.Splits), splitText(r.Time()))
+		if d, ok := r.Delta(len(r.Splits) - 1); ok {
+			msg = fmt.Sprintf("Split %d: %s", len(r.Splits), deltaText(d))
+		}
+		g.notify(msg)
+	}
+}
+
+// A run's time to the millisecond, e.g. "1:23.456"
+func splitText(d time.Duration) string {
+	ms := int(d / time.Millisecond)
+	return fmt.Sprintf("%d:%02d.%03d", ms/60000, ms/1000%60, ms%1000)
+}
+
+// A gap to the personal best in seconds, e.g. "-1.234" when ahead
+func deltaText(d time.Duration) string {
+	sign := "+"
+	if d < 0 {
+		sign, d = "-", -d
+	}
+	ms := int(d / time.Millisecond)
+	return fmt.Sprintf("%s%d.%03d", sign, ms/1000, ms%1000)
+}
+
+// Draw the run's time and how it stands against the personal best: the
+// last split's gap, or once the best's next split has passed, the time
+// being lost to it as it grows
+func drawSpeedrun(sb *Sidebar, g *Game) {
+	r := g.speedrun
+	sb.Textf(colorScore|AttrBold, "TIME %s", splitText(r.Time()))
+	n := len(r.Splits)
+	switch d, ok := r.Delta(n - 1); {
+	case n < len(r.Best) && r.Time() > r.Best[n]:
+		sb.Textf(colorFood, "SPLIT %d %s", n+1, deltaText(r.Time()-r.Best[n]))
+	case ok:
+		fg := ColorGreen
+		if d > 0 {
+			fg = colorFood
+		}
+		sb.Textf(fg, "SPLIT %d %s", n, deltaText(d))
+	case n > 0:
+		sb.Textf(colorText, "SPLIT %d %s", n, splitText(r.Splits[n-1]))
+	default:
+		sb.Blank()
+	}
+}
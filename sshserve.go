@@ -0,0 +1,231 @@
+//go:build !js
+
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/creack/pty"
+	"github.com/gliderlabs/ssh"
+	gossh "golang.org/x/crypto/ssh"
+)
+
+// SSH server constants
+const (
+	sshDefaultAddr  = ":2222"
+	sshHostKeyFile  = "ssh_host_ed25519_key"
+	sshUsersDir     = "ssh-users"   // Data directories of players who connected over SSH, by key
+	sshConfigFile   = "config.toml" // Each player's own config, in their directory
+	sshMaxNameLen   = 32
+	sshDefaultGuest = "player"
+)
+
+// Run "go-snake serve-ssh": host the game for anyone who connects with
+// ssh. Each session plays its own copy of go-snake in a pseudo-terminal.
+// Players sign in with an SSH key, any key at all, and high scores and
+// other data are kept apart for each key. The SSH user name is only what
+// the game calls the player: anyone can send any name, so it is never
+// trusted to tell players apart. Arguments after "--" are passed on to
+// every game.
+func serveSSHCommand(args []string) int {
+	flags := flag.NewFlagSet("serve-ssh", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-snake serve-ssh [-addr :2222] [-key file] [-- game flags]")
+		flags.PrintDefaults()
+	}
+	addr := flags.String("addr", sshDefaultAddr, "address to listen on")
+	keyPath := flags.String("key", "", "host key file, created if missing (default in the data directory)")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	gameArgs := flags.Args()
+	// Players save their settings to a config of their own, never the
+	// server's, so one can't be passed on
+	for _, arg := range gameArgs {
+		if name, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "="); strings.HasPrefix(arg, "-") && name == "config" {
+			fmt.Fprintln(os.Stderr, "go-snake: serve-ssh: each player keeps a config of their own, so -config can't be passed on to games")
+			return 2
+		}
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	dir, err := dataDir()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	if *keyPath == "" {
+		*keyPath = filepath.Join(dir, sshHostKeyFile)
+	}
+	signer, err := loadHostKey(*keyPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake: host key:", err)
+		return 1
+	}
+
+	server := &ssh.Server{
+		Addr: *addr,
+		Handler: func(s ssh.Session) {
+			s.Exit(serveSSHSession(s, exe, filepath.Join(dir, sshUsersDir), gameArgs))
+		},
+		// Every key is welcome; it's what keeps one player's data from
+		// another's
+		PublicKeyHandler: func(ctx ssh.Context, key ssh.PublicKey) bool { return true },
+	}
+	server.AddHostKey(signer)
+	log.Printf("Serving go-snake over SSH on %s (host key %s)", *addr, gossh.FingerprintSHA256(signer.PublicKey()))
+	if err := server.ListenAndServe(); err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	return 0
+}
+
+// Play one game session over SSH, returning its exit status
+func serveSSHSession(s ssh.Session, exe, usersDir string, gameArgs []string) int {
+	ptyReq, winCh, isPty := s.Pty()
+	if !isPty {
+		io.WriteString(s.Stderr(), "go-snake needs a terminal: connect with ssh -t\n")
+		return 1
+	}
+	key := s.PublicKey()
+	if key == nil {
+		io.WriteString(s.Stderr(), "go-snake: connect with an SSH key, which keeps your scores yours\n")
+		return 1
+	}
+	user := sshUserName(s.User())
+	log.Printf("%s (%s) connected from %s", user, gossh.FingerprintSHA256(key), s.RemoteAddr())
+	defer log.Printf("%s disconnected", user)
+
+	// Data and settings go in the key's own directory, and the client's
+	// locale picks the theme and language as it would locally
+	home := filepath.Join(usersDir, sshKeyID(key))
+	config, err := sshUserConfig(home)
+	if err != nil {
+		log.Printf("%s: config: %v", user, err)
+		io.WriteString(s.Stderr(), "go-snake: couldn't set up your settings\n")
+		return 1
+	}
+	cmd := exec.CommandContext(s.Context(), exe, append([]string{"-name", user, "-config", config}, gameArgs...)...)
+	cmd.Env = append(os.Environ(), "TERM="+ptyReq.Term, "XDG_DATA_HOME="+home)
+	for _, v := range s.Environ() {
+		if name, _, _ := strings.Cut(v, "="); name == "LANG" || strings.HasPrefix(name, "LC_") {
+			cmd.Env = append(cmd.Env, v)
+		}
+	}
+	f, err := pty.StartWithSize(cmd, windowSize(ptyReq.Window))
+	if err != nil {
+		log.Printf("%s: start game: %v", user, err)
+		io.WriteString(s.Stderr(), "go-snake: couldn't start a game\n")
+		return 1
+	}
+	defer f.Close()
+
+	go func() {
+		for win := range winCh {
+			pty.Setsize(f, windowSize(win))
+		}
+	}()
+	go io.Copy(f, s)
+	io.Copy(s, f) // Ends when the game exits and closes the terminal
+
+	if err := cmd.Wait(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.ExitCode()
+		}
+		return 1
+	}
+	return 0
+}
+
+func windowSize(w ssh.Window) *pty.Winsize {
+	return &pty.Winsize{Rows: uint16(w.Height), Cols: uint16(w.Width)}
+}
+
+// Make sure a player's own config file is there, empty to begin with, and
+// return its path. The settings menu saves to it rather than the server's.
+func sshUserConfig(home string) (string, error) {
+	if err := os.MkdirAll(home, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(home, sshConfigFile)
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return "", err
+	}
+	return path, f.Close()
+}
+
+// Name a player's data directory after their key's SHA-256 fingerprint
+func sshKeyID(key ssh.PublicKey) string {
+	sum := sha256.Sum256(key.Marshal())
+	return hex.EncodeToString(sum[:])
+}
+
+// Turn an SSH user name into a player name to show: letters, digits,
+// dashes and underscores only
+func sshUserName(user string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_':
+			return r
+		}
+		return -1
+	}, user)
+	if len(name) > sshMaxNameLen {
+		name = name[:sshMaxNameLen]
+	}
+	if name == "" {
+		return sshDefaultGuest
+	}
+	return name
+}
+
+// Load the server's host key, creating one the first time so clients see
+// the same key from one run to the next
+func loadHostKey(path string) (gossh.Signer, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, err
+		}
+		block, err := gossh.MarshalPrivateKey(key, "go-snake host key")
+		if err != nil {
+			return nil, err
+		}
+		data = pem.EncodeToMemory(block)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	signer, err := gossh.ParsePrivateKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return signer, nil
+}
@@ -0,0 +1,14 @@
+//go:build js
+
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// A browser can't listen for SSH connections
+func serveSSHCommand(args []string) int {
+	fmt.Fprintln(os.Stderr, "go-snake: serve-ssh isn't available in the browser build")
+	return 2
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// StartPlacement is how player 1's snake starts a game: how long it is,
+// where its head is and which way it faces. Anything left at zero keeps the
+// usual start, three segments at the level's spawn point or in the middle
+// of an open board, facing right.
+type StartPlacement struct {
+	Length  int    `toml:"length"`  // Segments, head included
+	X       int    `toml:"x"`       // Column of the head, counting from 1
+	Y       int    `toml:"y"`       // Row of the head, counting from 1
+	Heading string `toml:"heading"` // up, right, down or left
+}
+
+// StartConfig is the config file's [start]: a placement for every game,
+// and placements for particular modes and levels over it. A level's wins
+// over a mode's, and the command line's over both.
+type StartConfig struct {
+	Length  int                       `toml:"length"`
+	X       int                       `toml:"x"`
+	Y       int                       `toml:"y"`
+	Heading string                    `toml:"heading"`
+	Modes   map[string]StartPlacement `toml:"modes"`  // Mode name -> placement
+	Levels  map[string]StartPlacement `toml:"levels"` // Level name -> placement
+
+	flags StartPlacement // From -start-length, -start-at and -heading
+}
+
+// Start placements from the config file and command line
+var startConfig StartConfig
+
+// Placement for a game of a mode on a level, "" for an open board
+func (c StartConfig) placement(mode, level string) StartPlacement {
+	p := StartPlacement{Length: c.Length, X: c.X, Y: c.Y, Heading: c.Heading}
+	p = p.over(c.Modes[mode])
+	if level != "" {
+		p = p.over(c.Levels[level])
+	}
+	return p.over(c.flags)
+}
+
+// Check every placement fits a board of a size, reporting a bad one in
+// terms of the config file
+func (c StartConfig) validate(w, h int) error {
+	global := StartPlacement{Length: c.Length, X: c.X, Y: c.Y, Heading: c.Heading}
+	if err := global.validate("start.", w, h); err != nil {
+		return err
+	}
+	for name, p := range c.Modes {
+		if !validMode(name) {
+			return fmt.Errorf("start.modes: unknown mode %q", name)
+		}
+		if err := p.validate("start.modes."+name+".", w, h); err != nil {
+			return err
+		}
+	}
+	for name, p := range c.Levels {
+		if err := p.validate("start.levels."+name+".", w, h); err != nil {
+			return err
+		}
+	}
+	if f := c.flags; f.Length > max(w, h) {
+		return fmt.Errorf("-start-length must be at most %d, got %d", max(w, h), f.Length)
+	} else if f.X > w || f.Y > h {
+		return fmt.Errorf("-start-at must be on the %dx%d board, got %d,%d", w, h, f.X, f.Y)
+	}
+	return nil
+}
+
+// Take q's settings over p's, where q has them
+func (p StartPlacement) over(q StartPlacement) StartPlacement {
+	if q.Length > 0 {
+		p.Length = q.Length
+	}
+	if q.X > 0 {
+		p.X = q.X
+	}
+	if q.Y > 0 {
+		p.Y = q.Y
+	}
+	if q.Heading != "" {
+		p.Heading = q.Heading
+	}
+	return p
+}
+
+// Check a placement's values on a board of a size, naming them with a
+// prefix
+func (p StartPlacement) validate(prefix string, w, h int) error {
+	if p.Length < 0 || p.Length > max(w, h) {
+		return fmt.Errorf("%slength must be between 1 and %d, got %d", prefix, max(w, h), p.Length)
+	}
+	if p.X < 0 || p.X > w {
+		return fmt.Errorf("%sx must be between 1 and %d, got %d", prefix, w, p.X)
+	}
+	if p.Y < 0 || p.Y > h {
+		return fmt.Errorf("%sy must be between 1 and %d, got %d", prefix, h, p.Y)
+	}
+	if _, ok := directionByName(p.Heading); p.Heading != "" && !ok {
+		return fmt.Errorf("%sheading must be up, right, down or left, got %q", prefix, p.Heading)
+	}
+	return nil
+}
+
+// Parse -start-at's "x,y", counting from 1
+func parseStartAt(s string) (x, y int, err error) {
+	xs, ys, ok := strings.Cut(s, ",")
+	if ok {
+		x, err = strconv.Atoi(strings.TrimSpace(xs))
+	}
+	if ok && err == nil {
+		y, err = strconv.Atoi(strings.TrimSpace(ys))
+	}
+	if !ok || err != nil || x < 1 || y < 1 {
+		return 0, 0, fmt.Errorf("-start-at must be a column and row like 5,10, got %q", s)
+	}
+	return x, y, nil
+}
+
+// Work out where the snakes start with a placement applied to the usual
+// spawn points, and how long they are. A second snake keeps the level's
+// spawn point if it has one, or else mirrors the first, as usual.
+func startSpawns(p StartPlacement, level *Level, players int) ([]SpawnPoint, int) {
+	spawns := append([]SpawnPoint(nil), spawnPoints(level, players)...)
+	first := &spawns[0]
+	if p.X > 0 {
+		first.At.X = p.X - 1
+	}
+	if p.Y > 0 {
+		first.At.Y = p.Y - 1
+	}
+	if dir, ok := directionByName(p.Heading); ok {
+		first.Heading = dir
+	}
+	if players > 1 && (level == nil || len(level.Spawns) < players) && (p.X > 0 || p.Y > 0 || p.Heading != "") {
+		spawns[1] = SpawnPoint{
+			At:      Point{X: width - 1 - first.At.X, Y: height - 1 - first.At.Y},
+			Heading: first.Heading.Opposite(),
+		}
+	}
+	size := initialSize
+	if p.Length > 0 {
+		size = p.Length
+	}
+	return spawns, size
+}
+
+// Check snakes of a size can start at the spawn points: with their bodies
+// on the board rather than wrapped round its edge, and clear of the level's
+// walls and portals and of each other
+func checkStart(level *Level, spawns []SpawnPoint, size int) error {
+	for _, sp := range spawns {
+		tail := sp.At
+		switch sp.Heading {
+		case Up:
+			tail.Y += size - 1
+		case Right:
+			tail.X -= size - 1
+		case Down:
+			tail.Y -= size - 1
+		case Left:
+			tail.X += size - 1
+		}
+		if tail.X < 0 || tail.Y < 0 || tail.X >= width || tail.Y >= height {
+			return fmt.Errorf("a snake of %d segments heading %s from %d,%d runs off the board", size, directionNames[sp.Heading], sp.At.X+1, sp.At.Y+1)
+		}
+	}
+	if level == nil {
+		level = &Level{Name: "start"}
+	}
+	return level.checkSpawns(spawns, size)
+}
+
+// Check a placement fits the board and level for a number of players
+func checkPlacement(p StartPlacement, level *Level, players int) error {
+	spawns, size := startSpawns(p, level, players)
+	return checkStart(level, spawns, size)
+}
+
+// Put the snakes where a placement starts them and fill the board with food
+// again around them. A placement that doesn't fit the game's board leaves
+// the snakes where they were.
+func (g *Game) placeSnakes(p StartPlacement, level *Level) error {
+	spawns, size := startSpawns(p, level, len(g.snakes))
+	if err := checkStart(level, spawns, size); err != nil {
+		return err
+	}
+	for i, s := range g.snakes {
+		s.body = spawns[i].snakeStart(s.body, size)
+		s.direction = spawns[i].Heading
+	}
+	g.startLength = size
+	g.resetFood()
+	return nil
+}
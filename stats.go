@@ -0,0 +1,272 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Stats constants
+const (
+	statsFileName = "stats.json"
+)
+
+// GameStats is what player 1 did in a game, or summed over many
+type GameStats struct {
+	Eaten     map[string]int `json:"eaten"`      // Foods eaten, by symbol
+	MaxLength int            `json:"max_length"` // Longest the snake grew
+	Turns     int            `json:"turns"`
+	Moves     int            `json:"moves"`           // Cells moved, for the average speed
+	Time      time.Duration  `json:"time"`            // Active play time, leaving out pauses
+	Wall      time.Duration  `json:"wall,omitempty"`  // Wall-clock time, pauses and all
+	Ticks     int            `json:"ticks,omitempty"` // Ticks played
+}
+
+// Count a food eaten
+func (s *GameStats) eat(symbol rune) {
+	if s.Eaten == nil {
+		s.Eaten = make(map[string]int)
+	}
+	s.Eaten[string(symbol)]++
+}
+
+// Foods eaten in all
+func (s *GameStats) Food() int {
+	n := 0
+	for _, count := range s.Eaten {
+		n += count
+	}
+	return n
+}
+
+// Average speed in cells per second
+func (s *GameStats) Speed() float64 {
+	if s.Time <= 0 {
+		return 0
+	}
+	return float64(s.Moves) / s.Time.Seconds()
+}
+
+// Foods eaten as "🍆 3  🍗 2", most eaten first
+func (s *GameStats) FoodText() string {
+	symbols := make([]string, 0, len(s.Eaten))
+	for sym := range s.Eaten {
+		symbols = append(symbols, sym)
+	}
+	sort.Slice(symbols, func(i, j int) bool {
+		if s.Eaten[symbols[i]] != s.Eaten[symbols[j]] {
+			return s.Eaten[symbols[i]] > s.Eaten[symbols[j]]
+		}
+		return symbols[i] < symbols[j]
+	})
+	parts := make([]string, len(symbols))
+	for i, sym := range symbols {
+		parts[i] = fmt.Sprintf("%s %d", sym, s.Eaten[sym])
+	}
+	return strings.Join(parts, "  ")
+}
+
+// Add another game's stats to these
+func (s *GameStats) add(o GameStats) {
+	for sym, count := range o.Eaten {
+		if s.Eaten == nil {
+			s.Eaten = make(map[string]int)
+		}
+		s.Eaten[sym] += count
+	}
+	s.MaxLength = max(s.MaxLength, o.MaxLength)
+	s.Turns += o.Turns
+	s.Moves += o.Moves
+	s.Time += o.Time
+	s.Wall += o.Wall
+	s.Ticks += o.Ticks
+}
+
+// Symbol of the food at p, if there is any
+func (g *Game) foodSymbolAt(p Point) (rune, bool) {
+	for i := range g.foods {
+		if g.foods[i].At == p {
+			return g.foods[i].Symbol(), true
+		}
+	}
+	for _, f := range g.frenzyFood {
+		if f.At == p {
+			return foodSymbols[f.Type], true
+		}
+	}
+	for _, c := range g.crumbs {
+		if c.At == p {
+			return symbolCrumb, true
+		}
+	}
+	if g.puzzle != nil {
+		for _, f := range g.puzzle.Food {
+			if f == p {
+				return foodSymbols[0], true
+			}
+		}
+	}
+	return 0, false
+}
+
+// Only single player games go into the lifetime stats; puzzles don't, as
+// they have no clock
+func (g *Game) countsForStats() bool {
+	return !g.Versus() && g.puzzle == nil && !g.watching
+}
+
+// LifetimeStats sums up every game counted so far
+type LifetimeStats struct {
+	Version int       `json:"version"` // Format version, see statsFormat
+	Games   int       `json:"games"`
+	Totals  GameStats `json:"totals"` // MaxLength is the longest in any game
+	path    string
+}
+
+// Return the full path of the stats file
+func statsPath() (string, error) {
+	dir, err := dataDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, statsFileName), nil
+}
+
+// Load the lifetime stats from disk. The stats returned are usable, if
+// empty, even when loading fails.
+func LoadLifetimeStats() (*LifetimeStats, error) {
+	path, err := statsPath()
+	if err != nil {
+		return &LifetimeStats{}, err
+	}
+	ls, err := readLifetimeStats(path)
+	if err != nil {
+		return &LifetimeStats{path: path}, err
+	}
+	return ls, nil
+}
+
+// Read a stats file, empty if there isn't one
+func readLifetimeStats(path string) (*LifetimeStats, error) {
+	ls := &LifetimeStats{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ls, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if data, err = statsFormat.upgradeJSON(data); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	if err := json.Unmarshal(data, ls); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	return ls, nil
+}
+
+// Add a finished game and save. The game goes on top of the stats as saved
+// now rather than as loaded, so games another go-snake has recorded in the
+// meantime are kept.
+func (ls *LifetimeStats) Record(s GameStats) error {
+	if ls.path == "" {
+		ls.Games++
+		ls.Totals.add(s)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(ls.path), 0o755); err != nil {
+		return err
+	}
+	return withFileLock(ls.path, func() error {
+		saved, err := readLifetimeStats(ls.path)
+		if err != nil {
+			ls.Games++
+			ls.Totals.add(s)
+			return err
+		}
+		saved.Games++
+		saved.Totals.add(s)
+		saved.Version = statsFormat.Version
+		*ls = *saved
+		data, err := json.MarshalIndent(ls, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeFileAtomic(ls.path, data, 0o644)
+	})
+}
+
+// Format a duration as h:mm:ss, or m:ss under an hour
+func clockText(d time.Duration) string {
+	secs := int(d / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// Draw the finished game's stats in a panel, with the lifetime totals under
+// them
+func drawGameStats(g *Game) {
+	s := g.gameStats()
+	lines := []Line{
+		{"GAME STATS", colorScore | AttrBold},
+		{},
+		{fmt.Sprintf("Time: %s   Wall: %s   Ticks: %d", clockText(s.Time), clockText(s.Wall), s.Ticks), colorText},
+		{fmt.Sprintf("Turns: %d   Speed: %.1f cells/s", s.Turns, s.Speed()), colorText},
+		{fmt.Sprintf("Food: %d   Longest: %d", s.Food(), s.MaxLength), colorText},
+	}
+	if s.Food() > 0 {
+		lines = append(lines, Line{s.FoodText(), colorFood})
+	}
+	if g.session != nil && g.session.Lifetime != nil && g.session.Lifetime.Games > 0 {
+		ls := g.session.Lifetime
+		lines = append(lines, Line{},
+			Line{fmt.Sprintf("All time: %d games, %s", ls.Games, clockText(ls.Totals.Time)), colorText},
+			Line{fmt.Sprintf("Food: %d   Longest: %d", ls.Totals.Food(), ls.Totals.MaxLength), colorText},
+		)
+	}
+	lines = append(lines, Line{}, Line{fmt.Sprintf("Press %s to go back", keyHint(ActionStats)), ColorDarkGray})
+	drawPanel(lines)
+}
+
+// Run the stats subcommand: print the lifetime stats
+func statsCommand(args []string) int {
+	if len(args) != 0 {
+		fmt.Fprintln(os.Stderr, "usage: go-snake stats")
+		return 2
+	}
+	ls, err := LoadLifetimeStats()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	if ls.Games == 0 {
+		fmt.Fprintln(os.Stderr, "go-snake: no games played yet")
+		return 1
+	}
+	t := &ls.Totals
+	fmt.Printf("Games played:   %d\n", ls.Games)
+	fmt.Printf("Time played:    %s\n", clockText(t.Time))
+	fmt.Printf("Average game:   %s\n", clockText(t.Time/time.Duration(ls.Games)))
+	// Games from before the wall clock and ticks were kept add nothing to
+	// them
+	if t.Ticks > 0 {
+		fmt.Printf("Wall clock:     %s, pauses and all\n", clockText(t.Wall))
+		fmt.Printf("Ticks played:   %d\n", t.Ticks)
+	}
+	fmt.Printf("Food eaten:     %d\n", t.Food())
+	if t.Food() > 0 {
+		fmt.Printf("  by type:      %s\n", t.FoodText())
+	}
+	fmt.Printf("Longest snake:  %d\n", t.MaxLength)
+	fmt.Printf("Turns made:     %d\n", t.Turns)
+	fmt.Printf("Average speed:  %.1f cells/s\n", t.Speed())
+	return 0
+}
@@ -0,0 +1,161 @@
+//go:build !js
+
+package main
+
+import (
+	"github.com/gdamore/tcell/v2"
+)
+
+// tcellRenderer draws with tcell, which knows the width of emoji and other
+// wide runes, so double-width food keeps the rest of the row in line
+type tcellRenderer struct {
+	screen  tcell.Screen
+	buttons tcell.ButtonMask // Mouse buttons held, to tell presses from releases
+}
+
+func (r *tcellRenderer) Init() error {
+	s, err := tcell.NewScreen()
+	if err != nil {
+		return err
+	}
+	if err := s.Init(); err != nil {
+		return err
+	}
+	r.screen = s
+	return nil
+}
+
+func (r *tcellRenderer) Close()           { r.screen.Fini() }
+func (r *tcellRenderer) Size() (int, int) { return r.screen.Size() }
+func (r *tcellRenderer) Clear()           { r.screen.Clear() }
+func (r *tcellRenderer) Flush()           { r.screen.Show() }
+
+func (r *tcellRenderer) EnableMouse() { r.screen.EnableMouse(tcell.MouseButtonEvents) }
+
+// A wide rune takes its cell and the next one. Whatever is drawn in the next
+// cell is hidden rather than pushing the rest of the row along.
+func (r *tcellRenderer) SetCell(x, y int, ch rune, fg, bg Attribute) {
+	style := tcell.StyleDefault.Foreground(tcellColor(fg)).Background(tcellColor(bg)).
+		Bold(fg&AttrBold != 0).
+		Blink(fg&AttrBlink != 0).
+		Dim(fg&AttrDim != 0).
+		Underline(fg&AttrUnderline != 0).
+		Reverse(fg&AttrReverse != 0)
+	r.screen.SetContent(x, y, ch, nil, style)
+}
+
+// Translate tcell's events into the game's. Only keys, resizes and mouse
+// buttons matter to it.
+func (r *tcellRenderer) PollEvent() Event {
+	for {
+		switch ev := r.screen.PollEvent().(type) {
+		case *tcell.EventKey:
+			return tcellKeyEvent(ev)
+		case *tcell.EventMouse:
+			if out, ok := r.mouseEvent(ev); ok {
+				return out
+			}
+		case *tcell.EventResize:
+			r.screen.Sync()
+			w, h := ev.Size()
+			return Event{Type: EventResize, Width: w, Height: h}
+		case nil:
+			return Event{Type: EventInterrupt}
+		}
+	}
+}
+
+// tcell keys with no matching control character
+var tcellKeys = map[tcell.Key]Key{
+	tcell.KeyUp:     KeyArrowUp,
+	tcell.KeyDown:   KeyArrowDown,
+	tcell.KeyLeft:   KeyArrowLeft,
+	tcell.KeyRight:  KeyArrowRight,
+	tcell.KeyHome:   KeyHome,
+	tcell.KeyEnd:    KeyEnd,
+	tcell.KeyPgUp:   KeyPgup,
+	tcell.KeyPgDn:   KeyPgdn,
+	tcell.KeyInsert: KeyInsert,
+	tcell.KeyDelete: KeyDelete,
+	tcell.KeyF1:     KeyF1,
+	tcell.KeyF2:     KeyF2,
+	tcell.KeyF3:     KeyF3,
+	tcell.KeyF4:     KeyF4,
+	tcell.KeyF5:     KeyF5,
+	tcell.KeyF6:     KeyF6,
+	tcell.KeyF7:     KeyF7,
+	tcell.KeyF8:     KeyF8,
+	tcell.KeyF9:     KeyF9,
+	tcell.KeyF10:    KeyF10,
+	tcell.KeyF11:    KeyF11,
+	tcell.KeyF12:    KeyF12,
+	tcell.KeyCenter: KeyCenter,
+	tcell.KeyClear:  KeyCenter, // Keypad 5 with NumLock off, in the Windows console
+}
+
+// Translate a key press. Control characters have the same codes in both
+// libraries; the game, like termbox, treats space as a key rather than a
+// rune.
+func tcellKeyEvent(ev *tcell.EventKey) Event {
+	out := Event{Type: EventKey}
+	if ev.Modifiers()&tcell.ModAlt != 0 {
+		out.Mod = ModAlt
+	}
+	switch key := ev.Key(); {
+	case key == tcell.KeyRune && ev.Rune() == ' ':
+		out.Key = KeySpace
+	case key == tcell.KeyRune:
+		out.Ch = ev.Rune()
+	case key < 0x80:
+		out.Key = Key(key)
+	default:
+		out.Key = tcellKeys[key]
+	}
+	return out
+}
+
+// tcell mouse buttons, in the order a press of several is reported
+var tcellButtons = []struct {
+	mask tcell.ButtonMask
+	key  Key
+}{
+	{tcell.Button1, KeyMouseLeft},
+	{tcell.Button3, KeyMouseMiddle},
+	{tcell.Button2, KeyMouseRight},
+	{tcell.WheelUp, KeyMouseWheelUp},
+	{tcell.WheelDown, KeyMouseWheelDown},
+}
+
+// Translate a mouse event. tcell reports which buttons are held, so a
+// button that wasn't held before is a press and none held after some were
+// is a release, as termbox has them. Anything else, like the wheel
+// scrolling sideways, is dropped.
+func (r *tcellRenderer) mouseEvent(ev *tcell.EventMouse) (Event, bool) {
+	buttons := ev.Buttons()
+	was := r.buttons
+	r.buttons = buttons &^ (tcell.WheelUp | tcell.WheelDown | tcell.WheelLeft | tcell.WheelRight) // The wheel has nothing to let go of
+	x, y := ev.Position()
+	out := Event{Type: EventMouse, MouseX: x, MouseY: y}
+	if buttons == tcell.ButtonNone {
+		out.Key = KeyMouseRelease
+		return out, was != tcell.ButtonNone
+	}
+	pressed := buttons &^ was
+	for _, b := range tcellButtons {
+		if pressed&b.mask != 0 {
+			out.Key = b.key
+			return out, true
+		}
+	}
+	return out, false
+}
+
+// Translate a color: the eight standard colors, then their bright
+// variants from dark gray on
+func tcellColor(attr Attribute) tcell.Color {
+	c := attr.Color()
+	if c == ColorDefault {
+		return tcell.ColorDefault
+	}
+	return tcell.PaletteColor(int(c) - 1)
+}
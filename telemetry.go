@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Telemetry is strictly opt in: nothing is sent unless telemetry.enabled is
+// set in the config file or turned on in the settings menu. Reports are
+// coarse on purpose, with no name, seed, date or exact score, so a game
+// can't be traced back to whoever played it.
+
+// Telemetry constants
+const telemetryTimeout = 5 * time.Second // Longest a report is given to send
+
+// Lower bounds of the bands scores and game lengths (in seconds) are
+// reported in
+var (
+	scoreBands    = []int{0, 1, 10, 25, 50, 100, 200, 500}
+	durationBands = []int{0, 30, 60, 120, 300, 600, 1200}
+)
+
+// TelemetryReport is what's sent about a finished game
+type TelemetryReport struct {
+	Mode       string `json:"mode"`
+	Rules      int    `json:"rules"`
+	Difficulty string `json:"difficulty"`
+	Width      int    `json:"width"`
+	Height     int    `json:"height"`
+	Players    int    `json:"players"`
+	Score      string `json:"score"`    // Band player 1's score fell in, e.g. "25-49"
+	Cause      string `json:"cause"`    // What player 1 died of, or survived
+	Duration   string `json:"duration"` // Band of game time played, e.g. "60-119s"
+}
+
+// telemetry sends reports to a server as JSON, one POST for each game
+type telemetry struct {
+	url    string
+	client *http.Client
+}
+
+// Set up telemetry to a URL, or none when it is empty
+func newTelemetry(rawURL string) (*telemetry, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("telemetry URL %q must be an http or https address", rawURL)
+	}
+	return &telemetry{url: rawURL, client: &http.Client{Timeout: telemetryTimeout}}, nil
+}
+
+// Send a report in the background. It's only telemetry, so a report that
+// doesn't get through is dropped.
+func (t *telemetry) send(r TelemetryReport) {
+	body, err := json.Marshal(r)
+	if err != nil {
+		return
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), telemetryTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+		if err != nil {
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if resp, err := t.client.Do(req); err == nil {
+			resp.Body.Close()
+		}
+	}()
+}
+
+// The report for a finished game, if it's one to learn from: games the
+// autopilot played or helped with, puzzles and replays aren't
+func (g *Game) telemetryReport() (TelemetryReport, bool) {
+	p := g.Player()
+	cause := p.died
+	if cause == "" {
+		cause = "survived"
+	}
+	r := TelemetryReport{
+		Mode:       g.mode,
+		Rules:      g.rules,
+		Difficulty: g.difficulty.Name,
+		Width:      width,
+		Height:     height,
+		Players:    len(g.snakes),
+		Score:      band(p.score, scoreBands, ""),
+		Cause:      cause,
+		Duration:   band(int(g.clock/time.Second), durationBands, "s"),
+	}
+	return r, !g.botAssisted && g.puzzle == nil && !g.watching
+}
+
+// The band a value falls in, given the bands' lower bounds in order, e.g.
+// "10-24", or "500+" for the last band
+func band(v int, bounds []int, unit string) string {
+	for i := len(bounds) - 1; i >= 0; i-- {
+		if v < bounds[i] {
+			continue
+		}
+		lo := strconv.Itoa(bounds[i])
+		switch {
+		case i == len(bounds)-1:
+			return lo + unit + "+"
+		case bounds[i+1]-1 == bounds[i]:
+			return lo + unit
+		}
+		return lo + "-" + strconv.Itoa(bounds[i+1]-1) + unit
+	}
+	return strconv.Itoa(v) + unit
+}
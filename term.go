@@ -0,0 +1,137 @@
+package main
+
+// Colors, styles, keys and events shared by every renderer. Game code and
+// themes only use these; each renderer maps them to its own. The values
+// follow termbox's, so the termbox renderer passes them straight through
+// and the others translate them.
+
+// Attribute is a cell's color, with style flags added on top
+type Attribute uint64
+
+// Colors
+const (
+	ColorDefault Attribute = iota
+	ColorBlack
+	ColorRed
+	ColorGreen
+	ColorYellow
+	ColorBlue
+	ColorMagenta
+	ColorCyan
+	ColorWhite
+	ColorDarkGray
+	ColorLightRed
+	ColorLightGreen
+	ColorLightYellow
+	ColorLightBlue
+	ColorLightMagenta
+	ColorLightCyan
+	ColorLightGray
+)
+
+// Styles, combined with a color using |
+const (
+	AttrBold Attribute = 1 << (iota + 9)
+	AttrBlink
+	AttrHidden
+	AttrDim
+	AttrUnderline
+	AttrCursive
+	AttrReverse
+)
+
+// Color is the attribute's color, without its styles
+func (a Attribute) Color() Attribute {
+	return a & (AttrBold - 1)
+}
+
+// Style is the attribute's style flags, without its color
+func (a Attribute) Style() Attribute {
+	return a &^ (AttrBold - 1)
+}
+
+// Key is a key that isn't a printable character
+type Key uint16
+
+// Special keys
+const (
+	KeyF1 Key = 0xFFFF - iota
+	KeyF2
+	KeyF3
+	KeyF4
+	KeyF5
+	KeyF6
+	KeyF7
+	KeyF8
+	KeyF9
+	KeyF10
+	KeyF11
+	KeyF12
+	KeyInsert
+	KeyDelete
+	KeyHome
+	KeyEnd
+	KeyPgup
+	KeyPgdn
+	KeyArrowUp
+	KeyArrowDown
+	KeyArrowLeft
+	KeyArrowRight
+	_ // Unused, as in termbox
+	KeyMouseLeft
+	KeyMouseMiddle
+	KeyMouseRight
+	KeyMouseRelease
+	KeyMouseWheelUp
+	KeyMouseWheelDown
+	KeyPadB // Gamepad buttons, which termbox doesn't have. A presses Enter.
+	KeyPadX
+	KeyPadY
+	KeyPadL
+	KeyPadR
+	KeyPadSelect
+	KeyPadStart
+	KeyCenter // Keypad 5 with NumLock off, which termbox doesn't report
+)
+
+// Control keys
+const (
+	KeyCtrlC      Key = 0x03
+	KeyBackspace  Key = 0x08
+	KeyTab        Key = 0x09
+	KeyEnter      Key = 0x0D
+	KeyEsc        Key = 0x1B
+	KeySpace      Key = 0x20
+	KeyBackspace2 Key = 0x7F
+)
+
+// Modifier is a modifier key held with a key press
+type Modifier uint8
+
+const (
+	ModAlt Modifier = 1 << iota
+)
+
+// EventType says what an Event is about
+type EventType uint8
+
+const (
+	EventKey EventType = iota
+	EventResize
+	EventMouse
+	EventError
+	EventInterrupt
+)
+
+// Event is a key press or other input from the renderer
+type Event struct {
+	Type   EventType
+	Mod    Modifier
+	Key    Key  // Special key or mouse button, or 0 for a character
+	Ch     rune // Character typed, or 0 for a special key
+	Width  int  // New size, for resize events
+	Height int
+	MouseX int // Cell clicked, for mouse events
+	MouseY int
+	Err    error
+}
@@ -0,0 +1,77 @@
+package main
+
+import "time"
+
+// Territory mode constants
+const (
+	territoryLength   = 3 * time.Minute // Length of a territory game
+	territoryPayEvery = 5 * time.Second // Game time between payouts for the territory held
+	territoryShares   = 10              // A payout is a point for each tenth of the board held
+)
+
+// Paint the cell a snake has moved onto in its color, taking it from
+// whoever held it before
+func (g *Game) paintCell(snake int, p Point) {
+	if g.mode != modeTerritory {
+		return
+	}
+	if g.paint == nil {
+		g.paint = make([]int8, width*height)
+	}
+	g.paint[p.Y*width+p.X] = int8(snake + 1)
+}
+
+// Which snake holds a cell, or -1 if none does
+func (g *Game) owner(p Point) int {
+	if g.paint == nil {
+		return -1
+	}
+	return int(g.paint[p.Y*width+p.X]) - 1
+}
+
+// Number of cells each snake holds, by snake index
+func (g *Game) territory() []int {
+	held := make([]int, len(g.snakes))
+	for _, o := range g.paint {
+		if o > 0 && int(o) <= len(held) {
+			held[o-1]++
+		}
+	}
+	return held
+}
+
+// Number of cells there are to paint: the board less its walls
+func (g *Game) paintable() int {
+	return max(width*height-len(g.walls), 1)
+}
+
+// Pay each live snake for the territory it holds, every few seconds. A
+// snake that has crashed keeps its paint, but earns nothing more from it.
+func (g *Game) payTerritory() {
+	for g.clock >= g.nextPayout {
+		g.nextPayout += territoryPayEvery
+		held := g.territory()
+		for i, s := range g.snakes {
+			if points := held[i] * territoryShares / g.paintable(); s.alive && points > 0 {
+				g.award(s, points)
+				g.popScore(s, s.Head(), points)
+			}
+		}
+	}
+}
+
+// Draw the time left in a territory game and the share of the board each
+// snake holds
+func drawTerritoryStatus(sb *Sidebar, g *Game) {
+	left := max(int((territoryLength-g.clock+time.Second-1)/time.Second), 0)
+	fg := colorScore | AttrBold
+	if time.Duration(left)*time.Second <= timeWarning {
+		fg = colorFood | AttrBold
+	}
+	sb.Textf(fg, "TIME: %d:%02d", left/60, left%60)
+	held := g.territory()
+	for i, s := range g.snakes {
+		sb.Columnf(i, len(g.snakes), s.color, "%s %d%%", s.name, held[i]*100/g.paintable())
+	}
+	sb.Next()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// VerifyResult is how replaying one saved puzzle solution went
+type VerifyResult struct {
+	Puzzle string `json:"puzzle"`
+	Moves  int    `json:"moves"` // Moves the record claims
+	Stars  int    `json:"stars"`
+	Valid  bool   `json:"valid"`
+	Error  string `json:"error,omitempty"` // Why it isn't valid
+}
+
+// Play a saved solution back on a fresh puzzle and check it solves it in
+// the moves it claims
+func verifyPuzzle(m *PuzzleMenu, name string) VerifyResult {
+	res := VerifyResult{Puzzle: name}
+	rec, ok := m.Records.Best[name]
+	if !ok {
+		res.Error = "not solved"
+		return res
+	}
+	res.Moves, res.Stars = rec.Moves, rec.Stars
+	m.Selected = -1
+	for i := range builtinPuzzles {
+		if builtinPuzzles[i].Name == name {
+			m.Selected = i
+		}
+	}
+	if err := checkSolution(m, rec); err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Valid = true
+	return res
+}
+
+// Check a puzzle record's replay against the selected puzzle
+func checkSolution(m *PuzzleMenu, rec PuzzleRecord) error {
+	if m.Selected < 0 {
+		return errors.New("no such puzzle")
+	}
+	p := &builtinPuzzles[m.Selected]
+	if rec.Replay.Puzzle != p.Name {
+		return fmt.Errorf("replay is of puzzle %q", rec.Replay.Puzzle)
+	}
+	if rec.Stars != stars(rec.Moves, p.Par) {
+		return fmt.Errorf("%d stars claimed for %d moves at par %d", rec.Stars, rec.Moves, p.Par)
+	}
+	rules, err := checkRules(rec.Replay.Rules)
+	if err != nil {
+		return err
+	}
+	g := m.Start()
+	g.replay, g.rules = nil, rules
+	rp := &ReplayPlayer{replay: &rec.Replay}
+	for rp.Step(g) {
+	}
+	switch {
+	case !g.puzzle.Solved && !g.Player().alive:
+		return fmt.Errorf("snake crashed after %d moves", g.puzzle.Moves)
+	case !g.puzzle.Solved:
+		return fmt.Errorf("%d food left after %d moves", len(g.puzzle.Food), g.puzzle.Moves)
+	case g.puzzle.Moves != rec.Moves:
+		return fmt.Errorf("solved in %d moves, not %d", g.puzzle.Moves, rec.Moves)
+	}
+	return nil
+}
+
+// Run the verify subcommand: replay saved puzzle solutions and check each
+// really solves its puzzle in the moves it claims, e.g. before accepting a
+// puzzles.json sent in for a tournament
+func verifyCommand(args []string) int {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "usage: go-snake verify [-file puzzles.json] [-format text|json] [puzzle...]")
+		fmt.Fprintln(flags.Output(), "Prints a line per puzzle on stdout, or with -format json a result per puzzle as JSON.")
+		fmt.Fprintf(flags.Output(), "Exits 0 if every solution checks out, %d if any doesn't, 1 if the records can't be read\nand 2 for bad usage or an unknown puzzle.\n", exitInvalid)
+		flags.PrintDefaults()
+	}
+	file := flags.String("file", "", "puzzle records to check (default the profile's "+puzzlesFileName+")")
+	format := flags.String("format", "text", "output: text, a line per puzzle, or json")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Fprintf(os.Stderr, "go-snake: unknown format %q (want text or json)\n", *format)
+		return 2
+	}
+
+	var records *PuzzleRecords
+	var err error
+	if *file != "" {
+		records, err = readPuzzleRecords(*file)
+	} else {
+		records, err = LoadPuzzleRecords()
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+	m, err := NewPuzzleMenu(records)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "go-snake:", err)
+		return 1
+	}
+
+	// Every solution saved, built-in puzzles first in their order, unless
+	// some puzzles are named
+	names := flags.Args()
+	for _, name := range names {
+		if !slices.ContainsFunc(builtinPuzzles, func(p Puzzle) bool { return p.Name == name }) {
+			fmt.Fprintf(os.Stderr, "go-snake: unknown puzzle %q\n", name)
+			return 2
+		}
+	}
+	if len(names) == 0 {
+		seen := map[string]bool{}
+		for _, p := range builtinPuzzles {
+			if _, ok := records.Best[p.Name]; ok {
+				names = append(names, p.Name)
+				seen[p.Name] = true
+			}
+		}
+		for name := range records.Best {
+			if !seen[name] {
+				names = append(names, name)
+			}
+		}
+	}
+	results := make([]VerifyResult, len(names))
+	invalid := 0
+	for i, name := range names {
+		results[i] = verifyPuzzle(m, name)
+		if !results[i].Valid {
+			invalid++
+		}
+	}
+
+	if *format == "json" {
+		data, err := json.MarshalIndent(struct {
+			Checked int            `json:"checked"`
+			Invalid int            `json:"invalid"`
+			Results []VerifyResult `json:"results"`
+		}{len(results), invalid, results}, "", "  ")
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "go-snake:", err)
+			return 1
+		}
+		fmt.Println(string(data))
+	} else {
+		for _, r := range results {
+			status := fmt.Sprintf("ok, %d moves, %s", r.Moves, strings.Repeat("*", r.Stars))
+			if !r.Valid {
+				status = "INVALID: " + r.Error
+			}
+			fmt.Printf("%-20s %s\n", r.Puzzle, status)
+		}
+		fmt.Fprintf(os.Stderr, "%d checked, %d invalid\n", len(results), invalid)
+	}
+	if invalid > 0 {
+		return exitInvalid
+	}
+	return 0
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// Solve the first puzzle, returning its record
+func solveNibble(t *testing.T) PuzzleRecord {
+	t.Helper()
+	m, err := NewPuzzleMenu(&PuzzleRecords{Best: map[string]PuzzleRecord{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := m.Start()
+	for _, dir := range []Direction{Down, Left, Left, Up, Left} {
+		g.puzzleMove(dir)
+	}
+	if !g.puzzle.Solved {
+		t.Fatalf("%s wasn't solved", g.puzzle.Puzzle.Name)
+	}
+	return PuzzleRecord{Moves: g.puzzle.Moves, Stars: stars(g.puzzle.Moves, g.puzzle.Puzzle.Par), Replay: *g.replay}
+}
+
+// Run verify, returning its exit status and what it wrote to stdout.
+// What it writes to stderr is dropped.
+func runVerify(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	null, err := os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer null.Close()
+	stdout, stderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = w, null
+	code := verifyCommand(args)
+	os.Stdout, os.Stderr = stdout, stderr
+	w.Close()
+	out, _ := io.ReadAll(r)
+	return code, string(out)
+}
+
+func TestVerify(t *testing.T) {
+	good := solveNibble(t)
+	name := good.Replay.Puzzle
+	cheat := good
+	cheat.Moves--
+	cheat.Stars = stars(cheat.Moves, builtinPuzzles[0].Par)
+
+	dir := t.TempDir()
+	write := func(file string, records map[string]PuzzleRecord) string {
+		path := filepath.Join(dir, file)
+		data, err := json.Marshal(PuzzleRecords{Best: records})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	goodFile := write("good.json", map[string]PuzzleRecord{name: good})
+	cheatFile := write("cheat.json", map[string]PuzzleRecord{name: cheat})
+	brokenFile := filepath.Join(dir, "broken.json")
+	os.WriteFile(brokenFile, []byte("{"), 0o644)
+
+	tests := []struct {
+		name    string
+		args    []string
+		code    int
+		invalid int // In the JSON, -1 for no JSON
+	}{
+		{"valid", []string{"-file", goodFile, "-format", "json"}, 0, 0},
+		{"valid as text", []string{"-file", goodFile}, 0, -1},
+		{"named", []string{"-file", goodFile, "-format", "json", name}, 0, 0},
+		{"wrong moves", []string{"-file", cheatFile, "-format", "json"}, exitInvalid, 1},
+		{"not solved", []string{"-file", goodFile, "-format", "json", builtinPuzzles[1].Name}, exitInvalid, 1},
+		{"unknown puzzle", []string{"-file", goodFile, "no such puzzle"}, 2, -1},
+		{"unknown format", []string{"-file", goodFile, "-format", "xml"}, 2, -1},
+		{"unreadable records", []string{"-file", brokenFile}, 1, -1},
+	}
+	for _, tt := range tests {
+		code, out := runVerify(t, tt.args...)
+		if code != tt.code {
+			t.Errorf("%s: exit status %d, want %d", tt.name, code, tt.code)
+		}
+		if tt.invalid < 0 {
+			continue
+		}
+		var res struct {
+			Checked int            `json:"checked"`
+			Invalid int            `json:"invalid"`
+			Results []VerifyResult `json:"results"`
+		}
+		if err := json.Unmarshal([]byte(out), &res); err != nil {
+			t.Errorf("%s: %v in %q", tt.name, err, out)
+			continue
+		}
+		if res.Checked != 1 || res.Invalid != tt.invalid || len(res.Results) != 1 || res.Results[0].Valid != (tt.invalid == 0) {
+			t.Errorf("%s: got %+v", tt.name, res)
+		}
+	}
+}
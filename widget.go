@@ -0,0 +1,292 @@
+package main
+
+import (
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Widget constants
+const (
+	toastTime    = 2 * time.Second // How long a toast stays up
+	maxToasts    = 3               // Toasts up at once; older ones make way
+	panelPadding = 2               // Blank columns either side of a panel's text
+)
+
+// Rect is an area of the screen, in cells
+type Rect struct {
+	X, Y, W, H int
+}
+
+// The game area inside the board's border, which screens and overlays are
+// drawn over
+func boardArea() Rect {
+	return Rect{X: boardLeft + 1, Y: 1, W: viewWidth, H: viewHeight}
+}
+
+// Cells a string takes on screen, counting wide runes as two
+func textWidth(text string) int {
+	return runewidth.StringWidth(text)
+}
+
+// Draw text starting at x, y, cut off once it would run past room cells.
+// Returns the cells drawn.
+func drawTextIn(x, y, room int, text string, fg Attribute) int {
+	used := 0
+	for _, ch := range text {
+		w := runewidth.RuneWidth(ch)
+		if used+w > room {
+			break
+		}
+		screen.SetCell(x+used, y, ch, fg, ColorDefault)
+		used += w
+	}
+	return used
+}
+
+// Draw a line of text centered across an area, cut off at its edges
+func (r Rect) Center(y int, text string, fg Attribute) {
+	w := min(textWidth(text), r.W)
+	drawTextIn(r.X+(r.W-w)/2, y, r.W, text, fg)
+}
+
+// Draw a line of text centered over the game area
+func drawCentered(y int, text string, fg Attribute) {
+	boardArea().Center(y, text, fg)
+}
+
+// Draw a frame around an area, blanking what was inside it. The frame uses
+// the theme's border symbols so it matches the board.
+func drawBox(r Rect, fg Attribute) {
+	right, bottom := r.X+r.W-1, r.Y+r.H-1
+	for y := r.Y; y <= bottom; y++ {
+		for x := r.X; x <= right; x++ {
+			ch := ' '
+			switch {
+			case x == r.X && y == r.Y:
+				ch = symbolBorderTopLeft
+			case x == right && y == r.Y:
+				ch = symbolBorderTopRight
+			case x == r.X && y == bottom:
+				ch = symbolBorderBottomLeft
+			case x == right && y == bottom:
+				ch = symbolBorderBottomRight
+			case y == r.Y:
+				ch = symbolBorderTop
+			case y == bottom:
+				ch = symbolBorderBottom
+			case x == r.X:
+				ch = symbolBorderLeft
+			case x == right:
+				ch = symbolBorderRight
+			}
+			screen.SetCell(x, y, ch, fg, ColorDefault)
+		}
+	}
+}
+
+// Line is a line of text in a panel
+type Line struct {
+	Text string
+	Fg   Attribute
+}
+
+// Draw lines in a box centered over the game area, sized to fit them. Blank
+// lines leave a gap. A line may hold several, separated by newlines, and
+// lines too wide for the board are wrapped at spaces. Lines that still
+// don't fit in its height are left out.
+func drawPanel(lines []Line) {
+	area := boardArea()
+	room := max(area.W-2, 1) // Padding gives way before lines wrap
+	var wrapped []Line
+	for _, l := range lines {
+		for _, text := range wrapText(l.Text, room) {
+			wrapped = append(wrapped, Line{text, l.Fg})
+		}
+	}
+	lines = wrapped
+
+	w := 0
+	for _, l := range lines {
+		w = max(w, textWidth(l.Text))
+	}
+	box := Rect{W: min(w+2*panelPadding+2, area.W), H: min(len(lines)+2, area.H)}
+	box.X = area.X + (area.W-box.W)/2
+	box.Y = area.Y + (area.H-box.H)/2
+	drawBox(box, colorBorder)
+
+	inside := Rect{X: box.X + 1, Y: box.Y + 1, W: box.W - 2, H: box.H - 2}
+	for i, l := range lines[:min(len(lines), inside.H)] {
+		inside.Center(inside.Y+i, l.Text, l.Fg)
+	}
+}
+
+// Break text into lines no wider than w cells: at newlines, then at the
+// last space that fits. Words too long for a line are split, and lines
+// that are wrapped lose runs of spaces. Carriage
+// returns are dropped, so "\r\n" line ends work too. Empty text is one
+// empty line.
+func wrapText(text string, w int) []string {
+	var lines []string
+	for _, para := range strings.Split(strings.ReplaceAll(text, "\r", ""), "\n") {
+		if textWidth(para) <= w {
+			lines = append(lines, para) // Keeping its spacing
+			continue
+		}
+		line := ""
+		for _, word := range strings.Fields(para) {
+			switch {
+			case line == "":
+				line = word
+			case textWidth(line)+1+textWidth(word) <= w:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+			for textWidth(line) > w {
+				cut := runewidth.Truncate(line, w, "")
+				if cut == "" { // A rune wider than the line
+					cut = string([]rune(line)[:1])
+				}
+				lines = append(lines, cut)
+				line = line[len(cut):]
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// Draw a scrolling list of rows lines from x, top, marking the selected line
+// with a cursor and keeping it in view. Lines are cut off at the board's
+// edge. Clicking a line calls pick with it.
+func drawList(x, top, rows int, lines []string, selected int, pick func(int)) {
+	rows = max(rows, 1)
+	first := max(selected-rows+1, 0)
+	shownList = &ListArea{Rect: Rect{X: x, Y: top, W: boardArea().X + viewWidth - x, H: min(rows, len(lines)-first)}, First: first, Count: len(lines), Pick: pick}
+	for i := first; i < len(lines) && i < first+rows; i++ {
+		fg, cursor := colorText, "  "
+		if i == selected {
+			fg, cursor = ColorGreen|AttrBold, "> "
+		}
+		drawTextIn(x, top+i-first, shownList.W, cursor+lines[i], fg)
+	}
+}
+
+// Draw rows of text in columns across an area, under a header row along its
+// top. Columns are as wide as their widest cell, one space apart; when they
+// don't all fit, the shrink column gives up the difference. Rows are shown
+// from top on, as many as fit, and the highlighted one stands out.
+func drawTable(r Rect, header []string, align []Align, rows [][]string, top, highlight, shrink int) {
+	widths := make([]int, len(header))
+	total := len(header) - 1
+	for c := range header {
+		widths[c] = textWidth(header[c])
+		for _, row := range rows {
+			widths[c] = max(widths[c], textWidth(row[c]))
+		}
+		total += widths[c]
+	}
+	if total > r.W {
+		widths[shrink] = max(widths[shrink]-(total-r.W), 1)
+	}
+
+	drawRow := func(y int, cells []string, fg Attribute) {
+		x := r.X
+		for c, text := range cells {
+			room := min(widths[c], r.X+r.W-x)
+			if align[c] == AlignRight {
+				drawTextIn(x+max(room-textWidth(text), 0), y, room, text, fg)
+			} else {
+				drawTextIn(x, y, room, text, fg)
+			}
+			x += widths[c] + 1
+		}
+	}
+	drawRow(r.Y, header, colorScore|AttrBold)
+	for i := top; i < len(rows) && i-top < r.H-1; i++ {
+		fg := colorText
+		if i == highlight {
+			fg = ColorGreen | AttrBold
+		}
+		drawRow(r.Y+1+i-top, rows[i], fg)
+	}
+}
+
+// Draw a progress bar w cells wide, filled in proportion to value of total
+func drawProgressBar(x, y, w, value, total int, fg Attribute) {
+	filled := 0
+	if total > 0 {
+		filled = w * min(value, total) / total
+	}
+	for i := 0; i < w; i++ {
+		ch := sidebarBarGap
+		if i < filled {
+			ch = sidebarBarFill
+		}
+		screen.SetCell(x+i, y, ch, fg, ColorDefault)
+	}
+}
+
+// Toast is a short notice shown for a moment at the bottom of the sidebar
+type Toast struct {
+	Text  string
+	Until time.Time
+}
+
+// Show a toast under any already up, dropping the oldest if there are too
+// many
+func (g *Game) notify(text string) {
+	g.logMove(nil, "%s", text)
+	g.toasts = append(g.toasts, Toast{Text: text, Until: time.Now().Add(toastTime)})
+	if n := len(g.toasts); n > maxToasts {
+		g.toasts = g.toasts[n-maxToasts:]
+	}
+}
+
+// When the oldest toast up now goes, if there are any
+func (g *Game) nextToastGone() (time.Time, bool) {
+	if len(g.toasts) == 0 {
+		return time.Time{}, false
+	}
+	return g.toasts[0].Until, true
+}
+
+// Draw the game's toasts while they last, stacked up from the bottom of
+// the sidebar with the newest last. Without the sidebar only the newest
+// is shown, over the bottom of the board.
+func drawToasts(g *Game) {
+	now := time.Now()
+	left := g.toasts[:0]
+	for _, t := range g.toasts {
+		if now.Before(t.Until) {
+			left = append(left, t)
+		}
+	}
+	g.toasts = left
+	if len(g.toasts) == 0 {
+		return
+	}
+
+	fg := colorScore | AttrReverse
+	if compactLayout {
+		drawCentered(viewHeight, " "+g.toasts[len(g.toasts)-1].Text+" ", fg)
+		return
+	}
+	// Toasts too long for the sidebar, like a file's path, wrap onto more
+	// lines
+	room := sidebarWidth - 1 - sidebarLeft
+	var lines []string
+	for _, t := range g.toasts {
+		lines = append(lines, wrapText(t.Text, room-2)...)
+	}
+	y := max(viewHeight+2-len(lines), 0)
+	for i, line := range lines {
+		for x := 0; x < sidebarWidth-1; x++ {
+			screen.SetCell(x, y+i, ' ', ColorDefault, ColorDefault)
+		}
+		drawTextIn(sidebarLeft, y+i, room, " "+line+" ", fg)
+	}
+}